@@ -0,0 +1,98 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/getangry/sqld"
+)
+
+// Querier is the subset of database/sql shared by *sql.DB, *sql.Tx and *sql.Conn
+type Querier interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// MySQLAdapter wraps a database/sql connection to implement the sqld DBTX interface.
+// Register a MySQL driver (e.g. github.com/go-sql-driver/mysql) and pair the
+// adapter with sqld.MySQL so builders emit ? placeholders and backtick quoting.
+type MySQLAdapter struct {
+	db Querier
+}
+
+// NewMySQLAdapter creates a new adapter for *sql.DB, *sql.Tx or *sql.Conn
+func NewMySQLAdapter(db Querier) *MySQLAdapter {
+	return &MySQLAdapter{db: db}
+}
+
+// Query implements the DBTX interface
+func (m *MySQLAdapter) Query(ctx context.Context, query string, args ...interface{}) (sqld.Rows, error) {
+	rows, err := m.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, sqld.NormalizeError(err)
+	}
+	return rows, nil
+}
+
+// QueryRow implements the DBTX interface
+func (m *MySQLAdapter) QueryRow(ctx context.Context, query string, args ...interface{}) sqld.Row {
+	return mysqlRow{row: m.db.QueryRowContext(ctx, query, args...)}
+}
+
+// Exec implements the DBTXWithExec interface
+func (m *MySQLAdapter) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	result, err := m.db.ExecContext(ctx, query, args...)
+	return result, sqld.NormalizeError(err)
+}
+
+// Begin implements the sqld TxBeginner interface. The adapter must have been
+// created from *sql.DB or *sql.Conn; *sql.Tx cannot start a transaction.
+func (m *MySQLAdapter) Begin(ctx context.Context) (sqld.TxConn, error) {
+	beginner, ok := m.db.(interface {
+		BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+	})
+	if !ok {
+		return nil, fmt.Errorf("%T cannot start transactions", m.db)
+	}
+	tx, err := beginner.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, sqld.NormalizeError(err)
+	}
+	return &MySQLTxAdapter{MySQLAdapter: MySQLAdapter{db: tx}, tx: tx}, nil
+}
+
+// MySQLTxAdapter wraps *sql.Tx to implement the sqld TxConn interface
+type MySQLTxAdapter struct {
+	MySQLAdapter
+	tx *sql.Tx
+}
+
+// Commit implements the TxConn interface
+func (m *MySQLTxAdapter) Commit(ctx context.Context) error {
+	return sqld.NormalizeError(m.tx.Commit())
+}
+
+// mysqlRow normalizes the error of a single-row query, which *sql.Row
+// defers to Scan
+type mysqlRow struct {
+	row *sql.Row
+}
+
+// Scan implements the Row interface
+func (r mysqlRow) Scan(dest ...interface{}) error {
+	return sqld.NormalizeError(r.row.Scan(dest...))
+}
+
+// Rollback implements the TxConn interface
+func (m *MySQLTxAdapter) Rollback(ctx context.Context) error {
+	return sqld.NormalizeError(m.tx.Rollback())
+}
+
+// Compile-time checks that the adapters satisfy the sqld interfaces
+var (
+	_ sqld.DBTXWithExec = (*MySQLAdapter)(nil)
+	_ sqld.TxBeginner   = (*MySQLAdapter)(nil)
+	_ sqld.TxConn       = (*MySQLTxAdapter)(nil)
+)
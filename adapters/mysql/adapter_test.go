@@ -0,0 +1,213 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/getangry/sqld"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// duplicateEntry is a go-sql-driver/mysql unique violation message
+var duplicateEntry = errors.New("Error 1062 (23000): Duplicate entry 'ann' for key 'users.name'")
+
+// fakeConnector opens fakeConns, letting tests drive *sql.DB without a
+// MySQL server
+type fakeConnector struct {
+	conn *fakeConn
+}
+
+func (c *fakeConnector) Connect(context.Context) (driver.Conn, error) { return c.conn, nil }
+func (c *fakeConnector) Driver() driver.Driver                        { return nil }
+
+// fakeConn answers every query with rows, or with err when it is set
+type fakeConn struct {
+	rows        [][]driver.Value
+	err         error
+	beginErr    error
+	rollbackErr error
+}
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (c *fakeConn) Close() error                        { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *fakeConn) BeginTx(context.Context, driver.TxOptions) (driver.Tx, error) {
+	if c.beginErr != nil {
+		return nil, c.beginErr
+	}
+	return &fakeTx{conn: c}, nil
+}
+
+func (c *fakeConn) QueryContext(context.Context, string, []driver.NamedValue) (driver.Rows, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	return &fakeRows{values: c.rows}, nil
+}
+
+func (c *fakeConn) ExecContext(context.Context, string, []driver.NamedValue) (driver.Result, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	return driver.RowsAffected(len(c.rows)), nil
+}
+
+type fakeTx struct {
+	conn *fakeConn
+}
+
+func (t *fakeTx) Commit() error   { return t.conn.err }
+func (t *fakeTx) Rollback() error { return t.conn.rollbackErr }
+
+// fakeRows returns one int64 column per row
+type fakeRows struct {
+	values [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return []string{"id"} }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+// fakeQuerier exposes only the Querier methods of a *sql.DB, like a
+// *sql.Tx it cannot start transactions
+type fakeQuerier struct {
+	Querier
+}
+
+func openFake(t *testing.T, conn *fakeConn) *sql.DB {
+	t.Helper()
+	db := sql.OpenDB(&fakeConnector{conn: conn})
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestMySQLAdapter(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		err      error
+		expected error
+	}{
+		{"success", nil, nil},
+		{"unique violation", duplicateEntry, sqld.ErrUniqueViolation},
+		{"other errors pass through", io.ErrUnexpectedEOF, io.ErrUnexpectedEOF},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			adapter := NewMySQLAdapter(fakeQuerier{openFake(t, &fakeConn{rows: [][]driver.Value{{int64(7)}}, err: tt.err})})
+
+			rows, err := adapter.Query(ctx, "SELECT id FROM users")
+			if tt.expected != nil {
+				assert.ErrorIs(t, err, tt.expected)
+			} else {
+				require.NoError(t, err)
+				require.True(t, rows.Next())
+				var id int64
+				require.NoError(t, rows.Scan(&id))
+				assert.Equal(t, int64(7), id)
+				rows.Close()
+			}
+
+			var id int64
+			err = adapter.QueryRow(ctx, "SELECT id FROM users").Scan(&id)
+			if tt.expected != nil {
+				assert.ErrorIs(t, err, tt.expected)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, int64(7), id)
+			}
+
+			result, err := adapter.Exec(ctx, "UPDATE users SET name = ?", "ann")
+			if tt.expected != nil {
+				assert.ErrorIs(t, err, tt.expected)
+			} else {
+				require.NoError(t, err)
+				affected, err := result.RowsAffected()
+				require.NoError(t, err)
+				assert.Equal(t, int64(1), affected)
+			}
+		})
+	}
+
+	t.Run("missing row matches ErrNoRows", func(t *testing.T) {
+		adapter := NewMySQLAdapter(openFake(t, &fakeConn{}))
+		var id int64
+		err := adapter.QueryRow(ctx, "SELECT id FROM users").Scan(&id)
+		assert.ErrorIs(t, err, sqld.ErrNoRows)
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+	})
+}
+
+func TestMySQLAdapter_Begin(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name        string
+		beginErr    error
+		rollbackErr error
+		expected    error
+	}{
+		{"success", nil, nil, nil},
+		{"begin error", duplicateEntry, nil, sqld.ErrUniqueViolation},
+		{"rollback error", nil, duplicateEntry, sqld.ErrUniqueViolation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			adapter := NewMySQLAdapter(openFake(t, &fakeConn{
+				rows:        [][]driver.Value{{int64(7)}},
+				beginErr:    tt.beginErr,
+				rollbackErr: tt.rollbackErr,
+			}))
+
+			tx, err := adapter.Begin(ctx)
+			if tt.beginErr != nil {
+				assert.ErrorIs(t, err, tt.expected)
+				assert.Nil(t, tx)
+				return
+			}
+			require.NoError(t, err)
+
+			var id int64
+			require.NoError(t, tx.QueryRow(ctx, "SELECT id FROM users").Scan(&id))
+			assert.Equal(t, int64(7), id)
+
+			err = tx.Rollback(ctx)
+			if tt.expected != nil {
+				assert.ErrorIs(t, err, tt.expected)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+
+	t.Run("commit", func(t *testing.T) {
+		tx, err := NewMySQLAdapter(openFake(t, &fakeConn{})).Begin(ctx)
+		require.NoError(t, err)
+		assert.NoError(t, tx.Commit(ctx))
+	})
+
+	t.Run("querier without transactions", func(t *testing.T) {
+		tx, err := NewMySQLAdapter(fakeQuerier{openFake(t, &fakeConn{})}).Begin(ctx)
+		assert.Error(t, err)
+		assert.Nil(t, tx)
+	})
+}
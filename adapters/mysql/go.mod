@@ -0,0 +1,16 @@
+module github.com/getangry/sqld/adapters/mysql
+
+go 1.23.0
+
+require (
+	github.com/getangry/sqld v0.1.1
+	github.com/stretchr/testify v1.8.4
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace github.com/getangry/sqld => ../../
@@ -2,14 +2,27 @@ package pgx
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"strings"
 
 	"github.com/getangry/sqld"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
+// conn is the part of pgx.Conn the adapter uses, which pgx.Tx shares
+type conn interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error)
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
 // PgxAdapter wraps pgx.Conn to implement the sqld DBTX interface
 type PgxAdapter struct {
-	conn *pgx.Conn
+	conn conn
 }
 
 // NewPgxAdapter creates a new adapter for pgx.Conn
@@ -21,7 +34,7 @@ func NewPgxAdapter(conn *pgx.Conn) *PgxAdapter {
 func (p *PgxAdapter) Query(ctx context.Context, sql string, args ...interface{}) (sqld.Rows, error) {
 	rows, err := p.conn.Query(ctx, sql, args...)
 	if err != nil {
-		return nil, err
+		return nil, normalizeError(err)
 	}
 	return &PgxRowsAdapter{rows: rows}, nil
 }
@@ -32,6 +45,67 @@ func (p *PgxAdapter) QueryRow(ctx context.Context, sql string, args ...interface
 	return &PgxRowAdapter{row: row}
 }
 
+// Exec implements the sqld DBTXWithExec interface
+func (p *PgxAdapter) Exec(ctx context.Context, sql string, args ...interface{}) (sql.Result, error) {
+	tag, err := p.conn.Exec(ctx, sql, args...)
+	if err != nil {
+		return nil, normalizeError(err)
+	}
+	return commandTagResult{tag: tag}, nil
+}
+
+// Begin implements the sqld TxBeginner interface
+func (p *PgxAdapter) Begin(ctx context.Context) (sqld.TxConn, error) {
+	tx, err := p.conn.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &PgxTxAdapter{PgxAdapter: PgxAdapter{conn: tx}, tx: tx}, nil
+}
+
+// CopyFrom implements the sqld CopyFromer interface with the COPY protocol.
+// A schema-qualified table such as "billing.invoices" is split into its parts.
+func (p *PgxAdapter) CopyFrom(ctx context.Context, table string, columns []string, rows [][]interface{}) (int64, error) {
+	identifier := strings.Split(table, ".")
+	for i, part := range identifier {
+		identifier[i] = strings.Trim(part, `"`)
+	}
+	n, err := p.conn.CopyFrom(ctx, pgx.Identifier(identifier), columns, pgx.CopyFromRows(rows))
+	return n, normalizeError(err)
+}
+
+// PgxTxAdapter wraps pgx.Tx to implement the sqld TxConn interface. It
+// also implements CopyFromer, copying rows within the transaction.
+type PgxTxAdapter struct {
+	PgxAdapter
+	tx pgx.Tx
+}
+
+// Commit implements the TxConn interface
+func (p *PgxTxAdapter) Commit(ctx context.Context) error {
+	return normalizeError(p.tx.Commit(ctx))
+}
+
+// Rollback implements the TxConn interface
+func (p *PgxTxAdapter) Rollback(ctx context.Context) error {
+	return p.tx.Rollback(ctx)
+}
+
+// commandTagResult adapts a pgx command tag to sql.Result
+type commandTagResult struct {
+	tag pgconn.CommandTag
+}
+
+// LastInsertId is not supported by Postgres; use RETURNING instead
+func (r commandTagResult) LastInsertId() (int64, error) {
+	return 0, errors.New("LastInsertId is not supported by postgres, use RETURNING")
+}
+
+// RowsAffected returns the number of rows the command changed
+func (r commandTagResult) RowsAffected() (int64, error) {
+	return r.tag.RowsAffected(), nil
+}
+
 // PgxRowsAdapter wraps pgx.Rows to implement the sqld Rows interface
 type PgxRowsAdapter struct {
 	rows pgx.Rows
@@ -55,7 +129,17 @@ func (p *PgxRowsAdapter) Scan(dest ...interface{}) error {
 
 // Err implements the Rows interface
 func (p *PgxRowsAdapter) Err() error {
-	return p.rows.Err()
+	return normalizeError(p.rows.Err())
+}
+
+// Columns implements the sqld ColumnRows interface
+func (p *PgxRowsAdapter) Columns() ([]string, error) {
+	fields := p.rows.FieldDescriptions()
+	columns := make([]string, len(fields))
+	for i, field := range fields {
+		columns[i] = field.Name
+	}
+	return columns, nil
 }
 
 // PgxRowAdapter wraps pgx.Row to implement the sqld Row interface
@@ -65,5 +149,40 @@ type PgxRowAdapter struct {
 
 // Scan implements the Row interface
 func (p *PgxRowAdapter) Scan(dest ...interface{}) error {
-	return p.row.Scan(dest...)
+	return normalizeError(p.row.Scan(dest...))
 }
+
+// normalizeError turns constraint violations, serialization failures and
+// deadlocks into a *sqld.DatabaseError carrying the names Postgres reports,
+// and pgx.ErrNoRows into an error that also matches sqld.ErrNoRows
+func normalizeError(err error) error {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return sqld.WrapNoRows(err)
+	}
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return err
+	}
+	kind := sqld.ClassifySQLState(pgErr.Code)
+	if kind == nil {
+		return err
+	}
+	return &sqld.DatabaseError{
+		Kind:       kind,
+		Code:       pgErr.Code,
+		Constraint: pgErr.ConstraintName,
+		Table:      pgErr.TableName,
+		Column:     pgErr.ColumnName,
+		Err:        err,
+	}
+}
+
+// Compile-time checks that the adapters implement the optional interfaces
+var (
+	_ sqld.DBTXWithExec = (*PgxAdapter)(nil)
+	_ sqld.CopyFromer   = (*PgxAdapter)(nil)
+	_ sqld.TxBeginner   = (*PgxAdapter)(nil)
+	_ sqld.TxConn       = (*PgxTxAdapter)(nil)
+	_ sqld.CopyFromer   = (*PgxTxAdapter)(nil)
+	_ sqld.ColumnRows   = (*PgxRowsAdapter)(nil)
+)
@@ -1,6 +1,6 @@
 module github.com/getangry/sqld/adapters/pgx
 
-go 1.21
+go 1.23.0
 
 require (
 	github.com/getangry/sqld v0.1.1
@@ -5,20 +5,151 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"unicode"
 )
 
 // AnnotatedQuery represents a SQLc query with sqld annotations
 type AnnotatedQuery struct {
 	OriginalSQL    string
 	FilterEnabled  bool
+	OrderByEnabled bool
 	CursorEnabled  bool
+	TotalEnabled   bool
+	LimitEnabled   bool
 	DefaultLimit   int
+	MaxLimit       int
 	RequiredParams []string // For queries like SearchUsersByStatus that need specific params
 }
 
+// limitAnnotationPattern matches "/* sqld:limit */" and its options form
+// "/* sqld:limit default=20 max=100 */"
+var limitAnnotationPattern = regexp.MustCompile(`/\* sqld:limit((?:\s+\w+=\S*)*)\s*\*/`)
+
+// numberedPlaceholderPattern matches numbered placeholders such as $1
+var numberedPlaceholderPattern = regexp.MustCompile(`\$(\d+)`)
+
+// sqlcArgPattern matches named sqlc parameters: sqlc.arg(name) and sqlc.narg('name')
+var sqlcArgPattern = regexp.MustCompile(`sqlc\.n?arg\(\s*'?(\w+)'?\s*\)`)
+
+// atParamPattern matches sqlc's @name parameter shorthand
+var atParamPattern = regexp.MustCompile(`@(\w+)`)
+
+// ParseAnnotations reports which sqld annotations a query uses and the
+// parameters it requires, so services can check their queries' capabilities
+// at startup. RequiredParams lists named sqlc parameters (sqlc.arg(name),
+// @name) or, in generated code, the placeholders: "$1", "$2", ... for
+// numbered dialects and "?1", "?2", ... by position for the others.
+func ParseAnnotations(sql string) (*AnnotatedQuery, error) {
+	query := &AnnotatedQuery{
+		OriginalSQL:    sql,
+		FilterEnabled:  strings.Contains(sql, "/* sqld:where */"),
+		OrderByEnabled: strings.Contains(sql, "/* sqld:orderby */"),
+		CursorEnabled:  strings.Contains(sql, "/* sqld:cursor */"),
+		TotalEnabled:   strings.Contains(sql, "/* sqld:total */"),
+	}
+	if err := parseLimitAnnotation(sql, query); err != nil {
+		return nil, err
+	}
+	query.RequiredParams = requiredParams(sql)
+	return query, nil
+}
+
+// parseLimitAnnotation fills in the limit settings of query from the first
+// limit annotation in sql
+func parseLimitAnnotation(sql string, query *AnnotatedQuery) error {
+	match := limitAnnotationPattern.FindStringSubmatch(sql)
+	if match == nil {
+		return nil
+	}
+	query.LimitEnabled = true
+
+	for _, option := range strings.Fields(match[1]) {
+		key, value, _ := strings.Cut(option, "=")
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return fmt.Errorf("%w: limit annotation option %q must be a non-negative integer", ErrInvalidQuery, option)
+		}
+		switch key {
+		case "default":
+			query.DefaultLimit = n
+		case "max":
+			query.MaxLimit = n
+		default:
+			return fmt.Errorf("%w: unknown limit annotation option %q", ErrInvalidQuery, key)
+		}
+	}
+	if query.MaxLimit > 0 && query.DefaultLimit > query.MaxLimit {
+		return fmt.Errorf("%w: limit annotation default %d exceeds max %d", ErrInvalidQuery, query.DefaultLimit, query.MaxLimit)
+	}
+	return nil
+}
+
+// requiredParams lists the distinct parameters of sql in order of first use
+func requiredParams(sql string) []string {
+	var params []string
+	seen := make(map[string]bool)
+	add := func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			params = append(params, name)
+		}
+	}
+
+	// sqlc.arg names are read from the original SQL since they may be quoted
+	for _, match := range sqlcArgPattern.FindAllStringSubmatch(sql, -1) {
+		add(match[1])
+	}
+	cleaned := removeStringLiteralsAndComments(sql)
+	for _, match := range atParamPattern.FindAllStringSubmatch(cleaned, -1) {
+		add(match[1])
+	}
+	if len(params) > 0 {
+		return params
+	}
+
+	for _, match := range numberedPlaceholderPattern.FindAllString(cleaned, -1) {
+		add(match)
+	}
+	if len(params) > 0 {
+		return params
+	}
+
+	for i := 1; i <= strings.Count(cleaned, "?"); i++ {
+		add("?" + strconv.Itoa(i))
+	}
+	return params
+}
+
+// EffectiveLimit applies the query's limit annotation to a caller-provided
+// limit: a missing (zero or negative) limit becomes DefaultLimit and a limit
+// above MaxLimit is clamped to it
+func (q *AnnotatedQuery) EffectiveLimit(limit int) int {
+	if limit <= 0 {
+		limit = q.DefaultLimit
+	}
+	if q.MaxLimit > 0 && limit > q.MaxLimit {
+		limit = q.MaxLimit
+	}
+	return limit
+}
+
 // AnnotationProcessor processes sqld annotations in SQLc queries
 type AnnotationProcessor struct {
 	dialect Dialect
+
+	// lookahead fetches one row beyond the effective limit so paginated
+	// queries can tell whether another page exists
+	lookahead bool
+
+	// maxLimit caps the limit of every query with a limit annotation
+	maxLimit int
+
+	// offset skips rows after the limit annotation's LIMIT
+	offset int
+
+	// windowCount expands the total annotation into a COUNT(*) OVER()
+	// column; otherwise the annotation is removed
+	windowCount bool
 }
 
 // NewAnnotationProcessor creates a new annotation processor
@@ -26,7 +157,81 @@ func NewAnnotationProcessor(dialect Dialect) *AnnotationProcessor {
 	return &AnnotationProcessor{dialect: dialect}
 }
 
-// ProcessQuery processes a SQLc query with sqld annotations
+// WithMaxLimit caps the limit of queries with a limit annotation at max,
+// on top of the annotation's own max option. A query that would otherwise
+// run without a limit gets LIMIT max. Zero disables the cap.
+func (ap *AnnotationProcessor) WithMaxLimit(max int) *AnnotationProcessor {
+	ap.maxLimit = max
+	return ap
+}
+
+// effectiveLimit applies the limit annotation of sql and the processor's
+// max limit to a caller-provided limit. The annotation's default replaces
+// an unset limit and its max caps set ones; the processor's max limit caps
+// every limit of a query with a limit annotation.
+func (ap *AnnotationProcessor) effectiveLimit(sql string, limit Limit) (Limit, error) {
+	var limits AnnotatedQuery
+	if err := parseLimitAnnotation(sql, &limits); err != nil {
+		return Limit{}, err
+	}
+	if !limit.IsSet() && limits.DefaultLimit > 0 {
+		limit = LimitTo(limits.DefaultLimit)
+	}
+	if limits.MaxLimit > 0 && limit.IsSet() {
+		limit = limit.atMost(limits.MaxLimit)
+	}
+	if ap.maxLimit > 0 && limits.LimitEnabled {
+		limit = limit.atMost(ap.maxLimit)
+	}
+	return limit, nil
+}
+
+// withoutLimitOptions replaces the limit annotations of sql, after checking
+// their options, with a bare "/* sqld:limit */", so that neither their
+// default nor their max applies
+func withoutLimitOptions(sql string) (string, error) {
+	if err := parseLimitAnnotation(sql, &AnnotatedQuery{}); err != nil {
+		return "", err
+	}
+	return limitAnnotationPattern.ReplaceAllString(sql, "/* sqld:limit */"), nil
+}
+
+// cursorComparisons returns the operators of the cursor annotation's seek
+// predicate on created_at and id under orderBy. The query's own ordering is
+// taken to be created_at DESC, id DESC. A dynamic sort must start with
+// created_at, whose direction decides the created_at comparison; an id
+// field following it decides the id comparison, which otherwise follows
+// created_at. A Cursor holds only those two values, so sorts led by any
+// other field cannot be continued from it.
+func cursorComparisons(orderBy *OrderByBuilder) (createdAt, id string, err error) {
+	if orderBy == nil || len(orderBy.fields) == 0 {
+		return "<", "<", nil
+	}
+
+	fields := orderBy.fields
+	if lead := fields[0]; lead.raw || lead.random || lead.Field != "created_at" {
+		return "", "", fmt.Errorf("%w: the cursor annotation pages by created_at and id, so it cannot continue sort %q",
+			ErrInvalidCursor, orderBy.SortSpec())
+	}
+	createdAt = seekComparison(fields[0].Direction)
+	id = createdAt
+	if len(fields) > 1 && !fields[1].raw && !fields[1].random && fields[1].Field == "id" {
+		id = seekComparison(fields[1].Direction)
+	}
+	return createdAt, id, nil
+}
+
+// seekComparison returns the operator selecting the rows after a cursor in
+// the given direction.
+func seekComparison(direction SortDirection) string {
+	if direction == SortAsc {
+		return ">"
+	}
+	return "<"
+}
+
+// ProcessQuery processes a SQLc query with sqld annotations. A zero or
+// negative limit is unset, see ProcessQueryLimit.
 func (ap *AnnotationProcessor) ProcessQuery(
 	originalSQL string,
 	where *WhereBuilder,
@@ -35,6 +240,53 @@ func (ap *AnnotationProcessor) ProcessQuery(
 	limit int,
 	originalParams ...interface{},
 ) (string, []interface{}, error) {
+	return ap.ProcessQueryLimit(originalSQL, where, cursor, orderBy, LimitFromInt(limit), originalParams...)
+}
+
+// ProcessQueryLimit is ProcessQuery with a Limit, which can also ask for
+// every row with NoLimit, or for no rows with LimitTo(0)
+//
+// Example:
+//
+//	// LIMIT 0: validates the query and returns its columns without rows
+//	sql, params, err := processor.ProcessQueryLimit(query, where, nil, orderBy, sqld.LimitTo(0))
+func (ap *AnnotationProcessor) ProcessQueryLimit(
+	originalSQL string,
+	where *WhereBuilder,
+	cursor *Cursor,
+	orderBy *OrderByBuilder,
+	limit Limit,
+	originalParams ...interface{},
+) (string, []interface{}, error) {
+	if where != nil {
+		if err := where.Err(); err != nil {
+			return "", nil, err
+		}
+	}
+	if orderBy != nil {
+		if err := orderBy.Err(); err != nil {
+			return "", nil, err
+		}
+		if err := orderBy.checkDialect(ap.dialect); err != nil {
+			return "", nil, err
+		}
+	}
+
+	if cursor != nil && cursor.Sort != "" {
+		if sort := orderBy.SortSpec(); sort != cursor.Sort {
+			return "", nil, fmt.Errorf("%w: cursor was created for sort %q but the request sorts by %q",
+				ErrInvalidCursor, cursor.Sort, sort)
+		}
+	}
+
+	limit, err := ap.effectiveLimit(originalSQL, limit)
+	if err != nil {
+		return "", nil, err
+	}
+	if n, ok := limit.Value(); ok && ap.lookahead {
+		limit = LimitTo(n + 1)
+	}
+
 	sql := originalSQL
 	params := make([]interface{}, len(originalParams))
 	copy(params, originalParams)
@@ -42,104 +294,234 @@ func (ap *AnnotationProcessor) ProcessQuery(
 	// Track parameter index for new parameters
 	paramIndex := len(params)
 
-	// Build all WHERE conditions first
-	var whereConditions []string
+	// Build all WHERE conditions first, each prefixed with " AND ", and
+	// collect their parameters
+	var conditions strings.Builder
+	var conditionParams []interface{}
 
 	// Add cursor condition if present
 	if cursor != nil && strings.Contains(sql, "/* sqld:cursor */") {
-		cursorCondition := fmt.Sprintf("(created_at < $%d OR (created_at = $%d AND id < $%d))",
-			paramIndex+1, paramIndex+1, paramIndex+2)
-		whereConditions = append(whereConditions, cursorCondition)
-		params = append(params, cursor.CreatedAt, cursor.ID)
-		paramIndex += 2
+		createdAtCmp, idCmp, err := cursorComparisons(orderBy)
+		if err != nil {
+			return "", nil, err
+		}
+		if ap.dialect.Capabilities().NumberedPlaceholders {
+			// Numbered placeholders can reference the timestamp twice
+			createdAt, id := strconv.Itoa(paramIndex+1), strconv.Itoa(paramIndex+2)
+			conditions.WriteString(" AND (created_at " + createdAtCmp + " $" + createdAt + " OR (created_at = $" + createdAt + " AND id " + idCmp + " $" + id + "))")
+			conditionParams = append(conditionParams, cursor.CreatedAt, cursor.ID)
+			paramIndex += 2
+		} else {
+			// Positional placeholders bind in order, so the timestamp is passed twice
+			conditions.WriteString(" AND (created_at " + createdAtCmp + " ? OR (created_at = ? AND id " + idCmp + " ?))")
+			conditionParams = append(conditionParams, cursor.CreatedAt, cursor.CreatedAt, cursor.ID)
+			paramIndex += 3
+		}
 	}
 
-	// Add dynamic where conditions if present
+	// Add dynamic where conditions if present, renumbered behind the
+	// parameters before them
 	if where != nil && where.HasConditions() {
 		whereSQL, whereParams := where.Build()
-		// Adjust parameter placeholders
-		whereSQL = ap.adjustParameterPlaceholders(whereSQL, paramIndex)
-		whereConditions = append(whereConditions, whereSQL)
-		params = append(params, whereParams...)
+		conditions.Grow(len(" AND ") + len(whereSQL) + 16)
+		conditions.WriteString(" AND ")
+		writeRenumbered(&conditions, whereSQL, paramIndex)
+		conditionParams = append(conditionParams, whereParams...)
 		paramIndex += len(whereParams)
 	}
 
-	// Replace where annotation with all conditions
-	if len(whereConditions) > 0 && strings.Contains(sql, "/* sqld:where */") {
-		allConditions := " AND " + strings.Join(whereConditions, " AND ")
-		sql = strings.Replace(sql, "/* sqld:where */", allConditions, 1)
+	// In a UNION, INTERSECT or EXCEPT every branch gets the conditions at its
+	// own where annotation. Numbered placeholders are shared by the branches;
+	// positional ones need the parameters once per branch, in text order.
+	setOperation := setOperations(sql) > 0
+	if !ap.dialect.Capabilities().NumberedPlaceholders && setOperation && conditions.Len() > 0 {
+		params = branchParams(sql, originalParams, conditionParams)
 	} else {
-		// Remove where annotation if no conditions
-		sql = strings.Replace(sql, "/* sqld:where */", "", 1)
-	}
-
-	// Remove cursor annotation (it's now handled in WHERE clause)
-	sql = strings.Replace(sql, "/* sqld:cursor */", "", 1)
-
-	// Process orderby annotation
-	if strings.Contains(sql, "/* sqld:orderby */") {
-		if orderBy != nil && orderBy.HasFields() {
-			// Replace the default ORDER BY with dynamic ordering
-			// Find the ORDER BY clause and replace everything before the annotation
-			// Use (?s) flag to make . match newlines, handle whitespace between ORDER BY and fields
-			re := regexp.MustCompile(`(?s)ORDER BY\s+([\s\S]*?)\s*/\* sqld:orderby \*/`)
-			if re.MatchString(sql) {
-				// Replace the default ORDER BY fields with dynamic ones
-				orderBySQL := orderBy.Build()
-				// Use ReplaceAllStringFunc to replace only the first occurrence
-				replaced := false
-				sql = re.ReplaceAllStringFunc(sql, func(match string) string {
-					if !replaced {
-						replaced = true
-						return "ORDER BY " + orderBySQL + " "
-					}
-					return match // Leave subsequent matches unchanged
-				})
+		params = append(params, conditionParams...)
+	}
+
+	// Replace the where annotation with all conditions and remove the cursor
+	// annotation (it's now handled in WHERE clause) in a single pass
+	annotations := [...]annotationReplacement{
+		{annotation: "/* sqld:where */", replacement: conditions.String(), all: setOperation},
+		{annotation: "/* sqld:cursor */", all: setOperation},
+		{annotation: "/* sqld:total */"},
+		{annotation: "/* sqld:orderby */"},
+	}
+	if ap.windowCount {
+		annotations[2].replacement = ", COUNT(*) OVER() AS sqld_total"
+	}
+	orderByFields := orderBy != nil && orderBy.HasFields()
+	replacements := annotations[:]
+	if orderByFields {
+		// The dynamic ordering replaces the whole default ORDER BY below
+		replacements = annotations[:3]
+	}
+	sql = replaceAnnotations(sql, replacements)
+
+	// Replace the default ORDER BY fields with dynamic ones, or add the
+	// clause when the query has no default order
+	if orderByFields {
+		if at := strings.Index(sql, "/* sqld:orderby */"); at >= 0 {
+			end := at + len("/* sqld:orderby */")
+			if start, ok := orderByClause(sql, at); ok {
+				sql = sql[:start] + "ORDER BY " + orderBy.buildFor(ap.dialect) + " " + sql[end:]
+			} else {
+				sql = sql[:at] + "ORDER BY " + orderBy.buildFor(ap.dialect) + sql[end:]
 			}
-		} else {
-			// No dynamic ordering provided, just remove the annotation
-			sql = strings.Replace(sql, "/* sqld:orderby */", "", 1)
 		}
 	}
 
 	// Process limit annotation
-	if limit > 0 && strings.Contains(sql, "/* sqld:limit */") {
-		var limitSQL string
-		switch ap.dialect {
-		case Postgres:
-			limitSQL = fmt.Sprintf(" LIMIT $%d", paramIndex+1)
-		case MySQL, SQLite:
-			limitSQL = " LIMIT ?"
-		}
-		sql = strings.Replace(sql, "/* sqld:limit */", limitSQL, 1)
-		params = append(params, limit)
-	} else {
-		// Remove limit annotation if no limit
-		sql = strings.Replace(sql, "/* sqld:limit */", "", 1)
+	if loc := limitAnnotationPattern.FindStringIndex(sql); loc != nil {
+		limitSQL := ""
+		if n, ok := limit.Value(); ok {
+			limitSQL = " LIMIT " + ap.dialect.Placeholder(paramIndex+1)
+			params = append(params, n)
+			paramIndex++
+			if ap.offset > 0 {
+				limitSQL += " OFFSET " + ap.dialect.Placeholder(paramIndex+1)
+				params = append(params, ap.offset)
+			}
+		}
+		// Remove limit annotation if unset or NoLimit
+		sql = sql[:loc[0]] + limitSQL + sql[loc[1]:]
 	}
 
 	return sql, params, nil
 }
 
-// adjustParameterPlaceholders adjusts $1, $2, etc. placeholders by an offset
-func (ap *AnnotationProcessor) adjustParameterPlaceholders(sql string, offset int) string {
-	// Use regex to find and replace parameter placeholders
-	re := regexp.MustCompile(`\$(\d+)`)
-	return re.ReplaceAllStringFunc(sql, func(match string) string {
-		// Extract the number
-		numStr := match[1:] // Remove the $
-		num, err := strconv.Atoi(numStr)
-		if err != nil {
-			return match // Return original if can't parse
+// orderByClause returns the start of the ORDER BY clause the orderby
+// annotation at position at belongs to: the last upper-case ORDER BY before
+// it in the same (sub)query. ORDER BY inside parentheses, as in window
+// functions or subqueries, belongs to another clause and is skipped.
+func orderByClause(sql string, at int) (int, bool) {
+	depth := 0
+	for i := at - 1; i >= 0; i-- {
+		switch sql[i] {
+		case ')':
+			depth++
+		case '(':
+			if depth == 0 {
+				return 0, false // start of the annotation's subquery
+			}
+			depth--
+		case 'O':
+			if depth == 0 && strings.HasPrefix(sql[i:], "ORDER BY") &&
+				i+len("ORDER BY") < at && unicode.IsSpace(rune(sql[i+len("ORDER BY")])) &&
+				(i == 0 || !isIdentifierRune(rune(sql[i-1]))) {
+				return i, true
+			}
 		}
-		return fmt.Sprintf("$%d", num+offset)
-	})
+	}
+	return 0, false
 }
 
-// Cursor represents a pagination cursor for annotation processing
+// setOperations counts the UNION, INTERSECT and EXCEPT operators combining
+// the queries of sql, outside parentheses, string literals and comments.
+// A query with n of them has n+1 branches.
+func setOperations(sql string) int {
+	cleaned := removeStringLiteralsAndComments(sql)
+	count, depth := 0, 0
+	for i := 0; i < len(cleaned); i++ {
+		switch c := cleaned[i]; {
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case depth == 0 && (i == 0 || !isIdentifierRune(rune(cleaned[i-1]))):
+			for _, keyword := range [...]string{"UNION", "INTERSECT", "EXCEPT"} {
+				end := i + len(keyword)
+				if end <= len(cleaned) && strings.EqualFold(cleaned[i:end], keyword) &&
+					(end == len(cleaned) || !isIdentifierRune(rune(cleaned[end]))) {
+					count++
+					i = end - 1
+					break
+				}
+			}
+		}
+	}
+	return count
+}
+
+// branchParams returns the parameters of a query with positional
+// placeholders whose where annotations are all expanded: the condition
+// parameters are repeated at each annotation, after the original
+// parameters whose ? markers come before it
+func branchParams(sql string, original, conditionParams []interface{}) []interface{} {
+	params := make([]interface{}, 0, len(original)+len(conditionParams)*strings.Count(sql, "/* sqld:where */"))
+	used, offset := 0, 0
+	for {
+		i := strings.Index(sql[offset:], "/* sqld:where */")
+		if i < 0 {
+			break
+		}
+		offset += i
+		before := min(strings.Count(removeStringLiteralsAndComments(sql[:offset]), "?"), len(original))
+		params = append(params, original[used:max(before, used)]...)
+		params = append(params, conditionParams...)
+		used = max(before, used)
+		offset += len("/* sqld:where */")
+	}
+	return append(params, original[used:]...)
+}
+
+// annotationReplacement is an annotation replaced by replaceAnnotations
+type annotationReplacement struct {
+	annotation  string
+	replacement string
+	all         bool // replace every occurrence, not only the first
+	done        bool
+}
+
+// replaceAnnotations replaces the first occurrence of each annotation in
+// sql with its replacement, or every occurrence when all is set, in a
+// single pass. Other annotations, and later occurrences of the replaced
+// ones, are kept. Applied replacements are marked done.
+func replaceAnnotations(sql string, replacements []annotationReplacement) string {
+	size := len(sql)
+	for _, r := range replacements {
+		size += len(r.replacement)
+	}
+	var b strings.Builder
+	b.Grow(size)
+
+	for {
+		i := strings.Index(sql, "/* sqld:")
+		if i < 0 {
+			break
+		}
+		next := i + 1
+		for n := range replacements {
+			r := &replacements[n]
+			if !r.done && strings.HasPrefix(sql[i:], r.annotation) {
+				r.done = !r.all
+				b.WriteString(sql[:i])
+				b.WriteString(r.replacement)
+				sql = sql[i+len(r.annotation):]
+				next = 0
+				break
+			}
+		}
+		if next > 0 {
+			b.WriteString(sql[:next])
+			sql = sql[next:]
+		}
+	}
+	b.WriteString(sql)
+	return b.String()
+}
+
+// Cursor represents a pagination cursor for annotation processing. It is
+// decoded from the cursors of QueryPaginated by DecodeCursor, and from
+// signed cursors of a CursorCodec[CreatedAtKey] by AnnotationCursor.
 type Cursor struct {
 	CreatedAt interface{} `json:"created_at"`
 	ID        int32       `json:"id"`
+
+	// Sort is the SortSpec of the ordering the cursor was created under.
+	// When set, the processor rejects requests sorted any other way.
+	Sort string `json:"sort,omitempty"`
 }
 
 // Example helper functions for common patterns
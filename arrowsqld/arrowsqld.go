@@ -0,0 +1,301 @@
+// Package arrowsqld streams the results of filtered sqld queries as Apache
+// Arrow record batches, and writes them as Arrow IPC streams or Parquet
+// files for analytics consumers. Rows are read one at a time through
+// Executor.Each and batched, so memory stays bounded by the batch size.
+//
+//	exec := sqld.NewExecutor[db.Event](q).WithMaxRows(1000000, true)
+//	w.Header().Set("Content-Type", arrowsqld.ContentTypeParquet)
+//	n, err := arrowsqld.WriteParquet(ctx, w, exec, db.SearchEvents, params.Where, params.OrderBy, 0)
+package arrowsqld
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/ipc"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/apache/arrow-go/v18/parquet"
+	"github.com/apache/arrow-go/v18/parquet/pqarrow"
+	"github.com/getangry/sqld"
+)
+
+// DefaultBatchSize is the number of rows per record batch when the caller
+// passes zero
+const DefaultBatchSize = 8192
+
+const (
+	// ContentTypeArrow is the MIME type of an Arrow IPC stream
+	ContentTypeArrow = "application/vnd.apache.arrow.stream"
+
+	// ContentTypeParquet is the MIME type of a Parquet file
+	ContentTypeParquet = "application/vnd.apache.parquet"
+)
+
+// Schema returns the Arrow schema of the rows of T: one field per exported
+// struct field, named by its db or json tag or its snake_case name.
+// Pointers and sql.Null-style structs (a value and a Valid flag, as in
+// database/sql and pgtype) become nullable fields, time.Time a UTC
+// microsecond timestamp, and types Arrow has no direct equivalent for are
+// written as strings.
+func Schema[T any]() (*arrow.Schema, error) {
+	schema, _, err := newColumns[T]()
+	return schema, err
+}
+
+// StreamRecords runs the query through exec and calls fn with record
+// batches of up to batchSize rows. The record is released when fn returns;
+// fn must Retain it to keep it longer. It returns the number of rows read.
+func StreamRecords[T any](
+	ctx context.Context,
+	exec *sqld.Executor[T],
+	sqlcQuery string,
+	where *sqld.WhereBuilder,
+	orderBy *sqld.OrderByBuilder,
+	batchSize int,
+	fn func(arrow.Record) error,
+	originalParams ...interface{},
+) (int64, error) {
+	schema, columns, err := newColumns[T]()
+	if err != nil {
+		return 0, err
+	}
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+
+	builder := array.NewRecordBuilder(memory.DefaultAllocator, schema)
+	defer builder.Release()
+
+	pending := 0
+	flush := func() error {
+		record := builder.NewRecord()
+		defer record.Release()
+		pending = 0
+		return fn(record)
+	}
+
+	n, err := exec.Each(ctx, sqlcQuery, where, orderBy, func(row T) error {
+		value := reflect.ValueOf(row)
+		for i, column := range columns {
+			if err := column.append(builder.Field(i), value.Field(column.index)); err != nil {
+				return fmt.Errorf("arrowsqld: column %s: %w", schema.Field(i).Name, err)
+			}
+		}
+		pending++
+		if pending == batchSize {
+			return flush()
+		}
+		return nil
+	}, originalParams...)
+	if err != nil {
+		return n, err
+	}
+	if pending > 0 {
+		err = flush()
+	}
+	return n, err
+}
+
+// WriteIPC runs the query through exec and writes the rows to w as an Arrow
+// IPC stream of record batches of up to batchSize rows
+func WriteIPC[T any](
+	ctx context.Context,
+	w io.Writer,
+	exec *sqld.Executor[T],
+	sqlcQuery string,
+	where *sqld.WhereBuilder,
+	orderBy *sqld.OrderByBuilder,
+	batchSize int,
+	originalParams ...interface{},
+) (int64, error) {
+	schema, err := Schema[T]()
+	if err != nil {
+		return 0, err
+	}
+
+	writer := ipc.NewWriter(w, ipc.WithSchema(schema))
+	n, err := StreamRecords(ctx, exec, sqlcQuery, where, orderBy, batchSize, writer.Write, originalParams...)
+	if closeErr := writer.Close(); err == nil {
+		err = closeErr
+	}
+	return n, err
+}
+
+// WriteParquet runs the query through exec and writes the rows to w as a
+// Parquet file with a row group per batch of up to batchSize rows
+func WriteParquet[T any](
+	ctx context.Context,
+	w io.Writer,
+	exec *sqld.Executor[T],
+	sqlcQuery string,
+	where *sqld.WhereBuilder,
+	orderBy *sqld.OrderByBuilder,
+	batchSize int,
+	originalParams ...interface{},
+) (int64, error) {
+	schema, err := Schema[T]()
+	if err != nil {
+		return 0, err
+	}
+
+	writer, err := pqarrow.NewFileWriter(schema, w, parquet.NewWriterProperties(), pqarrow.DefaultWriterProps())
+	if err != nil {
+		return 0, err
+	}
+	n, err := StreamRecords(ctx, exec, sqlcQuery, where, orderBy, batchSize, writer.Write, originalParams...)
+	if closeErr := writer.Close(); err == nil {
+		err = closeErr
+	}
+	return n, err
+}
+
+// appendFunc appends a struct field's value to the builder of its column
+type appendFunc func(b array.Builder, v reflect.Value) error
+
+// column is how one struct field is written to a record
+type column struct {
+	index  int
+	append appendFunc
+}
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	stringerType = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+)
+
+// newColumns returns the schema of T and how to fill each of its fields
+func newColumns[T any]() (*arrow.Schema, []column, error) {
+	structType := reflect.TypeOf((*T)(nil)).Elem()
+	if structType.Kind() != reflect.Struct {
+		return nil, nil, fmt.Errorf("arrowsqld: rows must be structs, got %s", structType)
+	}
+
+	var fields []arrow.Field
+	var columns []column
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		if !field.IsExported() || field.Tag.Get("db") == "-" || field.Tag.Get("json") == "-" {
+			continue
+		}
+		dataType, nullable, appendValue := arrowType(field.Type)
+		fields = append(fields, arrow.Field{Name: columnName(field), Type: dataType, Nullable: nullable})
+		columns = append(columns, column{index: i, append: appendValue})
+	}
+	return arrow.NewSchema(fields, nil), columns, nil
+}
+
+// columnName returns the column a struct field holds, taken from its db or
+// json tag and falling back to the snake_case field name
+func columnName(field reflect.StructField) string {
+	for _, key := range []string{"db", "json"} {
+		if name, _, _ := strings.Cut(field.Tag.Get(key), ","); name != "" {
+			return name
+		}
+	}
+	return sqld.SnakeCase(field.Name)
+}
+
+// arrowType maps a Go type to its Arrow type, whether it is nullable and
+// how to append its values
+func arrowType(t reflect.Type) (arrow.DataType, bool, appendFunc) {
+	if t == timeType {
+		return arrow.FixedWidthTypes.Timestamp_us, false, func(b array.Builder, v reflect.Value) error {
+			ts, err := arrow.TimestampFromTime(v.Interface().(time.Time), arrow.Microsecond)
+			if err != nil {
+				return err
+			}
+			b.(*array.TimestampBuilder).Append(ts)
+			return nil
+		}
+	}
+
+	if t.Kind() == reflect.Ptr {
+		dataType, _, appendElem := arrowType(t.Elem())
+		return dataType, true, func(b array.Builder, v reflect.Value) error {
+			if v.IsNil() {
+				b.AppendNull()
+				return nil
+			}
+			return appendElem(b, v.Elem())
+		}
+	}
+
+	// sql.NullString, sql.Null[T], pgtype.Text and the like: the value
+	// comes first and Valid reports whether it is set
+	if t.Kind() == reflect.Struct {
+		valid, ok := t.FieldByName("Valid")
+		if ok && valid.Type.Kind() == reflect.Bool && len(valid.Index) == 1 && valid.Index[0] > 0 && t.Field(0).IsExported() {
+			dataType, _, appendValue := arrowType(t.Field(0).Type)
+			return dataType, true, func(b array.Builder, v reflect.Value) error {
+				if !v.Field(valid.Index[0]).Bool() {
+					b.AppendNull()
+					return nil
+				}
+				return appendValue(b, v.Field(0))
+			}
+		}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return arrow.FixedWidthTypes.Boolean, false, func(b array.Builder, v reflect.Value) error {
+			b.(*array.BooleanBuilder).Append(v.Bool())
+			return nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return arrow.PrimitiveTypes.Int64, false, func(b array.Builder, v reflect.Value) error {
+			b.(*array.Int64Builder).Append(v.Int())
+			return nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return arrow.PrimitiveTypes.Uint64, false, func(b array.Builder, v reflect.Value) error {
+			b.(*array.Uint64Builder).Append(v.Uint())
+			return nil
+		}
+	case reflect.Float32, reflect.Float64:
+		return arrow.PrimitiveTypes.Float64, false, func(b array.Builder, v reflect.Value) error {
+			b.(*array.Float64Builder).Append(v.Float())
+			return nil
+		}
+	case reflect.String:
+		return arrow.BinaryTypes.String, false, func(b array.Builder, v reflect.Value) error {
+			b.(*array.StringBuilder).Append(v.String())
+			return nil
+		}
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return arrow.BinaryTypes.Binary, true, func(b array.Builder, v reflect.Value) error {
+				if v.IsNil() {
+					b.AppendNull()
+					return nil
+				}
+				b.(*array.BinaryBuilder).Append(v.Bytes())
+				return nil
+			}
+		}
+	}
+
+	if t.Implements(stringerType) {
+		return arrow.BinaryTypes.String, false, func(b array.Builder, v reflect.Value) error {
+			b.(*array.StringBuilder).Append(v.Interface().(fmt.Stringer).String())
+			return nil
+		}
+	}
+
+	// Anything else, e.g. JSON columns scanned into maps, is written as JSON
+	return arrow.BinaryTypes.String, true, func(b array.Builder, v reflect.Value) error {
+		data, err := json.Marshal(v.Interface())
+		if err != nil {
+			return err
+		}
+		b.(*array.StringBuilder).Append(string(data))
+		return nil
+	}
+}
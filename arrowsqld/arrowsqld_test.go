@@ -0,0 +1,152 @@
+package arrowsqld
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/ipc"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/getangry/sqld"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type event struct {
+	ID        int32
+	Kind      string `db:"event_kind"`
+	Score     *float64
+	Note      sql.NullString
+	CreatedAt time.Time
+	Payload   map[string]int
+	internal  bool
+}
+
+// fakeDB returns rows holding the values of each row in column order
+type fakeDB struct {
+	rows    [][]interface{}
+	queries []string
+}
+
+func (db *fakeDB) Query(ctx context.Context, query string, args ...interface{}) (sqld.Rows, error) {
+	db.queries = append(db.queries, query)
+	return &fakeRows{rows: db.rows, next: -1}, nil
+}
+
+func (db *fakeDB) QueryRow(ctx context.Context, query string, args ...interface{}) sqld.Row {
+	panic("not used")
+}
+
+type fakeRows struct {
+	rows [][]interface{}
+	next int
+}
+
+func (r *fakeRows) Close() error { return nil }
+func (r *fakeRows) Err() error   { return nil }
+
+func (r *fakeRows) Next() bool {
+	r.next++
+	return r.next < len(r.rows)
+}
+
+func (r *fakeRows) Scan(dest ...interface{}) error {
+	for i, value := range r.rows[r.next] {
+		if scanner, ok := dest[i].(sql.Scanner); ok {
+			if err := scanner.Scan(value); err != nil {
+				return err
+			}
+			continue
+		}
+		target := reflect.ValueOf(dest[i]).Elem()
+		if value == nil {
+			target.Set(reflect.Zero(target.Type()))
+			continue
+		}
+		target.Set(reflect.ValueOf(value).Convert(target.Type()))
+	}
+	return nil
+}
+
+func TestSchema(t *testing.T) {
+	schema, err := Schema[event]()
+	require.NoError(t, err)
+
+	want := []arrow.Field{
+		{Name: "id", Type: arrow.PrimitiveTypes.Int64},
+		{Name: "event_kind", Type: arrow.BinaryTypes.String},
+		{Name: "score", Type: arrow.PrimitiveTypes.Float64, Nullable: true},
+		{Name: "note", Type: arrow.BinaryTypes.String, Nullable: true},
+		{Name: "created_at", Type: arrow.FixedWidthTypes.Timestamp_us},
+		{Name: "payload", Type: arrow.BinaryTypes.String, Nullable: true},
+	}
+	assert.Equal(t, want, schema.Fields())
+
+	_, err = Schema[int]()
+	assert.Error(t, err)
+}
+
+func TestStreamRecords(t *testing.T) {
+	createdAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	score := 0.5
+	db := &fakeDB{rows: [][]interface{}{
+		{int32(1), "click", &score, "first", createdAt, nil, nil},
+		{int32(2), "view", nil, nil, createdAt, map[string]int{"n": 1}, nil},
+		{int32(3), "click", nil, nil, createdAt, nil, nil},
+	}}
+	exec := sqld.NewExecutor[event](sqld.New(db, sqld.Postgres))
+	const query = "SELECT * FROM events WHERE true /* sqld:where */"
+
+	var batches []int64
+	var first arrow.Record
+	n, err := StreamRecords(context.Background(), exec, query, nil, nil, 2, func(record arrow.Record) error {
+		batches = append(batches, record.NumRows())
+		if first == nil {
+			record.Retain()
+			first = record
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	defer first.Release()
+
+	assert.Equal(t, int64(3), n)
+	assert.Equal(t, []int64{2, 1}, batches)
+	assert.Equal(t, []string{"SELECT * FROM events WHERE true "}, db.queries)
+
+	assert.Equal(t, []int64{1, 2}, first.Column(0).(*array.Int64).Int64Values())
+	kinds := first.Column(1).(*array.String)
+	assert.Equal(t, "click", kinds.Value(0))
+	scores := first.Column(2).(*array.Float64)
+	assert.Equal(t, 0.5, scores.Value(0))
+	assert.True(t, scores.IsNull(1))
+	notes := first.Column(3).(*array.String)
+	assert.Equal(t, "first", notes.Value(0))
+	assert.True(t, notes.IsNull(1))
+	assert.Equal(t, createdAt, first.Column(4).(*array.Timestamp).Value(0).ToTime(arrow.Microsecond))
+	assert.Equal(t, `{"n":1}`, first.Column(5).(*array.String).Value(1))
+}
+
+func TestWriteIPC(t *testing.T) {
+	db := &fakeDB{rows: [][]interface{}{
+		{int32(1), "click", nil, nil, time.Now(), nil, nil},
+	}}
+	exec := sqld.NewExecutor[event](sqld.New(db, sqld.Postgres))
+
+	var buf bytes.Buffer
+	n, err := WriteIPC(context.Background(), &buf, exec, "SELECT * FROM events", nil, nil, 0)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	reader, err := ipc.NewReader(&buf, ipc.WithAllocator(memory.DefaultAllocator))
+	require.NoError(t, err)
+	defer reader.Release()
+	require.True(t, reader.Next())
+	assert.Equal(t, int64(1), reader.Record().NumRows())
+	assert.False(t, reader.Next())
+}
@@ -0,0 +1,149 @@
+// Package bench holds the benchmark suite of sqld's hot paths: building
+// conditions, processing annotations, renumbering placeholders and scanning
+// rows. Its helpers compare two runs of the suite, so performance-motivated
+// changes can be checked against a baseline:
+//
+//	go test -run '^$' -bench . -benchmem -count 5 ./bench > bench/baseline.txt
+//	# change the code
+//	go test -run '^$' -bench . -benchmem -count 5 ./bench > bench/current.txt
+//	go test ./bench -run TestRegressions -baseline baseline.txt -current current.txt
+//
+// make bench-baseline and make bench-compare run these steps.
+package bench
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Result is the outcome of one benchmark. With several runs of a benchmark
+// (-count), each figure is the lowest measured, which is the least affected
+// by noise.
+type Result struct {
+	Name        string
+	NsPerOp     float64
+	BytesPerOp  int64
+	AllocsPerOp int64
+	Runs        int
+}
+
+// Parse reads the output of go test -bench, with or without -benchmem, and
+// returns the results by benchmark name. The -N suffix naming GOMAXPROCS is
+// removed from names, so runs on machines with different CPU counts match.
+func Parse(r io.Reader) (map[string]Result, error) {
+	results := make(map[string]Result)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 || !strings.HasPrefix(fields[0], "Benchmark") {
+			continue
+		}
+
+		name := fields[0]
+		if i := strings.LastIndex(name, "-"); i > 0 {
+			if _, err := strconv.Atoi(name[i+1:]); err == nil {
+				name = name[:i]
+			}
+		}
+		current := Result{Name: name, BytesPerOp: -1, AllocsPerOp: -1}
+
+		// Measurements come as "<value> <unit>" pairs after the iteration count
+		for i := 2; i+1 < len(fields); i += 2 {
+			value, err := strconv.ParseFloat(fields[i], 64)
+			if err != nil {
+				return nil, fmt.Errorf("bench: %s: invalid value %q", name, fields[i])
+			}
+			switch fields[i+1] {
+			case "ns/op":
+				current.NsPerOp = value
+			case "B/op":
+				current.BytesPerOp = int64(value)
+			case "allocs/op":
+				current.AllocsPerOp = int64(value)
+			}
+		}
+
+		previous, seen := results[name]
+		if !seen {
+			current.Runs = 1
+			results[name] = current
+			continue
+		}
+		previous.Runs++
+		previous.NsPerOp = min(previous.NsPerOp, current.NsPerOp)
+		previous.BytesPerOp = min(previous.BytesPerOp, current.BytesPerOp)
+		previous.AllocsPerOp = min(previous.AllocsPerOp, current.AllocsPerOp)
+		results[name] = previous
+	}
+	return results, scanner.Err()
+}
+
+// Change is how a benchmark moved between the baseline and the current run
+type Change struct {
+	Name     string
+	Baseline Result
+	Current  Result
+
+	// Delta is the relative change in ns/op: 0.1 is 10% slower, -0.1 10% faster
+	Delta float64
+}
+
+// Regressed reports whether the benchmark got slower by more than
+// threshold, or allocates more per operation than in the baseline. Runs
+// without -benchmem only compare times.
+func (c Change) Regressed(threshold float64) bool {
+	moreAllocs := c.Baseline.AllocsPerOp >= 0 && c.Current.AllocsPerOp > c.Baseline.AllocsPerOp
+	return c.Delta > threshold || moreAllocs
+}
+
+// Compare returns the changes of the benchmarks present in both runs,
+// sorted by name. Benchmarks only in one of them are left out.
+func Compare(baseline, current map[string]Result) []Change {
+	var changes []Change
+	for name, before := range baseline {
+		after, ok := current[name]
+		if !ok {
+			continue
+		}
+		change := Change{Name: name, Baseline: before, Current: after}
+		if before.NsPerOp > 0 {
+			change.Delta = after.NsPerOp/before.NsPerOp - 1
+		}
+		changes = append(changes, change)
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Name < changes[j].Name })
+	return changes
+}
+
+// Regressions returns the changes that regressed beyond threshold
+func Regressions(changes []Change, threshold float64) []Change {
+	var regressed []Change
+	for _, change := range changes {
+		if change.Regressed(threshold) {
+			regressed = append(regressed, change)
+		}
+	}
+	return regressed
+}
+
+// WriteReport writes a table of changes to w, marking regressions beyond
+// threshold
+func WriteReport(w io.Writer, changes []Change, threshold float64) error {
+	for _, change := range changes {
+		mark := ""
+		if change.Regressed(threshold) {
+			mark = "  REGRESSION"
+		}
+		_, err := fmt.Fprintf(w, "%-50s %12.1f -> %12.1f ns/op %+7.1f%%  %4d -> %4d allocs/op%s\n",
+			change.Name, change.Baseline.NsPerOp, change.Current.NsPerOp, change.Delta*100,
+			change.Baseline.AllocsPerOp, change.Current.AllocsPerOp, mark)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,282 @@
+package bench
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/getangry/sqld"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	baselineFile = flag.String("baseline", "", "benchmark output to compare against, for TestRegressions")
+	currentFile  = flag.String("current", "", "benchmark output to check, for TestRegressions")
+	threshold    = flag.Float64("threshold", 0.15, "relative ns/op slowdown TestRegressions tolerates")
+)
+
+const searchQuery = `SELECT id, name, email, status, age, score, verified, created_at
+FROM users
+WHERE org_id = $1 AND deleted_at IS NULL /* sqld:where */
+/* sqld:cursor */
+ORDER BY created_at DESC /* sqld:orderby */ /* sqld:limit */`
+
+// buildWhere adds the conditions of a typical filtered list request
+func buildWhere(dialect sqld.Dialect) *sqld.WhereBuilder {
+	where := sqld.NewWhereBuilder(dialect)
+	where.Equal("status", "active")
+	where.GreaterThanOrEqual("age", 18)
+	where.ILike("name", "%ann%")
+	where.In("role", []interface{}{"admin", "editor", "viewer"})
+	where.Between("created_at", "2024-01-01", "2024-12-31")
+	where.IsNotNull("verified_at")
+	where.Or(func(or sqld.ConditionBuilder) {
+		or.Equal("plan", "pro")
+		or.GreaterThan("score", 9.5)
+	})
+	return where
+}
+
+func BenchmarkWhereBuilder(b *testing.B) {
+	for _, dialect := range []sqld.Dialect{sqld.Postgres, sqld.MySQL} {
+		b.Run(string(dialect), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				buildWhere(dialect).Build()
+			}
+		})
+	}
+}
+
+func BenchmarkWhereBuilder_InList(b *testing.B) {
+	values := make([]interface{}, 500)
+	for i := range values {
+		values[i] = i
+	}
+	for _, arrayIn := range []bool{true, false} {
+		b.Run(fmt.Sprintf("array=%t", arrayIn), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				where := sqld.NewWhereBuilder(sqld.Postgres).ArrayIn(arrayIn)
+				where.In("id", values)
+				where.Build()
+			}
+		})
+	}
+}
+
+func BenchmarkOrderByBuilder(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		orderBy := sqld.NewOrderByBuilder().ForDialect(sqld.Postgres)
+		orderBy.Add("created_at", sqld.SortDesc).Add("name", sqld.SortAsc).Add("id", sqld.SortAsc)
+		orderBy.Build()
+	}
+}
+
+func BenchmarkParseQueryString(b *testing.B) {
+	config := sqld.DefaultConfig().WithAllowedFields(map[string]bool{
+		"name": true, "email": true, "status": true, "age": true, "created_at": true,
+	})
+	query := "name[contains]=ann&status[in]=active,verified&age[gte]=18&created_at[between]=2024-01-01,2024-12-31&sort=-created_at"
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := sqld.ParseQueryString(query, config); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkProcessQuery(b *testing.B) {
+	processor := sqld.NewAnnotationProcessor(sqld.Postgres)
+	where := buildWhere(sqld.Postgres)
+	orderBy := sqld.NewOrderByBuilder().Add("created_at", sqld.SortDesc).Add("id", sqld.SortDesc)
+	cursor := &sqld.Cursor{CreatedAt: "2024-06-01T00:00:00Z", ID: 1000}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := processor.ProcessQuery(searchQuery, where, cursor, orderBy, 50, 42); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkPlaceholderRenumbering measures shifting the placeholders of
+// conditions behind the query's own parameters, which grows with both
+func BenchmarkPlaceholderRenumbering(b *testing.B) {
+	for _, n := range []int{1, 10, 50} {
+		b.Run(fmt.Sprintf("params=%d", n), func(b *testing.B) {
+			original := make([]interface{}, n)
+			var conditions []string
+			for i := range original {
+				original[i] = i
+				conditions = append(conditions, fmt.Sprintf("c%d = $%d", i, i+1))
+			}
+			query := "SELECT * FROM t WHERE " + strings.Join(conditions, " AND ") + " /* sqld:where */"
+
+			where := sqld.NewWhereBuilder(sqld.Postgres)
+			for i := 0; i < n; i++ {
+				where.Raw(fmt.Sprintf("d%d = $1 OR e%d = $2", i, i), i, i)
+			}
+			processor := sqld.NewAnnotationProcessor(sqld.Postgres)
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, _, err := processor.ProcessQuery(query, where, nil, nil, 0, original...); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+type user struct {
+	ID        int64
+	Name      string
+	Email     string
+	Status    string
+	Age       int64
+	Score     float64
+	Verified  bool
+	CreatedAt string
+}
+
+// rows serves the same row n times without reflection, so the benchmarks
+// measure the scanner rather than the fake driver
+type rows struct {
+	n, next int
+}
+
+func (r *rows) Close() error { return nil }
+func (r *rows) Err() error   { return nil }
+
+func (r *rows) Next() bool {
+	r.next++
+	return r.next <= r.n
+}
+
+func (r *rows) Scan(dest ...interface{}) error {
+	*dest[0].(*int64) = int64(r.next)
+	*dest[1].(*string) = "Ann"
+	*dest[2].(*string) = "ann@example.com"
+	*dest[3].(*string) = "active"
+	*dest[4].(*int64) = 42
+	*dest[5].(*float64) = 9.5
+	*dest[6].(*bool) = true
+	*dest[7].(*string) = "2024-01-01T00:00:00Z"
+	return nil
+}
+
+// db returns a fresh result of n rows for every query
+type db struct{ n int }
+
+func (d db) Query(ctx context.Context, query string, args ...interface{}) (sqld.Rows, error) {
+	return &rows{n: d.n}, nil
+}
+
+func (d db) QueryRow(ctx context.Context, query string, args ...interface{}) sqld.Row {
+	return nil
+}
+
+func BenchmarkScanAll_1k(b *testing.B) {
+	scanner := sqld.NewReflectionScanner[user]()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		users, err := scanner.ScanAll(context.Background(), db{n: 1000}, "SELECT * FROM users")
+		if err != nil || len(users) != 1000 {
+			b.Fatal(len(users), err)
+		}
+	}
+}
+
+func BenchmarkExecutorQueryAll_1k(b *testing.B) {
+	exec := sqld.NewExecutor[user](sqld.New(db{n: 1000}, sqld.Postgres))
+	where := buildWhere(sqld.Postgres)
+	orderBy := sqld.NewOrderByBuilder().Add("created_at", sqld.SortDesc)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := exec.QueryAll(context.Background(), searchQuery, where, nil, orderBy, 0, 42); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// TestRegressions compares two benchmark runs given with -baseline and
+// -current, and fails when a benchmark regressed beyond -threshold
+func TestRegressions(t *testing.T) {
+	if *baselineFile == "" || *currentFile == "" {
+		t.Skip("set -baseline and -current to compare benchmark runs")
+	}
+
+	read := func(path string) map[string]Result {
+		f, err := os.Open(path)
+		require.NoError(t, err)
+		defer f.Close()
+		results, err := Parse(f)
+		require.NoError(t, err)
+		return results
+	}
+	changes := Compare(read(*baselineFile), read(*currentFile))
+
+	var report strings.Builder
+	require.NoError(t, WriteReport(&report, changes, *threshold))
+	t.Log("\n" + report.String())
+	for _, change := range Regressions(changes, *threshold) {
+		t.Errorf("%s regressed: %+.1f%% ns/op, %d -> %d allocs/op",
+			change.Name, change.Delta*100, change.Baseline.AllocsPerOp, change.Current.AllocsPerOp)
+	}
+}
+
+func TestParse(t *testing.T) {
+	output := `goos: linux
+goarch: amd64
+pkg: github.com/getangry/sqld/bench
+BenchmarkWhereBuilder/postgres-8         	  300000	      4100 ns/op	    2456 B/op	      61 allocs/op
+BenchmarkWhereBuilder/postgres-8         	  300000	      3900 ns/op	    2456 B/op	      61 allocs/op
+BenchmarkScanAll_1k-8                    	    2000	    610000 ns/op
+PASS
+ok  	github.com/getangry/sqld/bench	3.2s
+`
+	results, err := Parse(strings.NewReader(output))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]Result{
+		"BenchmarkWhereBuilder/postgres": {Name: "BenchmarkWhereBuilder/postgres", NsPerOp: 3900, BytesPerOp: 2456, AllocsPerOp: 61, Runs: 2},
+		"BenchmarkScanAll_1k":            {Name: "BenchmarkScanAll_1k", NsPerOp: 610000, BytesPerOp: -1, AllocsPerOp: -1, Runs: 1},
+	}, results)
+
+	_, err = Parse(strings.NewReader("BenchmarkBroken-8  100  fast ns/op\n"))
+	assert.Error(t, err)
+}
+
+func TestCompare(t *testing.T) {
+	baseline := map[string]Result{
+		"BenchmarkA":    {Name: "BenchmarkA", NsPerOp: 100, AllocsPerOp: 5},
+		"BenchmarkB":    {Name: "BenchmarkB", NsPerOp: 100, AllocsPerOp: 5},
+		"BenchmarkC":    {Name: "BenchmarkC", NsPerOp: 100, AllocsPerOp: 5},
+		"BenchmarkGone": {Name: "BenchmarkGone", NsPerOp: 100},
+	}
+	current := map[string]Result{
+		"BenchmarkA":   {Name: "BenchmarkA", NsPerOp: 105, AllocsPerOp: 5},
+		"BenchmarkB":   {Name: "BenchmarkB", NsPerOp: 150, AllocsPerOp: 4},
+		"BenchmarkC":   {Name: "BenchmarkC", NsPerOp: 80, AllocsPerOp: 6},
+		"BenchmarkNew": {Name: "BenchmarkNew", NsPerOp: 100},
+	}
+
+	changes := Compare(baseline, current)
+	require.Len(t, changes, 3)
+	assert.Equal(t, []string{"BenchmarkA", "BenchmarkB", "BenchmarkC"}, []string{changes[0].Name, changes[1].Name, changes[2].Name})
+	assert.InDelta(t, 0.5, changes[1].Delta, 1e-9)
+
+	regressed := Regressions(changes, 0.10)
+	require.Len(t, regressed, 2)
+	assert.Equal(t, "BenchmarkB", regressed[0].Name, "slower beyond the threshold")
+	assert.Equal(t, "BenchmarkC", regressed[1].Name, "more allocations")
+
+	var report strings.Builder
+	require.NoError(t, WriteReport(&report, changes, 0.10))
+	assert.Equal(t, 2, strings.Count(report.String(), "REGRESSION"))
+}
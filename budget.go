@@ -0,0 +1,110 @@
+package sqld
+
+import (
+	"context"
+	"strings"
+)
+
+// QueryBudget limits the filters a single request may combine, so clients
+// cannot craft requests that force full table scans. Zero limits are not
+// enforced.
+type QueryBudget struct {
+	// MaxOrGroups limits the number of OR groups, including free-text search
+	MaxOrGroups int
+
+	// MaxLeadingWildcards limits the pattern filters that start with a
+	// wildcard and so cannot use a B-tree index: contains, endsWith, their
+	// negations, and like/ilike values beginning with % or _
+	MaxLeadingWildcards int
+
+	// MaxUnindexedFilters limits the filters on fields not marked as indexed
+	// with WithIndexedFields
+	MaxUnindexedFilters int
+
+	// Warn, when set, is called for every exceeded limit and the request is
+	// allowed, e.g. to log offenders before enforcing a budget
+	Warn func(ctx context.Context, err *BudgetError)
+}
+
+// leadingWildcardOperators are the operators whose patterns start with %
+var leadingWildcardOperators = map[Operator]bool{
+	OpContains:       true,
+	OpIncludes:       true,
+	OpDoesNotContain: true,
+	OpEndsWith:       true,
+	OpDoesNotEndWith: true,
+}
+
+// hasLeadingWildcard reports whether a filter matches a pattern starting
+// with a wildcard, which a B-tree index cannot serve
+func hasLeadingWildcard(op Operator, value interface{}) bool {
+	if _, ok := value.(rangeValue); ok {
+		return false
+	}
+	if op == OpLike || op == OpILike {
+		pattern, ok := value.(string)
+		return ok && (strings.HasPrefix(pattern, "%") || strings.HasPrefix(pattern, "_"))
+	}
+	return leadingWildcardOperators[op]
+}
+
+// budgetUsage counts what a request spends of a QueryBudget
+type budgetUsage struct {
+	orGroups         int
+	leadingWildcards int
+	unindexedFilters int
+	indexed          map[string]bool
+}
+
+func (u *budgetUsage) add(filters []Filter) {
+	for _, filter := range filters {
+		switch filter.Operator {
+		case OpOr:
+			u.orGroups++
+			u.add(filter.Or)
+			continue
+		case OpExists:
+			u.add(filter.Related)
+			continue
+		}
+		if hasLeadingWildcard(filter.Operator, filter.Value) {
+			u.leadingWildcards++
+		}
+		if !u.indexed[filter.Field] {
+			u.unindexedFilters++
+		}
+	}
+}
+
+// checkBudget enforces the config's QueryBudget on parsed filters
+func (c *Config) checkBudget(ctx context.Context, filters []Filter) error {
+	budget := c.Budget
+	if budget.MaxOrGroups <= 0 && budget.MaxLeadingWildcards <= 0 && budget.MaxUnindexedFilters <= 0 {
+		return nil
+	}
+
+	usage := budgetUsage{indexed: make(map[string]bool)}
+	for name, field := range c.Fields {
+		if field.Indexed {
+			usage.indexed[c.ColumnFor(name)] = true
+		}
+	}
+	usage.add(filters)
+
+	limits := []BudgetError{
+		{Limit: "or_groups", Max: budget.MaxOrGroups, Count: usage.orGroups},
+		{Limit: "leading_wildcards", Max: budget.MaxLeadingWildcards, Count: usage.leadingWildcards},
+		{Limit: "unindexed_filters", Max: budget.MaxUnindexedFilters, Count: usage.unindexedFilters},
+	}
+	for i := range limits {
+		limit := &limits[i]
+		if limit.Max <= 0 || limit.Count <= limit.Max {
+			continue
+		}
+		if budget.Warn == nil {
+			return limit
+		}
+		budget.Warn(ctx, limit)
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package sqld
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryBudget(t *testing.T) {
+	newConfig := func(budget QueryBudget) *Config {
+		return DefaultConfig().
+			WithAllowedFields(map[string]bool{"name": true, "email": true, "bio": true, "status": true}).
+			WithSearchParam("q", []string{"name", "email"}).
+			WithIndexedFields("email", "status").
+			WithBudget(budget)
+	}
+
+	tests := []struct {
+		name   string
+		budget QueryBudget
+		query  string
+		limit  string
+		count  int
+	}{
+		{
+			name:   "or groups",
+			budget: QueryBudget{MaxOrGroups: 1},
+			query:  "or=(name=a,email=b)&q=acme",
+			limit:  "or_groups",
+			count:  2,
+		},
+		{
+			name:   "leading wildcards",
+			budget: QueryBudget{MaxLeadingWildcards: 1},
+			query:  "name[contains]=ann&bio[like]=%25engineer",
+			limit:  "leading_wildcards",
+			count:  2,
+		},
+		{
+			name:   "search columns count as wildcards",
+			budget: QueryBudget{MaxLeadingWildcards: 1},
+			query:  "q=acme",
+			limit:  "leading_wildcards",
+			count:  2,
+		},
+		{
+			name:   "unindexed filters",
+			budget: QueryBudget{MaxUnindexedFilters: 1},
+			query:  "name=ann&bio=x&email=a@example.com&status=active",
+			limit:  "unindexed_filters",
+			count:  2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseQueryString(tt.query, newConfig(tt.budget))
+			assert.ErrorIs(t, err, ErrQueryTooComplex)
+
+			var budgetErr *BudgetError
+			require.ErrorAs(t, err, &budgetErr)
+			assert.Equal(t, tt.limit, budgetErr.Limit)
+			assert.Equal(t, tt.count, budgetErr.Count)
+		})
+	}
+
+	t.Run("within budget", func(t *testing.T) {
+		config := newConfig(QueryBudget{MaxOrGroups: 1, MaxLeadingWildcards: 1, MaxUnindexedFilters: 1})
+		filters, err := ParseQueryString("name[startsWith]=ann&email=a@example.com&status=active", config)
+		require.NoError(t, err)
+		assert.Len(t, filters, 3)
+	})
+
+	t.Run("warn", func(t *testing.T) {
+		var warnings []*BudgetError
+		config := newConfig(QueryBudget{
+			MaxOrGroups:         1,
+			MaxLeadingWildcards: 1,
+			Warn: func(ctx context.Context, err *BudgetError) {
+				warnings = append(warnings, err)
+			},
+		})
+
+		filters, err := ParseQueryString("or=(name=a,email=b)&q=acme", config)
+		require.NoError(t, err)
+		assert.Len(t, filters, 2)
+		require.Len(t, warnings, 2)
+		assert.Equal(t, "query too complex: 2 or groups, maximum allowed: 1", warnings[0].Error())
+		assert.Equal(t, "leading_wildcards", warnings[1].Limit)
+	})
+
+	t.Run("url values", func(t *testing.T) {
+		_, err := ParseURLValues(map[string][]string{"name[endsWith]": {"son"}, "bio[contains]": {"go"}},
+			newConfig(QueryBudget{MaxLeadingWildcards: 1}))
+		assert.ErrorIs(t, err, ErrQueryTooComplex)
+	})
+}
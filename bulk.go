@@ -0,0 +1,203 @@
+package sqld
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// CopyFromer is implemented by adapters that can load rows with the
+// driver's bulk copy protocol, such as pgx's CopyFrom. It is the adapter
+// side of sqlc's :copyfrom queries. table and columns are passed unquoted;
+// the adapter is responsible for quoting them.
+type CopyFromer interface {
+	CopyFrom(ctx context.Context, table string, columns []string, rows [][]interface{}) (int64, error)
+}
+
+// defaultBulkInsertRows is the batch size used for dialects without a bind
+// parameter limit
+const defaultBulkInsertRows = 1000
+
+// bareIdentifierPattern matches unquoted, unqualified identifiers. BulkInsert
+// requires them for columns, since qualified names and expressions are not
+// valid in an INSERT column list.
+var bareIdentifierPattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// BulkInsert inserts rows into table and returns the number of rows
+// inserted. Each row holds one value per column, in column order.
+//
+// When db implements CopyFromer, or is a Tx whose transaction does, the
+// rows are loaded with it; otherwise db
+// must implement DBTXWithExec and the rows are sent as multi-row
+// INSERT ... VALUES statements, split so that no statement exceeds the
+// dialect's MaxParams. Batches are not atomic on their own: run BulkInsert
+// inside a transaction when a partial insert must not be left behind.
+//
+// Example:
+//
+//	n, err := sqld.BulkInsert(ctx, db, sqld.Postgres, "users",
+//		[]string{"name", "email"},
+//		[][]interface{}{{"Ada", "ada@example.com"}, {"Alan", "alan@example.com"}})
+func BulkInsert(ctx context.Context, db DBTX, dialect Dialect, table string, columns []string, rows [][]interface{}) (int64, error) {
+	return bulkInsert(ctx, db, dialect, false, table, columns, rows)
+}
+
+// BulkInsert inserts rows into table with the executor's database, quoting
+// identifiers when the executor's config has QuoteIdentifiers set. See the
+// BulkInsert function. Policies and scopes such as the tenant scope only
+// filter queries; they are not added to the inserted rows.
+func (e *Executor[T]) BulkInsert(ctx context.Context, table string, columns []string, rows [][]interface{}) (int64, error) {
+	quote := e.config != nil && e.config.QuoteIdentifiers
+	inserted, err := bulkInsert(ctx, e.queries.db, e.queries.dialect, quote, table, columns, rows)
+	return inserted, withParamRedaction(err, e.queries.redaction)
+}
+
+func bulkInsert(ctx context.Context, db DBTX, dialect Dialect, quote bool, table string, columns []string, rows [][]interface{}) (int64, error) {
+	if err := validateBulkInsert(table, columns, rows); err != nil {
+		return 0, err
+	}
+	if len(rows) == 0 {
+		return 0, nil
+	}
+
+	if copier, ok := copierOf(db); ok {
+		n, err := copier.CopyFrom(ctx, table, columns, rows)
+		if err != nil {
+			return n, fmt.Errorf("copying %d rows into %s: %w", len(rows), table, err)
+		}
+		return n, nil
+	}
+
+	execer, ok := db.(DBTXWithExec)
+	if !ok {
+		return 0, fmt.Errorf("%w: database of type %T supports neither CopyFrom nor Exec", ErrInvalidParameter, db)
+	}
+	if maxParams := dialect.Capabilities().MaxParams; maxParams > 0 && len(columns) > maxParams {
+		return 0, &ValidationError{
+			Field:   "columns",
+			Value:   len(columns),
+			Message: fmt.Sprintf("%d columns exceed the %d parameters %s allows per statement", len(columns), maxParams, dialect),
+		}
+	}
+
+	var inserted int64
+	for _, batch := range bulkInsertBatches(dialect, len(columns), rows) {
+		query, params := buildBulkInsert(dialect, quote, table, columns, batch)
+		result, err := execer.Exec(ctx, query, params...)
+		if err != nil {
+			return inserted, WrapQueryError(err, query, params, "executing bulk insert")
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return inserted, WrapQueryError(err, query, params, "reading rows affected")
+		}
+		inserted += n
+	}
+	return inserted, nil
+}
+
+// copierOf returns db as a CopyFromer. A Tx is one when its transaction is,
+// so BulkInsert keeps using the copy protocol inside transactions.
+func copierOf(db DBTX) (CopyFromer, bool) {
+	if tx, ok := db.(*Tx); ok {
+		copier, ok := tx.conn.(CopyFromer)
+		return copier, ok
+	}
+	copier, ok := db.(CopyFromer)
+	return copier, ok
+}
+
+// validateBulkInsert checks the table and column names and that every row
+// has one value per column
+func validateBulkInsert(table string, columns []string, rows [][]interface{}) error {
+	if err := ValidateTableName(table); err != nil {
+		return err
+	}
+	if len(columns) == 0 {
+		return &ValidationError{
+			Field:   "columns",
+			Message: "at least one column is required",
+		}
+	}
+
+	seen := make(map[string]bool, len(columns))
+	for _, column := range columns {
+		if !bareIdentifierPattern.MatchString(column) {
+			return &ValidationError{
+				Field:   "column",
+				Value:   column,
+				Message: "invalid column name format",
+			}
+		}
+		if seen[column] {
+			return &ValidationError{
+				Field:   "column",
+				Value:   column,
+				Message: "duplicate column",
+			}
+		}
+		seen[column] = true
+	}
+
+	for i, row := range rows {
+		if len(row) != len(columns) {
+			return &ValidationError{
+				Field:   "rows",
+				Value:   i,
+				Message: fmt.Sprintf("row %d has %d values for %d columns", i, len(row), len(columns)),
+			}
+		}
+	}
+	return nil
+}
+
+// bulkInsertBatches splits rows into batches that stay within the dialect's
+// bind parameter limit
+func bulkInsertBatches(dialect Dialect, columns int, rows [][]interface{}) [][][]interface{} {
+	size := defaultBulkInsertRows
+	if maxParams := dialect.Capabilities().MaxParams; maxParams > 0 {
+		size = maxParams / columns
+	}
+
+	var batches [][][]interface{}
+	for len(rows) > size {
+		batches = append(batches, rows[:size])
+		rows = rows[size:]
+	}
+	return append(batches, rows)
+}
+
+// buildBulkInsert builds a multi-row INSERT statement for rows
+func buildBulkInsert(dialect Dialect, quote bool, table string, columns []string, rows [][]interface{}) (string, []interface{}) {
+	names := make([]string, len(columns))
+	for i, column := range columns {
+		names[i] = column
+		if quote {
+			names[i] = quoteColumn(column, dialect)
+		}
+	}
+	if quote {
+		table = quoteColumn(table, dialect)
+	}
+
+	var query strings.Builder
+	query.WriteString("INSERT INTO " + table + " (" + strings.Join(names, ", ") + ") VALUES ")
+
+	params := make([]interface{}, 0, len(rows)*len(columns))
+	for i, row := range rows {
+		if i > 0 {
+			query.WriteString(", ")
+		}
+		query.WriteString("(")
+		for j, value := range row {
+			if j > 0 {
+				query.WriteString(", ")
+			}
+			params = append(params, value)
+			query.WriteString(dialect.Placeholder(len(params)))
+		}
+		query.WriteString(")")
+	}
+	return query.String(), params
+}
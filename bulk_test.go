@@ -0,0 +1,176 @@
+package sqld
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// execDB records the statements passed to Exec
+type execDB struct {
+	MockDB
+	queries []string
+	params  [][]interface{}
+	err     error
+}
+
+func (db *execDB) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	if db.err != nil {
+		return nil, db.err
+	}
+	db.queries = append(db.queries, query)
+	db.params = append(db.params, args)
+	return driver.RowsAffected(len(args)), nil
+}
+
+// copyDB records the rows passed to CopyFrom
+type copyDB struct {
+	execDB
+	table   string
+	columns []string
+	rows    [][]interface{}
+}
+
+func (db *copyDB) CopyFrom(ctx context.Context, table string, columns []string, rows [][]interface{}) (int64, error) {
+	db.table, db.columns, db.rows = table, columns, rows
+	return int64(len(rows)), nil
+}
+
+// copyTx is a transaction that supports CopyFrom
+type copyTx struct {
+	copyDB
+}
+
+func (tx *copyTx) Commit(ctx context.Context) error   { return nil }
+func (tx *copyTx) Rollback(ctx context.Context) error { return nil }
+
+func TestBulkInsert(t *testing.T) {
+	ctx := context.Background()
+	columns := []string{"name", "email"}
+	rows := [][]interface{}{{"Ada", "ada@example.com"}, {"Alan", "alan@example.com"}}
+
+	t.Run("multi-row values", func(t *testing.T) {
+		db := &execDB{}
+		n, err := BulkInsert(ctx, db, Postgres, "users", columns, rows)
+		require.NoError(t, err)
+		assert.Equal(t, int64(4), n) // fake reports one row per parameter
+		assert.Equal(t, []string{"INSERT INTO users (name, email) VALUES ($1, $2), ($3, $4)"}, db.queries)
+		assert.Equal(t, []interface{}{"Ada", "ada@example.com", "Alan", "alan@example.com"}, db.params[0])
+	})
+
+	t.Run("copy from", func(t *testing.T) {
+		db := &copyDB{}
+		n, err := BulkInsert(ctx, db, Postgres, "users", columns, rows)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), n)
+		assert.Equal(t, "users", db.table)
+		assert.Equal(t, columns, db.columns)
+		assert.Equal(t, rows, db.rows)
+		assert.Empty(t, db.queries)
+	})
+
+	t.Run("batches by max params", func(t *testing.T) {
+		db := &execDB{}
+		many := make([][]interface{}, 40000)
+		for i := range many {
+			many[i] = []interface{}{i, i}
+		}
+		_, err := BulkInsert(ctx, db, Postgres, "users", columns, many)
+		require.NoError(t, err)
+		require.Len(t, db.params, 2)
+		assert.Len(t, db.params[0], 65534)
+		assert.Len(t, db.params[1], 80000-65534)
+	})
+
+	t.Run("quoted identifiers", func(t *testing.T) {
+		db := &execDB{}
+		exec := NewExecutor[struct{}](New(db, MySQL)).WithConfig(DefaultConfig().WithQuoteIdentifiers(true))
+		_, err := exec.BulkInsert(ctx, "users", []string{"order"}, [][]interface{}{{1}})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"INSERT INTO `users` (`order`) VALUES (?)"}, db.queries)
+	})
+
+	t.Run("no rows", func(t *testing.T) {
+		db := &execDB{}
+		n, err := BulkInsert(ctx, db, Postgres, "users", columns, nil)
+		require.NoError(t, err)
+		assert.Zero(t, n)
+		assert.Empty(t, db.queries)
+	})
+
+	t.Run("exec error", func(t *testing.T) {
+		db := &execDB{err: errors.New("connection reset")}
+		_, err := BulkInsert(ctx, db, Postgres, "users", columns, rows)
+		var queryErr *QueryError
+		require.ErrorAs(t, err, &queryErr)
+	})
+
+	t.Run("copy from in a transaction", func(t *testing.T) {
+		conn := &copyTx{}
+		tx := &Tx{conn: conn, dialect: Postgres}
+		n, err := NewExecutor[struct{}](New(&MockDB{}, Postgres).WithTx(tx)).BulkInsert(ctx, "users", columns, rows)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), n)
+		assert.Equal(t, rows, conn.rows)
+		assert.Empty(t, conn.queries)
+	})
+
+	t.Run("transactions without copy from use Exec", func(t *testing.T) {
+		conn := &fakeTx{}
+		_, err := BulkInsert(ctx, &Tx{conn: conn, dialect: Postgres}, Postgres, "users", columns, rows)
+		require.NoError(t, err)
+		assert.Len(t, conn.queries, 1)
+	})
+
+	t.Run("more columns than parameters", func(t *testing.T) {
+		db := &execDB{}
+		wide := make([]string, 65536)
+		row := make([]interface{}, len(wide))
+		for i := range wide {
+			wide[i] = fmt.Sprintf("c%d", i)
+		}
+		_, err := BulkInsert(ctx, db, Postgres, "users", wide, [][]interface{}{row})
+		var validationErr *ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		assert.Equal(t, "columns", validationErr.Field)
+		assert.Empty(t, db.queries)
+	})
+
+	t.Run("no exec", func(t *testing.T) {
+		_, err := BulkInsert(ctx, &MockDB{}, Postgres, "users", columns, rows)
+		assert.ErrorIs(t, err, ErrInvalidParameter)
+	})
+}
+
+func TestBulkInsert_Validation(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name    string
+		table   string
+		columns []string
+		rows    [][]interface{}
+	}{
+		{"bad table", "users; DROP TABLE users", []string{"name"}, [][]interface{}{{"Ada"}}},
+		{"no columns", "users", nil, [][]interface{}{{"Ada"}}},
+		{"expression column", "users", []string{"LOWER(name)"}, [][]interface{}{{"Ada"}}},
+		{"qualified column", "users", []string{"u.name"}, [][]interface{}{{"Ada"}}},
+		{"duplicate column", "users", []string{"name", "name"}, [][]interface{}{{"Ada", "Ada"}}},
+		{"short row", "users", []string{"name", "email"}, [][]interface{}{{"Ada"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &execDB{}
+			_, err := BulkInsert(ctx, db, Postgres, tt.table, tt.columns, tt.rows)
+			var validationErr *ValidationError
+			assert.ErrorAs(t, err, &validationErr)
+			assert.Empty(t, db.queries)
+		})
+	}
+}
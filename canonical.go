@@ -0,0 +1,153 @@
+package sqld
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// canonicalOperators are the names CanonicalQuery writes for each operator
+var canonicalOperators = map[Operator]string{
+	OpEq:               "eq",
+	OpNe:               "ne",
+	OpGt:               "gt",
+	OpGte:              "gte",
+	OpLt:               "lt",
+	OpLte:              "lte",
+	OpLike:             "like",
+	OpILike:            "ilike",
+	OpContains:         "contains",
+	OpIncludes:         "contains",
+	OpDoesNotContain:   "doesNotContain",
+	OpStartsWith:       "startsWith",
+	OpEndsWith:         "endsWith",
+	OpDoesNotStartWith: "doesNotStartWith",
+	OpDoesNotEndWith:   "doesNotEndWith",
+	OpBetween:          "between",
+	OpBefore:           "before",
+	OpAfter:            "after",
+	OpIn:               "in",
+	OpNotIn:            "notIn",
+	OpIsNull:           "isNull",
+	OpIsNotNull:        "isNotNull",
+	OpNear:             "near",
+	OpWithin:           "within",
+	OpOverlaps:         "overlaps",
+	OpSimilar:          "similar",
+}
+
+// canonicalEscaper restores characters url.QueryEscape encodes but the
+// filter syntax reads literally, keeping canonical links legible
+var canonicalEscaper = strings.NewReplacer(
+	"%5B", "[", "%5D", "]", "%28", "(", "%29", ")",
+	"%2C", ",", "%3A", ":", "%40", "@", "%3D", "=",
+)
+
+// CanonicalQuery re-serializes parsed filters and sort fields into a query
+// string that parses back to the same conditions. Equivalent requests get
+// the same string: filters are sorted, operator aliases are normalized
+// (sw becomes startsWith) and field names are written as configured, which
+// makes it suitable for shareable links and cache keys. Sort fields keep
+// their order.
+//
+// Filters from free-text search and presets are written out as the
+// conditions they expanded to.
+//
+// Example:
+//
+//	filters, _ := sqld.ParseRequest(r, config)
+//	sortFields := sqld.ParseSortFields(r.URL.Query().Get("sort"))
+//	key := sqld.CanonicalQuery(filters, sortFields, config)
+func CanonicalQuery(filters []Filter, sortFields []SortField, config *Config) string {
+	if config == nil {
+		config = DefaultConfig()
+	}
+
+	// Filters carry columns; write the field names clients use instead
+	names := make(map[string]string, len(config.AllowedFields))
+	for name, allowed := range config.AllowedFields {
+		if allowed {
+			names[config.ColumnFor(name)] = name
+		}
+	}
+
+	params := canonicalFilters(filters, names, config)
+	sort.Strings(params)
+
+	if len(sortFields) > 0 {
+		specs := make([]string, len(sortFields))
+		for i, field := range sortFields {
+			direction := field.Direction
+			if direction == "" {
+				direction = SortAsc
+			}
+			spec := config.canonicalField(field.Field) + ":" + strings.ToLower(string(direction))
+			if field.Nulls != NullsDefault {
+				spec += ":nulls" + strings.ToLower(string(field.Nulls))
+			}
+			specs[i] = spec
+		}
+		params = append(params, "sort="+canonicalEscaper.Replace(url.QueryEscape(strings.Join(specs, ","))))
+	}
+
+	return strings.Join(params, "&")
+}
+
+// canonicalFilters writes each filter as a key=value parameter
+func canonicalFilters(filters []Filter, names map[string]string, config *Config) []string {
+	var params []string
+	for _, filter := range filters {
+		switch filter.Operator {
+		case OpOr:
+			items := canonicalFilters(filter.Or, names, config)
+			sort.Strings(items)
+			params = append(params, OrParam+"=("+strings.Join(items, ",")+")")
+		case OpExists:
+			params = append(params, canonicalFilters(filter.Related, names, config)...)
+		default:
+			name, ok := names[filter.Field]
+			if !ok {
+				name = filter.Field
+			}
+			key := name
+			if filter.Operator != config.DefaultOperator {
+				key += "[" + canonicalOperators[filter.Operator] + "]"
+			}
+			params = append(params, canonicalEscaper.Replace(url.QueryEscape(key))+"="+
+				canonicalEscaper.Replace(url.QueryEscape(canonicalValue(filter.Value))))
+		}
+	}
+	return params
+}
+
+// canonicalValue formats a parsed filter value as the parser reads it
+func canonicalValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "true"
+	case string:
+		return v
+	case []string:
+		return strings.Join(v, ",")
+	case []float64:
+		parts := make([]string, len(v))
+		for i, n := range v {
+			parts[i] = strconv.FormatFloat(n, 'f', -1, 64)
+		}
+		return strings.Join(parts, ",")
+	case rangeValue:
+		parts := make([]string, len(v.values))
+		for i, bound := range v.values {
+			if bound != nil {
+				parts[i] = canonicalValue(bound)
+			}
+		}
+		return strings.Join(parts, ",")
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
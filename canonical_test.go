@@ -0,0 +1,64 @@
+package sqld
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCanonicalQuery(t *testing.T) {
+	config := DefaultConfig().
+		WithAllowedFields(map[string]bool{
+			"name": true, "email": true, "age": true, "status": true, "deleted_at": true,
+			"location": true, "booked": true, "orders.total": true,
+		}).
+		WithFieldMappings(map[string]string{"mail": "email"}).
+		WithGeoFields("location").
+		WithFieldType("booked", "int4range").
+		WithRelation("orders", Relation{Table: "orders", LocalColumn: "users.id", ForeignColumn: "orders.user_id"})
+
+	t.Run("normalizes and sorts", func(t *testing.T) {
+		a, err := ParseQueryString("status[in]=active,pending&name_sw=Jo&age[gt]=21&mail[includes]=@example.com", config)
+		require.NoError(t, err)
+		b, err := ParseQueryString("mail[contains]=@example.com&age_gt=21&name[startswith]=Jo&status[in]=active,pending", config)
+		require.NoError(t, err)
+
+		canonical := CanonicalQuery(a, []SortField{{Field: "age", Direction: SortDesc}, {Field: "name"}}, config)
+		assert.Equal(t, "age[gt]=21&email[contains]=@example.com&name[startsWith]=Jo&status[in]=active,pending&sort=age:desc,name:asc", canonical)
+		assert.Equal(t, canonical, CanonicalQuery(b, []SortField{{Field: "age", Direction: SortDesc}, {Field: "name", Direction: SortAsc}}, config))
+	})
+
+	t.Run("round trips", func(t *testing.T) {
+		query := "or=(name[contains]=jo,email[endsWith]=@example.com)&status=a%26b" +
+			"&age[between]=18,65&location[near]=52.52,13.405,5000&booked[overlaps]=10," +
+			"&orders.total[gte]=100&deleted_at[isNull]=true"
+
+		filters, err := ParseQueryString(query, config)
+		require.NoError(t, err)
+
+		canonical := CanonicalQuery(filters, nil, config)
+		reparsed, err := ParseQueryString(canonical, config)
+		require.NoError(t, err)
+
+		want := NewWhereBuilder(Postgres)
+		require.NoError(t, ApplyFiltersToBuilder(filters, want))
+		got := NewWhereBuilder(Postgres)
+		require.NoError(t, ApplyFiltersToBuilder(reparsed, got))
+
+		_, wantParams := want.Build()
+		_, gotParams := got.Build()
+		assert.ElementsMatch(t, wantParams, gotParams)
+		assert.Equal(t, canonical, CanonicalQuery(reparsed, nil, config), "canonical form is stable")
+		assert.Contains(t, canonical, "status=a%26b")
+	})
+
+	t.Run("explicit eq with another default operator", func(t *testing.T) {
+		config := DefaultConfig().WithAllowedFields(map[string]bool{"name": true})
+		config.DefaultOperator = OpContains
+
+		filters, err := ParseQueryString("name[eq]=ann", config)
+		require.NoError(t, err)
+		assert.Equal(t, "name[eq]=ann", CanonicalQuery(filters, nil, config))
+	})
+}
@@ -0,0 +1,45 @@
+// Package chisqld binds sqld query parameters in chi routers. chi uses plain
+// net/http middleware, so this package has no dependency on chi itself.
+//
+//	r := chi.NewRouter()
+//	r.With(chisqld.Middleware(sqld.Postgres, config)).Get("/users", listUsers)
+//
+//	func listUsers(w http.ResponseWriter, r *http.Request) {
+//		params := chisqld.MustFromRequest(r)
+//		users, err := exec.QueryAll(r.Context(), db.SearchUsers, params.Where, params.Cursor, params.OrderBy, 50)
+//		...
+//	}
+package chisqld
+
+import (
+	"net/http"
+
+	"github.com/getangry/sqld"
+)
+
+// Middleware parses filters, sorting and the cursor of each request into its
+// context. Invalid requests are answered with 400 Bad Request.
+func Middleware(dialect sqld.Dialect, config *sqld.Config) func(http.Handler) http.Handler {
+	return sqld.QueryParamsMiddleware(dialect, config)
+}
+
+// MiddlewareFor is like Middleware with the config of resource taken from
+// provider for every request, so config changes apply without a restart
+func MiddlewareFor(dialect sqld.Dialect, provider sqld.ConfigProvider, resource string) func(http.Handler) http.Handler {
+	return sqld.QueryParamsMiddlewareFor(dialect, provider, resource)
+}
+
+// FromRequest returns the params parsed by Middleware
+func FromRequest(r *http.Request) (*sqld.QueryParams, bool) {
+	return sqld.QueryParamsFromContext(r.Context())
+}
+
+// MustFromRequest is like FromRequest but panics when Middleware did not run
+// for r, which is a routing mistake rather than a client error
+func MustFromRequest(r *http.Request) *sqld.QueryParams {
+	params, ok := FromRequest(r)
+	if !ok {
+		panic("chisqld: no query params in request context, is the middleware installed?")
+	}
+	return params
+}
@@ -0,0 +1,67 @@
+package chisqld
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/getangry/sqld"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testConfig() *sqld.Config {
+	return sqld.DefaultConfig().
+		WithAllowedFields(map[string]bool{"name": true, "created_at": true}).
+		WithStrictFields(true).
+		WithLimits(20, 100)
+}
+
+func TestMiddleware(t *testing.T) {
+	var params *sqld.QueryParams
+	handler := Middleware(sqld.Postgres, testConfig())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		params = MustFromRequest(r)
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users?name=ann&sort=-created_at&limit=5", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	sql, args := params.Where.Build()
+	assert.Equal(t, "name = $1", sql)
+	assert.Equal(t, []interface{}{"ann"}, args)
+	assert.Equal(t, "created_at DESC", params.OrderBy.Build())
+	assert.Equal(t, 5, params.Limit)
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users?password=x", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestMiddlewareFor(t *testing.T) {
+	registry := sqld.NewSchemaRegistry().MustRegister("users", testConfig())
+
+	var params *sqld.QueryParams
+	handler := MiddlewareFor(sqld.Postgres, registry, "users")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		params = MustFromRequest(r)
+	}))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 20, params.Limit)
+
+	w = httptest.NewRecorder()
+	MiddlewareFor(sqld.Postgres, registry, "orders")(handler).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/orders", nil))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
+
+func TestFromRequest(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/users", nil)
+	_, ok := FromRequest(r)
+	assert.False(t, ok)
+	assert.Panics(t, func() { MustFromRequest(r) })
+
+	params := &sqld.QueryParams{Limit: 10}
+	r = r.WithContext(sqld.WithQueryParams(context.Background(), params))
+	assert.Same(t, params, MustFromRequest(r))
+}
@@ -0,0 +1,17 @@
+module github.com/getangry/sqld/cmd/sqld
+
+go 1.23.0
+
+require (
+	github.com/getangry/sqld v0.1.1
+	github.com/jackc/pgx/v5 v5.5.1
+)
+
+require (
+	github.com/jackc/pgpassfile v1.0.0 // indirect
+	github.com/jackc/pgservicefile v0.0.0-20221227161230-091c0ba34f0a // indirect
+	golang.org/x/crypto v0.9.0 // indirect
+	golang.org/x/text v0.9.0 // indirect
+)
+
+replace github.com/getangry/sqld => ../../
@@ -0,0 +1,58 @@
+// Command sqld is a toolbox for working with sqld outside a running service.
+//
+// Usage:
+//
+//	sqld query [flags] <query string>     show, and optionally run, the SQL for a request
+//	sqld typescript [flags] <schema>...   generate TypeScript types and query builders
+//	sqld vet <file.sql | directory>...    check the sqld annotations of sqlc queries
+//
+// Run "sqld <command> -h" for the flags of a command.
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// command is a sqld subcommand
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) error
+}
+
+var commands = []command{
+	{"query", "show and optionally run the SQL an annotated query produces for a request", runQuery},
+	{"typescript", "generate TypeScript types and query builders from schemas", runTypeScript},
+	{"vet", "check the sqld annotations of sqlc query files", runVet},
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	for _, cmd := range commands {
+		if cmd.name == os.Args[1] {
+			if err := cmd.run(os.Args[2:]); err != nil {
+				fmt.Fprintf(os.Stderr, "sqld %s: %v\n", cmd.name, err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "sqld: unknown command %q\n", os.Args[1])
+	usage()
+	os.Exit(2)
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: sqld <command> [arguments]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-12s %s\n", cmd.name, cmd.summary)
+	}
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/getangry/sqld"
+	"github.com/jackc/pgx/v5"
+)
+
+// stringList collects a repeatable flag
+type stringList []string
+
+func (l *stringList) String() string     { return strings.Join(*l, ",") }
+func (l *stringList) Set(v string) error { *l = append(*l, v); return nil }
+
+// runQuery implements "sqld query": it applies a request query string to an
+// annotated query, prints the resulting SQL and parameters, and runs it when
+// a DSN is given
+func runQuery(args []string) error {
+	flags := flag.NewFlagSet("query", flag.ContinueOnError)
+	sqlText := flags.String("sql", "", "annotated base query")
+	file := flags.String("file", "", "sqlc query file to read the base query from (with -name)")
+	name := flags.String("name", "", "name of the query in -file")
+	dialectName := flags.String("dialect", "postgres", "SQL dialect: postgres, mysql, sqlite or clickhouse")
+	fields := flags.String("fields", "", "comma-separated fields that may be filtered and sorted (default: every field in the query string)")
+	dsn := flags.String("dsn", "", "PostgreSQL connection string; when set the query is executed")
+	var params stringList
+	flags.Var(&params, "param", "value for the base query's own parameters, in order (repeatable)")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), `usage: sqld query [flags] "age[gte]=18&sort=-created_at&limit=10"`)
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 1 {
+		flags.Usage()
+		return fmt.Errorf("expected a single query string")
+	}
+
+	dialect, err := parseDialect(*dialectName)
+	if err != nil {
+		return err
+	}
+	base, err := baseQuery(*sqlText, *file, *name, dialect)
+	if err != nil {
+		return err
+	}
+
+	processed, values, err := buildQuery(base, flags.Arg(0), dialect, *fields, params)
+	if err != nil {
+		return err
+	}
+	printQuery(os.Stdout, processed, values)
+
+	if *dsn == "" {
+		return nil
+	}
+	if dialect != sqld.Postgres {
+		return fmt.Errorf("executing queries is only supported for postgres")
+	}
+	return execute(*dsn, processed, values, os.Stdout)
+}
+
+// baseQuery returns the query given with -sql or read from a sqlc query file
+func baseQuery(sqlText, file, name string, dialect sqld.Dialect) (string, error) {
+	switch {
+	case sqlText != "" && file != "":
+		return "", fmt.Errorf("use either -sql or -file, not both")
+	case sqlText != "":
+		return sqlText, nil
+	case file != "":
+		if name == "" {
+			return "", fmt.Errorf("-file requires -name")
+		}
+		query, err := findQuery(file, name)
+		if err != nil {
+			return "", err
+		}
+		return rewriteNamedParams(query.SQL, dialect), nil
+	default:
+		return "", fmt.Errorf("a base query is required, use -sql or -file")
+	}
+}
+
+// buildQuery parses queryString like an HTTP request and expands the
+// annotations of base with it. Only fields are allowed; when it is empty,
+// every field filtered in queryString is.
+func buildQuery(base, queryString string, dialect sqld.Dialect, fields string, params []string) (string, []interface{}, error) {
+	values, err := url.ParseQuery(strings.TrimPrefix(queryString, "?"))
+	if err != nil {
+		return "", nil, err
+	}
+
+	config := sqld.DefaultConfig().WithStrictFields(true)
+	for _, field := range strings.Split(fields, ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			config.AllowedFields[field] = true
+		}
+	}
+	if len(config.AllowedFields) == 0 {
+		// Without an allowlist sort and pagination parameters would be
+		// parsed as filters, so allow exactly the filtered and sorted fields
+		for key := range values {
+			if !config.IsReservedParam(key) {
+				config.AllowedFields[strings.SplitN(key, "[", 2)[0]] = true
+			}
+		}
+		orderBy, err := sqld.ParseSortFromValues(values, sqld.DefaultConfig())
+		if err != nil {
+			return "", nil, err
+		}
+		for _, field := range orderBy.GetFields() {
+			config.AllowedFields[field.Field] = true
+		}
+	}
+
+	req := &http.Request{Method: http.MethodGet, URL: &url.URL{RawQuery: values.Encode()}}
+	bound, err := sqld.BindRequest(req, dialect, config)
+	if err != nil {
+		return "", nil, err
+	}
+
+	args := make([]interface{}, len(params))
+	for i, param := range params {
+		args[i] = param
+	}
+	return sqld.NewAnnotationProcessor(dialect).ProcessQuery(base, bound.Where, bound.Cursor, bound.OrderBy, bound.Limit, args...)
+}
+
+func printQuery(w io.Writer, sql string, params []interface{}) {
+	fmt.Fprintln(w, sql)
+	for i, param := range params {
+		fmt.Fprintf(w, "  $%d = %#v\n", i+1, param)
+	}
+}
+
+// execute runs sql against a PostgreSQL database and prints each row as a
+// JSON object
+func execute(dsn, sql string, params []interface{}, w io.Writer) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	conn, err := pgx.Connect(ctx, dsn)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(ctx)
+
+	rows, err := conn.Query(ctx, sql, params...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	fields := rows.FieldDescriptions()
+	encoder := json.NewEncoder(w)
+	count := 0
+	for rows.Next() {
+		values, err := rows.Values()
+		if err != nil {
+			return err
+		}
+		row := make(map[string]interface{}, len(values))
+		for i, value := range values {
+			row[fields[i].Name] = value
+		}
+		if err := encoder.Encode(row); err != nil {
+			return err
+		}
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "(%d rows)\n", count)
+	return nil
+}
+
+func parseDialect(name string) (sqld.Dialect, error) {
+	switch dialect := sqld.Dialect(strings.ToLower(name)); dialect {
+	case sqld.Postgres, sqld.MySQL, sqld.SQLite, sqld.ClickHouse:
+		return dialect, nil
+	default:
+		return "", fmt.Errorf("unknown dialect %q", name)
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/getangry/sqld"
+)
+
+const queriesSQL = `-- name: GetUser :one
+SELECT * FROM users WHERE id = $1;
+
+-- name: ListUsers :many
+SELECT * FROM users
+WHERE status = sqlc.arg(status) AND org_id = @org_id AND owner_id = @org_id /* sqld:where */
+ORDER BY id /* sqld:orderby */ /* sqld:limit */;
+`
+
+func writeQueries(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "queries.sql")
+	if err := os.WriteFile(path, []byte(queriesSQL), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestParseQueryFile(t *testing.T) {
+	path := writeQueries(t)
+	queries, err := parseQueryFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(queries) != 2 {
+		t.Fatalf("got %d queries, want 2", len(queries))
+	}
+	if q := queries[0]; q.Name != "GetUser" || q.Kind != ":one" || q.SQL != "SELECT * FROM users WHERE id = $1" || q.Line != 1 {
+		t.Errorf("unexpected first query %+v", q)
+	}
+	if q := queries[1]; q.Name != "ListUsers" || q.Line != 4 || strings.HasSuffix(q.SQL, ";") {
+		t.Errorf("unexpected second query %+v", q)
+	}
+
+	if _, err := findQuery(path, "DeleteUser"); err == nil {
+		t.Error("expected an error for an unknown query")
+	}
+}
+
+func TestRewriteNamedParams(t *testing.T) {
+	sql := "status = sqlc.arg(status) AND org_id = @org_id AND owner_id = @org_id AND kind = sqlc.narg('kind')"
+
+	if got, want := rewriteNamedParams(sql, sqld.Postgres),
+		"status = $1 AND org_id = $2 AND owner_id = $2 AND kind = $3"; got != want {
+		t.Errorf("postgres: got %q, want %q", got, want)
+	}
+	if got, want := rewriteNamedParams(sql, sqld.MySQL),
+		"status = ? AND org_id = ? AND owner_id = ? AND kind = ?"; got != want {
+		t.Errorf("mysql: got %q, want %q", got, want)
+	}
+}
+
+func TestBuildQuery(t *testing.T) {
+	base, err := baseQuery("", writeQueries(t), "ListUsers", sqld.Postgres)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sql, params, err := buildQuery(base, "?name[contains]=ann&sort=-created_at&limit=5", sqld.Postgres, "", []string{"active", "7"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"status = $1 AND org_id = $2", "name ILIKE $3", "ORDER BY created_at DESC", "LIMIT $4"} {
+		if !strings.Contains(sql, want) {
+			t.Errorf("sql %q does not contain %q", sql, want)
+		}
+	}
+	if want := []interface{}{"active", "7", "%ann%", 5}; !reflect.DeepEqual(params, want) {
+		t.Errorf("got params %v, want %v", params, want)
+	}
+
+	if _, _, err := buildQuery("SELECT 1", "limit=-1", sqld.Postgres, "", nil); err == nil {
+		t.Error("expected an error for an invalid limit")
+	}
+	if _, _, err := buildQuery("SELECT 1 /* sqld:where */", "age=1", sqld.Postgres, "name", nil); err == nil {
+		t.Error("expected an error for a field outside -fields")
+	}
+}
+
+func TestBaseQuery_Errors(t *testing.T) {
+	for name, args := range map[string][3]string{
+		"none":     {"", "", ""},
+		"both":     {"SELECT 1", "queries.sql", "GetUser"},
+		"no name":  {"", "queries.sql", ""},
+		"no query": {"", "missing.sql", "GetUser"},
+	} {
+		if _, err := baseQuery(args[0], args[1], args[2], sqld.Postgres); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/getangry/sqld"
+)
+
+// sqlcQuery is a query read from a sqlc query file
+type sqlcQuery struct {
+	Name    string
+	Kind    string // :one, :many, :exec, ...
+	SQL     string
+	File    string
+	Line    int // line of the "-- name:" comment
+	SQLLine int // line SQL starts on
+}
+
+// queryNamePattern matches sqlc's "-- name: GetUser :one" marker
+var queryNamePattern = regexp.MustCompile(`^--\s*name:\s*(\w+)\s*(:\w+)?`)
+
+// parseQueryFile splits a sqlc query file into its named queries
+func parseQueryFile(path string) ([]sqlcQuery, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var queries []sqlcQuery
+	var body []string
+	flush := func() {
+		if len(queries) > 0 {
+			query := &queries[len(queries)-1]
+			query.SQLLine = query.Line + 1
+			for len(body) > 0 && strings.TrimSpace(body[0]) == "" {
+				body = body[1:]
+				query.SQLLine++
+			}
+			sql := strings.TrimSpace(strings.Join(body, "\n"))
+			query.SQL = strings.TrimSpace(strings.TrimSuffix(sql, ";"))
+		}
+		body = body[:0]
+	}
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		if match := queryNamePattern.FindStringSubmatch(strings.TrimSpace(text)); match != nil {
+			flush()
+			queries = append(queries, sqlcQuery{Name: match[1], Kind: match[2], File: path, Line: line})
+			continue
+		}
+		body = append(body, text)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	flush()
+	return queries, nil
+}
+
+// findQuery returns the query called name from a sqlc query file
+func findQuery(path, name string) (*sqlcQuery, error) {
+	queries, err := parseQueryFile(path)
+	if err != nil {
+		return nil, err
+	}
+	for i := range queries {
+		if queries[i].Name == name {
+			return &queries[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%s: no query named %s", path, name)
+}
+
+// namedParamPattern matches sqlc.arg(name), sqlc.narg(name) and @name
+var namedParamPattern = regexp.MustCompile(`sqlc\.n?arg\(\s*'?(\w+)'?\s*\)|@(\w+)`)
+
+// rewriteNamedParams replaces sqlc.arg(name), sqlc.narg(name) and @name with
+// the dialect's placeholders, numbering names in order of first use as sqlc
+// does. It is a textual rewrite meant for debugging; an @ inside a string
+// literal is rewritten too.
+func rewriteNamedParams(sql string, dialect sqld.Dialect) string {
+	numbers := make(map[string]int)
+	replace := func(name string) string {
+		if dialect != sqld.Postgres {
+			return "?"
+		}
+		n, ok := numbers[name]
+		if !ok {
+			n = len(numbers) + 1
+			numbers[name] = n
+		}
+		return "$" + strconv.Itoa(n)
+	}
+
+	return namedParamPattern.ReplaceAllStringFunc(sql, func(match string) string {
+		groups := namedParamPattern.FindStringSubmatch(match)
+		return replace(groups[1] + groups[2])
+	})
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/getangry/sqld"
+)
+
+// runTypeScript implements "sqld typescript". Each argument is a file or URL
+// holding either a QuerySchema or a SchemaIndex; the schemas an index points
+// to are fetched relative to it.
+func runTypeScript(args []string) error {
+	flags := flag.NewFlagSet("typescript", flag.ContinueOnError)
+	output := flags.String("out", "", "output file (default stdout)")
+	name := flags.String("name", "", "resource name for a single schema (default: file name)")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: sqld typescript [flags] <schema file or URL>...")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return fmt.Errorf("no schema given")
+	}
+	if *name != "" && flags.NArg() > 1 {
+		return fmt.Errorf("-name requires a single schema")
+	}
+
+	schemas := make(map[string]*sqld.QuerySchema)
+	for _, source := range flags.Args() {
+		if err := loadSchemas(source, *name, schemas); err != nil {
+			return err
+		}
+	}
+
+	src, err := sqld.GenerateTypeScript(schemas)
+	if err != nil {
+		return err
+	}
+	if *output == "" {
+		_, err = os.Stdout.Write(src)
+		return err
+	}
+	return os.WriteFile(*output, src, 0o644)
+}
+
+// loadSchemas reads source and adds its schema, or every schema listed by
+// its index, to schemas
+func loadSchemas(source, name string, schemas map[string]*sqld.QuerySchema) error {
+	data, err := readSource(source)
+	if err != nil {
+		return err
+	}
+
+	var document struct {
+		sqld.QuerySchema
+		Resources []sqld.SchemaIndexEntry `json:"resources"`
+	}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return fmt.Errorf("%s: %w", source, err)
+	}
+
+	if document.Resources == nil {
+		if name == "" {
+			name = strings.TrimSuffix(filepath.Base(source), filepath.Ext(source))
+		}
+		schema := document.QuerySchema
+		schemas[name] = &schema
+		return nil
+	}
+
+	for _, entry := range document.Resources {
+		location, err := resolve(source, entry.SchemaURL)
+		if err != nil {
+			return err
+		}
+		data, err := readSource(location)
+		if err != nil {
+			return err
+		}
+		var schema sqld.QuerySchema
+		if err := json.Unmarshal(data, &schema); err != nil {
+			return fmt.Errorf("%s: %w", location, err)
+		}
+		schemas[entry.Name] = &schema
+	}
+	return nil
+}
+
+// resolve returns ref relative to the index it was listed in
+func resolve(index, ref string) (string, error) {
+	if !isURL(index) {
+		return filepath.Join(filepath.Dir(index), filepath.FromSlash(strings.TrimPrefix(ref, "/"))), nil
+	}
+	base, err := url.Parse(index)
+	if err != nil {
+		return "", err
+	}
+	target, err := base.Parse(ref)
+	if err != nil {
+		return "", err
+	}
+	return target.String(), nil
+}
+
+// readSource reads a local file or fetches an http(s) URL
+func readSource(source string) ([]byte, error) {
+	if !isURL(source) {
+		return os.ReadFile(source)
+	}
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Get(source)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", source, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+func isURL(source string) bool {
+	return strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://")
+}
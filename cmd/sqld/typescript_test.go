@@ -0,0 +1,62 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/getangry/sqld"
+)
+
+func TestRunTypeScript_Index(t *testing.T) {
+	registry := sqld.NewSchemaRegistry().
+		MustRegister("users", sqld.DefaultConfig().WithAllowedFields(map[string]bool{"name": true})).
+		MustRegister("line_items", sqld.DefaultConfig().WithAllowedFields(map[string]bool{"price": true}))
+	mux := http.NewServeMux()
+	mux.Handle("/schemas/", registry.Handler("/schemas"))
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	out := filepath.Join(t.TempDir(), "sqld.ts")
+	if err := runTypeScript([]string{"-out", out, server.URL + "/schemas/"}); err != nil {
+		t.Fatal(err)
+	}
+
+	src, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"export class UsersQuery extends",
+		"export class LineItemsQuery extends",
+		`  "price": number;`,
+	} {
+		if !strings.Contains(string(src), want) {
+			t.Errorf("output does not contain %q", want)
+		}
+	}
+}
+
+func TestRunTypeScript_File(t *testing.T) {
+	dir := t.TempDir()
+	schema := filepath.Join(dir, "orders.json")
+	if err := os.WriteFile(schema, []byte(`{"fields":[{"name":"total","type":"number","filterable":true,"sortable":true,"operators":["eq","gt"]}]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	out := filepath.Join(dir, "orders.ts")
+	if err := runTypeScript([]string{"-out", out, schema}); err != nil {
+		t.Fatal(err)
+	}
+	src, _ := os.ReadFile(out)
+	if !strings.Contains(string(src), `  "total": "eq" | "gt";`) {
+		t.Errorf("unexpected output:\n%s", src)
+	}
+
+	if err := runTypeScript([]string{"-name", "x", schema, schema}); err == nil {
+		t.Error("expected -name with several schemas to fail")
+	}
+}
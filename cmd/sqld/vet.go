@@ -0,0 +1,91 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/getangry/sqld"
+)
+
+// runVet implements "sqld vet": it checks the annotations of every query in
+// the given sqlc query files and directories
+func runVet(args []string) error {
+	flags := flag.NewFlagSet("vet", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: sqld vet <file.sql | directory>...")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return fmt.Errorf("no files to vet")
+	}
+
+	files, err := sqlFiles(flags.Args())
+	if err != nil {
+		return err
+	}
+	problems, err := vetFiles(os.Stdout, files)
+	if err != nil {
+		return err
+	}
+	if problems > 0 {
+		return fmt.Errorf("%d problem(s) found", problems)
+	}
+	return nil
+}
+
+// sqlFiles expands directories in paths to the .sql files below them
+func sqlFiles(paths []string) ([]string, error) {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		err = filepath.WalkDir(path, func(file string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !entry.IsDir() && strings.EqualFold(filepath.Ext(file), ".sql") {
+				files = append(files, file)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// vetFiles reports the annotation issues of every query in files to w, one
+// per line as file:line: query: message, and returns how many it found
+func vetFiles(w io.Writer, files []string) (int, error) {
+	problems := 0
+	for _, file := range files {
+		queries, err := parseQueryFile(file)
+		if err != nil {
+			return problems, err
+		}
+		for _, query := range queries {
+			for _, issue := range sqld.VetAnnotations(query.SQL) {
+				line := query.SQLLine + strings.Count(query.SQL[:issue.Offset], "\n")
+				fmt.Fprintf(w, "%s:%d: %s: %s\n", file, line, query.Name, issue)
+				problems++
+			}
+		}
+	}
+	return problems, nil
+}
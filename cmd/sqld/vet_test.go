@@ -0,0 +1,41 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestVetFiles(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "users.sql"), []byte(`-- name: ListUsers :many
+
+SELECT * FROM users
+/* sqld:where */
+ORDER BY id /* sqld:orderby */;
+
+-- name: CountUsers :one
+SELECT count(*) FROM users WHERE 1=1 /* sqld:where */;
+`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "README.md"), []byte("/* sqld:bogus */"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := sqlFiles([]string{dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out strings.Builder
+	problems, err := vetFiles(&out, files)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := filepath.Join(dir, "users.sql") + ":4: ListUsers: /* sqld:where */: query has no WHERE clause; conditions are appended with AND\n"
+	if problems != 1 || out.String() != want {
+		t.Errorf("got %d problems:\n%s\nwant:\n%s", problems, out.String(), want)
+	}
+}
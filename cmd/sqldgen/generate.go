@@ -0,0 +1,489 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/getangry/sqld"
+)
+
+// queryHeaderPattern matches sqlc's "-- name: SearchUsers :many" query header
+var queryHeaderPattern = regexp.MustCompile(`--\s*name:\s*(\w+)\s+:(\w+)`)
+
+// reservedParams are the parameter names used by the generated wrappers
+var reservedParams = map[string]bool{
+	"ctx": true, "q": true, "where": true, "orderBy": true, "cursor": true, "limit": true,
+}
+
+// wrapper describes one generated query function
+type wrapper struct {
+	Name    string // sqlc query name, e.g. SearchUsers
+	Const   string // constant holding the query text
+	Command string // sqlc command: many or one
+	RowType string
+	Params  string // extra parameters copied from the sqlc method
+	Args    string // expressions passed as query parameters
+}
+
+// model describes a row type that gets a Config skeleton
+type model struct {
+	Name   string
+	Fields []string
+}
+
+// scanner describes a generated ScanFunc for a struct
+type scanner struct {
+	Name    string
+	Targets []string // scan destinations in field order, e.g. &i.ID
+	Ignored bool     // whether an unexported field needs the ignored destination
+}
+
+// generation is the input of outputTemplate
+type generation struct {
+	Package    string
+	StdImports []string
+	Imports    []string
+	Wrappers   []wrapper
+	Models     []model
+	Scanners   []scanner
+}
+
+// packageInfo holds what the generator reads from the sqlc package
+type packageInfo struct {
+	name    string
+	fset    *token.FileSet
+	queries map[string]string // annotated query constants by name
+	structs map[string]*ast.StructType
+	methods []*ast.FuncDecl // methods on *Queries
+	imports map[string]string
+}
+
+// generate reads the sqlc-generated Go files in dir and returns the source of
+// the sqld wrappers for every query carrying sqld annotations, plus a
+// reflection-free ScanFunc for each struct named in scanners. The output
+// file itself is ignored so the generator can be re-run.
+func generate(dir, output string, scanners []string) ([]byte, error) {
+	info, err := loadPackage(dir, output)
+	if err != nil {
+		return nil, err
+	}
+
+	gen := generation{Package: info.name}
+	imports := map[string]bool{
+		strconv.Quote("context"):                  true,
+		strconv.Quote("github.com/getangry/sqld"): true,
+	}
+	models := make(map[string]bool)
+
+	for _, method := range info.methods {
+		w, used, err := info.wrapperFor(method)
+		if err != nil {
+			return nil, err
+		}
+		if w == nil {
+			continue
+		}
+		gen.Wrappers = append(gen.Wrappers, *w)
+		for _, pkg := range used {
+			path, ok := info.imports[pkg]
+			if !ok {
+				return nil, fmt.Errorf("%s: package %s is not imported", w.Name, pkg)
+			}
+			imports[path] = true
+		}
+		models[w.RowType] = true
+	}
+	for _, name := range scanners {
+		st, ok := info.structs[name]
+		if !ok {
+			return nil, fmt.Errorf("scanner: no struct %s in %s", name, dir)
+		}
+		gen.Scanners = append(gen.Scanners, scannerFor(name, st))
+	}
+	if len(gen.Wrappers) == 0 && len(gen.Scanners) == 0 {
+		return nil, fmt.Errorf("no queries with sqld annotations found in %s", dir)
+	}
+	if len(gen.Wrappers) == 0 {
+		// Scanners alone only need the sqld import
+		imports = map[string]bool{strconv.Quote("github.com/getangry/sqld"): true}
+	}
+
+	for path := range imports {
+		if strings.Contains(path, ".") {
+			gen.Imports = append(gen.Imports, path)
+		} else {
+			gen.StdImports = append(gen.StdImports, path)
+		}
+	}
+	sort.Strings(gen.StdImports)
+	sort.Strings(gen.Imports)
+
+	for name := range models {
+		if st, ok := info.structs[name]; ok {
+			gen.Models = append(gen.Models, model{Name: name, Fields: columnNames(st)})
+		}
+	}
+	sort.Slice(gen.Models, func(i, j int) bool { return gen.Models[i].Name < gen.Models[j].Name })
+
+	var buf bytes.Buffer
+	if err := outputTemplate.Execute(&buf, gen); err != nil {
+		return nil, err
+	}
+	return format.Source(buf.Bytes())
+}
+
+// loadPackage parses the non-test Go files of dir
+func loadPackage(dir, output string) (*packageInfo, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+
+	info := &packageInfo{
+		fset:    token.NewFileSet(),
+		queries: make(map[string]string),
+		structs: make(map[string]*ast.StructType),
+		imports: make(map[string]string),
+	}
+	for _, path := range paths {
+		if strings.HasSuffix(path, "_test.go") || filepath.Base(path) == filepath.Base(output) {
+			continue
+		}
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		file, err := parser.ParseFile(info.fset, path, src, 0)
+		if err != nil {
+			return nil, err
+		}
+		if info.name == "" {
+			info.name = file.Name.Name
+		}
+		info.collect(file)
+	}
+	if info.name == "" {
+		return nil, fmt.Errorf("no Go files found in %s", dir)
+	}
+	return info, nil
+}
+
+// collect records the query constants, struct types, Queries methods and
+// imports declared in file
+func (info *packageInfo) collect(file *ast.File) {
+	for _, imp := range file.Imports {
+		path, _ := strconv.Unquote(imp.Path.Value)
+		name := path[strings.LastIndex(path, "/")+1:]
+		if imp.Name != nil {
+			name = imp.Name.Name
+		}
+		info.imports[name] = imp.Path.Value
+	}
+
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				switch spec := spec.(type) {
+				case *ast.ValueSpec:
+					info.collectConst(decl.Tok, spec)
+				case *ast.TypeSpec:
+					if st, ok := spec.Type.(*ast.StructType); ok {
+						info.structs[spec.Name.Name] = st
+					}
+				}
+			}
+		case *ast.FuncDecl:
+			if isQueriesMethod(decl) {
+				info.methods = append(info.methods, decl)
+			}
+		}
+	}
+}
+
+// collectConst records a string constant holding an annotated sqlc query
+func (info *packageInfo) collectConst(tok token.Token, spec *ast.ValueSpec) {
+	if tok != token.CONST || len(spec.Names) != 1 || len(spec.Values) != 1 {
+		return
+	}
+	lit, ok := spec.Values[0].(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return
+	}
+	text, err := strconv.Unquote(lit.Value)
+	if err != nil || !strings.Contains(text, "/* sqld:") {
+		return
+	}
+	info.queries[spec.Names[0].Name] = text
+}
+
+// isQueriesMethod reports whether decl is a method on sqlc's *Queries
+func isQueriesMethod(decl *ast.FuncDecl) bool {
+	if decl.Recv == nil || len(decl.Recv.List) != 1 || decl.Body == nil {
+		return false
+	}
+	star, ok := decl.Recv.List[0].Type.(*ast.StarExpr)
+	if !ok {
+		return false
+	}
+	ident, ok := star.X.(*ast.Ident)
+	return ok && ident.Name == "Queries"
+}
+
+// wrapperFor builds the wrapper for a sqlc method running an annotated query.
+// It returns nil when the method runs no annotated query, along with the
+// package names referenced by the copied parameter types.
+func (info *packageInfo) wrapperFor(method *ast.FuncDecl) (*wrapper, []string, error) {
+	constName, args := info.queryCall(method)
+	if constName == "" {
+		return nil, nil, nil
+	}
+	text := info.queries[constName]
+
+	header := queryHeaderPattern.FindStringSubmatch(text)
+	if header == nil {
+		return nil, nil, fmt.Errorf("%s: query has no sqlc name header", constName)
+	}
+	command := header[2]
+	if command != "many" && command != "one" {
+		return nil, nil, fmt.Errorf("%s: sqld annotations require a :many or :one query, got :%s", header[1], command)
+	}
+
+	if _, err := sqld.ParseAnnotations(text); err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", header[1], err)
+	}
+
+	results := method.Type.Results
+	if results == nil || len(results.List) != 2 {
+		return nil, nil, fmt.Errorf("%s: unexpected result list", header[1])
+	}
+	rowType := results.List[0].Type
+	if array, ok := rowType.(*ast.ArrayType); ok {
+		rowType = array.Elt
+	}
+	row, ok := rowType.(*ast.Ident)
+	if !ok {
+		return nil, nil, fmt.Errorf("%s: unsupported row type %s", header[1], info.render(rowType))
+	}
+
+	var params []string
+	var used []string
+	for i, field := range method.Type.Params.List {
+		if i == 0 {
+			continue // ctx
+		}
+		for _, name := range field.Names {
+			if reservedParams[name.Name] {
+				return nil, nil, fmt.Errorf("%s: parameter %q clashes with a generated parameter", header[1], name.Name)
+			}
+			params = append(params, name.Name+" "+info.render(field.Type))
+		}
+		used = append(used, packagesIn(field.Type)...)
+	}
+
+	var rendered []string
+	for _, arg := range args {
+		rendered = append(rendered, info.render(arg))
+	}
+
+	return &wrapper{
+		Name:    header[1],
+		Const:   constName,
+		Command: command,
+		RowType: row.Name,
+		Params:  strings.Join(params, ", "),
+		Args:    strings.Join(rendered, ", "),
+	}, used, nil
+}
+
+// queryCall finds the database call in method that runs an annotated query
+// constant, returning the constant and the query parameters passed after it
+func (info *packageInfo) queryCall(method *ast.FuncDecl) (string, []ast.Expr) {
+	var constName string
+	var args []ast.Expr
+	ast.Inspect(method.Body, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok || constName != "" {
+			return constName == ""
+		}
+		for i, arg := range call.Args {
+			if ident, ok := arg.(*ast.Ident); ok {
+				if _, annotated := info.queries[ident.Name]; annotated {
+					constName = ident.Name
+					args = call.Args[i+1:]
+					return false
+				}
+			}
+		}
+		return true
+	})
+	return constName, args
+}
+
+// render prints an expression as Go source
+func (info *packageInfo) render(expr ast.Expr) string {
+	var buf bytes.Buffer
+	_ = printer.Fprint(&buf, info.fset, expr)
+	return buf.String()
+}
+
+// packagesIn lists the package names referenced by a type expression
+func packagesIn(expr ast.Expr) []string {
+	var pkgs []string
+	ast.Inspect(expr, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if ident, ok := sel.X.(*ast.Ident); ok {
+				pkgs = append(pkgs, ident.Name)
+			}
+			return false
+		}
+		return true
+	})
+	return pkgs
+}
+
+// scannerFor lists the scan destinations of a struct's fields in declaration
+// order, matching the positional scanning of sqld.ReflectionScanner.
+// Unexported fields are scanned into a throwaway value, as reflection does.
+func scannerFor(name string, st *ast.StructType) scanner {
+	s := scanner{Name: name}
+	for _, field := range st.Fields.List {
+		names := field.Names
+		if len(names) == 0 {
+			names = []*ast.Ident{embeddedName(field.Type)}
+		}
+		for _, ident := range names {
+			if !ident.IsExported() {
+				s.Targets = append(s.Targets, "&ignored")
+				s.Ignored = true
+				continue
+			}
+			s.Targets = append(s.Targets, "&i."+ident.Name)
+		}
+	}
+	return s
+}
+
+// embeddedName returns the field name of an embedded type such as T, *T or pkg.T
+func embeddedName(expr ast.Expr) *ast.Ident {
+	switch expr := expr.(type) {
+	case *ast.StarExpr:
+		return embeddedName(expr.X)
+	case *ast.SelectorExpr:
+		return expr.Sel
+	case *ast.IndexExpr:
+		return embeddedName(expr.X)
+	case *ast.Ident:
+		return expr
+	}
+	return ast.NewIdent("_")
+}
+
+// columnNames returns the column of each struct field, taken from its db or
+// json tag and falling back to the snake_case field name
+func columnNames(st *ast.StructType) []string {
+	var columns []string
+	for _, field := range st.Fields.List {
+		for _, name := range field.Names {
+			if !name.IsExported() {
+				continue
+			}
+			column := ""
+			if field.Tag != nil {
+				tag, _ := strconv.Unquote(field.Tag.Value)
+				column = tagName(tag, "db")
+				if column == "" {
+					column = tagName(tag, "json")
+				}
+			}
+			if column == "-" {
+				continue
+			}
+			if column == "" {
+				column = sqld.SnakeCase(name.Name)
+			}
+			columns = append(columns, column)
+		}
+	}
+	return columns
+}
+
+// tagName returns the name part of a struct tag key, e.g. "id" for db:"id,omitempty"
+func tagName(tag, key string) string {
+	name, _, _ := strings.Cut(reflect.StructTag(tag).Get(key), ",")
+	return name
+}
+
+var outputTemplate = template.Must(template.New("sqldgen").Parse(`// Code generated by sqldgen. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+{{- range .StdImports}}
+	{{.}}
+{{- end}}
+{{range .Imports}}
+	{{.}}
+{{- end}}
+)
+{{range .Wrappers}}
+{{- if eq .Command "many"}}
+// {{.Name}}Dynamic runs {{.Name}} with dynamic conditions, ordering and pagination
+func {{.Name}}Dynamic(ctx context.Context, q *sqld.Queries, where *sqld.WhereBuilder, orderBy *sqld.OrderByBuilder, cursor *sqld.Cursor, limit int{{if .Params}}, {{.Params}}{{end}}) ([]{{.RowType}}, error) {
+	return sqld.NewExecutor[{{.RowType}}](q).QueryAll(ctx, {{.Const}}, where, cursor, orderBy, limit{{if .Args}}, {{.Args}}{{end}})
+}
+{{- else}}
+// {{.Name}}Dynamic runs {{.Name}} with dynamic conditions
+func {{.Name}}Dynamic(ctx context.Context, q *sqld.Queries, where *sqld.WhereBuilder{{if .Params}}, {{.Params}}{{end}}) ({{.RowType}}, error) {
+	return sqld.NewExecutor[{{.RowType}}](q).QueryOne(ctx, {{.Const}}, where{{if .Args}}, {{.Args}}{{end}})
+}
+{{- end}}
+{{end}}
+{{- range .Models}}
+// New{{.Name}}Config returns a starting sqld configuration for {{.Name}} rows.
+// Every column is allowed for filtering and sorting; trim the list before
+// exposing an endpoint.
+func New{{.Name}}Config() *sqld.Config {
+	return sqld.DefaultConfig().WithAllowedFields(map[string]bool{
+	{{- range .Fields}}
+		"{{.}}": true,
+	{{- end}}
+	})
+}
+{{end}}
+{{- range .Scanners}}
+// scan{{.Name}} scans a row into {{.Name}} without reflection. Fields are read
+// in declaration order, like sqld's reflection scanner.
+func scan{{.Name}}(rows sqld.Rows) ({{.Name}}, error) {
+	var i {{.Name}}
+	{{- if .Ignored}}
+	var ignored interface{}
+	{{- end}}
+	err := rows.Scan(
+	{{- range .Targets}}
+		{{.}},
+	{{- end}}
+	)
+	return i, err
+}
+{{end}}
+{{- if .Scanners}}
+func init() {
+{{- range .Scanners}}
+	sqld.RegisterScanFunc(scan{{.Name}})
+{{- end}}
+}
+{{end}}`))
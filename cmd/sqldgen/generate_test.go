@@ -0,0 +1,126 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const queriesFixture = "package db\n\n" +
+	"import (\n\t\"context\"\n\n\t\"github.com/jackc/pgx/v5/pgtype\"\n)\n\n" +
+	"const searchUsers = `-- name: SearchUsers :many\n" +
+	"SELECT id, name, created_at FROM users\n" +
+	"WHERE org_id = $1 /* sqld:where */\n" +
+	"ORDER BY id /* sqld:orderby */ /* sqld:limit max=100 */\n`\n\n" +
+	"func (q *Queries) SearchUsers(ctx context.Context, orgID pgtype.Int4) ([]User, error) {\n" +
+	"\trows, err := q.db.Query(ctx, searchUsers, orgID)\n" +
+	"\t_ = rows\n\treturn nil, err\n}\n\n" +
+	"const getUser = `-- name: GetUser :one\nSELECT id, name, created_at FROM users WHERE true /* sqld:where */\n`\n\n" +
+	"func (q *Queries) GetUser(ctx context.Context) (User, error) {\n" +
+	"\trow := q.db.QueryRow(ctx, getUser)\n" +
+	"\tvar i User\n\treturn i, row.Scan(&i.ID)\n}\n\n" +
+	"const deleteUser = `-- name: DeleteUser :exec\nDELETE FROM users WHERE id = $1\n`\n\n" +
+	"func (q *Queries) DeleteUser(ctx context.Context, id int32) error {\n" +
+	"\t_, err := q.db.Exec(ctx, deleteUser, id)\n\treturn err\n}\n"
+
+const modelsFixture = `package db
+
+import "github.com/jackc/pgx/v5/pgtype"
+
+type User struct {
+	ID        int32            ` + "`json:\"id\"`" + `
+	Name      string
+	CreatedAt pgtype.Timestamp
+	internal  bool
+}
+`
+
+func writeFixture(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+	return dir
+}
+
+func TestGenerate(t *testing.T) {
+	dir := writeFixture(t, map[string]string{
+		"queries.sql.go": queriesFixture,
+		"models.go":      modelsFixture,
+		"sqld.gen.go":    "package db\n\nthis file is regenerated and never parsed\n",
+	})
+
+	src, err := generate(dir, "sqld.gen.go", nil)
+	require.NoError(t, err)
+	out := string(src)
+
+	assert.Contains(t, out, "package db")
+	assert.Contains(t, out, `"github.com/jackc/pgx/v5/pgtype"`)
+	assert.Contains(t, out, "func SearchUsersDynamic(ctx context.Context, q *sqld.Queries, where *sqld.WhereBuilder, orderBy *sqld.OrderByBuilder, cursor *sqld.Cursor, limit int, orgID pgtype.Int4) ([]User, error) {")
+	assert.Contains(t, out, "sqld.NewExecutor[User](q).QueryAll(ctx, searchUsers, where, cursor, orderBy, limit, orgID)")
+	assert.Contains(t, out, "func GetUserDynamic(ctx context.Context, q *sqld.Queries, where *sqld.WhereBuilder) (User, error) {")
+	assert.Contains(t, out, "sqld.NewExecutor[User](q).QueryOne(ctx, getUser, where)")
+	assert.NotContains(t, out, "DeleteUser")
+
+	assert.Contains(t, out, "func NewUserConfig() *sqld.Config {")
+	assert.Contains(t, out, `"id":         true,`)
+	assert.Contains(t, out, `"name":       true,`)
+	assert.Contains(t, out, `"created_at": true,`)
+	assert.NotContains(t, out, "internal")
+}
+
+func TestGenerate_Scanners(t *testing.T) {
+	dir := writeFixture(t, map[string]string{
+		"queries.sql.go": queriesFixture,
+		"models.go":      modelsFixture,
+	})
+
+	src, err := generate(dir, "sqld.gen.go", []string{"User"})
+	require.NoError(t, err)
+	out := string(src)
+
+	assert.Contains(t, out, "func scanUser(rows sqld.Rows) (User, error) {")
+	assert.Contains(t, out, "\tvar ignored interface{}\n")
+	assert.Contains(t, out, "err := rows.Scan(\n\t\t&i.ID,\n\t\t&i.Name,\n\t\t&i.CreatedAt,\n\t\t&ignored,\n\t)")
+	assert.Contains(t, out, "func init() {\n\tsqld.RegisterScanFunc(scanUser)\n}")
+
+	t.Run("scanners only", func(t *testing.T) {
+		dir := writeFixture(t, map[string]string{"models.go": "package db\n\ntype Event struct {\n\tBase\n\tKind string\n}\n\ntype Base struct{ ID int64 }\n"})
+		src, err := generate(dir, "sqld.gen.go", []string{"Event"})
+		require.NoError(t, err)
+		assert.NotContains(t, string(src), `"context"`)
+		assert.Contains(t, string(src), "&i.Base,\n\t\t&i.Kind,")
+	})
+
+	t.Run("unknown struct", func(t *testing.T) {
+		_, err := generate(dir, "sqld.gen.go", []string{"Account"})
+		assert.ErrorContains(t, err, "no struct Account")
+	})
+}
+
+func TestGenerate_Errors(t *testing.T) {
+	t.Run("no annotated queries", func(t *testing.T) {
+		dir := writeFixture(t, map[string]string{"models.go": modelsFixture})
+		_, err := generate(dir, "sqld.gen.go", nil)
+		assert.ErrorContains(t, err, "no queries with sqld annotations")
+	})
+
+	t.Run("exec query", func(t *testing.T) {
+		dir := writeFixture(t, map[string]string{"queries.sql.go": "package db\n\nimport \"context\"\n\n" +
+			"const purge = `-- name: Purge :exec\nDELETE FROM users WHERE true /* sqld:where */\n`\n\n" +
+			"func (q *Queries) Purge(ctx context.Context) error {\n\t_, err := q.db.Exec(ctx, purge)\n\treturn err\n}\n"})
+		_, err := generate(dir, "sqld.gen.go", nil)
+		assert.ErrorContains(t, err, ":many or :one")
+	})
+
+	t.Run("invalid limit annotation", func(t *testing.T) {
+		dir := writeFixture(t, map[string]string{"queries.sql.go": "package db\n\nimport \"context\"\n\n" +
+			"const list = `-- name: List :many\nSELECT id FROM users /* sqld:limit default=x */\n`\n\n" +
+			"func (q *Queries) List(ctx context.Context) ([]int32, error) {\n\t_, err := q.db.Query(ctx, list)\n\treturn nil, err\n}\n"})
+		_, err := generate(dir, "sqld.gen.go", nil)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,47 @@
+// Command sqldgen generates typed wrappers for sqlc queries that carry sqld
+// annotations, plus a starting Config for each row type they return.
+//
+// Run it next to the code sqlc generates, for example with
+//
+//	//go:generate go run github.com/getangry/sqld/cmd/sqldgen -dir ./db
+//
+// For a query named SearchUsers it writes SearchUsersDynamic(ctx, q, where,
+// orderBy, cursor, limit, ...), taking the same parameters as the sqlc method,
+// so call sites no longer pass query constants around.
+//
+// With -scanners User,Post it also writes a reflection-free scan function for
+// each listed struct and registers it with sqld.RegisterScanFunc, so the
+// Executor scans those rows without reflection.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	dir := flag.String("dir", ".", "directory containing the sqlc-generated package")
+	output := flag.String("out", "sqld.gen.go", "output file name, written inside -dir")
+	scanners := flag.String("scanners", "", "comma-separated structs to generate reflection-free scanners for")
+	flag.Parse()
+
+	var names []string
+	for _, name := range strings.Split(*scanners, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+
+	src, err := generate(*dir, *output, names)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "sqldgen:", err)
+		os.Exit(1)
+	}
+	if err := os.WriteFile(filepath.Join(*dir, *output), src, 0o644); err != nil {
+		fmt.Fprintln(os.Stderr, "sqldgen:", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,106 @@
+// Command sqldschema reads a table's columns from a PostgreSQL catalog and
+// writes a Go function returning a sqld.Config for them, with the real column
+// types used by schema discovery instead of guesses based on field names.
+//
+// Run it with go:generate and commit the output:
+//
+//	//go:generate go run github.com/getangry/sqld/cmd/sqldschema -table users -func UsersConfig -out users_config.gen.go
+//
+// The connection string is taken from -dsn or DATABASE_URL. Other dialects are
+// supported by the library function sqld.IntrospectTable.
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/getangry/sqld"
+	pgxadapter "github.com/getangry/sqld/adapters/pgx"
+	"github.com/jackc/pgx/v5"
+)
+
+func main() {
+	dsn := flag.String("dsn", os.Getenv("DATABASE_URL"), "PostgreSQL connection string")
+	table := flag.String("table", "", "table to introspect, optionally schema-qualified")
+	funcName := flag.String("func", "", "name of the generated function (default <Table>Config)")
+	pkg := flag.String("pkg", os.Getenv("GOPACKAGE"), "package of the generated file")
+	output := flag.String("out", "", "output file (default stdout)")
+	flag.Parse()
+
+	if err := run(*dsn, *table, *funcName, *pkg, *output); err != nil {
+		fmt.Fprintln(os.Stderr, "sqldschema:", err)
+		os.Exit(1)
+	}
+}
+
+func run(dsn, table, funcName, pkg, output string) error {
+	if dsn == "" || table == "" {
+		return fmt.Errorf("-dsn (or DATABASE_URL) and -table are required")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	conn, err := pgx.Connect(ctx, dsn)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(ctx)
+
+	return generate(ctx, pgxadapter.NewPgxAdapter(conn), table, funcName, pkg, output, os.Stdout)
+}
+
+// generate introspects table on db and writes the config source to output,
+// or to stdout when output is empty
+func generate(ctx context.Context, db sqld.DBTX, table, funcName, pkg, output string, stdout io.Writer) error {
+	if pkg == "" {
+		pkg = "db"
+	}
+	if funcName == "" {
+		funcName = exportedName(table) + "Config"
+	}
+
+	columns, err := sqld.IntrospectTable(ctx, db, sqld.Postgres, table)
+	if err != nil {
+		return err
+	}
+
+	var src bytes.Buffer
+	if err := sqld.WriteConfigSource(&src, pkg, funcName, columns); err != nil {
+		return err
+	}
+	if output == "" {
+		_, err = stdout.Write(src.Bytes())
+		return err
+	}
+	return os.WriteFile(output, src.Bytes(), 0o644)
+}
+
+// exportedName turns a table name such as "billing.user_accounts" into
+// "UserAccounts"
+func exportedName(table string) string {
+	var name []byte
+	upper := true
+	for i := 0; i < len(table); i++ {
+		c := table[i]
+		switch {
+		case c == '.':
+			name = name[:0]
+			upper = true
+		case c == '_':
+			upper = true
+		case upper && c >= 'a' && c <= 'z':
+			name = append(name, c-'a'+'A')
+			upper = false
+		default:
+			name = append(name, c)
+			upper = false
+		}
+	}
+	return string(name)
+}
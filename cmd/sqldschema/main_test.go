@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/getangry/sqld"
+)
+
+// catalogDB answers the information_schema query with fixed columns
+type catalogDB struct {
+	columns [][]string
+	args    []interface{}
+}
+
+func (db *catalogDB) Query(ctx context.Context, query string, args ...interface{}) (sqld.Rows, error) {
+	db.args = args
+	return &catalogRows{rows: db.columns, next: -1}, nil
+}
+
+func (db *catalogDB) QueryRow(ctx context.Context, query string, args ...interface{}) sqld.Row {
+	panic("not used")
+}
+
+type catalogRows struct {
+	rows [][]string
+	next int
+}
+
+func (r *catalogRows) Close() error { return nil }
+func (r *catalogRows) Err() error   { return nil }
+
+func (r *catalogRows) Next() bool {
+	r.next++
+	return r.next < len(r.rows)
+}
+
+func (r *catalogRows) Scan(dest ...interface{}) error {
+	for i, value := range r.rows[r.next] {
+		*dest[i].(*string) = value
+	}
+	return nil
+}
+
+func TestGenerate(t *testing.T) {
+	db := &catalogDB{columns: [][]string{
+		{"id", "bigint", "NO"},
+		{"total", "numeric", "NO"},
+		{"paid_at", "timestamp with time zone", "YES"},
+	}}
+
+	var stdout bytes.Buffer
+	if err := generate(context.Background(), db, "billing.line_items", "", "", "", &stdout); err != nil {
+		t.Fatal(err)
+	}
+	if got := db.args; len(got) != 2 || got[0] != "billing" || got[1] != "line_items" {
+		t.Errorf("catalog queried with %v, want [billing line_items]", got)
+	}
+	src := stdout.String()
+	for _, want := range []string{
+		"package db",
+		"func LineItemsConfig() *sqld.Config {",
+		`config.WithFieldType("total", "number")`,
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("output does not contain %q:\n%s", want, src)
+		}
+	}
+
+	out := filepath.Join(t.TempDir(), "config.gen.go")
+	if err := generate(context.Background(), db, "invoices", "InvoiceFilters", "billing", out, &stdout); err != nil {
+		t.Fatal(err)
+	}
+	written, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(written), "package billing") || !strings.Contains(string(written), "func InvoiceFilters() *sqld.Config {") {
+		t.Errorf("unexpected output file:\n%s", written)
+	}
+}
+
+func TestGenerate_Errors(t *testing.T) {
+	var stdout bytes.Buffer
+	if err := generate(context.Background(), &catalogDB{}, "missing", "", "", "", &stdout); err == nil {
+		t.Error("expected an error for a table without columns")
+	}
+	if err := generate(context.Background(), &catalogDB{}, "users; DROP TABLE users", "", "", "", &stdout); err == nil {
+		t.Error("expected an error for an invalid table name")
+	}
+	if err := run("", "users", "", "", ""); err == nil {
+		t.Error("expected an error without a connection string")
+	}
+}
+
+func TestExportedName(t *testing.T) {
+	tests := map[string]string{
+		"users":                 "Users",
+		"line_items":            "LineItems",
+		"billing.user_accounts": "UserAccounts",
+		"Order2_history":        "Order2History",
+	}
+	for table, want := range tests {
+		if got := exportedName(table); got != want {
+			t.Errorf("exportedName(%q) = %q, want %q", table, got, want)
+		}
+	}
+}
@@ -1,7 +1,11 @@
 package sqld
 
 import (
+	"context"
 	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
 )
 
 // Config is the unified configuration for both filtering and sorting
@@ -20,9 +24,73 @@ type Config struct {
 	// DateLayout for parsing date strings in filters
 	DateLayout string
 
+	// UUIDParser validates and normalizes values filtered on fields of type
+	// "uuid", e.g. a wrapper around uuid.Parse from github.com/google/uuid.
+	// Nil uses ParseUUID.
+	UUIDParser func(string) (string, error)
+
 	// MaxFilters limits the number of filters to prevent abuse
 	MaxFilters int
 
+	// Budget limits costly filter combinations such as OR groups, leading
+	// wildcards and filters on unindexed fields
+	Budget QueryBudget
+
+	// StrictFields rejects filters on fields that are not allowed instead of
+	// silently skipping them. Reserved parameters (sorting, pagination and
+	// ReservedParams) are never treated as filters.
+	StrictFields bool
+
+	// StrictOperators rejects unknown filter operators such as name[contians]
+	// instead of falling back to DefaultOperator
+	StrictOperators bool
+
+	// CaseInsensitiveFields matches field names in filters, sorting and
+	// field selection regardless of case, so Name=john filters on name.
+	// Matched names are canonicalized to their configured spelling; an
+	// exact match always wins.
+	CaseInsensitiveFields bool
+
+	// SnakeCaseFields translates camelCase field names that are not
+	// configured to snake_case, so createdAt filters on created_at and
+	// profile.displayName on profile.display_name
+	SnakeCaseFields bool
+
+	// ReservedParams lists extra query parameters that are not filters
+	ReservedParams []string
+
+	// SearchParam names a free-text search parameter (e.g. "q"). Its value is
+	// matched with ILIKE against every column in SearchColumns, combined with OR.
+	SearchParam string
+
+	// SearchColumns lists the database columns searched by SearchParam
+	SearchColumns []string
+
+	// Presets holds named filter sets clients select with ?preset=name
+	Presets *PresetRegistry
+
+	// QuoteIdentifiers quotes column names in SQL generated by FromRequest,
+	// FromQueryString and FromRequestWithSort, e.g. "order" instead of order
+	QuoteIdentifiers bool
+
+	// InChunkSize splits expanded IN lists longer than this into OR-ed
+	// groups (see WhereBuilder.ChunkInLists). Zero disables chunking.
+	InChunkSize int
+
+	// SimilarityThreshold is the minimum pg_trgm similarity the similar
+	// operator requires. Zero uses the % operator and the server's
+	// pg_trgm.similarity_threshold, which can use a trigram index.
+	SimilarityThreshold float64
+
+	// ArrayInLists binds IN lists as one array parameter on dialects that
+	// support it, e.g. "id = ANY($1)" (see WhereBuilder.ArrayIn). The driver
+	// must be able to bind Go slices, as pgx does.
+	ArrayInLists bool
+
+	// Relations declares related tables that can be filtered through, keyed by
+	// the prefix used in field names (e.g. "orders" for orders.total[gt]=100)
+	Relations map[string]Relation
+
 	// === SORTING CONFIGURATION ===
 
 	// MaxSortFields limits the number of sort fields to prevent abuse
@@ -30,6 +98,123 @@ type Config struct {
 
 	// DefaultSort defines the default sorting when no sort is specified
 	DefaultSort []SortField
+
+	// Tiebreaker is appended to every ORDER BY that does not already sort by
+	// its field, typically a unique key such as "id", so rows with equal sort
+	// values keep a stable order across pages
+	Tiebreaker SortField
+
+	// AllowRandomSort accepts RandomSort ("random") as a sort field, ordering
+	// rows randomly for sampling endpoints
+	AllowRandomSort bool
+
+	// === PAGINATION CONFIGURATION ===
+
+	// DefaultLimit is the page size used by BindRequest when the request
+	// does not set one. Zero leaves the limit unset.
+	DefaultLimit int
+
+	// MaxLimit caps the page size a client can request through BindRequest,
+	// and the limit of annotated queries run by an Executor bound to the
+	// config, including queries that would otherwise have no limit.
+	// Zero means no cap.
+	MaxLimit int
+
+	// === FIELD CONFIGURATION ===
+
+	// Fields holds per-field options, keyed by the same field names used in AllowedFields
+	Fields map[string]FieldConfig
+
+	// === EXECUTION CONFIGURATION ===
+
+	// SoftDeleteColumn names the timestamp column marking soft-deleted rows.
+	// When set, executors bound to this config exclude deleted rows automatically.
+	SoftDeleteColumn string
+}
+
+// QueryFilterConfig is the former name of the filtering configuration,
+// now part of Config.
+//
+// Deprecated: Use Config, e.g. from DefaultConfig.
+type QueryFilterConfig = Config
+
+// OrderByConfig is the former name of the sorting configuration, now part
+// of Config.
+//
+// Deprecated: Use Config, e.g. from DefaultConfig.
+type OrderByConfig = Config
+
+// FieldConfig holds per-field options that go beyond simple allow-listing
+type FieldConfig struct {
+	// Roles restricts the field to callers holding at least one of these roles.
+	// An empty list makes the field available to every caller.
+	Roles []string
+
+	// DBColumn overrides the column written into generated filter and sort
+	// SQL, e.g. "u.name" to qualify the field with a table alias in joins
+	DBColumn string
+
+	// Expression computes the field with a SQL expression instead of reading
+	// a column, e.g. "first_name || ' ' || last_name". It takes precedence over
+	// DBColumn, must not contain placeholders, and is checked with
+	// ValidateExpression before use; filter values are still bound as parameters.
+	Expression string
+
+	// Collation is applied when sorting by the field, e.g. "und-x-icu" on
+	// PostgreSQL or "utf8mb4_unicode_ci" on MySQL, for locale-aware ordering
+	Collation string
+
+	// Type is the field type advertised by schema discovery: one of
+	// "string", "integer", "number", "boolean", "datetime", "uuid",
+	// "geography" or a PostgreSQL range type such as "tstzrange" or
+	// "int4range". When empty the type is guessed from the field name.
+	// IntrospectConfig fills it in from the database catalog. Filters on uuid
+	// fields only accept equality and list operators, and values are checked
+	// with UUIDParser. Geography fields (PostGIS) only accept near, within
+	// and the null checks; range fields only overlaps, contains and the null
+	// checks.
+	Type string
+
+	// Indexed marks the field as backed by an index, so filters on it do not
+	// count against QueryBudget.MaxUnindexedFilters
+	Indexed bool
+
+	// NoLeadingWildcard rejects filters on the field whose pattern starts
+	// with a wildcard (contains, endsWith and their negations, like=%...),
+	// which cannot use an index and scan the whole table
+	NoLeadingWildcard bool
+
+	// Values lists the values the field accepts in equality and list filters
+	// (eq, ne, in, notIn), e.g. the members of an enum. Empty accepts any value.
+	Values []string
+
+	// MaxLength limits the length in characters of each filter value. Zero
+	// means no limit.
+	MaxLength int
+
+	// Pattern must match each value of a non-pattern filter, e.g. a UUID
+	// format. Values of contains, startsWith and similar filters are
+	// fragments and are not matched.
+	Pattern *regexp.Regexp
+
+	// Min and Max bound each value of a non-pattern filter numerically;
+	// values that are not numbers are rejected. Nil means unbounded.
+	Min, Max *float64
+}
+
+// Relation describes how a related table joins to the queried table. Filters
+// on a relation's fields are compiled into a single correlated EXISTS
+// subquery, so matching rows are never duplicated the way a JOIN would.
+type Relation struct {
+	// Table is the related table, e.g. "orders"
+	Table string `json:"table"`
+
+	// LocalColumn is the key on the queried table, e.g. "users.id"
+	LocalColumn string `json:"local_column"`
+
+	// ForeignColumn is the column on the related table referencing
+	// LocalColumn, e.g. "orders.user_id"
+	ForeignColumn string `json:"foreign_column"`
 }
 
 // DefaultConfig returns a sensible default configuration
@@ -42,6 +227,8 @@ func DefaultConfig() *Config {
 		MaxFilters:      50,
 		MaxSortFields:   5,
 		DefaultSort:     []SortField{},
+		Fields:          make(map[string]FieldConfig),
+		Relations:       make(map[string]Relation),
 	}
 }
 
@@ -81,14 +268,254 @@ func (c *Config) WithDefaultSort(sort []SortField) *Config {
 	return c
 }
 
+// WithTiebreaker sets the unique field appended to every sort, e.g.
+// WithTiebreaker("id", SortAsc)
+func (c *Config) WithTiebreaker(field string, direction SortDirection) *Config {
+	c.Tiebreaker = SortField{Field: field, Direction: direction}
+	return c
+}
+
+// WithRandomSort allows clients to request random order with ?sort=random
+func (c *Config) WithRandomSort(allow bool) *Config {
+	c.AllowRandomSort = allow
+	return c
+}
+
+// WithLimits sets the default and maximum page size used by BindRequest
+func (c *Config) WithLimits(defaultLimit, maxLimit int) *Config {
+	c.DefaultLimit = defaultLimit
+	c.MaxLimit = maxLimit
+	return c
+}
+
 // WithDateLayout sets the date parsing layout
 func (c *Config) WithDateLayout(layout string) *Config {
 	c.DateLayout = layout
 	return c
 }
 
+// WithStrictFields enables or disables rejection of unknown filter fields
+func (c *Config) WithStrictFields(strict bool) *Config {
+	c.StrictFields = strict
+	return c
+}
+
+// WithCaseInsensitiveFields enables or disables case-insensitive matching
+// of field names
+func (c *Config) WithCaseInsensitiveFields(enabled bool) *Config {
+	c.CaseInsensitiveFields = enabled
+	return c
+}
+
+// WithSnakeCaseFields enables or disables translation of camelCase field
+// names to snake_case
+func (c *Config) WithSnakeCaseFields(enabled bool) *Config {
+	c.SnakeCaseFields = enabled
+	return c
+}
+
+// WithStrictOperators enables or disables rejection of unknown filter operators
+func (c *Config) WithStrictOperators(strict bool) *Config {
+	c.StrictOperators = strict
+	return c
+}
+
+// WithReservedParams declares additional query parameters that are not filters
+func (c *Config) WithReservedParams(params ...string) *Config {
+	c.ReservedParams = append(c.ReservedParams, params...)
+	return c
+}
+
+// WithSearchParam enables free-text search: ?param=text matches text against
+// each of the given columns with ILIKE '%text%', combined with OR
+func (c *Config) WithSearchParam(param string, columns []string) *Config {
+	c.SearchParam = param
+	c.SearchColumns = columns
+	return c
+}
+
+// WithPresets enables ?preset=name, expanding to the filters of the named
+// presets in registry
+func (c *Config) WithPresets(registry *PresetRegistry) *Config {
+	c.Presets = registry
+	return c
+}
+
+// WithQuoteIdentifiers enables or disables dialect-aware quoting of generated column names
+func (c *Config) WithQuoteIdentifiers(quote bool) *Config {
+	c.QuoteIdentifiers = quote
+	return c
+}
+
+// WithInChunkSize splits IN lists longer than size into several groups
+func (c *Config) WithInChunkSize(size int) *Config {
+	c.InChunkSize = size
+	return c
+}
+
+// WithArrayInLists binds IN lists as a single array parameter where supported
+func (c *Config) WithArrayInLists(enabled bool) *Config {
+	c.ArrayInLists = enabled
+	return c
+}
+
+// WithRelation declares a related table. Fields prefixed with name (e.g.
+// "orders.total") filter on that table; they must be allowed like any other field.
+func (c *Config) WithRelation(name string, relation Relation) *Config {
+	if c.Relations == nil {
+		c.Relations = make(map[string]Relation)
+	}
+	c.Relations[name] = relation
+	return c
+}
+
+// WithBudget sets the limits on costly filter combinations
+//
+// Example:
+//
+//	config.WithIndexedFields("id", "email", "created_at").
+//		WithBudget(sqld.QueryBudget{MaxOrGroups: 2, MaxLeadingWildcards: 1, MaxUnindexedFilters: 2})
+func (c *Config) WithBudget(budget QueryBudget) *Config {
+	c.Budget = budget
+	return c
+}
+
+// WithIndexedFields marks fields as backed by an index for QueryBudget
+func (c *Config) WithIndexedFields(names ...string) *Config {
+	for _, name := range names {
+		field := c.Fields[name]
+		field.Indexed = true
+		c.WithField(name, field)
+	}
+	return c
+}
+
+// WithoutLeadingWildcards rejects contains, endsWith and other patterns with
+// a leading wildcard on the given fields, typically large-table columns
+// where they would cause sequential scans
+func (c *Config) WithoutLeadingWildcards(names ...string) *Config {
+	for _, name := range names {
+		field := c.Fields[name]
+		field.NoLeadingWildcard = true
+		c.WithField(name, field)
+	}
+	return c
+}
+
+// WithFieldValues restricts the values accepted for a field, e.g.
+// WithFieldValues("status", "active", "pending", "banned"). Schema discovery
+// advertises them as the field's enum.
+func (c *Config) WithFieldValues(name string, values ...string) *Config {
+	field := c.Fields[name]
+	field.Values = values
+	return c.WithField(name, field)
+}
+
+// WithMaxLength limits the length of each filter value for a field, so
+// oversized values are rejected before reaching the database
+func (c *Config) WithMaxLength(name string, max int) *Config {
+	field := c.Fields[name]
+	field.MaxLength = max
+	return c.WithField(name, field)
+}
+
+// WithPattern requires each filter value for a field to match pattern,
+// e.g. WithPattern("sku", regexp.MustCompile(`^[A-Z]{3}-\d{4}$`))
+func (c *Config) WithPattern(name string, pattern *regexp.Regexp) *Config {
+	field := c.Fields[name]
+	field.Pattern = pattern
+	return c.WithField(name, field)
+}
+
+// WithRange requires each filter value for a field to be a number between
+// min and max inclusive
+func (c *Config) WithRange(name string, min, max float64) *Config {
+	field := c.Fields[name]
+	field.Min, field.Max = &min, &max
+	return c.WithField(name, field)
+}
+
+// WithSimilarityThreshold sets the minimum similarity, between 0 and 1,
+// for ?name[similar]= filters
+func (c *Config) WithSimilarityThreshold(threshold float64) *Config {
+	c.SimilarityThreshold = threshold
+	return c
+}
+
+// WithGeoFields declares PostGIS geography columns, enabling the near and
+// within operators on them, e.g. ?location[near]=52.52,13.405,5000
+func (c *Config) WithGeoFields(names ...string) *Config {
+	for _, name := range names {
+		c.WithFieldType(name, "geography")
+	}
+	return c
+}
+
+// WithSoftDelete enables soft-delete filtering on the given column (e.g. "deleted_at")
+func (c *Config) WithSoftDelete(column string) *Config {
+	c.SoftDeleteColumn = column
+	return c
+}
+
+// WithField sets the per-field options for a field
+func (c *Config) WithField(name string, field FieldConfig) *Config {
+	if c.Fields == nil {
+		c.Fields = make(map[string]FieldConfig)
+	}
+	c.Fields[name] = field
+	return c
+}
+
+// WithFieldRoles restricts a field to callers holding at least one of the given roles
+func (c *Config) WithFieldRoles(name string, roles ...string) *Config {
+	field := c.Fields[name]
+	field.Roles = roles
+	return c.WithField(name, field)
+}
+
+// WithDBColumn sets the column used in generated SQL for a field, e.g.
+// WithDBColumn("name", "u.name") for queries that alias their tables
+func (c *Config) WithDBColumn(name, column string) *Config {
+	field := c.Fields[name]
+	field.DBColumn = column
+	return c.WithField(name, field)
+}
+
+// WithExpression maps a field to a SQL expression usable in filters and sorting,
+// e.g. WithExpression("order_total", "price * quantity")
+func (c *Config) WithExpression(name, expression string) *Config {
+	field := c.Fields[name]
+	field.Expression = expression
+	return c.WithField(name, field)
+}
+
+// WithCollation sets the collation used when sorting by a field, e.g.
+// WithCollation("name", "und-x-icu")
+func (c *Config) WithCollation(name, collation string) *Config {
+	field := c.Fields[name]
+	field.Collation = collation
+	return c.WithField(name, field)
+}
+
+// WithFieldType sets the type advertised for a field by schema discovery,
+// e.g. WithFieldType("score", "number")
+func (c *Config) WithFieldType(name, fieldType string) *Config {
+	field := c.Fields[name]
+	field.Type = fieldType
+	return c.WithField(name, field)
+}
+
 // HELPER METHODS
 
+// rangeType returns the PostgreSQL range type of a field, or "" when the
+// field is not a range column
+func (c *Config) rangeType(name string) string {
+	if t := c.Fields[name].Type; rangeElementTypes[t] != "" {
+		return t
+	}
+	return ""
+}
+
 // IsFieldAllowed checks if a field is allowed for filtering/sorting
 func (c *Config) IsFieldAllowed(field string) bool {
 	if len(c.AllowedFields) == 0 {
@@ -98,6 +525,103 @@ func (c *Config) IsFieldAllowed(field string) bool {
 	return c.AllowedFields[field]
 }
 
+// IsFieldPermitted checks if the caller identified by the roles in ctx may
+// use a field. Fields without role requirements are permitted for everyone.
+func (c *Config) IsFieldPermitted(ctx context.Context, field string) bool {
+	required := c.Fields[field].Roles
+	return len(required) == 0 || hasAnyRole(RolesFromContext(ctx), required)
+}
+
+// defaultReservedParams are query parameters used for sorting, pagination
+// and field selection
+var defaultReservedParams = map[string]bool{
+	"sort": true, "sort_by": true, "order_by": true, "orderby": true, "order": true,
+	"limit": true, "offset": true, "cursor": true, "page": true, "page_size": true, "per_page": true,
+	"fields": true,
+}
+
+// IsReservedParam reports whether a query parameter key is used for sorting,
+// pagination or field selection rather than filtering
+func (c *Config) IsReservedParam(key string) bool {
+	if defaultReservedParams[key] || strings.HasPrefix(key, "sort_") {
+		return true
+	}
+	for _, param := range c.ReservedParams {
+		if param == key {
+			return true
+		}
+	}
+	return false
+}
+
+// hasRestrictedFields reports whether any field requires a role
+func (c *Config) hasRestrictedFields() bool {
+	for _, field := range c.Fields {
+		if len(field.Roles) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// canonicalField returns the configured name a requested field name refers
+// to, honoring CaseInsensitiveFields and SnakeCaseFields. Names without a
+// match are returned unchanged.
+func (c *Config) canonicalField(name string) string {
+	if configured, ok := c.lookupField(name); ok {
+		return configured
+	}
+	if c.SnakeCaseFields {
+		snake := SnakeCase(name)
+		if configured, ok := c.lookupField(snake); ok || len(c.AllowedFields) == 0 {
+			return configured
+		}
+	}
+	return name
+}
+
+// lookupField finds a field name in FieldMappings and then in
+// AllowedFields, ignoring case when CaseInsensitiveFields is set. It returns
+// name itself, unmatched, when neither has it.
+func (c *Config) lookupField(name string) (string, bool) {
+	if _, ok := c.FieldMappings[name]; ok || c.AllowedFields[name] {
+		return name, true
+	}
+	if c.CaseInsensitiveFields {
+		for configured := range c.FieldMappings {
+			if strings.EqualFold(configured, name) {
+				return configured, true
+			}
+		}
+		for configured := range c.AllowedFields {
+			if strings.EqualFold(configured, name) {
+				return configured, true
+			}
+		}
+	}
+	return name, false
+}
+
+// SnakeCase converts a camelCase or Go identifier such as createdAt or
+// UserID to snake_case. Dots and other separators are kept, so
+// profile.displayName becomes profile.display_name.
+func SnakeCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			prevLower := i > 0 && unicode.IsLower(runes[i-1])
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if i > 0 && (prevLower || (nextLower && unicode.IsUpper(runes[i-1]))) {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
 // MapField maps a query parameter field name to the actual database column
 func (c *Config) MapField(field string) string {
 	if mapped, exists := c.FieldMappings[field]; exists {
@@ -106,32 +630,137 @@ func (c *Config) MapField(field string) string {
 	return field
 }
 
+// ColumnFor returns the column written into SQL for a field, honoring
+// FieldConfig.Expression (parenthesized) and FieldConfig.DBColumn. The field
+// is the mapped name used as key in AllowedFields.
+func (c *Config) ColumnFor(field string) string {
+	if expression := c.Fields[field].Expression; expression != "" {
+		return "(" + expression + ")"
+	}
+	if column := c.Fields[field].DBColumn; column != "" {
+		return column
+	}
+	return field
+}
+
+// validateFieldExpression checks the expression configured for a field, if any
+func (c *Config) validateFieldExpression(field string) error {
+	if expression := c.Fields[field].Expression; expression != "" {
+		if err := ValidateExpression(expression); err != nil {
+			return fmt.Errorf("expression for field '%s': %w", field, err)
+		}
+	}
+	return nil
+}
+
 // ValidateAndBuild validates sort fields against the config and builds the ORDER BY clause
 func (c *Config) ValidateAndBuild(fields []SortField) (*OrderByBuilder, error) {
+	return c.ValidateAndBuildContext(context.Background(), fields)
+}
+
+// ValidateAndBuildContext is like ValidateAndBuild but also enforces per-field
+// role requirements against the roles carried by ctx
+func (c *Config) ValidateAndBuildContext(ctx context.Context, fields []SortField) (*OrderByBuilder, error) {
 	if len(fields) > c.MaxSortFields {
 		return nil, fmt.Errorf("too many sort fields: %d (max %d)", len(fields), c.MaxSortFields)
 	}
 
 	builder := NewOrderByBuilder()
+	sorted := make(map[string]bool)
 
 	if len(fields) == 0 {
 		for _, defaultField := range c.DefaultSort {
-			if c.IsFieldAllowed(defaultField.Field) {
-				mappedField := c.MapField(defaultField.Field)
-				builder.Add(mappedField, defaultField.Direction)
+			mappedField := c.MapField(defaultField.Field)
+			if c.IsFieldAllowed(defaultField.Field) && c.IsFieldPermitted(ctx, mappedField) {
+				if err := c.validateFieldExpression(mappedField); err != nil {
+					return nil, err
+				}
+				collation := defaultField.Collation
+				if collation == "" {
+					collation = c.Fields[mappedField].Collation
+				}
+				builder.Add(c.ColumnFor(mappedField), defaultField.Direction).
+					setNulls(defaultField.Nulls).
+					Collate(collation)
+				sorted[mappedField] = true
 			}
 		}
-		return builder, nil
 	}
 
+	random := false
 	for _, field := range fields {
-		if !c.IsFieldAllowed(field.Field) {
+		if field.Field == RandomSort && c.AllowRandomSort {
+			builder.Random()
+			random = true
+			continue
+		}
+
+		name := c.canonicalField(field.Field)
+		if !c.IsFieldAllowed(name) {
 			return nil, fmt.Errorf("field '%s' is not allowed for sorting", field.Field)
 		}
 
-		mappedField := c.MapField(field.Field)
-		builder.Add(mappedField, field.Direction)
+		mappedField := c.MapField(name)
+		if !c.IsFieldPermitted(ctx, mappedField) {
+			return nil, fmt.Errorf("sorting by field '%s': %w", field.Field, ErrPermissionDenied)
+		}
+		if err := c.validateFieldExpression(mappedField); err != nil {
+			return nil, err
+		}
+		builder.Add(c.ColumnFor(mappedField), field.Direction).
+			setNulls(field.Nulls).
+			Collate(c.Fields[mappedField].Collation)
+		sorted[mappedField] = true
+	}
+
+	// The tiebreaker is server-defined, so it is added even when the caller
+	// could not sort by it explicitly. Random order has no ties worth breaking.
+	if tiebreaker := c.Tiebreaker; tiebreaker.Field != "" && builder.HasFields() && !random {
+		mappedField := c.MapField(tiebreaker.Field)
+		if !sorted[mappedField] {
+			builder.Add(c.ColumnFor(mappedField), tiebreaker.Direction)
+		}
 	}
 
+	if err := builder.Err(); err != nil {
+		return nil, err
+	}
 	return builder, nil
 }
+
+// SortKey returns the fields that determine row order for a request: the
+// requested fields (or DefaultSort when none are given) followed by the
+// Tiebreaker unless already present. Keyset cursors should encode the value of
+// every field in the key so the next page resumes exactly after the last row.
+func (c *Config) SortKey(fields []SortField) []SortField {
+	if len(fields) == 0 {
+		fields = c.DefaultSort
+	}
+	key := make([]SortField, len(fields), len(fields)+1)
+	copy(key, fields)
+
+	if c.Tiebreaker.Field == "" || len(key) == 0 {
+		return key
+	}
+	for _, field := range key {
+		if c.MapField(c.canonicalField(field.Field)) == c.MapField(c.Tiebreaker.Field) {
+			return key
+		}
+	}
+	return append(key, c.Tiebreaker)
+}
+
+// rolesKey is the context key for caller roles
+type rolesKey struct{}
+
+// WithRoles returns a context carrying the caller's roles, which are checked
+// against per-field role requirements during parsing and schema generation
+func WithRoles(ctx context.Context, roles ...string) context.Context {
+	return context.WithValue(ctx, rolesKey{}, roles)
+}
+
+// RolesFromContext returns the caller's roles stored by WithRoles
+func RolesFromContext(ctx context.Context) []string {
+	roles, _ := ctx.Value(rolesKey{}).([]string)
+	return roles
+}
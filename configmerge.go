@@ -0,0 +1,162 @@
+package sqld
+
+import (
+	"maps"
+	"slices"
+)
+
+// Clone returns a deep copy of the config: its maps and slices are copied,
+// so changing the copy leaves c untouched. Shared objects, such as the
+// preset registry and field patterns, are not copied.
+func (c *Config) Clone() *Config {
+	clone := *c
+	clone.AllowedFields = maps.Clone(c.AllowedFields)
+	clone.FieldMappings = maps.Clone(c.FieldMappings)
+	clone.Relations = maps.Clone(c.Relations)
+	clone.ReservedParams = slices.Clone(c.ReservedParams)
+	clone.SearchColumns = slices.Clone(c.SearchColumns)
+	clone.DefaultSort = slices.Clone(c.DefaultSort)
+	if c.Fields != nil {
+		clone.Fields = make(map[string]FieldConfig, len(c.Fields))
+		for name, field := range c.Fields {
+			field.Roles = slices.Clone(field.Roles)
+			field.Values = slices.Clone(field.Values)
+			clone.Fields[name] = field
+		}
+	}
+	return &clone
+}
+
+// Extend returns a copy of the config for a resource to build on with the
+// With methods, so a shared base config holding tenant scoping and common
+// fields is written once. Unlike the With methods on the base, changes to
+// the copy do not leak into the base or other resources extending it.
+//
+// Example:
+//
+//	base := sqld.DefaultConfig().
+//		WithAllowedFields(map[string]bool{"id": true, "created_at": true}).
+//		WithTiebreaker("id", sqld.SortAsc).
+//		WithSoftDelete("deleted_at")
+//
+//	users := base.Extend().
+//		AllowFields("name", "email").
+//		WithFieldRoles("email", "admin")
+func (c *Config) Extend() *Config {
+	return c.Clone()
+}
+
+// AllowFields allows additional fields for filtering and sorting,
+// keeping the fields already allowed
+func (c *Config) AllowFields(names ...string) *Config {
+	if c.AllowedFields == nil {
+		c.AllowedFields = make(map[string]bool)
+	}
+	for _, name := range names {
+		c.AllowedFields[name] = true
+	}
+	return c
+}
+
+// Merge returns a new config combining c with the overrides in other;
+// neither is modified. The rules are:
+//
+//   - AllowedFields, FieldMappings and Relations are combined key by key,
+//     with other's entries winning, so other can disallow a field of c by
+//     setting it to false
+//   - Fields are combined by name; a field configured in other replaces
+//     c's options for that field as a whole
+//   - ReservedParams are combined
+//   - DefaultSort, and SearchColumns together with SearchParam, are taken
+//     from other when it sets them
+//   - other's QueryBudget limits, Tiebreaker, Presets, UUIDParser and every
+//     other setting replace c's when they are not zero, and other's boolean
+//     options are added to c's
+//
+// Zero values in other never override c, so Merge cannot turn a boolean
+// option off or clear a setting; use Extend and the With methods for that.
+// A config from DefaultConfig sets DefaultOperator, DateLayout, MaxFilters
+// and MaxSortFields, which override c's when merged. A nil other returns a
+// copy of c.
+func (c *Config) Merge(other *Config) *Config {
+	merged := c.Clone()
+	if other == nil {
+		return merged
+	}
+
+	merged.AllowedFields = mergeMaps(merged.AllowedFields, other.AllowedFields)
+	merged.FieldMappings = mergeMaps(merged.FieldMappings, other.FieldMappings)
+	merged.Relations = mergeMaps(merged.Relations, other.Relations)
+	for name, field := range other.Clone().Fields {
+		merged.WithField(name, field)
+	}
+	for _, param := range other.ReservedParams {
+		if !slices.Contains(merged.ReservedParams, param) {
+			merged.ReservedParams = append(merged.ReservedParams, param)
+		}
+	}
+
+	if len(other.DefaultSort) > 0 {
+		merged.DefaultSort = slices.Clone(other.DefaultSort)
+	}
+	if other.SearchParam != "" {
+		merged.SearchParam = other.SearchParam
+		merged.SearchColumns = slices.Clone(other.SearchColumns)
+	}
+	if other.Tiebreaker.Field != "" {
+		merged.Tiebreaker = other.Tiebreaker
+	}
+	if other.Presets != nil {
+		merged.Presets = other.Presets
+	}
+	if other.UUIDParser != nil {
+		merged.UUIDParser = other.UUIDParser
+	}
+
+	override(&merged.DefaultOperator, other.DefaultOperator)
+	override(&merged.DateLayout, other.DateLayout)
+	override(&merged.MaxFilters, other.MaxFilters)
+	override(&merged.InChunkSize, other.InChunkSize)
+	override(&merged.SimilarityThreshold, other.SimilarityThreshold)
+	override(&merged.MaxSortFields, other.MaxSortFields)
+	override(&merged.DefaultLimit, other.DefaultLimit)
+	override(&merged.MaxLimit, other.MaxLimit)
+	override(&merged.SoftDeleteColumn, other.SoftDeleteColumn)
+
+	override(&merged.Budget.MaxOrGroups, other.Budget.MaxOrGroups)
+	override(&merged.Budget.MaxLeadingWildcards, other.Budget.MaxLeadingWildcards)
+	override(&merged.Budget.MaxUnindexedFilters, other.Budget.MaxUnindexedFilters)
+	if other.Budget.Warn != nil {
+		merged.Budget.Warn = other.Budget.Warn
+	}
+
+	merged.StrictFields = merged.StrictFields || other.StrictFields
+	merged.StrictOperators = merged.StrictOperators || other.StrictOperators
+	merged.CaseInsensitiveFields = merged.CaseInsensitiveFields || other.CaseInsensitiveFields
+	merged.SnakeCaseFields = merged.SnakeCaseFields || other.SnakeCaseFields
+	merged.QuoteIdentifiers = merged.QuoteIdentifiers || other.QuoteIdentifiers
+	merged.ArrayInLists = merged.ArrayInLists || other.ArrayInLists
+	merged.AllowRandomSort = merged.AllowRandomSort || other.AllowRandomSort
+
+	return merged
+}
+
+// mergeMaps adds the entries of src to dst, allocating dst if needed
+func mergeMaps[K comparable, V any](dst, src map[K]V) map[K]V {
+	if len(src) == 0 {
+		return dst
+	}
+	if dst == nil {
+		dst = make(map[K]V, len(src))
+	}
+	maps.Copy(dst, src)
+	return dst
+}
+
+// override sets *dst to value unless value is zero
+func override[T comparable](dst *T, value T) {
+	var zero T
+	if value != zero {
+		*dst = value
+	}
+}
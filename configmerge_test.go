@@ -0,0 +1,105 @@
+package sqld
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func baseConfig() *Config {
+	return DefaultConfig().
+		WithAllowedFields(map[string]bool{"id": true, "created_at": true, "tenant_id": true}).
+		WithFieldMappings(map[string]string{"createdAt": "created_at"}).
+		WithTiebreaker("id", SortAsc).
+		WithReservedParams("include").
+		WithFieldRoles("tenant_id", "admin").
+		WithLimits(20, 100).
+		WithMaxFilters(10).
+		WithSoftDelete("deleted_at")
+}
+
+func TestConfig_Extend(t *testing.T) {
+	base := baseConfig()
+	users := base.Extend().
+		AllowFields("name", "email").
+		WithFieldRoles("tenant_id", "owner").
+		WithReservedParams("expand")
+
+	assert.True(t, users.IsFieldAllowed("name"))
+	assert.True(t, users.IsFieldAllowed("id"))
+	assert.Equal(t, []string{"owner"}, users.Fields["tenant_id"].Roles)
+	assert.Equal(t, "deleted_at", users.SoftDeleteColumn)
+
+	// The base is untouched
+	assert.False(t, base.IsFieldAllowed("name"))
+	assert.Equal(t, []string{"admin"}, base.Fields["tenant_id"].Roles)
+	assert.Equal(t, []string{"include"}, base.ReservedParams)
+}
+
+func TestConfig_Merge(t *testing.T) {
+	base := baseConfig()
+	override := &Config{
+		AllowedFields: map[string]bool{"name": true, "tenant_id": false},
+		FieldMappings: map[string]string{"fullName": "name"},
+		Fields:        map[string]FieldConfig{"name": {Collation: "und-x-icu"}},
+		DefaultSort:   []SortField{{Field: "name", Direction: SortAsc}},
+		MaxLimit:      50,
+		StrictFields:  true,
+		Budget:        QueryBudget{MaxOrGroups: 2},
+	}
+	override.WithReservedParams("include", "expand")
+
+	merged := base.Merge(override)
+
+	t.Run("maps are combined with the override winning", func(t *testing.T) {
+		assert.True(t, merged.IsFieldAllowed("id"))
+		assert.True(t, merged.IsFieldAllowed("name"))
+		assert.False(t, merged.IsFieldAllowed("tenant_id"))
+		assert.Equal(t, "created_at", merged.MapField("createdAt"))
+		assert.Equal(t, "name", merged.MapField("fullName"))
+		assert.Equal(t, "und-x-icu", merged.Fields["name"].Collation)
+		assert.Equal(t, []string{"admin"}, merged.Fields["tenant_id"].Roles)
+		assert.Equal(t, []string{"include", "expand"}, merged.ReservedParams)
+	})
+
+	t.Run("set values override, zero values keep the base", func(t *testing.T) {
+		assert.Equal(t, []SortField{{Field: "name", Direction: SortAsc}}, merged.DefaultSort)
+		assert.Equal(t, 50, merged.MaxLimit)
+		assert.Equal(t, 20, merged.DefaultLimit)
+		assert.Equal(t, 10, merged.MaxFilters)
+		assert.Equal(t, "id", merged.Tiebreaker.Field)
+		assert.Equal(t, "deleted_at", merged.SoftDeleteColumn)
+		assert.Equal(t, 2, merged.Budget.MaxOrGroups)
+		assert.True(t, merged.StrictFields)
+	})
+
+	t.Run("neither config is modified", func(t *testing.T) {
+		assert.False(t, base.IsFieldAllowed("name"))
+		assert.True(t, base.IsFieldAllowed("tenant_id"))
+		assert.False(t, base.StrictFields)
+		assert.Equal(t, []string{"include"}, base.ReservedParams)
+		assert.Len(t, override.AllowedFields, 2)
+
+		merged.Fields["name"] = FieldConfig{}
+		assert.Equal(t, "und-x-icu", override.Fields["name"].Collation)
+	})
+
+	t.Run("merged config parses requests", func(t *testing.T) {
+		r := httptest.NewRequest("GET", "/users?name=ann&sort=-created_at", nil)
+		where, orderBy, err := FromRequestWithSort(r, Postgres, merged)
+		require.NoError(t, err)
+		sql, params := where.Build()
+		assert.Equal(t, "name = $1", sql)
+		assert.Equal(t, []interface{}{"ann"}, params)
+		assert.Equal(t, "created_at DESC, id ASC", orderBy.Build())
+	})
+
+	t.Run("nil override copies", func(t *testing.T) {
+		copied := base.Merge(nil)
+		assert.Equal(t, base.AllowedFields, copied.AllowedFields)
+		copied.AllowFields("name")
+		assert.False(t, base.IsFieldAllowed("name"))
+	})
+}
@@ -0,0 +1,134 @@
+package sqld
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ConfigProvider supplies the Config of a resource at request time, so
+// filterable fields and limits can be loaded from a database or a
+// feature-flag system and changed without a redeploy. Implementations must
+// be safe for concurrent use and should return ErrUnknownResource for
+// resources they do not know. The returned config must not be modified by
+// callers; providers return a new config to change it.
+//
+// SchemaRegistry is a ConfigProvider updated with Replace, and
+// CachedConfigProvider puts a cache in front of a slow one.
+type ConfigProvider interface {
+	GetConfig(ctx context.Context, resource string) (*Config, error)
+}
+
+// ConfigProviderFunc adapts a function to ConfigProvider
+//
+// Example:
+//
+//	provider := sqld.ConfigProviderFunc(func(ctx context.Context, resource string) (*sqld.Config, error) {
+//		return loadConfig(ctx, db, resource)
+//	})
+type ConfigProviderFunc func(ctx context.Context, resource string) (*Config, error)
+
+// GetConfig calls f(ctx, resource)
+func (f ConfigProviderFunc) GetConfig(ctx context.Context, resource string) (*Config, error) {
+	return f(ctx, resource)
+}
+
+// CachedConfigProvider caches the configs of another provider for a fixed
+// time. When reloading an expired config fails, the previous config keeps
+// being served, and the reload is retried once the TTL passes again, so a
+// database outage does not take list endpoints down with it.
+type CachedConfigProvider struct {
+	source ConfigProvider
+	ttl    time.Duration
+
+	mu      sync.Mutex
+	configs map[string]cachedConfig
+}
+
+// cachedConfig is a config and the time it was loaded
+type cachedConfig struct {
+	config *Config
+	loaded time.Time
+}
+
+// NewCachedConfigProvider caches the configs of source for ttl. A zero ttl
+// caches configs until they are invalidated.
+//
+// Example:
+//
+//	provider := sqld.NewCachedConfigProvider(dbProvider, time.Minute)
+//	r.With(chisqld.MiddlewareFor(sqld.Postgres, provider, "users")).Get("/users", listUsers)
+func NewCachedConfigProvider(source ConfigProvider, ttl time.Duration) *CachedConfigProvider {
+	return &CachedConfigProvider{source: source, ttl: ttl, configs: make(map[string]cachedConfig)}
+}
+
+// GetConfig returns the cached config of resource, loading it from the
+// source when it is missing or expired
+func (p *CachedConfigProvider) GetConfig(ctx context.Context, resource string) (*Config, error) {
+	p.mu.Lock()
+	cached, ok := p.configs[resource]
+	p.mu.Unlock()
+	if ok && (p.ttl <= 0 || time.Since(cached.loaded) < p.ttl) {
+		return cached.config, nil
+	}
+
+	config, err := p.source.GetConfig(ctx, resource)
+	if err != nil {
+		if !ok {
+			return nil, err
+		}
+		// Serve the stale config and retry after another TTL
+		config = cached.config
+	}
+
+	p.mu.Lock()
+	p.configs[resource] = cachedConfig{config: config, loaded: time.Now()}
+	p.mu.Unlock()
+	return config, nil
+}
+
+// Invalidate drops the cached configs of resources, or of every resource
+// when none are given, so the next request loads them from the source,
+// e.g. when a feature-flag system reports a change
+func (p *CachedConfigProvider) Invalidate(resources ...string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(resources) == 0 {
+		clear(p.configs)
+		return
+	}
+	for _, resource := range resources {
+		delete(p.configs, resource)
+	}
+}
+
+// QueryParamsMiddlewareFor is QueryParamsMiddleware with the config of
+// resource taken from provider for every request. When the provider fails,
+// requests are answered with the status from HTTPStatus, 500 Internal Server
+// Error for most errors, without the error message.
+func QueryParamsMiddlewareFor(dialect Dialect, provider ConfigProvider, resource string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			config, err := provider.GetConfig(r.Context(), resource)
+			if err != nil {
+				http.Error(w, "query configuration unavailable", HTTPStatus(err))
+				return
+			}
+			QueryParamsMiddleware(dialect, config)(next).ServeHTTP(w, r)
+		})
+	}
+}
+
+// SchemaHandlerFor is SchemaHandler with the config of resource taken from
+// provider for every request
+func SchemaHandlerFor(provider ConfigProvider, resource string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		config, err := provider.GetConfig(r.Context(), resource)
+		if err != nil {
+			http.Error(w, "query configuration unavailable", HTTPStatus(err))
+			return
+		}
+		SchemaHandler(config)(w, r)
+	}
+}
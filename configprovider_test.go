@@ -0,0 +1,113 @@
+package sqld
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchemaRegistry_ConfigProvider(t *testing.T) {
+	registry := NewSchemaRegistry().MustRegister("users", baseConfig())
+
+	config, err := registry.GetConfig(context.Background(), "users")
+	require.NoError(t, err)
+	assert.True(t, config.IsFieldAllowed("id"))
+
+	_, err = registry.GetConfig(context.Background(), "orders")
+	assert.ErrorIs(t, err, ErrUnknownResource)
+
+	require.NoError(t, registry.Replace("users", baseConfig().Extend().AllowFields("name")))
+	config, err = registry.GetConfig(context.Background(), "users")
+	require.NoError(t, err)
+	assert.True(t, config.IsFieldAllowed("name"))
+
+	var validationErr *ValidationError
+	assert.ErrorAs(t, registry.Replace("bad name", baseConfig()), &validationErr)
+}
+
+func TestCachedConfigProvider(t *testing.T) {
+	var loads atomic.Int32
+	fail := false
+	source := ConfigProviderFunc(func(ctx context.Context, resource string) (*Config, error) {
+		loads.Add(1)
+		if fail {
+			return nil, errors.New("database is down")
+		}
+		return baseConfig(), nil
+	})
+	ctx := context.Background()
+
+	t.Run("caches until invalidated", func(t *testing.T) {
+		loads.Store(0)
+		provider := NewCachedConfigProvider(source, 0)
+		first, err := provider.GetConfig(ctx, "users")
+		require.NoError(t, err)
+		second, err := provider.GetConfig(ctx, "users")
+		require.NoError(t, err)
+		assert.Same(t, first, second)
+		assert.Equal(t, int32(1), loads.Load())
+
+		provider.Invalidate("users")
+		third, err := provider.GetConfig(ctx, "users")
+		require.NoError(t, err)
+		assert.NotSame(t, first, third)
+		assert.Equal(t, int32(2), loads.Load())
+	})
+
+	t.Run("serves the stale config while reloads fail", func(t *testing.T) {
+		loads.Store(0)
+		provider := NewCachedConfigProvider(source, time.Millisecond)
+		first, err := provider.GetConfig(ctx, "users")
+		require.NoError(t, err)
+
+		fail = true
+		defer func() { fail = false }()
+		time.Sleep(2 * time.Millisecond)
+		stale, err := provider.GetConfig(ctx, "users")
+		require.NoError(t, err)
+		assert.Same(t, first, stale)
+		assert.Equal(t, int32(2), loads.Load())
+
+		_, err = provider.GetConfig(ctx, "orders")
+		assert.Error(t, err, "nothing to fall back on")
+	})
+}
+
+func TestQueryParamsMiddlewareFor(t *testing.T) {
+	registry := NewSchemaRegistry().MustRegister("users", baseConfig())
+
+	var params *QueryParams
+	handler := QueryParamsMiddlewareFor(Postgres, registry, "users")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		params, _ = QueryParamsFromContext(r.Context())
+	}))
+	serve := func(target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		return w
+	}
+
+	require.Equal(t, http.StatusOK, serve("/users?id=1").Code)
+	assert.Equal(t, 20, params.Limit)
+
+	// A reloaded config applies to the next request
+	require.NoError(t, registry.Replace("users", baseConfig().WithLimits(5, 10)))
+	require.Equal(t, http.StatusOK, serve("/users?id=1").Code)
+	assert.Equal(t, 5, params.Limit)
+
+	w := httptest.NewRecorder()
+	QueryParamsMiddlewareFor(Postgres, registry, "orders")(handler).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/orders", nil))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.NotContains(t, w.Body.String(), "orders")
+
+	w = httptest.NewRecorder()
+	SchemaHandlerFor(registry, "users")(w, httptest.NewRequest(http.MethodGet, "/users", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"created_at"`)
+}
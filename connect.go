@@ -0,0 +1,87 @@
+package sqld
+
+import (
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+)
+
+// SchemaServiceName is the fully qualified name of the Connect service that
+// serves query schemas
+const SchemaServiceName = "sqld.v1.SchemaService"
+
+// SchemaGetProcedure is the Connect procedure returning the QuerySchema
+const SchemaGetProcedure = "/" + SchemaServiceName + "/GetSchema"
+
+// NewConnectSchemaHandler serves the schema of config as a unary Connect
+// procedure, so clients of a ConnectRPC API can discover filterable fields
+// without a separate REST endpoint. Connect has no per-call content
+// negotiation, so instead of wrapping existing procedures the way
+// SchemaMiddleware wraps HTTP handlers, the schema gets its own procedure.
+// Like connect-go's generated constructors it returns the path to mount the
+// handler on:
+//
+//	mux.Handle(sqld.NewConnectSchemaHandler(config))
+//
+// The handler speaks the Connect protocol with the JSON codec, both as POST
+// and as a cacheable GET (?encoding=json). The request message is ignored;
+// send {}. It does not implement the gRPC or gRPC-Web protocols.
+func NewConnectSchemaHandler(config *Config) (string, http.Handler) {
+	return SchemaGetProcedure, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+			if mediaType != "application/json" {
+				w.Header().Set("Accept-Post", "application/json")
+				w.WriteHeader(http.StatusUnsupportedMediaType)
+				return
+			}
+			if !readConnectRequest(w, r.Body) {
+				return
+			}
+		case http.MethodGet:
+			if encoding := r.URL.Query().Get("encoding"); encoding != "json" {
+				writeConnectError(w, http.StatusUnsupportedMediaType, "unimplemented", "unsupported encoding "+encoding)
+				return
+			}
+			w.Header().Set("Cache-Control", schemaCacheControl(config))
+		default:
+			w.Header().Set("Allow", "GET, POST")
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		schema := GenerateSchemaContext(r.Context(), config)
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(schema); err != nil {
+			writeConnectError(w, http.StatusInternalServerError, "internal", "failed to encode schema")
+		}
+	})
+}
+
+// readConnectRequest checks that the request body is empty or a JSON
+// object, answering with an invalid_argument error otherwise
+func readConnectRequest(w http.ResponseWriter, body io.Reader) bool {
+	data, err := io.ReadAll(io.LimitReader(body, 1<<16))
+	if err != nil {
+		writeConnectError(w, http.StatusBadRequest, "invalid_argument", "reading request: "+err.Error())
+		return false
+	}
+	if len(data) == 0 {
+		return true
+	}
+	var message map[string]json.RawMessage
+	if err := json.Unmarshal(data, &message); err != nil {
+		writeConnectError(w, http.StatusBadRequest, "invalid_argument", "request must be a JSON object")
+		return false
+	}
+	return true
+}
+
+// writeConnectError writes an error in the Connect protocol's JSON format
+func writeConnectError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"code": code, "message": message})
+}
@@ -0,0 +1,61 @@
+package sqld
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConnectSchemaHandler(t *testing.T) {
+	config := DefaultConfig().WithAllowedFields(map[string]bool{"name": true, "status": true})
+	path, handler := NewConnectSchemaHandler(config)
+	assert.Equal(t, "/sqld.v1.SchemaService/GetSchema", path)
+
+	mux := http.NewServeMux()
+	mux.Handle(path, handler)
+
+	t.Run("post", func(t *testing.T) {
+		req := httptest.NewRequest("POST", path, strings.NewReader("{}"))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+		var schema QuerySchema
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &schema))
+		assert.Len(t, schema.Fields, 2)
+	})
+
+	t.Run("get", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest("GET", path+"?encoding=json&message=%7B%7D", nil))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "public, max-age=3600", rec.Header().Get("Cache-Control"))
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		req := httptest.NewRequest("POST", path, strings.NewReader("{}"))
+		req.Header.Set("Content-Type", "application/proto")
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusUnsupportedMediaType, rec.Code)
+
+		req = httptest.NewRequest("POST", path, strings.NewReader("[1]"))
+		req.Header.Set("Content-Type", "application/json; charset=utf-8")
+		rec = httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.JSONEq(t, `{"code":"invalid_argument","message":"request must be a JSON object"}`, rec.Body.String())
+
+		rec = httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest("DELETE", path, nil))
+		assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	})
+}
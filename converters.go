@@ -0,0 +1,99 @@
+package sqld
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// TypeConverter converts a value scanned from the database into dst, a
+// settable struct field of the type it was registered for. src is whatever
+// the driver returns when scanning into interface{}, including nil for NULL.
+type TypeConverter func(src interface{}, dst reflect.Value) error
+
+// TypeConverterRegistry maps struct field types to the converters
+// ReflectionScanner uses for them. It lets models hold types the driver
+// cannot scan into directly: uuid.UUID into a string field, pgtype values
+// into plain Go types, or string enums with validation.
+type TypeConverterRegistry struct {
+	mu         sync.RWMutex
+	converters map[reflect.Type]TypeConverter
+
+	// version changes on every Register so cached scan plans are rebuilt
+	version atomic.Uint64
+}
+
+// NewTypeConverterRegistry creates an empty registry
+func NewTypeConverterRegistry() *TypeConverterRegistry {
+	return &TypeConverterRegistry{converters: make(map[reflect.Type]TypeConverter)}
+}
+
+// DefaultTypeConverters is the registry used by RegisterTypeConverter and by
+// every ReflectionScanner that was not given another one
+var DefaultTypeConverters = NewTypeConverterRegistry()
+
+// Register sets the converter for fields of type typ, replacing any
+// converter registered before
+func (r *TypeConverterRegistry) Register(typ reflect.Type, convert TypeConverter) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.converters[typ] = convert
+	r.version.Add(1)
+}
+
+// Lookup returns the converter for fields of type typ
+func (r *TypeConverterRegistry) Lookup(typ reflect.Type) (TypeConverter, bool) {
+	if r == nil {
+		return nil, false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	convert, ok := r.converters[typ]
+	return convert, ok
+}
+
+// RegisterTypeConverter registers convert in DefaultTypeConverters for
+// fields of type T. Register converters at init, before any query runs.
+//
+// Example:
+//
+//	sqld.RegisterTypeConverter(func(src interface{}) (Status, error) {
+//		s, ok := src.(string)
+//		if !ok || !Status(s).Valid() {
+//			return "", fmt.Errorf("invalid status %v", src)
+//		}
+//		return Status(s), nil
+//	})
+func RegisterTypeConverter[T any](convert func(src interface{}) (T, error)) {
+	RegisterTypeConverterIn(DefaultTypeConverters, convert)
+}
+
+// RegisterTypeConverterIn is like RegisterTypeConverter for a registry
+// other than DefaultTypeConverters
+func RegisterTypeConverterIn[T any](registry *TypeConverterRegistry, convert func(src interface{}) (T, error)) {
+	registry.Register(reflect.TypeOf((*T)(nil)).Elem(), func(src interface{}, dst reflect.Value) error {
+		value, err := convert(src)
+		if err != nil {
+			return err
+		}
+		dst.Set(reflect.ValueOf(&value).Elem())
+		return nil
+	})
+}
+
+// convertedField is a struct field scanned into a temporary value and
+// converted after the row has been read
+type convertedField struct {
+	name    string
+	dst     reflect.Value
+	src     interface{}
+	convert TypeConverter
+}
+
+func (f *convertedField) apply() error {
+	if err := f.convert(f.src, f.dst); err != nil {
+		return fmt.Errorf("converting column into field %s: %w", f.name, err)
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package sqld
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type ticketStatus string
+
+type ticket struct {
+	ID     int64
+	Status ticketStatus
+	Owner  string
+}
+
+func TestReflectionScanner_TypeConverters(t *testing.T) {
+	registry := NewTypeConverterRegistry()
+	RegisterTypeConverterIn(registry, func(src interface{}) (ticketStatus, error) {
+		switch src {
+		case "open", "closed":
+			return ticketStatus(src.(string)), nil
+		}
+		return "", fmt.Errorf("unknown status %v", src)
+	})
+	RegisterTypeConverterIn(registry, func(src interface{}) (string, error) {
+		if id, ok := src.([16]byte); ok {
+			return fmt.Sprintf("%x", id), nil
+		}
+		return fmt.Sprint(src), nil
+	})
+
+	query := "SELECT id, status, owner FROM tickets"
+	scan := func(rows [][]interface{}) ([]ticket, error) {
+		mockDB := &MockDB{}
+		mockDB.On("Query", mock.Anything, query).Return(&valueRows{rows: rows}, nil)
+		return NewReflectionScanner[ticket]().WithTypeConverters(registry).ScanAll(context.Background(), mockDB, query)
+	}
+
+	tickets, err := scan([][]interface{}{
+		{int64(1), "open", [16]byte{0xab, 0xcd}},
+		{int64(2), "closed", "alice"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []ticket{
+		{ID: 1, Status: "open", Owner: "abcd0000000000000000000000000000"},
+		{ID: 2, Status: "closed", Owner: "alice"},
+	}, tickets)
+
+	_, err = scan([][]interface{}{{int64(3), "lost", "bob"}})
+	assert.ErrorContains(t, err, "converting column into field Status: unknown status lost")
+
+	// The default registry is untouched
+	_, ok := DefaultTypeConverters.Lookup(reflect.TypeOf(ticketStatus("")))
+	assert.False(t, ok)
+}
+
+func TestRegisterTypeConverter(t *testing.T) {
+	type cents int64
+	RegisterTypeConverter(func(src interface{}) (cents, error) {
+		f, ok := src.(float64)
+		if !ok {
+			return 0, errors.New("not a number")
+		}
+		return cents(f * 100), nil
+	})
+	convert, ok := DefaultTypeConverters.Lookup(reflect.TypeOf(cents(0)))
+	require.True(t, ok)
+
+	var value cents
+	require.NoError(t, convert(12.5, reflect.ValueOf(&value).Elem()))
+	assert.Equal(t, cents(1250), value)
+}
+
+func TestReflectionScanner_PlanFollowsRegistry(t *testing.T) {
+	registry := NewTypeConverterRegistry()
+	query := "SELECT id, status, owner FROM tickets"
+	scan := func() ([]ticket, error) {
+		mockDB := &MockDB{}
+		mockDB.On("Query", mock.Anything, query).Return(&valueRows{rows: [][]interface{}{
+			{int64(1), ticketStatus("open"), "ann"},
+		}}, nil)
+		return NewReflectionScanner[ticket]().WithTypeConverters(registry).ScanAll(context.Background(), mockDB, query)
+	}
+
+	tickets, err := scan()
+	require.NoError(t, err)
+	assert.Equal(t, ticketStatus("open"), tickets[0].Status)
+
+	// A converter registered after the first scan replaces the cached plan
+	RegisterTypeConverterIn(registry, func(src interface{}) (string, error) {
+		return strings.ToUpper(src.(string)), nil
+	})
+	tickets, err = scan()
+	require.NoError(t, err)
+	assert.Equal(t, "ANN", tickets[0].Owner)
+}
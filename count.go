@@ -0,0 +1,93 @@
+package sqld
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// TotalCount is the number of rows matching a query, as returned by
+// Executor.Count. Estimated reports that Total comes from the planner's
+// row estimate rather than from counting the rows.
+type TotalCount struct {
+	Total     int64 `json:"total"`
+	Estimated bool  `json:"estimated"`
+}
+
+// WithCountEstimate returns a copy of the executor whose Count uses the
+// PostgreSQL planner's row estimate instead of COUNT(*), which has to visit
+// every matching row. When the estimate is below exactBelow the rows are
+// counted exactly, so small result sets still report precise totals. Other
+// dialects always count exactly.
+//
+// Example:
+//
+//	userExec := sqld.NewExecutor[db.User](q).WithCountEstimate(10000)
+//	total, err := userExec.Count(ctx, db.SearchUsers, where)
+func (e *Executor[T]) WithCountEstimate(exactBelow int64) *Executor[T] {
+	clone := *e
+	clone.countEstimate = true
+	clone.exactCountBelow = exactBelow
+	return &clone
+}
+
+// Count returns the number of rows the query returns with the given
+// conditions, ignoring its limit, cursor and dynamic ordering. Policies and
+// automatic conditions apply as they do for QueryAll.
+func (e *Executor[T]) Count(ctx context.Context, sqlcQuery string, where *WhereBuilder, originalParams ...interface{}) (TotalCount, error) {
+	where, err := e.scopedWhere(ctx, sqlcQuery, where)
+	if err != nil {
+		return TotalCount{}, err
+	}
+
+	// The limit annotation's default or max would cap the count, and a fresh
+	// processor skips the executor's offset, lookahead and max limit
+	countable, err := withoutLimitOptions(sqlcQuery)
+	if err != nil {
+		return TotalCount{}, err
+	}
+	query, params, err := NewAnnotationProcessor(e.queries.dialect).ProcessQueryLimit(countable, where, nil, nil, NoLimit, originalParams...)
+	if err != nil {
+		return TotalCount{}, err
+	}
+
+	if e.countEstimate && e.queries.dialect == Postgres {
+		estimate, err := e.estimateRows(ctx, query, params)
+		if err != nil {
+			return TotalCount{}, err
+		}
+		if estimate >= e.exactCountBelow {
+			return TotalCount{Total: estimate, Estimated: true}, nil
+		}
+	}
+
+	countQuery := "SELECT COUNT(*) FROM (" + query + ") AS sqld_count"
+	start := time.Now()
+	var total int64
+	if err := e.queries.conn().QueryRow(ctx, countQuery, params...).Scan(&total); err != nil {
+		return TotalCount{}, e.queries.queryError(ctx, start, err, countQuery, params, "counting rows")
+	}
+	return TotalCount{Total: total}, nil
+}
+
+// estimateRows returns the planner's estimate of the rows query returns,
+// read from the top plan node of EXPLAIN (FORMAT JSON)
+func (e *Executor[T]) estimateRows(ctx context.Context, query string, params []interface{}) (int64, error) {
+	explainQuery := "EXPLAIN (FORMAT JSON) " + query
+	start := time.Now()
+	var output []byte
+	if err := e.queries.conn().QueryRow(ctx, explainQuery, params...).Scan(&output); err != nil {
+		return 0, e.queries.queryError(ctx, start, err, explainQuery, params, "estimating row count")
+	}
+
+	var plans []struct {
+		Plan struct {
+			Rows float64 `json:"Plan Rows"`
+		} `json:"Plan"`
+	}
+	if err := json.Unmarshal(output, &plans); err != nil || len(plans) == 0 {
+		return 0, fmt.Errorf("%w: unexpected EXPLAIN output: %s", ErrInvalidQuery, output)
+	}
+	return int64(plans[0].Plan.Rows), nil
+}
@@ -0,0 +1,133 @@
+package sqld
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countDB answers COUNT(*) with count and EXPLAIN with plan, recording the
+// queries it receives
+type countDB struct {
+	count   int64
+	plan    string
+	queries []string
+	args    [][]interface{}
+}
+
+func (db *countDB) Query(ctx context.Context, query string, args ...interface{}) (Rows, error) {
+	return nil, errors.New("unexpected Query")
+}
+
+func (db *countDB) QueryRow(ctx context.Context, query string, args ...interface{}) Row {
+	db.queries = append(db.queries, query)
+	db.args = append(db.args, args)
+	return countRow{db: db, query: query}
+}
+
+type countRow struct {
+	db    *countDB
+	query string
+}
+
+func (r countRow) Scan(dest ...interface{}) error {
+	if strings.HasPrefix(r.query, "EXPLAIN") {
+		*dest[0].(*[]byte) = []byte(r.db.plan)
+		return nil
+	}
+	*dest[0].(*int64) = r.db.count
+	return nil
+}
+
+func TestExecutor_Count(t *testing.T) {
+	ctx := context.Background()
+	const query = "SELECT id, name FROM users WHERE tenant_id = $1 /* sqld:where */ ORDER BY id /* sqld:limit */"
+
+	newWhere := func() *WhereBuilder {
+		where := NewWhereBuilder(Postgres)
+		where.Equal("status", "active")
+		return where
+	}
+
+	t.Run("exact count ignores limits", func(t *testing.T) {
+		db := &countDB{count: 42}
+		exec := NewExecutor[testUser](New(db, Postgres)).WithConfig(DefaultConfig().WithLimits(10, 25))
+
+		total, err := exec.Count(ctx, query, newWhere(), 7)
+		require.NoError(t, err)
+		assert.Equal(t, TotalCount{Total: 42}, total)
+
+		require.Len(t, db.queries, 1)
+		assert.True(t, strings.HasPrefix(db.queries[0], "SELECT COUNT(*) FROM (SELECT id, name FROM users WHERE tenant_id = $1"))
+		assert.Contains(t, db.queries[0], "status = $2")
+		assert.NotContains(t, db.queries[0], "LIMIT")
+		assert.Equal(t, []interface{}{7, "active"}, db.args[0])
+	})
+
+	t.Run("annotation default and max do not cap the count", func(t *testing.T) {
+		for _, annotation := range []string{"/* sqld:limit default=20 */", "/* sqld:limit max=50 */", "/* sqld:limit default=20 max=50 */"} {
+			db := &countDB{count: 420}
+			exec := NewExecutor[testUser](New(db, Postgres)).WithConfig(DefaultConfig().WithLimits(10, 25))
+
+			total, err := exec.Count(ctx, "SELECT id, name FROM users WHERE tenant_id = $1 /* sqld:where */ ORDER BY id "+annotation, newWhere(), 7)
+			require.NoError(t, err, annotation)
+			assert.Equal(t, TotalCount{Total: 420}, total)
+
+			require.Len(t, db.queries, 1)
+			assert.NotContains(t, db.queries[0], "LIMIT", annotation)
+			assert.NotContains(t, db.queries[0], "sqld:limit", annotation)
+			assert.Equal(t, []interface{}{7, "active"}, db.args[0], annotation)
+		}
+	})
+
+	t.Run("invalid limit annotations are rejected", func(t *testing.T) {
+		exec := NewExecutor[testUser](New(&countDB{}, Postgres))
+
+		_, err := exec.Count(ctx, "SELECT id FROM users WHERE true /* sqld:where */ /* sqld:limit default=x */", nil)
+		assert.ErrorIs(t, err, ErrInvalidQuery)
+	})
+
+	t.Run("large estimates are returned", func(t *testing.T) {
+		db := &countDB{count: 42, plan: `[{"Plan": {"Node Type": "Seq Scan", "Plan Rows": 1250000}}]`}
+		exec := NewExecutor[testUser](New(db, Postgres)).WithCountEstimate(10000)
+
+		total, err := exec.Count(ctx, query, newWhere(), 7)
+		require.NoError(t, err)
+		assert.Equal(t, TotalCount{Total: 1250000, Estimated: true}, total)
+		require.Len(t, db.queries, 1)
+		assert.True(t, strings.HasPrefix(db.queries[0], "EXPLAIN (FORMAT JSON) SELECT id, name FROM users"))
+	})
+
+	t.Run("small estimates are counted exactly", func(t *testing.T) {
+		db := &countDB{count: 42, plan: `[{"Plan": {"Plan Rows": 50}}]`}
+		exec := NewExecutor[testUser](New(db, Postgres)).WithCountEstimate(10000)
+
+		total, err := exec.Count(ctx, query, newWhere(), 7)
+		require.NoError(t, err)
+		assert.Equal(t, TotalCount{Total: 42}, total)
+		assert.Len(t, db.queries, 2)
+	})
+
+	t.Run("other dialects count exactly", func(t *testing.T) {
+		db := &countDB{count: 42}
+		exec := NewExecutor[testUser](New(db, MySQL)).WithCountEstimate(0)
+
+		total, err := exec.Count(ctx, "SELECT id, name FROM users WHERE 1=1 /* sqld:where */", nil)
+		require.NoError(t, err)
+		assert.Equal(t, TotalCount{Total: 42}, total)
+		require.Len(t, db.queries, 1)
+		assert.True(t, strings.HasPrefix(db.queries[0], "SELECT COUNT(*)"))
+	})
+
+	t.Run("unexpected plan output", func(t *testing.T) {
+		db := &countDB{plan: `not json`}
+		exec := NewExecutor[testUser](New(db, Postgres)).WithCountEstimate(0)
+
+		_, err := exec.Count(ctx, query, newWhere(), 7)
+		assert.ErrorIs(t, err, ErrInvalidQuery)
+	})
+}
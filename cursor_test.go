@@ -0,0 +1,94 @@
+package sqld
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type nameKey struct {
+	Name string `json:"name"`
+	ID   int64  `json:"id"`
+}
+
+func TestCursorCodec(t *testing.T) {
+	t.Run("round trip", func(t *testing.T) {
+		codec := NewCursorCodec[nameKey](nil)
+		encoded, err := codec.Encode(nameKey{Name: "ann", ID: 7}, "name:asc,id:asc")
+		require.NoError(t, err)
+		assert.NotContains(t, encoded, ".")
+
+		cursor, err := codec.Decode(encoded)
+		require.NoError(t, err)
+		assert.Equal(t, &KeysetCursor[nameKey]{Key: nameKey{Name: "ann", ID: 7}, Sort: "name:asc,id:asc"}, cursor)
+	})
+
+	t.Run("empty cursor", func(t *testing.T) {
+		cursor, err := NewCursorCodec[nameKey](nil).Decode("")
+		require.NoError(t, err)
+		assert.Nil(t, cursor)
+	})
+
+	t.Run("signed", func(t *testing.T) {
+		codec := NewCursorCodec[nameKey]([]byte("secret"))
+		encoded, err := codec.Encode(nameKey{Name: "ann", ID: 7}, "")
+		require.NoError(t, err)
+
+		cursor, err := codec.Decode(encoded)
+		require.NoError(t, err)
+		assert.Equal(t, int64(7), cursor.Key.ID)
+
+		payload, signature, _ := strings.Cut(encoded, ".")
+		forged, err := NewCursorCodec[nameKey](nil).Encode(nameKey{Name: "ann", ID: 1}, "")
+		require.NoError(t, err)
+
+		for name, tampered := range map[string]string{
+			"unsigned":         payload,
+			"forged key":       forged + "." + signature,
+			"other secret":     mustEncode(t, NewCursorCodec[nameKey]([]byte("other")), nameKey{Name: "ann", ID: 7}),
+			"broken signature": payload + ".!!",
+		} {
+			_, err := codec.Decode(tampered)
+			assert.ErrorIs(t, err, ErrInvalidCursor, name)
+		}
+
+		_, err = NewCursorCodec[nameKey](nil).Decode(encoded)
+		assert.ErrorIs(t, err, ErrInvalidCursor, "signed cursor for an unsigned codec")
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		codec := NewCursorCodec[nameKey](nil)
+		for _, encoded := range []string{"not base64!", "bnVsbA", "W10="} {
+			_, err := codec.Decode(encoded)
+			assert.ErrorIs(t, err, ErrInvalidCursor, encoded)
+		}
+	})
+}
+
+func mustEncode[K any](t *testing.T, codec *CursorCodec[K], key K) string {
+	t.Helper()
+	encoded, err := codec.Encode(key, "")
+	require.NoError(t, err)
+	return encoded
+}
+
+func TestAnnotationCursor(t *testing.T) {
+	createdAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	codec := NewCursorCodec[CreatedAtKey]([]byte("secret"))
+	encoded := mustEncode(t, codec, CreatedAtKey{CreatedAt: createdAt, ID: 42})
+
+	decoded, err := codec.Decode(encoded)
+	require.NoError(t, err)
+	cursor := AnnotationCursor(decoded)
+	assert.Equal(t, &Cursor{CreatedAt: createdAt, ID: 42}, cursor)
+	assert.Nil(t, AnnotationCursor(nil))
+
+	sql, params, err := NewAnnotationProcessor(Postgres).ProcessQuery(
+		"SELECT * FROM users WHERE true /* sqld:where */ /* sqld:cursor */", nil, cursor, nil, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT * FROM users WHERE true  AND (created_at < $1 OR (created_at = $1 AND id < $2)) ", sql)
+	assert.Equal(t, []interface{}{createdAt, int32(42)}, params)
+}
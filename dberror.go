@@ -0,0 +1,203 @@
+package sqld
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// Database errors NormalizeError classifies driver errors as. Match them
+// with errors.Is, and read the constraint with errors.As and *DatabaseError.
+var (
+	// ErrUniqueViolation is returned when a write violates a unique constraint
+	ErrUniqueViolation = errors.New("unique constraint violation")
+
+	// ErrFKViolation is returned when a write violates a foreign key
+	ErrFKViolation = errors.New("foreign key violation")
+
+	// ErrNotNullViolation is returned when a write sets a NOT NULL column to NULL
+	ErrNotNullViolation = errors.New("not null violation")
+
+	// ErrCheckViolation is returned when a write violates a check constraint
+	ErrCheckViolation = errors.New("check constraint violation")
+
+	// ErrSerialization is returned when a transaction could not be serialized
+	ErrSerialization = errors.New("serialization failure")
+
+	// ErrDeadlock is returned when the database aborted a deadlocked transaction
+	ErrDeadlock = errors.New("deadlock detected")
+)
+
+// DatabaseError is a driver error NormalizeError recognized. It matches its
+// Kind with errors.Is and unwraps to the driver error.
+//
+// Example:
+//
+//	_, err := exec.Exec(ctx, db.CreateUser, email)
+//	var dbErr *sqld.DatabaseError
+//	if errors.Is(err, sqld.ErrUniqueViolation) && errors.As(err, &dbErr) {
+//		log.Printf("duplicate on %s", dbErr.Constraint)
+//	}
+type DatabaseError struct {
+	// Kind is one of ErrUniqueViolation, ErrFKViolation, ErrNotNullViolation,
+	// ErrCheckViolation, ErrSerialization or ErrDeadlock
+	Kind error
+
+	// Code is the SQLSTATE or, for MySQL, the error number
+	Code string
+
+	// Constraint, Table and Column name the violated constraint, where the
+	// driver reports them
+	Constraint string
+	Table      string
+	Column     string
+
+	// Err is the driver error
+	Err error
+}
+
+// Error implements the error interface
+func (e *DatabaseError) Error() string {
+	if e.Constraint != "" {
+		return fmt.Sprintf("%v on %s: %v", e.Kind, e.Constraint, e.Err)
+	}
+	return fmt.Sprintf("%v: %v", e.Kind, e.Err)
+}
+
+// Unwrap returns the driver error
+func (e *DatabaseError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is the kind of the error
+func (e *DatabaseError) Is(target error) bool {
+	return target == e.Kind
+}
+
+// sqlStateKinds are the SQLSTATE codes NormalizeError classifies
+var sqlStateKinds = map[string]error{
+	"23505": ErrUniqueViolation,
+	"23503": ErrFKViolation,
+	"23502": ErrNotNullViolation,
+	"23514": ErrCheckViolation,
+	"40001": ErrSerialization,
+	"40P01": ErrDeadlock,
+}
+
+// mysqlErrorKinds are the MySQL error numbers NormalizeError classifies
+var mysqlErrorKinds = map[int]error{
+	1062: ErrUniqueViolation,
+	1451: ErrFKViolation,
+	1452: ErrFKViolation,
+	1048: ErrNotNullViolation,
+	3819: ErrCheckViolation,
+	1213: ErrDeadlock,
+}
+
+var (
+	// mysqlErrorPattern matches the message of a go-sql-driver/mysql error,
+	// "Error 1062 (23000): Duplicate entry ...", whose type has no methods
+	// to read the number from
+	mysqlErrorPattern = regexp.MustCompile(`\bError (\d+)(?: \([0-9A-Z]{5}\))?: (.*)`)
+
+	// mysqlConstraintPatterns extract the constraint, or for NOT NULL the
+	// column, from MySQL error messages
+	mysqlConstraintPatterns = map[int]*regexp.Regexp{
+		1062: regexp.MustCompile(`for key '([^']+)'`),
+		1451: regexp.MustCompile("CONSTRAINT `([^`]+)`"),
+		1452: regexp.MustCompile("CONSTRAINT `([^`]+)`"),
+		1048: regexp.MustCompile(`Column '([^']+)'`),
+		3819: regexp.MustCompile(`Check constraint '([^']+)'`),
+	}
+)
+
+// ClassifySQLState returns the kind of error NormalizeError reports for a
+// SQLSTATE code, or nil if it does not classify the code. Adapters use it
+// to build a DatabaseError with the details of their driver's error type.
+func ClassifySQLState(code string) error {
+	return sqlStateKinds[code]
+}
+
+// NormalizeError turns driver errors for constraint violations,
+// serialization failures and deadlocks into a *DatabaseError, and
+// sql.ErrNoRows into an error matching ErrNoRows (see WrapNoRows), so code
+// can branch on them with errors.Is without importing driver packages. It
+// recognizes errors with a SQLState method, such as lib/pq's *pq.Error, and
+// go-sql-driver/mysql errors by their message; other errors, including nil,
+// are returned unchanged. The pgx and mysql adapters normalize the errors
+// they return, the pgx one with the constraint, table and column names.
+func NormalizeError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return WrapNoRows(err)
+	}
+	var dbErr *DatabaseError
+	if errors.As(err, &dbErr) {
+		return err
+	}
+
+	var stateErr interface{ SQLState() string }
+	if errors.As(err, &stateErr) {
+		if kind := ClassifySQLState(stateErr.SQLState()); kind != nil {
+			return &DatabaseError{Kind: kind, Code: stateErr.SQLState(), Err: err}
+		}
+		return err
+	}
+
+	match := mysqlErrorPattern.FindStringSubmatch(err.Error())
+	if match == nil {
+		return err
+	}
+	number, _ := strconv.Atoi(match[1])
+	kind, ok := mysqlErrorKinds[number]
+	if !ok {
+		return err
+	}
+	normalized := &DatabaseError{Kind: kind, Code: match[1], Err: err}
+	if pattern, ok := mysqlConstraintPatterns[number]; ok {
+		if name := pattern.FindStringSubmatch(match[2]); name != nil {
+			if kind == ErrNotNullViolation {
+				normalized.Column = name[1]
+			} else {
+				normalized.Constraint = name[1]
+			}
+		}
+	}
+	return normalized
+}
+
+// noRowsError is a driver's no-rows error that also matches ErrNoRows
+type noRowsError struct {
+	err error
+}
+
+// Error implements the error interface
+func (e *noRowsError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the driver error
+func (e *noRowsError) Unwrap() error {
+	return e.err
+}
+
+// Is reports whether target is ErrNoRows
+func (e *noRowsError) Is(target error) bool {
+	return target == ErrNoRows
+}
+
+// WrapNoRows returns err, a driver's error for a query that returned no
+// rows such as sql.ErrNoRows or pgx.ErrNoRows, wrapped so it matches both
+// ErrNoRows and the driver's error with errors.Is. Errors already matching
+// ErrNoRows, and nil, are returned unchanged. Adapters use it so callers
+// can check for missing rows the same way whatever the driver.
+func WrapNoRows(err error) error {
+	if err == nil || errors.Is(err, ErrNoRows) {
+		return err
+	}
+	return &noRowsError{err: err}
+}
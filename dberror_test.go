@@ -0,0 +1,118 @@
+package sqld
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeError(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		kind       error
+		code       string
+		constraint string
+		column     string
+	}{
+		{"postgres unique", stateError("23505"), ErrUniqueViolation, "23505", "", ""},
+		{"postgres foreign key", fmt.Errorf("insert: %w", stateError("23503")), ErrFKViolation, "23503", "", ""},
+		{"postgres not null", stateError("23502"), ErrNotNullViolation, "23502", "", ""},
+		{"postgres check", stateError("23514"), ErrCheckViolation, "23514", "", ""},
+		{"postgres serialization", stateError("40001"), ErrSerialization, "40001", "", ""},
+		{"postgres deadlock", stateError("40P01"), ErrDeadlock, "40P01", "", ""},
+		{
+			"mysql unique",
+			errors.New("Error 1062 (23000): Duplicate entry 'ann@example.com' for key 'users.email'"),
+			ErrUniqueViolation, "1062", "users.email", "",
+		},
+		{
+			"mysql foreign key",
+			errors.New("Error 1452 (23000): Cannot add or update a child row: a foreign key constraint fails (`app`.`orders`, CONSTRAINT `orders_user_id_fk` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`))"),
+			ErrFKViolation, "1452", "orders_user_id_fk", "",
+		},
+		{"mysql not null", errors.New("Error 1048 (23000): Column 'name' cannot be null"), ErrNotNullViolation, "1048", "", "name"},
+		{"mysql check", errors.New("Error 3819 (HY000): Check constraint 'price_positive' is violated."), ErrCheckViolation, "3819", "price_positive", ""},
+		{"mysql deadlock without state", errors.New("Error 1213: Deadlock found when trying to get lock"), ErrDeadlock, "1213", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NormalizeError(tt.err)
+			assert.ErrorIs(t, err, tt.kind)
+			assert.ErrorIs(t, err, tt.err)
+
+			var dbErr *DatabaseError
+			require.ErrorAs(t, err, &dbErr)
+			assert.Equal(t, tt.code, dbErr.Code)
+			assert.Equal(t, tt.constraint, dbErr.Constraint)
+			assert.Equal(t, tt.column, dbErr.Column)
+		})
+	}
+
+	t.Run("unclassified errors are unchanged", func(t *testing.T) {
+		for _, err := range []error{nil, ErrNoRows, stateError("42P01"), errors.New("Error 1146 (42S02): Table 'app.users' doesn't exist")} {
+			assert.Equal(t, err, NormalizeError(err))
+		}
+	})
+
+	t.Run("normalized errors are unchanged", func(t *testing.T) {
+		err := NormalizeError(stateError("23505"))
+		assert.Same(t, err, NormalizeError(err))
+	})
+}
+
+func TestDatabaseError(t *testing.T) {
+	err := &DatabaseError{Kind: ErrUniqueViolation, Code: "23505", Constraint: "users_email_key", Err: stateError("23505")}
+	assert.Equal(t, "unique constraint violation on users_email_key: ERROR: 23505", err.Error())
+	assert.NotErrorIs(t, err, ErrFKViolation)
+
+	wrapped := WrapQueryError(err, "INSERT INTO users (email) VALUES ($1)", nil, "executing statement")
+	assert.ErrorIs(t, wrapped, ErrUniqueViolation)
+	assert.Equal(t, http.StatusConflict, HTTPStatus(wrapped))
+	assert.False(t, IsRetryableError(wrapped))
+
+	assert.True(t, IsRetryableError(&DatabaseError{Kind: ErrDeadlock, Code: "1213", Err: errors.New("deadlock")}))
+	assert.Equal(t, ErrSerialization, ClassifySQLState("40001"))
+	assert.Nil(t, ClassifySQLState("42P01"))
+}
+
+func TestWrapQueryError_Normalizes(t *testing.T) {
+	err := WrapQueryError(errors.New("Error 1062 (23000): Duplicate entry 'ann' for key 'users.name'"), "INSERT", nil, "executing statement")
+	var dbErr *DatabaseError
+	require.ErrorAs(t, err, &dbErr)
+	assert.Equal(t, "users.name", dbErr.Constraint)
+
+	assert.ErrorIs(t, WrapTransactionError(stateError("40001"), "commit"), ErrSerialization)
+}
+
+func TestWrapNoRows(t *testing.T) {
+	// driverNoRows stands in for a driver sentinel such as pgx.ErrNoRows
+	driverNoRows := errors.New("no rows in result set")
+
+	err := WrapNoRows(driverNoRows)
+	assert.ErrorIs(t, err, ErrNoRows)
+	assert.ErrorIs(t, err, driverNoRows)
+	assert.Equal(t, driverNoRows.Error(), err.Error())
+	assert.Equal(t, http.StatusNotFound, HTTPStatus(err))
+
+	assert.Same(t, err, WrapNoRows(err))
+	assert.Equal(t, ErrNoRows, WrapNoRows(ErrNoRows))
+	assert.Nil(t, WrapNoRows(nil))
+
+	normalized := NormalizeError(fmt.Errorf("get user: %w", sql.ErrNoRows))
+	assert.ErrorIs(t, normalized, ErrNoRows)
+	assert.ErrorIs(t, normalized, sql.ErrNoRows)
+
+	row := &MockRow{}
+	row.On("Scan", mock.Anything).Return(sql.ErrNoRows)
+	scanErr := WrapQueryError(row.Scan(new(int64)), "SELECT 1", nil, "counting rows")
+	assert.ErrorIs(t, scanErr, ErrNoRows)
+	assert.ErrorIs(t, scanErr, sql.ErrNoRows)
+}
@@ -0,0 +1,165 @@
+package sqld
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DialectCapabilities describes the SQL features supported by a dialect.
+// Builders consult this table rather than switching on the dialect directly,
+// so adding a dialect only requires registering its capabilities.
+type DialectCapabilities struct {
+	// NumberedPlaceholders is true when parameters are written as $1, $2, ...
+	// and false when the dialect uses positional ? markers
+	NumberedPlaceholders bool
+
+	// SupportsReturning indicates support for INSERT/UPDATE/DELETE ... RETURNING
+	SupportsReturning bool
+
+	// SupportsIlike indicates a native case-insensitive ILIKE operator
+	SupportsIlike bool
+
+	// SupportsTransactions indicates BEGIN/COMMIT/ROLLBACK support
+	SupportsTransactions bool
+
+	// SupportsLimitBy indicates support for ClickHouse-style LIMIT n BY columns
+	SupportsLimitBy bool
+
+	// SupportsNullsOrder indicates native ORDER BY ... NULLS FIRST/LAST.
+	// Without it, null ordering is emulated with a leading "column IS NULL" key.
+	SupportsNullsOrder bool
+
+	// RandomFunction is the function returning a random value per row, used
+	// for ORDER BY random sampling
+	RandomFunction string
+
+	// SupportsSeededRandom indicates RandomFunction accepts a seed producing a
+	// repeatable sequence, e.g. MySQL's RAND(42)
+	SupportsSeededRandom bool
+
+	// SupportsArrayParams indicates a whole slice can be bound as one array
+	// parameter, as in "col = ANY($1)"
+	SupportsArrayParams bool
+
+	// SupportsTrigram indicates pg_trgm similarity matching (% and
+	// similarity()), which requires the extension to be installed
+	SupportsTrigram bool
+
+	// IdentifierQuote is the character used to quote identifiers
+	IdentifierQuote string
+
+	// MaxParams is the most bind parameters one statement may carry, or 0
+	// when the dialect has no practical limit
+	MaxParams int
+}
+
+// dialectCapabilities is the capability table for the built-in dialects
+var dialectCapabilities = map[Dialect]DialectCapabilities{
+	Postgres: {
+		NumberedPlaceholders: true,
+		SupportsReturning:    true,
+		SupportsIlike:        true,
+		SupportsTransactions: true,
+		SupportsNullsOrder:   true,
+		RandomFunction:       "RANDOM",
+		SupportsArrayParams:  true,
+		SupportsTrigram:      true,
+		IdentifierQuote:      `"`,
+		MaxParams:            65535,
+	},
+	MySQL: {
+		NumberedPlaceholders: false,
+		SupportsReturning:    false,
+		SupportsIlike:        false,
+		SupportsTransactions: true,
+		RandomFunction:       "RAND",
+		SupportsSeededRandom: true,
+		IdentifierQuote:      "`",
+		MaxParams:            65535,
+	},
+	SQLite: {
+		NumberedPlaceholders: false,
+		SupportsReturning:    true,
+		SupportsIlike:        false,
+		SupportsTransactions: true,
+		RandomFunction:       "RANDOM",
+		IdentifierQuote:      `"`,
+		MaxParams:            32766,
+	},
+	ClickHouse: {
+		NumberedPlaceholders: false,
+		SupportsReturning:    false,
+		SupportsIlike:        true,
+		SupportsTransactions: false,
+		SupportsLimitBy:      true,
+		SupportsNullsOrder:   true,
+		RandomFunction:       "rand",
+		IdentifierQuote:      "`",
+	},
+}
+
+// defaultCapabilities is used for unknown dialects and follows plain ANSI SQL
+var defaultCapabilities = DialectCapabilities{
+	RandomFunction:  "RANDOM",
+	IdentifierQuote: `"`,
+}
+
+// Capabilities returns the capability table entry for the dialect.
+// Unknown dialects fall back to conservative ANSI behavior.
+func (d Dialect) Capabilities() DialectCapabilities {
+	if caps, ok := dialectCapabilities[d]; ok {
+		return caps
+	}
+	return defaultCapabilities
+}
+
+// Placeholder returns the parameter placeholder for the n-th (1-based) parameter
+func (d Dialect) Placeholder(n int) string {
+	if d.Capabilities().NumberedPlaceholders {
+		return "$" + strconv.Itoa(n)
+	}
+	return "?"
+}
+
+// QuoteIdentifier quotes an identifier using the dialect's quote character.
+// Qualified names such as "u.name" are quoted part by part, and embedded
+// quote characters are escaped by doubling them.
+func (d Dialect) QuoteIdentifier(name string) string {
+	quote := d.Capabilities().IdentifierQuote
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		parts[i] = quote + strings.ReplaceAll(part, quote, quote+quote) + quote
+	}
+	return strings.Join(parts, ".")
+}
+
+// BuildLimitBy builds a "LIMIT n BY col1, col2" clause, which keeps at most n
+// rows for each distinct combination of the given columns. It is only
+// available on dialects that support LIMIT BY (ClickHouse).
+func BuildLimitBy(dialect Dialect, limit int, columns ...string) (string, error) {
+	if !dialect.Capabilities().SupportsLimitBy {
+		return "", fmt.Errorf("%w: %s does not support LIMIT BY", ErrUnsupportedDialect, dialect)
+	}
+	if limit <= 0 {
+		return "", &ValidationError{
+			Field:   "limit",
+			Value:   limit,
+			Message: "LIMIT BY requires a positive limit",
+		}
+	}
+	if len(columns) == 0 {
+		return "", &ValidationError{
+			Field:   "columns",
+			Message: "LIMIT BY requires at least one column",
+		}
+	}
+
+	for _, column := range columns {
+		if err := ValidateColumnName(column); err != nil {
+			return "", err
+		}
+	}
+
+	return "LIMIT " + strconv.Itoa(limit) + " BY " + strings.Join(columns, ", "), nil
+}
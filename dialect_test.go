@@ -0,0 +1,165 @@
+package sqld
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDialectCapabilities(t *testing.T) {
+	tests := []struct {
+		dialect      Dialect
+		numbered     bool
+		returning    bool
+		ilike        bool
+		transactions bool
+		limitBy      bool
+		nullsOrder   bool
+		random       string
+		seededRandom bool
+		arrayParams  bool
+		trigram      bool
+		quote        string
+	}{
+		{Postgres, true, true, true, true, false, true, "RANDOM", false, true, true, `"`},
+		{MySQL, false, false, false, true, false, false, "RAND", true, false, false, "`"},
+		{SQLite, false, true, false, true, false, false, "RANDOM", false, false, false, `"`},
+		{ClickHouse, false, false, true, false, true, true, "rand", false, false, false, "`"},
+		{Dialect("unknown"), false, false, false, false, false, false, "RANDOM", false, false, false, `"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.dialect), func(t *testing.T) {
+			caps := tt.dialect.Capabilities()
+			assert.Equal(t, tt.numbered, caps.NumberedPlaceholders)
+			assert.Equal(t, tt.returning, caps.SupportsReturning)
+			assert.Equal(t, tt.ilike, caps.SupportsIlike)
+			assert.Equal(t, tt.transactions, caps.SupportsTransactions)
+			assert.Equal(t, tt.limitBy, caps.SupportsLimitBy)
+			assert.Equal(t, tt.nullsOrder, caps.SupportsNullsOrder)
+			assert.Equal(t, tt.random, caps.RandomFunction)
+			assert.Equal(t, tt.seededRandom, caps.SupportsSeededRandom)
+			assert.Equal(t, tt.arrayParams, caps.SupportsArrayParams)
+			assert.Equal(t, tt.trigram, caps.SupportsTrigram)
+			assert.Equal(t, tt.quote, caps.IdentifierQuote)
+		})
+	}
+}
+
+func TestDialectPlaceholder(t *testing.T) {
+	assert.Equal(t, "$3", Postgres.Placeholder(3))
+	assert.Equal(t, "?", MySQL.Placeholder(3))
+	assert.Equal(t, "?", SQLite.Placeholder(3))
+	assert.Equal(t, "?", ClickHouse.Placeholder(3))
+	assert.Equal(t, "?", Dialect("unknown").Placeholder(3))
+}
+
+func TestDialectQuoteIdentifier(t *testing.T) {
+	tests := []struct {
+		name     string
+		dialect  Dialect
+		input    string
+		expected string
+	}{
+		{"postgres simple", Postgres, "order", `"order"`},
+		{"postgres qualified", Postgres, "u.name", `"u"."name"`},
+		{"postgres embedded quote", Postgres, `we"ird`, `"we""ird"`},
+		{"mysql simple", MySQL, "group", "`group`"},
+		{"mysql qualified", MySQL, "u.name", "`u`.`name`"},
+		{"mysql embedded quote", MySQL, "we`ird", "`we``ird`"},
+		{"sqlite simple", SQLite, "order", `"order"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.dialect.QuoteIdentifier(tt.input))
+		})
+	}
+}
+
+func TestDialectSuite(t *testing.T) {
+	tests := []struct {
+		dialect       Dialect
+		whereSQL      string
+		annotatedSQL  string
+		adjustedWhere string
+	}{
+		{
+			dialect:       Postgres,
+			whereSQL:      "name ILIKE $1 AND age >= $2 AND role IN ($3, $4)",
+			annotatedSQL:  "SELECT * FROM users WHERE status = $1 AND name ILIKE $2 AND age >= $3 AND role IN ($4, $5) LIMIT $6",
+			adjustedWhere: "name = $3",
+		},
+		{
+			dialect:       MySQL,
+			whereSQL:      "LOWER(name) LIKE LOWER(?) AND age >= ? AND role IN (?, ?)",
+			annotatedSQL:  "SELECT * FROM users WHERE status = ? AND LOWER(name) LIKE LOWER(?) AND age >= ? AND role IN (?, ?) LIMIT ?",
+			adjustedWhere: "name = ?",
+		},
+		{
+			dialect:       ClickHouse,
+			whereSQL:      "name ILIKE ? AND age >= ? AND role IN (?, ?)",
+			annotatedSQL:  "SELECT * FROM users WHERE status = ? AND name ILIKE ? AND age >= ? AND role IN (?, ?) LIMIT ?",
+			adjustedWhere: "name = ?",
+		},
+		{
+			dialect:       SQLite,
+			whereSQL:      "LOWER(name) LIKE LOWER(?) AND age >= ? AND role IN (?, ?)",
+			annotatedSQL:  "SELECT * FROM users WHERE status = ? AND LOWER(name) LIKE LOWER(?) AND age >= ? AND role IN (?, ?) LIMIT ?",
+			adjustedWhere: "name = ?",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.dialect), func(t *testing.T) {
+			build := func() *WhereBuilder {
+				where := NewWhereBuilder(tt.dialect)
+				where.ILike("name", "%john%")
+				where.Raw("age >= ?", 18)
+				where.In("role", []interface{}{"admin", "user"})
+				return where
+			}
+
+			sql, params := build().Build()
+			assert.Equal(t, tt.whereSQL, sql)
+			assert.Equal(t, []interface{}{"%john%", 18, "admin", "user"}, params)
+
+			status := tt.dialect.Placeholder(1)
+			query := "SELECT * FROM users WHERE status = " + status + "/* sqld:where *//* sqld:limit */"
+			processed, allParams, err := NewAnnotationProcessor(tt.dialect).ProcessQuery(
+				query, build(), nil, nil, 10, "active",
+			)
+			require.NoError(t, err)
+			assert.Equal(t, tt.annotatedSQL, processed)
+			assert.Equal(t, []interface{}{"active", "%john%", 18, "admin", "user", 10}, allParams)
+
+			adjusted := NewParameterAdjuster(tt.dialect).AdjustSQL("name = "+tt.dialect.Placeholder(1), 2)
+			assert.Equal(t, tt.adjustedWhere, adjusted)
+		})
+	}
+}
+
+func TestBuildLimitBy(t *testing.T) {
+	t.Run("clickhouse", func(t *testing.T) {
+		clause, err := BuildLimitBy(ClickHouse, 5, "user_id", "day")
+		require.NoError(t, err)
+		assert.Equal(t, "LIMIT 5 BY user_id, day", clause)
+	})
+
+	t.Run("unsupported dialect", func(t *testing.T) {
+		_, err := BuildLimitBy(Postgres, 5, "user_id")
+		assert.ErrorIs(t, err, ErrUnsupportedDialect)
+	})
+
+	t.Run("invalid arguments", func(t *testing.T) {
+		_, err := BuildLimitBy(ClickHouse, 0, "user_id")
+		assert.Error(t, err)
+
+		_, err = BuildLimitBy(ClickHouse, 5)
+		assert.Error(t, err)
+
+		_, err = BuildLimitBy(ClickHouse, 5, "user_id; DROP TABLE users")
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,73 @@
+// Package echosqld binds sqld query parameters in Echo handlers.
+//
+//	e.GET("/users", listUsers, echosqld.Middleware(sqld.Postgres, config))
+//
+//	func listUsers(c echo.Context) error {
+//		params := echosqld.MustFromContext(c)
+//		users, err := exec.QueryAll(c.Request().Context(), db.SearchUsers, params.Where, params.Cursor, params.OrderBy, 50)
+//		...
+//	}
+package echosqld
+
+import (
+	"github.com/getangry/sqld"
+	"github.com/labstack/echo/v4"
+)
+
+// ContextKey is the echo.Context key holding the parsed *sqld.QueryParams
+const ContextKey = "sqld.params"
+
+// Middleware parses filters, sorting and the cursor of each request and
+// stores them in the echo.Context and the request context. Invalid requests
+// fail with an *echo.HTTPError carrying the status from sqld.HTTPStatus,
+// e.g. 400 Bad Request, so the app's HTTPErrorHandler renders them.
+func Middleware(dialect sqld.Dialect, config *sqld.Config) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			return bind(c, next, dialect, config)
+		}
+	}
+}
+
+// MiddlewareFor is like Middleware with the config of resource taken from
+// provider for every request, so config changes apply without a restart.
+// Provider errors fail with an *echo.HTTPError carrying the status from
+// sqld.HTTPStatus and a generic message, with the error as internal error.
+func MiddlewareFor(dialect sqld.Dialect, provider sqld.ConfigProvider, resource string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			config, err := provider.GetConfig(c.Request().Context(), resource)
+			if err != nil {
+				return echo.NewHTTPError(sqld.HTTPStatus(err), "query configuration unavailable").SetInternal(err)
+			}
+			return bind(c, next, dialect, config)
+		}
+	}
+}
+
+// bind parses the request with config, stores the params and calls next
+func bind(c echo.Context, next echo.HandlerFunc, dialect sqld.Dialect, config *sqld.Config) error {
+	params, err := sqld.BindRequest(c.Request(), dialect, config)
+	if err != nil {
+		return echo.NewHTTPError(sqld.HTTPStatus(err), err.Error()).SetInternal(err)
+	}
+	c.Set(ContextKey, params)
+	c.SetRequest(c.Request().WithContext(sqld.WithQueryParams(c.Request().Context(), params)))
+	return next(c)
+}
+
+// FromContext returns the params parsed by Middleware
+func FromContext(c echo.Context) (*sqld.QueryParams, bool) {
+	params, ok := c.Get(ContextKey).(*sqld.QueryParams)
+	return params, ok
+}
+
+// MustFromContext is like FromContext but panics when Middleware did not run
+// for the request
+func MustFromContext(c echo.Context) *sqld.QueryParams {
+	params, ok := FromContext(c)
+	if !ok {
+		panic("echosqld: no query params in context, is the middleware installed?")
+	}
+	return params
+}
@@ -0,0 +1,77 @@
+package echosqld
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/getangry/sqld"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testConfig() *sqld.Config {
+	return sqld.DefaultConfig().
+		WithAllowedFields(map[string]bool{"name": true, "created_at": true}).
+		WithStrictFields(true).
+		WithLimits(20, 100)
+}
+
+// serve runs a request through middleware and returns the params the
+// handler saw and the error
+func serve(t *testing.T, middleware echo.MiddlewareFunc, target string) (*sqld.QueryParams, error) {
+	t.Helper()
+	e := echo.New()
+	c := e.NewContext(httptest.NewRequest(http.MethodGet, target, nil), httptest.NewRecorder())
+
+	var params *sqld.QueryParams
+	err := middleware(func(c echo.Context) error {
+		params = MustFromContext(c)
+		fromRequest, ok := sqld.QueryParamsFromContext(c.Request().Context())
+		require.True(t, ok)
+		assert.Same(t, params, fromRequest)
+		return nil
+	})(c)
+	return params, err
+}
+
+func TestMiddleware(t *testing.T) {
+	params, err := serve(t, Middleware(sqld.Postgres, testConfig()), "/users?name=ann&sort=-created_at&limit=5")
+	require.NoError(t, err)
+	sql, args := params.Where.Build()
+	assert.Equal(t, "name = $1", sql)
+	assert.Equal(t, []interface{}{"ann"}, args)
+	assert.Equal(t, "created_at DESC", params.OrderBy.Build())
+	assert.Equal(t, 5, params.Limit)
+
+	params, err = serve(t, Middleware(sqld.Postgres, testConfig()), "/users?password=x")
+	var httpErr *echo.HTTPError
+	require.True(t, errors.As(err, &httpErr))
+	assert.Equal(t, http.StatusBadRequest, httpErr.Code)
+	assert.Nil(t, params, "the handler must not run")
+}
+
+func TestMiddlewareFor(t *testing.T) {
+	registry := sqld.NewSchemaRegistry().MustRegister("users", testConfig())
+
+	params, err := serve(t, MiddlewareFor(sqld.Postgres, registry, "users"), "/users")
+	require.NoError(t, err)
+	assert.Equal(t, 20, params.Limit)
+
+	params, err = serve(t, MiddlewareFor(sqld.Postgres, registry, "orders"), "/users")
+	var httpErr *echo.HTTPError
+	require.True(t, errors.As(err, &httpErr))
+	assert.Equal(t, http.StatusInternalServerError, httpErr.Code)
+	assert.NotContains(t, httpErr.Message, "orders")
+	assert.ErrorIs(t, httpErr.Internal, sqld.ErrUnknownResource)
+	assert.Nil(t, params)
+}
+
+func TestFromContext(t *testing.T) {
+	c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/users", nil), httptest.NewRecorder())
+	_, ok := FromContext(c)
+	assert.False(t, ok)
+	assert.Panics(t, func() { MustFromContext(c) })
+}
@@ -1,8 +1,15 @@
 package sqld
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"time"
 )
 
 // Error types for structured error handling
@@ -30,18 +37,63 @@ var (
 
 	// ErrUnsupportedDialect indicates an unsupported database dialect
 	ErrUnsupportedDialect = errors.New("unsupported database dialect")
+
+	// ErrMissingTenant indicates a tenant-scoped query ran without a tenant in context
+	ErrMissingTenant = errors.New("tenant not found in context")
+
+	// ErrPermissionDenied indicates the caller lacks the role required for a field
+	ErrPermissionDenied = errors.New("permission denied")
+
+	// ErrInvalidCursor indicates a pagination cursor that cannot be used with the request
+	ErrInvalidCursor = errors.New("invalid cursor")
+
+	// ErrQueryTooComplex indicates a request exceeded the configured QueryBudget
+	ErrQueryTooComplex = errors.New("query too complex")
+
+	// ErrJobNotFound indicates a query job ID unknown to the JobStore
+	ErrJobNotFound = errors.New("job not found")
+
+	// ErrJobNotFinished indicates the result of a query job that has not completed
+	ErrJobNotFinished = errors.New("job not finished")
+
+	// ErrQueryTimeout indicates a query stopped by a context deadline or a
+	// statement timeout
+	ErrQueryTimeout = errors.New("query timed out")
+
+	// ErrQueryCanceled indicates a query stopped because its context was canceled
+	ErrQueryCanceled = errors.New("query canceled")
+
+	// ErrUnknownResource indicates a ConfigProvider has no config for a resource
+	ErrUnknownResource = errors.New("unknown resource")
 )
 
-// QueryError represents an error that occurred during query execution
+// QueryError represents an error that occurred during query execution.
+// Error leaves out the parameters; %+v and log/slog include them, hidden
+// as set with Queries.WithParamRedaction.
 type QueryError struct {
 	Query   string
 	Params  []interface{}
 	Err     error
 	Context string
+
+	// Elapsed is how long the query ran before it failed, for errors of
+	// queries run through an Executor
+	Elapsed time.Duration
+
+	// redaction hides Params when the error is formatted or logged
+	redaction *ParamRedaction
 }
 
-// Error implements the error interface
+// Error implements the error interface. Timeouts and cancellations include
+// the elapsed time.
 func (e *QueryError) Error() string {
+	if e.Elapsed > 0 && (errors.Is(e.Err, ErrQueryTimeout) || errors.Is(e.Err, ErrQueryCanceled)) {
+		elapsed := e.Elapsed
+		if elapsed > time.Millisecond {
+			elapsed = elapsed.Round(time.Millisecond)
+		}
+		return fmt.Sprintf("query error in %s after %s: %v (query: %s)", e.Context, elapsed, e.Err, e.Query)
+	}
 	return fmt.Sprintf("query error in %s: %v (query: %s)", e.Context, e.Err, e.Query)
 }
 
@@ -55,6 +107,39 @@ func (e *QueryError) Is(target error) bool {
 	return errors.Is(e.Err, target)
 }
 
+// RedactedParams returns the parameters with the redaction policy of the
+// Queries that ran the query applied
+func (e *QueryError) RedactedParams() []interface{} {
+	return e.redaction.Apply(e.Query, e.Params)
+}
+
+// Format implements fmt.Formatter: %+v adds the redacted parameters to the
+// message
+func (e *QueryError) Format(s fmt.State, verb rune) {
+	switch {
+	case verb == 'v' && s.Flag('+'):
+		fmt.Fprintf(s, "%s (params: %v)", e.Error(), e.RedactedParams())
+	case verb == 'q':
+		fmt.Fprintf(s, "%q", e.Error())
+	default:
+		io.WriteString(s, e.Error())
+	}
+}
+
+// LogValue implements slog.LogValuer, logging the redacted parameters
+func (e *QueryError) LogValue() slog.Value {
+	attrs := []slog.Attr{
+		slog.String("context", e.Context),
+		slog.String("query", e.Query),
+		slog.Any("params", e.RedactedParams()),
+		slog.String("error", fmt.Sprint(e.Err)),
+	}
+	if e.Elapsed > 0 {
+		attrs = append(attrs, slog.Duration("elapsed", e.Elapsed))
+	}
+	return slog.GroupValue(attrs...)
+}
+
 // ValidationError represents a validation error
 type ValidationError struct {
 	Field   string
@@ -67,6 +152,85 @@ func (e *ValidationError) Error() string {
 	return fmt.Sprintf("validation error for field %s: %s", e.Field, e.Message)
 }
 
+// FilterError describes a single query parameter that could not be turned
+// into a filter. It is designed to be returned to API clients as-is.
+type FilterError struct {
+	// Field is the field name as sent by the client
+	Field string `json:"field"`
+
+	// Operator is the filter operator parsed from the parameter key
+	Operator string `json:"operator,omitempty"`
+
+	// Value is the raw parameter value
+	Value string `json:"value,omitempty"`
+
+	// Reason explains why the parameter was rejected
+	Reason string `json:"reason"`
+
+	// Position is the zero-based index of the parameter in the query string,
+	// or -1 when the order is unknown (url.Values carries no order)
+	Position int `json:"position"`
+
+	// Err is the underlying error, if any
+	Err error `json:"-"`
+}
+
+// Error implements the error interface
+func (e *FilterError) Error() string {
+	return fmt.Sprintf("invalid filter for field %s: %s", e.Field, e.Reason)
+}
+
+// Unwrap returns the underlying error
+func (e *FilterError) Unwrap() error {
+	return e.Err
+}
+
+// FilterErrors collects every FilterError found while parsing a query, so
+// clients can fix all problems at once instead of one per request
+type FilterErrors []*FilterError
+
+// Error implements the error interface
+func (e FilterErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Unwrap returns the individual errors so errors.Is and errors.As can match them
+func (e FilterErrors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, err := range e {
+		errs[i] = err
+	}
+	return errs
+}
+
+// BudgetError reports a QueryBudget limit exceeded by a request. Like
+// FilterError it is designed to be returned to API clients as-is.
+type BudgetError struct {
+	// Limit names the exceeded limit: "or_groups", "leading_wildcards" or
+	// "unindexed_filters"
+	Limit string `json:"limit"`
+
+	// Max is the configured limit
+	Max int `json:"max"`
+
+	// Count is what the request used
+	Count int `json:"count"`
+}
+
+// Error implements the error interface
+func (e *BudgetError) Error() string {
+	return fmt.Sprintf("%s: %d %s, maximum allowed: %d", ErrQueryTooComplex, e.Count, strings.ReplaceAll(e.Limit, "_", " "), e.Max)
+}
+
+// Unwrap returns ErrQueryTooComplex
+func (e *BudgetError) Unwrap() error {
+	return ErrQueryTooComplex
+}
+
 // TransactionError represents an error during transaction operations
 type TransactionError struct {
 	Operation string
@@ -83,7 +247,8 @@ func (e *TransactionError) Unwrap() error {
 	return e.Err
 }
 
-// WrapQueryError wraps an error with query context
+// WrapQueryError wraps an error with query context, normalizing driver
+// errors with NormalizeError
 func WrapQueryError(err error, query string, params []interface{}, context string) error {
 	if err == nil {
 		return nil
@@ -91,18 +256,75 @@ func WrapQueryError(err error, query string, params []interface{}, context strin
 	return &QueryError{
 		Query:   query,
 		Params:  params,
-		Err:     err,
+		Err:     NormalizeError(err),
 		Context: context,
 	}
 }
 
-// WrapTransactionError wraps an error with transaction context
+// WrapTransactionError wraps an error with transaction context, normalizing
+// driver errors with NormalizeError
 func WrapTransactionError(err error, operation string) error {
 	if err == nil {
 		return nil
 	}
 	return &TransactionError{
 		Operation: operation,
-		Err:       err,
+		Err:       NormalizeError(err),
 	}
 }
+
+// HTTPStatus returns the HTTP status code for an error returned by sqld, so
+// handlers can answer without matching error messages:
+//
+//   - 400 Bad Request for rejected filters, sorts and pagination values
+//     (FilterError, ValidationError, BudgetError, ErrInvalidParameter,
+//     ErrInvalidCursor, ErrQueryTooComplex, ErrSQLInjection)
+//   - 401 Unauthorized for ErrMissingTenant
+//   - 403 Forbidden for ErrPermissionDenied
+//   - 404 Not Found for ErrNoRows and ErrJobNotFound
+//   - 409 Conflict for ErrJobNotFinished, ErrUniqueViolation and
+//     ErrFKViolation
+//   - 503 Service Unavailable for ErrNoConnection
+//   - 504 Gateway Timeout for ErrQueryTimeout and other timeouts, from the
+//     context or the network
+//   - 500 Internal Server Error for everything else
+//
+// It returns 200 OK for a nil error.
+func HTTPStatus(err error) int {
+	var (
+		filterErr     *FilterError
+		validationErr *ValidationError
+		budgetErr     *BudgetError
+		netErr        net.Error
+	)
+	switch {
+	case err == nil:
+		return http.StatusOK
+	case errors.Is(err, ErrPermissionDenied):
+		return http.StatusForbidden
+	case errors.As(err, &filterErr), errors.As(err, &validationErr), errors.As(err, &budgetErr),
+		errors.Is(err, ErrInvalidParameter), errors.Is(err, ErrInvalidCursor),
+		errors.Is(err, ErrQueryTooComplex), errors.Is(err, ErrSQLInjection):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrMissingTenant):
+		return http.StatusUnauthorized
+	case errors.Is(err, ErrNoRows), errors.Is(err, ErrJobNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrJobNotFinished), errors.Is(err, ErrUniqueViolation), errors.Is(err, ErrFKViolation):
+		return http.StatusConflict
+	case errors.Is(err, ErrQueryTimeout), errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return http.StatusGatewayTimeout
+	case errors.Is(err, ErrNoConnection):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// IsUserError reports whether err was caused by the request rather than
+// the server, i.e. HTTPStatus maps it to a 4xx status. The messages of
+// user errors are safe to return to clients.
+func IsUserError(err error) bool {
+	status := HTTPStatus(err)
+	return status >= 400 && status < 500
+}
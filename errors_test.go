@@ -1,7 +1,11 @@
 package sqld
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"net/http"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -127,3 +131,64 @@ func TestErrorConstants(t *testing.T) {
 	assert.Contains(t, ErrTooManyRows.Error(), "rows")
 	assert.Contains(t, ErrUnsupportedDialect.Error(), "dialect")
 }
+
+func TestFilterError(t *testing.T) {
+	err := &FilterError{
+		Field:    "age",
+		Operator: "between",
+		Value:    "18",
+		Reason:   "between operator requires exactly 2 comma-separated values",
+		Position: 3,
+		Err:      ErrInvalidParameter,
+	}
+
+	assert.Equal(t, "invalid filter for field age: between operator requires exactly 2 comma-separated values", err.Error())
+	assert.ErrorIs(t, err, ErrInvalidParameter)
+
+	data, jsonErr := json.Marshal(err)
+	assert.NoError(t, jsonErr)
+	assert.JSONEq(t, `{"field":"age","operator":"between","value":"18","reason":"between operator requires exactly 2 comma-separated values","position":3}`, string(data))
+
+	errs := FilterErrors{err, {Field: "name", Reason: "unknown field"}}
+	assert.Equal(t, err.Error()+"; invalid filter for field name: unknown field", errs.Error())
+	assert.ErrorIs(t, errs, ErrInvalidParameter)
+}
+
+func TestHTTPStatus(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"nil", nil, http.StatusOK},
+		{"filter error", &FilterError{Field: "name", Reason: "unknown field"}, http.StatusBadRequest},
+		{"filter errors", FilterErrors{{Field: "name", Reason: "unknown field"}}, http.StatusBadRequest},
+		{"validation error", &ValidationError{Field: "limit", Message: "too large"}, http.StatusBadRequest},
+		{"budget error", &BudgetError{Limit: "or_groups", Max: 2, Count: 3}, http.StatusBadRequest},
+		{"invalid parameter", fmt.Errorf("%w: negative offset", ErrInvalidParameter), http.StatusBadRequest},
+		{"invalid cursor", ErrInvalidCursor, http.StatusBadRequest},
+		{"too complex", ErrQueryTooComplex, http.StatusBadRequest},
+		{"missing tenant", ErrMissingTenant, http.StatusUnauthorized},
+		{"permission denied", fmt.Errorf("%w: field email", ErrPermissionDenied), http.StatusForbidden},
+		{"no rows", WrapQueryError(ErrNoRows, "SELECT 1", nil, "scanning row"), http.StatusNotFound},
+		{"job not found", ErrJobNotFound, http.StatusNotFound},
+		{"job not finished", ErrJobNotFinished, http.StatusConflict},
+		{"no connection", ErrNoConnection, http.StatusServiceUnavailable},
+		{"deadline", WrapQueryError(context.DeadlineExceeded, "SELECT 1", nil, "executing query"), http.StatusGatewayTimeout},
+		{"other", errors.New("connection reset"), http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, HTTPStatus(tt.err))
+		})
+	}
+}
+
+func TestIsUserError(t *testing.T) {
+	assert.True(t, IsUserError(&FilterError{Field: "name", Reason: "unknown field"}))
+	assert.True(t, IsUserError(ErrPermissionDenied))
+	assert.False(t, IsUserError(nil))
+	assert.False(t, IsUserError(errors.New("connection reset")))
+	assert.False(t, IsUserError(context.DeadlineExceeded))
+}
@@ -0,0 +1,39 @@
+// Code generated by sqldgen. DO NOT EDIT.
+
+package db
+
+import (
+	"context"
+
+	"github.com/getangry/sqld"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// SearchUsersDynamic runs SearchUsers with dynamic conditions, ordering and pagination
+func SearchUsersDynamic(ctx context.Context, q *sqld.Queries, where *sqld.WhereBuilder, orderBy *sqld.OrderByBuilder, cursor *sqld.Cursor, limit int) ([]User, error) {
+	return sqld.NewExecutor[User](q).QueryAll(ctx, SearchUsers, where, cursor, orderBy, limit)
+}
+
+// SearchUsersByStatusDynamic runs SearchUsersByStatus with dynamic conditions, ordering and pagination
+func SearchUsersByStatusDynamic(ctx context.Context, q *sqld.Queries, where *sqld.WhereBuilder, orderBy *sqld.OrderByBuilder, cursor *sqld.Cursor, limit int, status pgtype.Text) ([]User, error) {
+	return sqld.NewExecutor[User](q).QueryAll(ctx, SearchUsersByStatus, where, cursor, orderBy, limit, status)
+}
+
+// NewUserConfig returns a starting sqld configuration for User rows.
+// Every column is allowed for filtering and sorting; trim the list before
+// exposing an endpoint.
+func NewUserConfig() *sqld.Config {
+	return sqld.DefaultConfig().WithAllowedFields(map[string]bool{
+		"id":         true,
+		"name":       true,
+		"email":      true,
+		"age":        true,
+		"status":     true,
+		"role":       true,
+		"country":    true,
+		"verified":   true,
+		"created_at": true,
+		"updated_at": true,
+		"deleted_at": true,
+	})
+}
@@ -2,15 +2,12 @@ package main
 
 import (
 	"context"
-	"encoding/base64"
-	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"strconv"
 	"strings"
-	"time"
 
 	"github.com/getangry/sqld"
 	pgxadapter "github.com/getangry/sqld/adapters/pgx"
@@ -90,12 +87,12 @@ func (s *UserService) respondWithPaginatedUsers(c *gin.Context, users []db.User,
 	var nextCursor *string
 	if hasMore && len(users) > 0 {
 		lastUser := users[len(users)-1]
-		nextCursorData := APICursor{
-			CreatedAt: lastUser.CreatedAt.Time,
-			ID:        lastUser.ID,
+		next, err := cursorCodec.Encode(sqld.CreatedAtKey{CreatedAt: lastUser.CreatedAt.Time, ID: lastUser.ID}, "")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to encode cursor"})
+			return
 		}
-		nextCursorStr := nextCursorData.Encode()
-		nextCursor = &nextCursorStr
+		nextCursor = &next
 	}
 
 	c.JSON(http.StatusOK, SearchUsersResponse{
@@ -106,34 +103,10 @@ func (s *UserService) respondWithPaginatedUsers(c *gin.Context, users []db.User,
 	})
 }
 
-// Cursor represents a pagination cursor for the API
-type APICursor struct {
-	CreatedAt time.Time `json:"created_at"`
-	ID        int32     `json:"id"`
-}
-
-// EncodeCursor encodes a cursor to a base64 string
-func (c APICursor) Encode() string {
-	data, _ := json.Marshal(c)
-	return base64.URLEncoding.EncodeToString(data)
-}
-
-// DecodeCursor decodes a base64 cursor string
-func DecodeCursor(encoded string) (*APICursor, error) {
-	if encoded == "" {
-		return nil, nil
-	}
-	data, err := base64.URLEncoding.DecodeString(encoded)
-	if err != nil {
-		return nil, err
-	}
-	var cursor APICursor
-	err = json.Unmarshal(data, &cursor)
-	if err != nil {
-		return nil, err
-	}
-	return &cursor, nil
-}
+// cursorCodec signs the created_at/id cursors handed out by the API, so
+// clients cannot forge them. Real services load the secret from their
+// configuration.
+var cursorCodec = sqld.NewCursorCodec[sqld.CreatedAtKey]([]byte(os.Getenv("CURSOR_SECRET")))
 
 // SearchUsersResponse represents the API response for user search with cursor pagination
 type SearchUsersResponse struct {
@@ -161,20 +134,12 @@ func (s *UserService) SearchUsers(c *gin.Context) {
 
 	// Parse cursor
 	cursorStr := c.Query("cursor")
-	apiCursor, err := DecodeCursor(cursorStr)
+	decoded, err := cursorCodec.Decode(cursorStr)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor: " + err.Error()})
 		return
 	}
-
-	// Convert API cursor to sqld cursor
-	var cursor *sqld.Cursor
-	if apiCursor != nil {
-		cursor = &sqld.Cursor{
-			CreatedAt: apiCursor.CreatedAt,
-			ID:        apiCursor.ID,
-		}
-	}
+	cursor := sqld.AnnotationCursor(decoded)
 
 	// Check if we have any query parameters for filtering (excluding pagination params)
 	queryParams := c.Request.URL.Query()
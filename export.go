@@ -0,0 +1,256 @@
+package sqld
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// ExportFormat is a file format Executor.Export writes
+type ExportFormat string
+
+const (
+	// ExportCSV writes a header row of column names followed by one line per row
+	ExportCSV ExportFormat = "csv"
+
+	// ExportNDJSON writes one JSON object per line
+	ExportNDJSON ExportFormat = "ndjson"
+)
+
+// exportFlushRows is how many rows Export writes between flushes of an
+// http.Flusher, so downloads start before the query finishes
+const exportFlushRows = 500
+
+// ContentType returns the MIME type of the format for a Content-Type header
+func (f ExportFormat) ContentType() string {
+	switch f {
+	case ExportCSV:
+		return "text/csv; charset=utf-8"
+	case ExportNDJSON:
+		return "application/x-ndjson"
+	default:
+		return "application/octet-stream"
+	}
+}
+
+// Export runs the query with the same conditions, policies, redaction and
+// transformers as QueryAll and streams the rows to w as CSV or NDJSON,
+// without holding the result in memory. If w is an http.Flusher, e.g. an
+// http.ResponseWriter, it is flushed as rows are written.
+//
+// The executor's WithMaxRows caps the rows written, as for Each; the rows
+// past the cap are dropped, since the start of the file has already been
+// sent. Export returns the number of rows written. CSV columns are the
+// struct's fields, named by their db or json tag.
+//
+// Example:
+//
+//	where, orderBy, err := sqld.FromRequestWithSort(r, sqld.Postgres, config)
+//	w.Header().Set("Content-Type", sqld.ExportCSV.ContentType())
+//	w.Header().Set("Content-Disposition", `attachment; filename="users.csv"`)
+//	_, err = userExec.WithMaxRows(100000, true).Export(ctx, w, sqld.ExportCSV, db.SearchUsers, where, orderBy)
+func (e *Executor[T]) Export(ctx context.Context, w io.Writer, format ExportFormat, sqlcQuery string, where *WhereBuilder, orderBy *OrderByBuilder, originalParams ...interface{}) (int64, error) {
+	writeRow, finish, err := newExportWriter[T](w, format)
+	if err != nil {
+		return 0, err
+	}
+
+	flusher, _ := w.(http.Flusher)
+	var written int64
+	_, err = e.Each(ctx, sqlcQuery, where, orderBy, func(row T) error {
+		if err := writeRow(row); err != nil {
+			return err
+		}
+		written++
+		if flusher != nil && written%exportFlushRows == 0 {
+			if err := finish(); err != nil {
+				return err
+			}
+			flusher.Flush()
+		}
+		return nil
+	}, originalParams...)
+	if err != nil {
+		return written, err
+	}
+
+	if err := finish(); err != nil {
+		return written, err
+	}
+	if flusher != nil {
+		flusher.Flush()
+	}
+	return written, nil
+}
+
+// Each runs the query with the same conditions, policies, redaction and
+// transformers as QueryAll and calls fn with every row as it is read,
+// without holding the result in memory. It is the basis of Export and of
+// streaming integrations such as arrowsqld. An error from fn stops the
+// query and is returned.
+//
+// The executor's WithMaxRows caps the rows passed to fn; the rows past the
+// cap are dropped. A limit annotation in the query receives the cap, and
+// its default and max options, meant for pages, do not apply: without a
+// cap every row is streamed. Each returns the number of rows passed to fn.
+func (e *Executor[T]) Each(ctx context.Context, sqlcQuery string, where *WhereBuilder, orderBy *OrderByBuilder, fn func(T) error, originalParams ...interface{}) (int64, error) {
+	where, err := e.scopedWhere(ctx, sqlcQuery, where)
+	if err != nil {
+		return 0, err
+	}
+
+	// A fresh processor, as Config.MaxLimit is meant for pages, not streams
+	streamed, err := withoutLimitOptions(sqlcQuery)
+	if err != nil {
+		return 0, err
+	}
+	limit := NoLimit
+	if e.maxRows > 0 {
+		limit = LimitTo(e.maxRows)
+	}
+	query, params, err := NewAnnotationProcessor(e.queries.dialect).ProcessQueryLimit(streamed, where, nil, orderBy, limit, originalParams...)
+	if err != nil {
+		return 0, err
+	}
+
+	start := time.Now()
+	rows, err := e.queries.conn().Query(ctx, query, params...)
+	if err != nil {
+		return 0, e.queries.queryError(ctx, start, err, query, params, "executing query")
+	}
+	defer rows.Close()
+
+	scanner := e.rowScanner()
+	transformers := e.rowTransformers()
+
+	var count int64
+	for rows.Next() {
+		if e.maxRows > 0 && count == int64(e.maxRows) {
+			break
+		}
+		row, err := scanner.ScanRow(rows)
+		if err != nil {
+			return count, e.queries.queryError(ctx, start, err, query, params, "scanning row")
+		}
+		if row, err = applyTransformers(ctx, transformers, row); err != nil {
+			return count, err
+		}
+		if err := fn(row); err != nil {
+			return count, err
+		}
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return count, e.queries.queryError(ctx, start, err, query, params, "iterating rows")
+	}
+	return count, nil
+}
+
+// newExportWriter returns functions writing a row of T to w in format and
+// flushing any buffered output
+func newExportWriter[T any](w io.Writer, format ExportFormat) (func(T) error, func() error, error) {
+	switch format {
+	case ExportNDJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetEscapeHTML(false)
+		return func(row T) error { return encoder.Encode(row) }, func() error { return nil }, nil
+
+	case ExportCSV:
+		structType := reflect.TypeOf((*T)(nil)).Elem()
+		if structType.Kind() != reflect.Struct {
+			return nil, nil, fmt.Errorf("%w: csv export needs a struct type, got %s", ErrInvalidParameter, structType)
+		}
+		indexes, header := exportColumns(structType)
+
+		writer := csv.NewWriter(w)
+		wroteHeader := false
+		record := make([]string, len(indexes))
+		writeRow := func(row T) error {
+			if !wroteHeader {
+				wroteHeader = true
+				if err := writer.Write(header); err != nil {
+					return err
+				}
+			}
+			value := reflect.ValueOf(row)
+			for i, index := range indexes {
+				record[i] = csvValue(value.Field(index))
+			}
+			return writer.Write(record)
+		}
+		finish := func() error {
+			if !wroteHeader {
+				wroteHeader = true
+				if err := writer.Write(header); err != nil {
+					return err
+				}
+			}
+			writer.Flush()
+			return writer.Error()
+		}
+		return writeRow, finish, nil
+
+	default:
+		return nil, nil, fmt.Errorf("%w: unknown export format %q", ErrInvalidParameter, format)
+	}
+}
+
+// exportColumns returns the indexes and column names of the exported
+// fields of structType, skipping those tagged "-"
+func exportColumns(structType reflect.Type) ([]int, []string) {
+	var indexes []int
+	var names []string
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		if !field.IsExported() || field.Tag.Get("db") == "-" || field.Tag.Get("json") == "-" {
+			continue
+		}
+		indexes = append(indexes, i)
+		names = append(names, structFieldColumn(field))
+	}
+	return indexes, names
+}
+
+// csvValue formats a field for CSV: NULL as an empty string, times as
+// RFC 3339 and driver.Valuer types such as sql.NullString by their value
+func csvValue(v reflect.Value) string {
+	if (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && v.IsNil() {
+		return ""
+	}
+	if valuer, ok := v.Interface().(driver.Valuer); ok {
+		value, err := valuer.Value()
+		if err != nil || value == nil {
+			return ""
+		}
+		return csvValue(reflect.ValueOf(value))
+	}
+
+	switch value := v.Interface().(type) {
+	case time.Time:
+		return value.Format(time.RFC3339Nano)
+	case []byte:
+		return string(value)
+	case fmt.Stringer:
+		return value.String()
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		return csvValue(v.Elem())
+	case reflect.Slice, reflect.Array:
+		items := make([]string, v.Len())
+		for i := range items {
+			items[i] = csvValue(v.Index(i))
+		}
+		return strings.Join(items, ",")
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+}
@@ -0,0 +1,147 @@
+package sqld
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type exportedUser struct {
+	ID        int64          `json:"id"`
+	Name      string         `json:"name"`
+	Nickname  sql.NullString `json:"nickname"`
+	Manager   *int64         `json:"manager_id"`
+	CreatedAt time.Time      `json:"created_at"`
+	Secret    string         `json:"-"`
+}
+
+func TestExecutor_Export(t *testing.T) {
+	const query = "SELECT id, name, nickname, manager_id, created_at, secret FROM users WHERE 1=1 /* sqld:where */ ORDER BY id /* sqld:orderby */ /* sqld:limit */"
+	created := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	db := &recordingDB{rows: [][]interface{}{
+		{int64(1), "Ada, Countess", sql.NullString{}, nil, created, "s1"},
+		{int64(2), "Bob", sql.NullString{String: "bobby", Valid: true}, int64(1), created, "s2"},
+	}}
+	ctx := context.Background()
+
+	t.Run("csv", func(t *testing.T) {
+		exec := NewExecutor[exportedUser](New(db, Postgres))
+		where := NewWhereBuilder(Postgres)
+		where.Equal("status", "active")
+
+		rec := httptest.NewRecorder()
+		n, err := exec.Export(ctx, rec, ExportCSV, query, where, NewOrderByBuilder().Desc("name"))
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), n)
+		assert.Equal(t, "id,name,nickname,manager_id,created_at\n"+
+			"1,\"Ada, Countess\",,,2024-03-01T12:00:00Z\n"+
+			"2,Bob,bobby,1,2024-03-01T12:00:00Z\n", rec.Body.String())
+		assert.True(t, rec.Flushed)
+
+		// The same filters and sort as a list query, without a limit
+		assert.Equal(t, "SELECT id, name, nickname, manager_id, created_at, secret FROM users WHERE 1=1  AND status = $1 ORDER BY name DESC  ", db.query)
+		assert.Equal(t, []interface{}{"active"}, db.args)
+	})
+
+	t.Run("ndjson", func(t *testing.T) {
+		exec := NewExecutor[exportedUser](New(db, Postgres))
+		var out strings.Builder
+		n, err := exec.Export(ctx, &out, ExportNDJSON, query, nil, nil)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), n)
+		lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+		require.Len(t, lines, 2)
+		assert.JSONEq(t, `{"id":2,"name":"Bob","nickname":{"String":"bobby","Valid":true},"manager_id":1,"created_at":"2024-03-01T12:00:00Z"}`, lines[1])
+	})
+
+	t.Run("row cap and redaction", func(t *testing.T) {
+		config := DefaultConfig().WithFieldRoles("name", "admin")
+		exec := NewExecutor[exportedUser](New(db, Postgres)).WithConfig(config).WithRedaction().WithMaxRows(1, true)
+
+		var out strings.Builder
+		n, err := exec.Export(ctx, &out, ExportCSV, query, nil, nil)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), n)
+		assert.Equal(t, "id,name,nickname,manager_id,created_at\n1,,,,2024-03-01T12:00:00Z\n", out.String())
+		assert.Contains(t, db.query, "LIMIT $1")
+		assert.Equal(t, []interface{}{1}, db.args)
+	})
+
+	t.Run("empty result and bad formats", func(t *testing.T) {
+		exec := NewExecutor[exportedUser](New(&recordingDB{}, Postgres))
+		var out strings.Builder
+		n, err := exec.Export(ctx, &out, ExportCSV, query, nil, nil)
+		require.NoError(t, err)
+		assert.Zero(t, n)
+		assert.Equal(t, "id,name,nickname,manager_id,created_at\n", out.String())
+
+		_, err = exec.Export(ctx, &out, ExportFormat("xlsx"), query, nil, nil)
+		assert.ErrorIs(t, err, ErrInvalidParameter)
+
+		_, err = NewExecutor[string](New(&recordingDB{}, Postgres)).Export(ctx, &out, ExportCSV, query, nil, nil)
+		assert.ErrorIs(t, err, ErrInvalidParameter)
+	})
+
+	assert.Equal(t, "text/csv; charset=utf-8", ExportCSV.ContentType())
+	assert.Equal(t, "application/x-ndjson", ExportNDJSON.ContentType())
+}
+
+func TestExecutor_Each(t *testing.T) {
+	const query = "SELECT id, name FROM users ORDER BY id /* sqld:limit */"
+	db := fixedRowsDB{rows: [][]interface{}{{int32(1), "alice"}, {int32(2), "bob"}, {int32(3), "carol"}}}
+	exec := NewExecutor[testUser](New(db, Postgres))
+
+	var names []string
+	n, err := exec.Each(context.Background(), query, nil, nil, func(u testUser) error {
+		names = append(names, u.Name)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), n)
+	assert.Equal(t, []string{"alice", "bob", "carol"}, names)
+
+	errStop := errors.New("stop")
+	n, err = exec.Each(context.Background(), query, nil, nil, func(u testUser) error {
+		if u.ID == 2 {
+			return errStop
+		}
+		return nil
+	})
+	assert.ErrorIs(t, err, errStop)
+	assert.Equal(t, int64(1), n)
+
+	n, err = exec.WithMaxRows(2, false).Each(context.Background(), query, nil, nil, func(testUser) error { return nil })
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+}
+
+func TestExecutor_Each_LimitAnnotation(t *testing.T) {
+	const query = "SELECT id, name FROM users ORDER BY id /* sqld:limit default=20 max=50 */"
+	ctx := context.Background()
+	noop := func(testUser) error { return nil }
+
+	t.Run("streams every row without a cap", func(t *testing.T) {
+		mockDB := &MockDB{}
+		expectEmptyQuery(mockDB, "SELECT id, name FROM users ORDER BY id ")
+
+		_, err := NewExecutor[testUser](New(mockDB, Postgres)).Each(ctx, query, nil, nil, noop)
+		require.NoError(t, err)
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("the cap is not clamped to the annotation max", func(t *testing.T) {
+		mockDB := &MockDB{}
+		expectEmptyQuery(mockDB, "SELECT id, name FROM users ORDER BY id  LIMIT $1", 1000)
+
+		_, err := NewExecutor[testUser](New(mockDB, Postgres)).WithMaxRows(1000, false).Each(ctx, query, nil, nil, noop)
+		require.NoError(t, err)
+		mockDB.AssertExpectations(t)
+	})
+}
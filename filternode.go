@@ -0,0 +1,203 @@
+package sqld
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// NodeKind identifies the type of a FilterNode
+type NodeKind string
+
+const (
+	// NodeAnd matches when all children match
+	NodeAnd NodeKind = "and"
+
+	// NodeOr matches when any child matches
+	NodeOr NodeKind = "or"
+
+	// NodeNot matches when its children, combined with AND, do not match
+	NodeNot NodeKind = "not"
+
+	// NodeCondition matches its Filter
+	NodeCondition NodeKind = "condition"
+)
+
+// FilterNode is a tree of filter conditions combined with AND, OR and NOT.
+// It marshals to and from JSON, so a search can be stored verbatim, e.g.
+// as a saved search in the database, and replayed later with Apply.
+//
+// Example:
+//
+//	tree := sqld.AndNode(
+//		sqld.ConditionNode(sqld.Filter{Field: "status", Operator: sqld.OpEq, Value: "active"}),
+//		sqld.NotNode(sqld.ConditionNode(sqld.Filter{Field: "role", Operator: sqld.OpIn, Value: []string{"bot", "test"}})),
+//	)
+//	data, _ := json.Marshal(tree)
+type FilterNode struct {
+	Kind     NodeKind     `json:"kind"`
+	Children []FilterNode `json:"children,omitempty"`
+	Filter   *Filter      `json:"filter,omitempty"`
+}
+
+// AndNode returns a node matching when all children match
+func AndNode(children ...FilterNode) FilterNode {
+	return FilterNode{Kind: NodeAnd, Children: children}
+}
+
+// OrNode returns a node matching when any child matches
+func OrNode(children ...FilterNode) FilterNode {
+	return FilterNode{Kind: NodeOr, Children: children}
+}
+
+// NotNode returns a node matching when the children, combined with AND,
+// do not match
+func NotNode(children ...FilterNode) FilterNode {
+	return FilterNode{Kind: NodeNot, Children: children}
+}
+
+// ConditionNode returns a leaf node for a single filter
+func ConditionNode(filter Filter) FilterNode {
+	return FilterNode{Kind: NodeCondition, Filter: &filter}
+}
+
+// FilterTree converts the filters returned by ParseRequest and the other
+// parsers into a tree: an AND node whose OR groups become OR nodes.
+// Filters through relations stay single conditions holding their related
+// filters.
+func FilterTree(filters []Filter) FilterNode {
+	children := make([]FilterNode, 0, len(filters))
+	for _, filter := range filters {
+		if filter.Operator == OpOr {
+			children = append(children, OrNode(FilterTree(filter.Or).Children...))
+			continue
+		}
+		children = append(children, ConditionNode(filter))
+	}
+	return AndNode(children...)
+}
+
+// Apply adds the conditions of the tree to builder. Values are bound as
+// parameters, but field names are written as they are, so trees from
+// untrusted sources must be built by the parsers or checked first.
+func (n FilterNode) Apply(builder ConditionBuilder) error {
+	switch n.Kind {
+	case NodeCondition:
+		if n.Filter == nil {
+			return fmt.Errorf("condition node without a filter")
+		}
+		return applyFilter(*n.Filter, builder)
+
+	case NodeAnd:
+		return applyNodes(n.Children, builder)
+
+	case NodeOr:
+		var groupErr error
+		builder.Or(func(or ConditionBuilder) {
+			for _, child := range n.Children {
+				var err error
+				if child.Kind == NodeAnd {
+					// Children of an AND node must stay together inside the OR
+					var ext ExtendedConditionBuilder
+					if ext, err = extended(or, "and node"); err == nil {
+						ext.And(func(and ConditionBuilder) { err = applyNodes(child.Children, and) })
+					}
+				} else {
+					err = child.Apply(or)
+				}
+				if err != nil && groupErr == nil {
+					groupErr = err
+				}
+			}
+		})
+		return groupErr
+
+	case NodeNot:
+		ext, err := extended(builder, "not node")
+		if err != nil {
+			return err
+		}
+		var groupErr error
+		ext.Not(func(not ConditionBuilder) {
+			groupErr = applyNodes(n.Children, not)
+		})
+		return groupErr
+
+	default:
+		return fmt.Errorf("unknown filter node kind %q", n.Kind)
+	}
+}
+
+// applyNodes applies nodes to builder one after the other
+func applyNodes(nodes []FilterNode, builder ConditionBuilder) error {
+	for _, node := range nodes {
+		if err := node.Apply(builder); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UnmarshalJSON decodes a Filter, restoring the Go type its operator's
+// value has after parsing, e.g. []string for in, so that a decoded Filter
+// compiles like the original
+func (f *Filter) UnmarshalJSON(data []byte) error {
+	type plain Filter
+	var raw struct {
+		plain
+		Value json.RawMessage `json:"value"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*f = Filter(raw.plain)
+
+	value, err := decodeFilterValue(f.Operator, raw.Value)
+	if err != nil {
+		return fmt.Errorf("filter %s %s: %w", f.Field, f.Operator, err)
+	}
+	f.Value = value
+	return nil
+}
+
+// decodeFilterValue decodes the JSON value of a filter with operator op
+func decodeFilterValue(op Operator, data json.RawMessage) (interface{}, error) {
+	if len(data) == 0 || string(data) == "null" {
+		return nil, nil
+	}
+
+	switch {
+	case op == OpIn || op == OpNotIn || op == OpBetween:
+		var items []interface{}
+		if err := json.Unmarshal(data, &items); err != nil {
+			return nil, err
+		}
+		values := make([]string, len(items))
+		for i, item := range items {
+			switch v := item.(type) {
+			case string:
+				values[i] = v
+			case float64:
+				values[i] = strconv.FormatFloat(v, 'f', -1, 64)
+			default:
+				values[i] = fmt.Sprint(v)
+			}
+		}
+		return values, nil
+
+	case geoOperators[op]:
+		var values []float64
+		err := json.Unmarshal(data, &values)
+		return values, err
+
+	case data[0] == '{' && (op == OpOverlaps || op == OpContains):
+		var rv rangeValue
+		err := json.Unmarshal(data, &rv)
+		return rv, err
+
+	default:
+		var value interface{}
+		err := json.Unmarshal(data, &value)
+		return value, err
+	}
+}
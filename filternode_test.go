@@ -0,0 +1,79 @@
+package sqld
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilterNode_Apply(t *testing.T) {
+	tree := AndNode(
+		ConditionNode(Filter{Field: "status", Operator: OpEq, Value: "active"}),
+		OrNode(
+			ConditionNode(Filter{Field: "role", Operator: OpEq, Value: "admin"}),
+			AndNode(
+				ConditionNode(Filter{Field: "role", Operator: OpEq, Value: "editor"}),
+				ConditionNode(Filter{Field: "verified", Operator: OpEq, Value: true}),
+			),
+		),
+		NotNode(ConditionNode(Filter{Field: "name", Operator: OpIn, Value: []string{"bot", "test"}})),
+	)
+
+	where := NewWhereBuilder(Postgres)
+	require.NoError(t, tree.Apply(where))
+
+	sql, params := where.Build()
+	assert.Equal(t, "status = $1 AND (role = $2 OR (role = $3 AND verified = $4)) AND NOT (name IN ($5, $6))", sql)
+	assert.Equal(t, []interface{}{"active", "admin", "editor", true, "bot", "test"}, params)
+
+	t.Run("invalid nodes", func(t *testing.T) {
+		assert.Error(t, FilterNode{Kind: "xor"}.Apply(NewWhereBuilder(Postgres)))
+		assert.Error(t, FilterNode{Kind: NodeCondition}.Apply(NewWhereBuilder(Postgres)))
+	})
+}
+
+func TestFilterTree_JSONRoundTrip(t *testing.T) {
+	config := DefaultConfig().
+		WithAllowedFields(map[string]bool{
+			"name": true, "email": true, "age": true, "status": true,
+			"location": true, "booked": true, "orders.total": true,
+		}).
+		WithGeoFields("location").
+		WithFieldType("booked", "int4range").
+		WithRelation("orders", Relation{Table: "orders", LocalColumn: "users.id", ForeignColumn: "orders.user_id"})
+
+	query := "or=(name[contains]=jo,email[endsWith]=@example.com)" +
+		"&status[in]=active,pending&age[between]=18,65&age[gt]=21" +
+		"&location[near]=52.52,13.405,5000&booked[overlaps]=10,20" +
+		"&orders.total[gte]=100&email[isNull]=false"
+
+	filters, err := ParseQueryString(query, config)
+	require.NoError(t, err)
+
+	original := NewWhereBuilder(Postgres)
+	require.NoError(t, ApplyFiltersToBuilder(filters, original))
+	wantSQL, wantParams := original.Build()
+
+	data, err := json.Marshal(FilterTree(filters))
+	require.NoError(t, err)
+
+	var decoded FilterNode
+	require.NoError(t, json.Unmarshal(data, &decoded))
+
+	replayed := NewWhereBuilder(Postgres)
+	require.NoError(t, decoded.Apply(replayed))
+	sql, params := replayed.Build()
+
+	assert.Equal(t, wantSQL, sql)
+	assert.Len(t, params, len(wantParams))
+	for i := range wantParams {
+		// JSON numbers decode as float64
+		if n, ok := wantParams[i].(int); ok {
+			assert.Equal(t, float64(n), params[i])
+			continue
+		}
+		assert.Equal(t, wantParams[i], params[i])
+	}
+}
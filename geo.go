@@ -0,0 +1,81 @@
+package sqld
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// geoOperators are the operators accepted on fields of type "geography"
+// besides the null checks
+var geoOperators = map[Operator]bool{
+	OpNear:   true,
+	OpWithin: true,
+}
+
+// geoValueCounts is the number of comma-separated numbers each geo operator
+// takes: lat,lng,radius for near and minLat,minLng,maxLat,maxLng for within
+var geoValueCounts = map[Operator]int{
+	OpNear:   3,
+	OpWithin: 4,
+}
+
+// parseGeoValue checks a filter involving a geography field or a geo
+// operator and returns the parsed coordinates, or a rejection reason and
+// an error. It returns a nil value for null checks, which need none.
+func parseGeoValue(isGeography bool, op Operator, value string) ([]float64, string, error) {
+	if !isGeography {
+		return nil, "operator not supported", fmt.Errorf("operator %s requires a geography field", op)
+	}
+	if op == OpIsNull || op == OpIsNotNull {
+		return nil, "", nil
+	}
+	if !geoOperators[op] {
+		return nil, "operator not supported", fmt.Errorf("operator %s not supported for geography fields", op)
+	}
+
+	parts := strings.Split(value, ",")
+	if len(parts) != geoValueCounts[op] {
+		return nil, "invalid coordinates", fmt.Errorf("%s requires %d comma-separated numbers", op, geoValueCounts[op])
+	}
+	numbers := make([]float64, len(parts))
+	for i, part := range parts {
+		n, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return nil, "invalid coordinates", fmt.Errorf("%q is not a number", part)
+		}
+		numbers[i] = n
+	}
+
+	// Latitudes and longitudes alternate, starting with a latitude
+	for i := 0; i+1 < len(numbers) && i < 4; i += 2 {
+		if numbers[i] < -90 || numbers[i] > 90 {
+			return nil, "invalid coordinates", fmt.Errorf("latitude %v out of range", numbers[i])
+		}
+		if numbers[i+1] < -180 || numbers[i+1] > 180 {
+			return nil, "invalid coordinates", fmt.Errorf("longitude %v out of range", numbers[i+1])
+		}
+	}
+	if op == OpNear && numbers[2] <= 0 {
+		return nil, "invalid coordinates", fmt.Errorf("radius must be positive")
+	}
+	return numbers, "", nil
+}
+
+// applyGeoFilter adds the PostGIS condition for a near or within filter
+func applyGeoFilter(filter Filter, builder ConditionBuilder) error {
+	vals, ok := filter.Value.([]float64)
+	if !ok || len(vals) != geoValueCounts[filter.Operator] {
+		return fmt.Errorf("%s operator requires %d coordinates", filter.Operator, geoValueCounts[filter.Operator])
+	}
+
+	switch filter.Operator {
+	case OpNear:
+		builder.Raw("ST_DWithin("+filter.Field+", ST_SetSRID(ST_MakePoint(?, ?), 4326)::geography, ?)",
+			vals[1], vals[0], vals[2])
+	case OpWithin:
+		builder.Raw("ST_Intersects("+filter.Field+", ST_MakeEnvelope(?, ?, ?, ?, 4326)::geography)",
+			vals[1], vals[0], vals[3], vals[2])
+	}
+	return nil
+}
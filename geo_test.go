@@ -0,0 +1,73 @@
+package sqld
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGeoOperators(t *testing.T) {
+	config := DefaultConfig().
+		WithAllowedFields(map[string]bool{"location": true, "name": true}).
+		WithGeoFields("location")
+
+	t.Run("near", func(t *testing.T) {
+		where, err := FromQueryString("location[near]=52.52,13.405,5000", Postgres, config)
+		require.NoError(t, err)
+
+		sql, params := where.Build()
+		assert.Equal(t, "ST_DWithin(location, ST_SetSRID(ST_MakePoint($1, $2), 4326)::geography, $3)", sql)
+		assert.Equal(t, []interface{}{13.405, 52.52, 5000.0}, params)
+	})
+
+	t.Run("within", func(t *testing.T) {
+		where, err := FromQueryString("name=Berlin&location[within]=52.3,13.0,52.7,13.8", Postgres, config)
+		require.NoError(t, err)
+
+		sql, params := where.Build()
+		assert.Equal(t, "name = $1 AND ST_Intersects(location, ST_MakeEnvelope($2, $3, $4, $5, 4326)::geography)", sql)
+		assert.Equal(t, []interface{}{"Berlin", 13.0, 52.3, 13.8, 52.7}, params)
+	})
+
+	t.Run("null checks", func(t *testing.T) {
+		filters, err := ParseQueryString("location[isNull]=true", config)
+		require.NoError(t, err)
+		assert.Len(t, filters, 1)
+	})
+
+	tests := []struct {
+		query  string
+		field  string
+		reason string
+	}{
+		{"location[near]=52.52,13.405", "location", "invalid coordinates"},
+		{"location[near]=52.52,east,100", "location", "invalid coordinates"},
+		{"location[near]=95,13.405,100", "location", "invalid coordinates"},
+		{"location[near]=52.52,13.405,0", "location", "invalid coordinates"},
+		{"location[within]=52.3,13.0,52.7,200", "location", "invalid coordinates"},
+		{"location=52.52,13.405", "location", "operator not supported"},
+		{"name[near]=52.52,13.405,5000", "name", "operator not supported"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			_, err := ParseQueryString(tt.query, config)
+
+			var filterErr *FilterError
+			require.True(t, errors.As(err, &filterErr))
+			assert.Equal(t, tt.field, filterErr.Field)
+			assert.Equal(t, tt.reason, filterErr.Reason)
+		})
+	}
+
+	t.Run("schema", func(t *testing.T) {
+		for _, field := range GenerateSchema(config).Fields {
+			if field.Name == "location" {
+				assert.Equal(t, "geography", field.Type)
+				assert.Equal(t, []string{"near", "within", "isnull", "isnotnull"}, field.Operators)
+			}
+		}
+	})
+}
@@ -0,0 +1,73 @@
+// Package ginsqld binds sqld query parameters in Gin handlers.
+//
+//	router.GET("/users", ginsqld.Middleware(sqld.Postgres, config), func(c *gin.Context) {
+//		params := ginsqld.MustFromContext(c)
+//		users, err := exec.QueryAll(c, db.SearchUsers, params.Where, params.Cursor, params.OrderBy, 50)
+//		...
+//	})
+package ginsqld
+
+import (
+	"github.com/getangry/sqld"
+	"github.com/gin-gonic/gin"
+)
+
+// ContextKey is the gin.Context key holding the parsed *sqld.QueryParams
+const ContextKey = "sqld.params"
+
+// Middleware parses filters, sorting and the cursor of each request and
+// stores them in the gin.Context and the request context. Invalid requests
+// are aborted with a JSON error and the status from sqld.HTTPStatus, e.g.
+// 400 Bad Request.
+func Middleware(dialect sqld.Dialect, config *sqld.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		bind(c, dialect, config)
+	}
+}
+
+// MiddlewareFor is like Middleware with the config of resource taken from
+// provider for every request, so config changes apply without a restart.
+// When the provider fails the request is aborted with the status from
+// sqld.HTTPStatus and a generic error.
+func MiddlewareFor(dialect sqld.Dialect, provider sqld.ConfigProvider, resource string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		config, err := provider.GetConfig(c.Request.Context(), resource)
+		if err != nil {
+			c.AbortWithStatusJSON(sqld.HTTPStatus(err), gin.H{"error": "query configuration unavailable"})
+			return
+		}
+		bind(c, dialect, config)
+	}
+}
+
+// bind parses the request with config and stores the params, or aborts
+func bind(c *gin.Context, dialect sqld.Dialect, config *sqld.Config) {
+	params, err := sqld.BindRequest(c.Request, dialect, config)
+	if err != nil {
+		c.AbortWithStatusJSON(sqld.HTTPStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.Set(ContextKey, params)
+	c.Request = c.Request.WithContext(sqld.WithQueryParams(c.Request.Context(), params))
+	c.Next()
+}
+
+// FromContext returns the params parsed by Middleware
+func FromContext(c *gin.Context) (*sqld.QueryParams, bool) {
+	value, ok := c.Get(ContextKey)
+	if !ok {
+		return nil, false
+	}
+	params, ok := value.(*sqld.QueryParams)
+	return params, ok
+}
+
+// MustFromContext is like FromContext but panics when Middleware did not run
+// for the request
+func MustFromContext(c *gin.Context) *sqld.QueryParams {
+	params, ok := FromContext(c)
+	if !ok {
+		panic("ginsqld: no query params in context, is the middleware installed?")
+	}
+	return params
+}
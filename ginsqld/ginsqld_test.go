@@ -0,0 +1,77 @@
+package ginsqld
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/getangry/sqld"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func testConfig() *sqld.Config {
+	return sqld.DefaultConfig().
+		WithAllowedFields(map[string]bool{"name": true, "created_at": true}).
+		WithStrictFields(true).
+		WithLimits(20, 100)
+}
+
+// serve runs a request through a router with middleware installed for
+// /users and returns the response and the params the handler saw
+func serve(t *testing.T, middleware gin.HandlerFunc, target string) (*httptest.ResponseRecorder, *sqld.QueryParams) {
+	t.Helper()
+	var params *sqld.QueryParams
+	router := gin.New()
+	router.GET("/users", middleware, func(c *gin.Context) {
+		params = MustFromContext(c)
+		fromRequest, ok := sqld.QueryParamsFromContext(c.Request.Context())
+		require.True(t, ok)
+		assert.Same(t, params, fromRequest)
+		c.Status(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+	return w, params
+}
+
+func TestMiddleware(t *testing.T) {
+	w, params := serve(t, Middleware(sqld.Postgres, testConfig()), "/users?name=ann&sort=-created_at&limit=5")
+	require.Equal(t, http.StatusOK, w.Code)
+	sql, args := params.Where.Build()
+	assert.Equal(t, "name = $1", sql)
+	assert.Equal(t, []interface{}{"ann"}, args)
+	assert.Equal(t, "created_at DESC", params.OrderBy.Build())
+	assert.Equal(t, 5, params.Limit)
+
+	w, params = serve(t, Middleware(sqld.Postgres, testConfig()), "/users?password=x")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"error"`)
+	assert.Nil(t, params, "the handler must not run")
+}
+
+func TestMiddlewareFor(t *testing.T) {
+	registry := sqld.NewSchemaRegistry().MustRegister("users", testConfig())
+
+	w, params := serve(t, MiddlewareFor(sqld.Postgres, registry, "users"), "/users")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 20, params.Limit)
+
+	w, params = serve(t, MiddlewareFor(sqld.Postgres, registry, "orders"), "/users")
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.NotContains(t, w.Body.String(), "orders")
+	assert.Nil(t, params)
+}
+
+func TestFromContext(t *testing.T) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	_, ok := FromContext(c)
+	assert.False(t, ok)
+	assert.Panics(t, func() { MustFromContext(c) })
+}
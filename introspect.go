@@ -0,0 +1,187 @@
+package sqld
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go/format"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ColumnInfo describes a table column as recorded in the database catalog
+type ColumnInfo struct {
+	// Name is the column name
+	Name string
+
+	// DataType is the type reported by the database, e.g. "timestamp with
+	// time zone", "varchar" or "Nullable(Int64)"
+	DataType string
+
+	// Nullable reports whether the column accepts NULL
+	Nullable bool
+}
+
+// Type returns the schema field type for the column, see ColumnType
+func (c ColumnInfo) Type() string {
+	return ColumnType(c.DataType)
+}
+
+// IntrospectTable reads the columns of table from the database catalog:
+// information_schema on PostgreSQL and MySQL, pragma_table_info on SQLite and
+// system.columns on ClickHouse. table may be qualified with a schema
+// ("billing.invoices"); otherwise the connection's current schema is used.
+func IntrospectTable(ctx context.Context, db DBTX, dialect Dialect, table string) ([]ColumnInfo, error) {
+	if err := ValidateColumnName(table); err != nil {
+		return nil, err
+	}
+	schema, name := "", table
+	if i := strings.LastIndex(table, "."); i >= 0 {
+		schema, name = table[:i], table[i+1:]
+	}
+
+	var query string
+	var args []interface{}
+	switch dialect {
+	case Postgres:
+		query = `SELECT column_name, data_type, is_nullable FROM information_schema.columns
+WHERE table_schema = COALESCE(NULLIF($1, ''), current_schema()) AND table_name = $2
+ORDER BY ordinal_position`
+		args = []interface{}{schema, name}
+	case MySQL:
+		query = `SELECT column_name, data_type, is_nullable FROM information_schema.columns
+WHERE table_schema = COALESCE(NULLIF(?, ''), DATABASE()) AND table_name = ?
+ORDER BY ordinal_position`
+		args = []interface{}{schema, name}
+	case SQLite:
+		if schema != "" {
+			return nil, fmt.Errorf("%w: sqlite tables cannot be qualified with a schema", ErrInvalidParameter)
+		}
+		query = `SELECT name, type, CASE WHEN "notnull" = 0 THEN 'YES' ELSE 'NO' END FROM pragma_table_info(?) ORDER BY cid`
+		args = []interface{}{name}
+	case ClickHouse:
+		query = `SELECT name, type, if(startsWith(type, 'Nullable'), 'YES', 'NO') FROM system.columns
+WHERE database = if(? = '', currentDatabase(), ?) AND table = ?
+ORDER BY position`
+		args = []interface{}{schema, schema, name}
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedDialect, dialect)
+	}
+
+	rows, err := db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, WrapQueryError(err, query, args, "introspecting table "+table)
+	}
+	defer rows.Close()
+
+	var columns []ColumnInfo
+	for rows.Next() {
+		var column ColumnInfo
+		var nullable string
+		if err := rows.Scan(&column.Name, &column.DataType, &nullable); err != nil {
+			return nil, WrapQueryError(err, query, args, "scanning column of "+table)
+		}
+		column.Nullable = strings.EqualFold(nullable, "YES")
+		columns = append(columns, column)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, WrapQueryError(err, query, args, "introspecting table "+table)
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("%w: table %s not found or has no columns", ErrInvalidQuery, table)
+	}
+	return columns, nil
+}
+
+// integerTypePattern matches integer type names across dialects, e.g. int4,
+// bigint, bigserial and ClickHouse's UInt64
+var integerTypePattern = regexp.MustCompile(`^(u?int\d*|integer|bigint|smallint|tinyint|mediumint|(small|big)?serial\d?)$`)
+
+// ColumnType maps a database type name to the field type used by schema
+// discovery: "integer", "number", "boolean", "datetime", "uuid", "geography",
+// a PostgreSQL range type such as "tstzrange", or "string"
+func ColumnType(dataType string) string {
+	t := strings.ToLower(strings.TrimSpace(dataType))
+	for _, wrapper := range []string{"nullable(", "lowcardinality("} {
+		if strings.HasPrefix(t, wrapper) {
+			t = strings.TrimSuffix(strings.TrimPrefix(t, wrapper), ")")
+		}
+	}
+	if i := strings.IndexAny(t, "( "); i >= 0 && !strings.HasPrefix(t, "double precision") && !strings.HasPrefix(t, "time") {
+		t = t[:i]
+	}
+
+	switch {
+	case t == "uuid" || t == "uniqueidentifier":
+		return "uuid"
+	case t == "geography":
+		return "geography"
+	case rangeElementTypes[t] != "":
+		return t
+	case t == "boolean" || t == "bool":
+		return "boolean"
+	case integerTypePattern.MatchString(t):
+		return "integer"
+	case t == "numeric" || t == "decimal" || t == "real" || t == "double" || t == "double precision" ||
+		t == "float" || strings.HasPrefix(t, "float") || strings.HasPrefix(t, "decimal") || t == "money":
+		return "number"
+	case strings.HasPrefix(t, "date") || strings.HasPrefix(t, "time") || t == "year":
+		return "datetime"
+	default:
+		return "string"
+	}
+}
+
+// ConfigFromColumns builds a Config allowing every column for filtering and
+// sorting, with each field's Type taken from the column type
+func ConfigFromColumns(columns []ColumnInfo) *Config {
+	config := DefaultConfig()
+	for _, column := range columns {
+		config.AllowedFields[column.Name] = true
+		config.WithFieldType(column.Name, column.Type())
+	}
+	return config
+}
+
+// IntrospectConfig reads the columns of table and returns a Config for them,
+// so GenerateSchema reports real column types instead of guessing from names.
+// Restrict the result with WithAllowedFields or WithFieldRoles as needed.
+func IntrospectConfig(ctx context.Context, db DBTX, dialect Dialect, table string) (*Config, error) {
+	columns, err := IntrospectTable(ctx, db, dialect, table)
+	if err != nil {
+		return nil, err
+	}
+	return ConfigFromColumns(columns), nil
+}
+
+// WriteConfigSource writes a gofmt-ed Go file in package pkg declaring
+// funcName, a function returning the Config for columns. It lets the
+// introspected config be generated once, e.g. from go:generate, and
+// committed instead of querying the catalog at startup.
+func WriteConfigSource(w io.Writer, pkg, funcName string, columns []ColumnInfo) error {
+	sorted := make([]ColumnInfo, len(columns))
+	copy(sorted, columns)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by sqld from the database catalog. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buf, "package %s\n\n", pkg)
+	fmt.Fprintf(&buf, "import \"github.com/getangry/sqld\"\n\n")
+	fmt.Fprintf(&buf, "// %s returns the filter and sort config for the introspected columns\n", funcName)
+	fmt.Fprintf(&buf, "func %s() *sqld.Config {\n", funcName)
+	fmt.Fprintf(&buf, "config := sqld.DefaultConfig()\n")
+	for _, column := range sorted {
+		fmt.Fprintf(&buf, "config.AllowedFields[%q] = true\n", column.Name)
+		fmt.Fprintf(&buf, "config.WithFieldType(%q, %q) // %s\n", column.Name, column.Type(), column.DataType)
+	}
+	fmt.Fprintf(&buf, "return config\n}\n")
+
+	source, err := format.Source(buf.Bytes())
+	if err != nil {
+		return fmt.Errorf("formatting generated config: %w", err)
+	}
+	_, err = w.Write(source)
+	return err
+}
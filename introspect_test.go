@@ -0,0 +1,116 @@
+package sqld
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// catalogRows serves rows of strings, as returned by catalog queries
+type catalogRows struct {
+	rows [][]string
+	next int
+}
+
+func (r *catalogRows) Close() error { return nil }
+func (r *catalogRows) Err() error   { return nil }
+
+func (r *catalogRows) Next() bool {
+	r.next++
+	return r.next <= len(r.rows)
+}
+
+func (r *catalogRows) Scan(dest ...interface{}) error {
+	for i, value := range r.rows[r.next-1] {
+		*dest[i].(*string) = value
+	}
+	return nil
+}
+
+func TestColumnType(t *testing.T) {
+	tests := map[string]string{
+		"integer":                  "integer",
+		"bigint":                   "integer",
+		"bigserial":                "integer",
+		"tinyint(1)":               "integer",
+		"Nullable(UInt64)":         "integer",
+		"interval":                 "string",
+		"point":                    "string",
+		"numeric":                  "number",
+		"decimal(10,2)":            "number",
+		"double precision":         "number",
+		"Float64":                  "number",
+		"boolean":                  "boolean",
+		"timestamp with time zone": "datetime",
+		"time without time zone":   "datetime",
+		"date":                     "datetime",
+		"DateTime64(3, 'UTC')":     "datetime",
+		"character varying":        "string",
+		"LowCardinality(String)":   "string",
+		"uuid":                     "uuid",
+		"UUID":                     "uuid",
+		"geography(Point,4326)":    "geography",
+		"tstzrange":                "tstzrange",
+		"daterange":                "daterange",
+		"jsonb":                    "string",
+	}
+	for dataType, expected := range tests {
+		assert.Equal(t, expected, ColumnType(dataType), dataType)
+	}
+}
+
+func TestIntrospectTable(t *testing.T) {
+	mockDB := &MockDB{}
+	mockDB.On("Query", mock.Anything, mock.MatchedBy(func(query string) bool {
+		return strings.Contains(query, "information_schema.columns")
+	}), "billing", "invoices").Return(&catalogRows{rows: [][]string{
+		{"id", "bigint", "NO"},
+		{"total", "numeric", "NO"},
+		{"paid", "boolean", "YES"},
+		{"issued_on", "date", "YES"},
+		{"customer_ref", "text", "NO"},
+	}}, nil)
+
+	columns, err := IntrospectTable(context.Background(), mockDB, Postgres, "billing.invoices")
+	require.NoError(t, err)
+	require.Len(t, columns, 5)
+	assert.Equal(t, ColumnInfo{Name: "paid", DataType: "boolean", Nullable: true}, columns[2])
+
+	// The name heuristic alone would report total, paid and issued_on as strings
+	schema := GenerateSchema(ConfigFromColumns(columns))
+	types := make(map[string]string)
+	for _, field := range schema.Fields {
+		types[field.Name] = field.Type
+	}
+	assert.Equal(t, map[string]string{
+		"id": "integer", "total": "number", "paid": "boolean", "issued_on": "datetime", "customer_ref": "string",
+	}, types)
+
+	var source strings.Builder
+	require.NoError(t, WriteConfigSource(&source, "billing", "InvoiceConfig", columns))
+	assert.Contains(t, source.String(), "func InvoiceConfig() *sqld.Config {")
+	assert.Contains(t, source.String(), `config.WithFieldType("total", "number") // numeric`)
+	assert.Less(t, strings.Index(source.String(), `"customer_ref"`), strings.Index(source.String(), `"total"`))
+}
+
+func TestIntrospectTable_Errors(t *testing.T) {
+	ctx := context.Background()
+
+	mockDB := &MockDB{}
+	mockDB.On("Query", mock.Anything, mock.Anything, "", "missing").Return(&catalogRows{}, nil)
+	_, err := IntrospectTable(ctx, mockDB, Postgres, "missing")
+	assert.ErrorIs(t, err, ErrInvalidQuery)
+
+	_, err = IntrospectTable(ctx, mockDB, Postgres, "users; DROP TABLE users")
+	assert.Error(t, err)
+
+	_, err = IntrospectTable(ctx, mockDB, SQLite, "main.users")
+	assert.ErrorIs(t, err, ErrInvalidParameter)
+
+	_, err = IntrospectTable(ctx, mockDB, Dialect("oracle"), "users")
+	assert.ErrorIs(t, err, ErrUnsupportedDialect)
+}
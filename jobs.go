@@ -0,0 +1,306 @@
+package sqld
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// JobStatus is the state of a query job
+type JobStatus string
+
+const (
+	// JobPending is a job waiting for a free slot
+	JobPending JobStatus = "pending"
+
+	// JobRunning is a job whose query is running
+	JobRunning JobStatus = "running"
+
+	// JobDone is a job whose result can be read
+	JobDone JobStatus = "done"
+
+	// JobFailed is a job that ended with an error
+	JobFailed JobStatus = "failed"
+)
+
+// Job describes a query submitted to a JobRunner
+type Job struct {
+	ID     string    `json:"id"`
+	Status JobStatus `json:"status"`
+
+	// ContentType is the MIME type of the result, e.g. text/csv
+	ContentType string `json:"content_type,omitempty"`
+
+	// Rows is the number of rows written to the result
+	Rows int64 `json:"rows"`
+
+	// Error is the error a failed job ended with
+	Error string `json:"error,omitempty"`
+
+	CreatedAt  time.Time  `json:"created_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// JobStore keeps the state and staged results of query jobs. The in-memory
+// store of NewMemoryJobStore suits a single instance; services running
+// several instances can stage results in shared storage such as a bucket.
+type JobStore interface {
+	// Save creates or updates the state of a job
+	Save(ctx context.Context, job Job) error
+
+	// Load returns the state of a job, or ErrJobNotFound
+	Load(ctx context.Context, id string) (Job, error)
+
+	// Create returns a writer staging the result of a job
+	Create(ctx context.Context, id string) (io.WriteCloser, error)
+
+	// Open returns a reader for the staged result of a job
+	Open(ctx context.Context, id string) (io.ReadCloser, error)
+
+	// Delete removes a job and its result
+	Delete(ctx context.Context, id string) error
+}
+
+// JobFunc runs a query and writes its result to w, returning the number of
+// rows written
+type JobFunc func(ctx context.Context, w io.Writer) (int64, error)
+
+// JobRunner runs queries in the background and stages their results in a
+// JobStore, so handlers can return a job ID at once instead of timing out
+// on long exports. Clients poll Status and fetch the result when the job
+// is done.
+//
+// Example:
+//
+//	jobs := sqld.NewJobRunner(sqld.NewMemoryJobStore()).WithConcurrency(4)
+//
+//	// POST /exports
+//	job, err := sqld.SubmitExport(r.Context(), jobs, userExec, sqld.ExportCSV, db.SearchUsers, where, orderBy)
+//
+//	// GET /exports/{id}
+//	job, err := jobs.Status(r.Context(), id)
+//
+//	// GET /exports/{id}/result
+//	result, job, err := jobs.Result(r.Context(), id)
+type JobRunner struct {
+	store   JobStore
+	slots   chan struct{}
+	timeout time.Duration
+	wg      sync.WaitGroup
+}
+
+// NewJobRunner creates a runner staging results in store
+func NewJobRunner(store JobStore) *JobRunner {
+	return &JobRunner{store: store}
+}
+
+// WithConcurrency limits the number of jobs running at once; further jobs
+// stay pending until a running one finishes. Zero removes the limit.
+// Set it before submitting jobs.
+func (r *JobRunner) WithConcurrency(n int) *JobRunner {
+	r.slots = nil
+	if n > 0 {
+		r.slots = make(chan struct{}, n)
+	}
+	return r
+}
+
+// WithTimeout cancels jobs running longer than d. Zero removes the limit.
+func (r *JobRunner) WithTimeout(d time.Duration) *JobRunner {
+	r.timeout = d
+	return r
+}
+
+// Submit starts fn in the background and returns the pending job. The job
+// keeps the values of ctx, such as roles and the tenant, but not its
+// cancellation, so it outlives the request submitting it.
+func (r *JobRunner) Submit(ctx context.Context, contentType string, fn JobFunc) (Job, error) {
+	id, err := newJobID()
+	if err != nil {
+		return Job{}, err
+	}
+	job := Job{ID: id, Status: JobPending, ContentType: contentType, CreatedAt: time.Now()}
+	if err := r.store.Save(ctx, job); err != nil {
+		return Job{}, err
+	}
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		r.run(context.WithoutCancel(ctx), job, fn)
+	}()
+	return job, nil
+}
+
+// run executes a submitted job and records its outcome
+func (r *JobRunner) run(ctx context.Context, job Job, fn JobFunc) {
+	if r.slots != nil {
+		r.slots <- struct{}{}
+		defer func() { <-r.slots }()
+	}
+	if r.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.timeout)
+		defer cancel()
+	}
+
+	job.Status = JobRunning
+	if err := r.store.Save(ctx, job); err != nil {
+		r.finish(ctx, job, err)
+		return
+	}
+
+	w, err := r.store.Create(ctx, job.ID)
+	if err != nil {
+		r.finish(ctx, job, err)
+		return
+	}
+	job.Rows, err = fn(ctx, w)
+	if closeErr := w.Close(); err == nil {
+		err = closeErr
+	}
+	r.finish(ctx, job, err)
+}
+
+// finish records the outcome of a job
+func (r *JobRunner) finish(ctx context.Context, job Job, err error) {
+	now := time.Now()
+	job.FinishedAt = &now
+	job.Status = JobDone
+	if err != nil {
+		job.Status = JobFailed
+		job.Error = err.Error()
+	}
+	// The job's own context may have timed out; the outcome is still saved
+	_ = r.store.Save(context.WithoutCancel(ctx), job)
+}
+
+// Status returns the current state of a job
+func (r *JobRunner) Status(ctx context.Context, id string) (Job, error) {
+	return r.store.Load(ctx, id)
+}
+
+// Result returns a reader for the result of a finished job. Jobs that are
+// still pending or running fail with ErrJobNotFinished; failed jobs fail
+// with their error.
+func (r *JobRunner) Result(ctx context.Context, id string) (io.ReadCloser, Job, error) {
+	job, err := r.store.Load(ctx, id)
+	if err != nil {
+		return nil, job, err
+	}
+	switch job.Status {
+	case JobDone:
+		result, err := r.store.Open(ctx, id)
+		return result, job, err
+	case JobFailed:
+		return nil, job, fmt.Errorf("job %s failed: %s", id, job.Error)
+	default:
+		return nil, job, fmt.Errorf("%w: job %s is %s", ErrJobNotFinished, id, job.Status)
+	}
+}
+
+// Delete removes a job and its result from the store
+func (r *JobRunner) Delete(ctx context.Context, id string) error {
+	return r.store.Delete(ctx, id)
+}
+
+// Wait blocks until all submitted jobs have finished, e.g. during shutdown
+func (r *JobRunner) Wait() {
+	r.wg.Wait()
+}
+
+// SubmitExport submits a job exporting the rows of a query with
+// Executor.Export in the given format
+func SubmitExport[T any](ctx context.Context, runner *JobRunner, exec *Executor[T], format ExportFormat, sqlcQuery string, where *WhereBuilder, orderBy *OrderByBuilder, originalParams ...interface{}) (Job, error) {
+	if format != ExportCSV && format != ExportNDJSON {
+		return Job{}, fmt.Errorf("%w: unknown export format %q", ErrInvalidParameter, format)
+	}
+	return runner.Submit(ctx, format.ContentType(), func(ctx context.Context, w io.Writer) (int64, error) {
+		return exec.Export(ctx, w, format, sqlcQuery, where, orderBy, originalParams...)
+	})
+}
+
+// newJobID returns a random job ID
+func newJobID() (string, error) {
+	var id [16]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return "", fmt.Errorf("generating job id: %w", err)
+	}
+	return hex.EncodeToString(id[:]), nil
+}
+
+// MemoryJobStore is a JobStore keeping jobs and results in memory
+type MemoryJobStore struct {
+	mu      sync.Mutex
+	jobs    map[string]Job
+	results map[string][]byte
+}
+
+// NewMemoryJobStore creates an empty in-memory JobStore
+func NewMemoryJobStore() *MemoryJobStore {
+	return &MemoryJobStore{jobs: make(map[string]Job), results: make(map[string][]byte)}
+}
+
+// Save implements JobStore
+func (s *MemoryJobStore) Save(ctx context.Context, job Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs[job.ID] = job
+	return nil
+}
+
+// Load implements JobStore
+func (s *MemoryJobStore) Load(ctx context.Context, id string) (Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[id]
+	if !ok {
+		return Job{}, fmt.Errorf("%w: %s", ErrJobNotFound, id)
+	}
+	return job, nil
+}
+
+// Create implements JobStore. The result becomes visible when the writer
+// is closed.
+func (s *MemoryJobStore) Create(ctx context.Context, id string) (io.WriteCloser, error) {
+	return &memoryResult{store: s, id: id}, nil
+}
+
+// Open implements JobStore
+func (s *MemoryJobStore) Open(ctx context.Context, id string) (io.ReadCloser, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result, ok := s.results[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: no result for %s", ErrJobNotFound, id)
+	}
+	return io.NopCloser(bytes.NewReader(result)), nil
+}
+
+// Delete implements JobStore
+func (s *MemoryJobStore) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.jobs, id)
+	delete(s.results, id)
+	return nil
+}
+
+// memoryResult buffers a result until it is closed
+type memoryResult struct {
+	bytes.Buffer
+	store *MemoryJobStore
+	id    string
+}
+
+func (r *memoryResult) Close() error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	r.store.results[r.id] = r.Bytes()
+	return nil
+}
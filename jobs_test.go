@@ -0,0 +1,105 @@
+package sqld
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJobRunner(t *testing.T) {
+	const query = "SELECT id, name FROM users ORDER BY id /* sqld:limit */"
+	db := fixedRowsDB{rows: [][]interface{}{{int32(1), "alice"}, {int32(2), "bob"}}}
+	exec := NewExecutor[testUser](New(db, Postgres))
+
+	t.Run("export job", func(t *testing.T) {
+		jobs := NewJobRunner(NewMemoryJobStore())
+		ctx, cancel := context.WithCancel(context.Background())
+		job, err := SubmitExport(ctx, jobs, exec, ExportNDJSON, query, nil, nil)
+		require.NoError(t, err)
+		// The job outlives the request that submitted it
+		cancel()
+		assert.Equal(t, JobPending, job.Status)
+		assert.Len(t, job.ID, 32)
+
+		jobs.Wait()
+		status, err := jobs.Status(context.Background(), job.ID)
+		require.NoError(t, err)
+		assert.Equal(t, JobDone, status.Status)
+		assert.Equal(t, int64(2), status.Rows)
+		assert.Equal(t, "application/x-ndjson", status.ContentType)
+		assert.NotNil(t, status.FinishedAt)
+
+		result, _, err := jobs.Result(context.Background(), job.ID)
+		require.NoError(t, err)
+		defer result.Close()
+		data, err := io.ReadAll(result)
+		require.NoError(t, err)
+		assert.Equal(t, "{\"ID\":1,\"Name\":\"alice\"}\n{\"ID\":2,\"Name\":\"bob\"}\n", string(data))
+
+		require.NoError(t, jobs.Delete(context.Background(), job.ID))
+		_, err = jobs.Status(context.Background(), job.ID)
+		assert.ErrorIs(t, err, ErrJobNotFound)
+	})
+
+	t.Run("unfinished and failed jobs", func(t *testing.T) {
+		jobs := NewJobRunner(NewMemoryJobStore())
+		release := make(chan struct{})
+		job, err := jobs.Submit(context.Background(), "text/plain", func(ctx context.Context, w io.Writer) (int64, error) {
+			<-release
+			return 0, errors.New("connection reset")
+		})
+		require.NoError(t, err)
+
+		_, _, err = jobs.Result(context.Background(), job.ID)
+		assert.ErrorIs(t, err, ErrJobNotFinished)
+
+		close(release)
+		jobs.Wait()
+		status, err := jobs.Status(context.Background(), job.ID)
+		require.NoError(t, err)
+		assert.Equal(t, JobFailed, status.Status)
+		assert.Equal(t, "connection reset", status.Error)
+		_, _, err = jobs.Result(context.Background(), job.ID)
+		assert.ErrorContains(t, err, "connection reset")
+	})
+
+	t.Run("timeout and concurrency", func(t *testing.T) {
+		jobs := NewJobRunner(NewMemoryJobStore()).WithConcurrency(1).WithTimeout(10 * time.Millisecond)
+		slow := func(ctx context.Context, w io.Writer) (int64, error) {
+			<-ctx.Done()
+			return 0, ctx.Err()
+		}
+		first, err := jobs.Submit(context.Background(), "text/plain", slow)
+		require.NoError(t, err)
+		second, err := jobs.Submit(context.Background(), "text/plain", func(ctx context.Context, w io.Writer) (int64, error) {
+			_, err := io.WriteString(w, "ok")
+			return 1, err
+		})
+		require.NoError(t, err)
+		jobs.Wait()
+
+		status, err := jobs.Status(context.Background(), first.ID)
+		require.NoError(t, err)
+		assert.Equal(t, JobFailed, status.Status)
+		assert.True(t, strings.Contains(status.Error, "deadline exceeded"))
+
+		status, err = jobs.Status(context.Background(), second.ID)
+		require.NoError(t, err)
+		assert.Equal(t, JobDone, status.Status)
+	})
+
+	t.Run("unknown jobs and formats", func(t *testing.T) {
+		jobs := NewJobRunner(NewMemoryJobStore())
+		_, _, err := jobs.Result(context.Background(), "missing")
+		assert.ErrorIs(t, err, ErrJobNotFound)
+
+		_, err = SubmitExport(context.Background(), jobs, exec, ExportFormat("xlsx"), query, nil, nil)
+		assert.ErrorIs(t, err, ErrInvalidParameter)
+	})
+}
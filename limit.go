@@ -0,0 +1,79 @@
+package sqld
+
+import "strconv"
+
+// Limit is the number of rows a query asks for. The zero value is unset:
+// the limit annotation's default applies, and without one the annotation is
+// removed. NoLimit asks for every row, and LimitTo for an exact number of
+// rows, including zero.
+//
+// The int limits taken by ProcessQuery and the Executor cannot express
+// these states, as zero and negative values there mean unset; LimitFromInt
+// converts them.
+type Limit struct {
+	n     int
+	state limitState
+}
+
+type limitState uint8
+
+const (
+	limitUnset limitState = iota
+	limitNone
+	limitValue
+)
+
+// NoLimit asks for every row: the limit annotation is removed even when it
+// has a default. A max option on the annotation and the processor's max
+// limit still cap it.
+var NoLimit = Limit{state: limitNone}
+
+// LimitTo returns a limit of exactly n rows. Zero runs the query with
+// LIMIT 0, returning no rows; negative n is treated as zero.
+func LimitTo(n int) Limit {
+	return Limit{n: max(n, 0), state: limitValue}
+}
+
+// LimitFromInt converts an int limit as taken by ProcessQuery: a positive
+// n is a limit of n rows, zero and negative values are unset
+func LimitFromInt(n int) Limit {
+	if n <= 0 {
+		return Limit{}
+	}
+	return LimitTo(n)
+}
+
+// IsSet reports whether the limit is NoLimit or a number of rows
+func (l Limit) IsSet() bool {
+	return l.state != limitUnset
+}
+
+// IsNoLimit reports whether the limit is NoLimit
+func (l Limit) IsNoLimit() bool {
+	return l.state == limitNone
+}
+
+// Value returns the number of rows and true for a limit created with
+// LimitTo, and false for an unset limit or NoLimit
+func (l Limit) Value() (int, bool) {
+	return l.n, l.state == limitValue
+}
+
+// atMost caps the limit at n rows; an unset limit and NoLimit become n
+func (l Limit) atMost(n int) Limit {
+	if l.state != limitValue || l.n > n {
+		return LimitTo(n)
+	}
+	return l
+}
+
+// String returns the number of rows, "none" for NoLimit or "unset"
+func (l Limit) String() string {
+	switch l.state {
+	case limitNone:
+		return "none"
+	case limitValue:
+		return strconv.Itoa(l.n)
+	}
+	return "unset"
+}
@@ -0,0 +1,80 @@
+package sqld
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimit(t *testing.T) {
+	var unset Limit
+	assert.False(t, unset.IsSet())
+	assert.Equal(t, "unset", unset.String())
+
+	assert.True(t, NoLimit.IsSet())
+	assert.True(t, NoLimit.IsNoLimit())
+	_, ok := NoLimit.Value()
+	assert.False(t, ok)
+	assert.Equal(t, "none", NoLimit.String())
+
+	n, ok := LimitTo(0).Value()
+	assert.True(t, ok)
+	assert.Equal(t, 0, n)
+	assert.Equal(t, LimitTo(0), LimitTo(-5))
+	assert.Equal(t, "25", LimitTo(25).String())
+
+	assert.Equal(t, unset, LimitFromInt(0))
+	assert.Equal(t, unset, LimitFromInt(-1))
+	assert.Equal(t, LimitTo(10), LimitFromInt(10))
+}
+
+func TestAnnotationProcessor_ProcessQueryLimit(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		maxLimit int
+		limit    Limit
+		sql      string
+		params   []interface{}
+	}{
+		{"unset without a default", "SELECT * FROM users /* sqld:limit */", 0, Limit{}, "SELECT * FROM users ", []interface{}{}},
+		{"unset takes the default", "SELECT * FROM users /* sqld:limit default=20 */", 0, Limit{}, "SELECT * FROM users  LIMIT $1", []interface{}{20}},
+		{"no limit overrides the default", "SELECT * FROM users /* sqld:limit default=20 */", 0, NoLimit, "SELECT * FROM users ", []interface{}{}},
+		{"no limit is capped by the annotation", "SELECT * FROM users /* sqld:limit default=20 max=100 */", 0, NoLimit, "SELECT * FROM users  LIMIT $1", []interface{}{100}},
+		{"no limit is capped by the processor", "SELECT * FROM users /* sqld:limit */", 50, NoLimit, "SELECT * FROM users  LIMIT $1", []interface{}{50}},
+		{"zero rows", "SELECT * FROM users /* sqld:limit default=20 */", 50, LimitTo(0), "SELECT * FROM users  LIMIT $1", []interface{}{0}},
+		{"value", "SELECT * FROM users /* sqld:limit max=10 */", 0, LimitTo(30), "SELECT * FROM users  LIMIT $1", []interface{}{10}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			processor := NewAnnotationProcessor(Postgres).WithMaxLimit(tt.maxLimit)
+			sql, params, err := processor.ProcessQueryLimit(tt.query, nil, nil, nil, tt.limit)
+			require.NoError(t, err)
+			assert.Equal(t, tt.sql, sql)
+			assert.Equal(t, tt.params, params)
+		})
+	}
+}
+
+func TestExecutor_QueryAllLimit(t *testing.T) {
+	const query = "SELECT id, name FROM users ORDER BY id /* sqld:limit default=20 */"
+	db := &recordingDB{}
+	exec := NewExecutor[testUser](New(db, MySQL))
+
+	_, err := exec.QueryAllLimit(context.Background(), query, nil, nil, nil, LimitTo(0))
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT id, name FROM users ORDER BY id  LIMIT ?", db.query)
+	assert.Equal(t, []interface{}{0}, db.args)
+
+	_, err = exec.QueryAllLimit(context.Background(), query, nil, nil, nil, NoLimit)
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT id, name FROM users ORDER BY id ", db.query)
+	assert.Empty(t, db.args)
+
+	_, err = exec.QueryAll(context.Background(), query, nil, nil, nil, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{20}, db.args)
+}
@@ -0,0 +1,99 @@
+package sqld
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// PageLinks holds the URLs of a page of results and its neighbours. It can
+// be embedded in a JSON response or sent as a Link header with SetHeader.
+type PageLinks struct {
+	Self  string `json:"self"`
+	First string `json:"first,omitempty"`
+	Prev  string `json:"prev,omitempty"`
+	Next  string `json:"next,omitempty"`
+}
+
+// NewPageLinks builds the links for a page of result served at u, keeping
+// every other query parameter. With cursor pagination, Next carries the
+// result's cursor and First drops the cursor; cursors only go forward, so
+// there is no Prev. Requests paginated with offset or page get Prev and
+// Next by moving the offset or page by one page.
+//
+// Example:
+//
+//	page, err := exec.QueryPaginated(ctx, query, params.Where, params.Cursor, params.OrderBy, params.Limit)
+//	links := sqld.NewPageLinks(r.URL, page)
+//	links.SetHeader(w.Header())
+func NewPageLinks[T any](u *url.URL, result *PaginatedResult[T]) PageLinks {
+	values := u.Query()
+	links := PageLinks{Self: u.String()}
+
+	with := func(set func(url.Values)) string {
+		query := url.Values{}
+		for key, vals := range values {
+			query[key] = vals
+		}
+		set(query)
+		next := *u
+		next.RawQuery = query.Encode()
+		return next.String()
+	}
+
+	switch {
+	case values.Has("offset"):
+		offset, _ := strconv.Atoi(values.Get("offset"))
+		links.First = with(func(q url.Values) { q.Del("offset") })
+		if offset > 0 && result.Limit > 0 {
+			links.Prev = with(func(q url.Values) { q.Set("offset", strconv.Itoa(max(offset-result.Limit, 0))) })
+		}
+		if result.HasMore && result.Limit > 0 {
+			links.Next = with(func(q url.Values) { q.Set("offset", strconv.Itoa(offset+result.Limit)) })
+		}
+
+	case values.Has("page"):
+		page, _ := strconv.Atoi(values.Get("page"))
+		links.First = with(func(q url.Values) { q.Set("page", "1") })
+		if page > 1 {
+			links.Prev = with(func(q url.Values) { q.Set("page", strconv.Itoa(page-1)) })
+		}
+		if result.HasMore {
+			links.Next = with(func(q url.Values) { q.Set("page", strconv.Itoa(max(page, 1)+1)) })
+		}
+
+	default:
+		if values.Has(CursorParam) {
+			links.First = with(func(q url.Values) { q.Del(CursorParam) })
+		}
+		if result.HasMore && result.NextCursor != nil {
+			links.Next = with(func(q url.Values) { q.Set(CursorParam, *result.NextCursor) })
+		}
+	}
+
+	return links
+}
+
+// Header returns the first, prev and next links as an RFC 8288 Link header
+// value, e.g. `</users?cursor=abc>; rel="next"`, or "" when there are none
+func (l PageLinks) Header() string {
+	var parts []string
+	for _, link := range []struct{ rel, url string }{
+		{"first", l.First},
+		{"prev", l.Prev},
+		{"next", l.Next},
+	} {
+		if link.url != "" {
+			parts = append(parts, "<"+link.url+`>; rel="`+link.rel+`"`)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// SetHeader sets the Link header from the links, if there are any
+func (l PageLinks) SetHeader(h http.Header) {
+	if header := l.Header(); header != "" {
+		h.Set("Link", header)
+	}
+}
@@ -0,0 +1,59 @@
+package sqld
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewPageLinks(t *testing.T) {
+	parse := func(raw string) *url.URL {
+		u, err := url.Parse(raw)
+		require.NoError(t, err)
+		return u
+	}
+	next := "eyJpZCI6NDJ9"
+
+	t.Run("cursor", func(t *testing.T) {
+		links := NewPageLinks(parse("/users?status=active&limit=20"), &PaginatedResult[testUser]{NextCursor: &next, HasMore: true, Limit: 20})
+		assert.Equal(t, "/users?status=active&limit=20", links.Self)
+		assert.Empty(t, links.First)
+		assert.Empty(t, links.Prev)
+		assert.Equal(t, "/users?cursor=eyJpZCI6NDJ9&limit=20&status=active", links.Next)
+		assert.Equal(t, `</users?cursor=eyJpZCI6NDJ9&limit=20&status=active>; rel="next"`, links.Header())
+	})
+
+	t.Run("last cursor page", func(t *testing.T) {
+		links := NewPageLinks(parse("https://api.example.com/users?cursor=abc"), &PaginatedResult[testUser]{Limit: 20})
+		assert.Equal(t, "https://api.example.com/users", links.First)
+		assert.Empty(t, links.Next)
+
+		header := http.Header{}
+		links.SetHeader(header)
+		assert.Equal(t, `<https://api.example.com/users>; rel="first"`, header.Get("Link"))
+	})
+
+	t.Run("offset", func(t *testing.T) {
+		links := NewPageLinks(parse("/users?offset=10&limit=20"), &PaginatedResult[testUser]{HasMore: true, Limit: 20})
+		assert.Equal(t, "/users?limit=20", links.First)
+		assert.Equal(t, "/users?limit=20&offset=0", links.Prev)
+		assert.Equal(t, "/users?limit=20&offset=30", links.Next)
+		assert.Equal(t, `</users?limit=20>; rel="first", </users?limit=20&offset=0>; rel="prev", </users?limit=20&offset=30>; rel="next"`, links.Header())
+	})
+
+	t.Run("page", func(t *testing.T) {
+		links := NewPageLinks(parse("/users?page=2&per_page=10"), &PaginatedResult[testUser]{Limit: 10})
+		assert.Equal(t, "/users?page=1&per_page=10", links.First)
+		assert.Equal(t, "/users?page=1&per_page=10", links.Prev)
+		assert.Empty(t, links.Next)
+	})
+
+	t.Run("no links", func(t *testing.T) {
+		header := http.Header{}
+		NewPageLinks(parse("/users"), &PaginatedResult[testUser]{Limit: 20}).SetHeader(header)
+		assert.Empty(t, header.Get("Link"))
+	})
+}
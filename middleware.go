@@ -0,0 +1,103 @@
+package sqld
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+)
+
+// QueryFunc runs a final query with its parameters and returns its rows
+type QueryFunc func(ctx context.Context, query string, params ...interface{}) (Rows, error)
+
+// Middleware wraps the execution of queries. It can inspect or rewrite the
+// query and its parameters before calling next, and inspect or replace the
+// rows and error after, which makes it the place for auditing, metrics,
+// caching and similar concerns.
+//
+// Example:
+//
+//	func Timing(next sqld.QueryFunc) sqld.QueryFunc {
+//		return func(ctx context.Context, query string, params ...interface{}) (sqld.Rows, error) {
+//			start := time.Now()
+//			rows, err := next(ctx, query, params...)
+//			slog.InfoContext(ctx, "query", "sql", query, "took", time.Since(start), "err", err)
+//			return rows, err
+//		}
+//	}
+type Middleware func(next QueryFunc) QueryFunc
+
+// Use registers middleware for the queries run by Executors created from
+// these Queries. The first middleware registered is the outermost one.
+// Middleware sees the final SQL, after annotations, scopes and policies
+// have been applied. Single-row queries, such as those of Count, pass
+// through it as a Query whose first row is scanned. Statements run with
+// Exec, such as BulkInsert and savepoints, do not pass through it.
+//
+// Example:
+//
+//	q := sqld.New(database, sqld.Postgres).Use(Timing, Audit)
+func (q *Queries) Use(middleware ...Middleware) *Queries {
+	q.middleware = append(slices.Clip(q.middleware), middleware...)
+	return q
+}
+
+// conn returns the database the Queries runs queries on, with its
+// middleware applied
+func (q *Queries) conn() DBTX {
+	if len(q.middleware) == 0 {
+		return q.db
+	}
+
+	query := QueryFunc(q.db.Query)
+	for i := len(q.middleware) - 1; i >= 0; i-- {
+		query = q.middleware[i](query)
+	}
+	return &middlewareDB{DBTX: q.db, query: query}
+}
+
+// middlewareDB runs Query and QueryRow through a middleware chain
+type middlewareDB struct {
+	DBTX
+	query QueryFunc
+}
+
+// Query implements the DBTX interface
+func (db *middlewareDB) Query(ctx context.Context, query string, args ...interface{}) (Rows, error) {
+	return db.query(ctx, query, args...)
+}
+
+// QueryRow implements the DBTX interface by running the query through the
+// chain and scanning its first row
+func (db *middlewareDB) QueryRow(ctx context.Context, query string, args ...interface{}) Row {
+	rows, err := db.query(ctx, query, args...)
+	return &firstRow{rows: rows, err: err}
+}
+
+// firstRow is the Row of a middlewareDB, reading the first of its rows
+type firstRow struct {
+	rows Rows
+	err  error
+}
+
+// Scan implements the Row interface. It returns ErrNoRows when there are
+// no rows, including when a middleware returned neither rows nor an error.
+func (r *firstRow) Scan(dest ...interface{}) error {
+	if r.err != nil {
+		return r.err
+	}
+	if r.rows == nil {
+		return WrapNoRows(sql.ErrNoRows)
+	}
+	defer r.rows.Close()
+
+	if !r.rows.Next() {
+		if err := r.rows.Err(); err != nil {
+			return err
+		}
+		return ErrNoRows
+	}
+	if err := r.rows.Scan(dest...); err != nil {
+		return err
+	}
+	return r.rows.Err()
+}
@@ -0,0 +1,125 @@
+package sqld
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueries_Use(t *testing.T) {
+	ctx := context.Background()
+	const query = "SELECT id, name FROM users WHERE status = 'active' /* sqld:where */"
+
+	t.Run("wraps queries in registration order", func(t *testing.T) {
+		mockDB := &MockDB{}
+		expectEmptyQuery(mockDB, "SELECT id, name FROM users WHERE status = 'active'  AND name = $1", "john")
+
+		var calls []string
+		record := func(name string) Middleware {
+			return func(next QueryFunc) QueryFunc {
+				return func(ctx context.Context, query string, params ...interface{}) (Rows, error) {
+					calls = append(calls, name+" before")
+					rows, err := next(ctx, query, params...)
+					calls = append(calls, name+" after")
+					return rows, err
+				}
+			}
+		}
+
+		q := New(mockDB, Postgres).Use(record("outer"), record("inner"))
+		where := NewWhereBuilder(Postgres)
+		where.Equal("name", "john")
+
+		_, err := NewExecutor[testUser](q).QueryAll(ctx, query, where, nil, nil, 0)
+		require.NoError(t, err)
+		mockDB.AssertExpectations(t)
+		assert.Equal(t, []string{"outer before", "inner before", "inner after", "outer after"}, calls)
+	})
+
+	t.Run("can short-circuit", func(t *testing.T) {
+		mockDB := &MockDB{}
+		denied := errors.New("read-only replica unavailable")
+		q := New(mockDB, Postgres).Use(func(next QueryFunc) QueryFunc {
+			return func(ctx context.Context, query string, params ...interface{}) (Rows, error) {
+				return nil, denied
+			}
+		})
+
+		_, err := NewExecutor[testUser](q).QueryOne(ctx, query, nil)
+		assert.ErrorIs(t, err, denied)
+		mockDB.AssertNotCalled(t, "Query")
+	})
+
+	t.Run("applies to single-row queries", func(t *testing.T) {
+		mockDB := &MockDB{}
+		mockDB.On("Query", mock.Anything, "SELECT COUNT(*) FROM (SELECT id, name FROM users WHERE status = 'active' ) AS sqld_count").
+			Return(&nullRows{rows: [][]interface{}{{int64(42)}}}, nil)
+
+		var seen []string
+		q := New(mockDB, Postgres).Use(func(next QueryFunc) QueryFunc {
+			return func(ctx context.Context, query string, params ...interface{}) (Rows, error) {
+				seen = append(seen, query)
+				return next(ctx, query, params...)
+			}
+		})
+
+		total, err := NewExecutor[testUser](q).Count(ctx, query, nil)
+		require.NoError(t, err)
+		assert.Equal(t, TotalCount{Total: 42}, total)
+		assert.Len(t, seen, 1)
+		mockDB.AssertNotCalled(t, "QueryRow")
+	})
+
+	t.Run("single-row queries report errors and missing rows", func(t *testing.T) {
+		denied := errors.New("denied")
+		deny := func(next QueryFunc) QueryFunc {
+			return func(ctx context.Context, query string, params ...interface{}) (Rows, error) {
+				return nil, denied
+			}
+		}
+		_, err := NewExecutor[testUser](New(&MockDB{}, Postgres).Use(deny)).Count(ctx, query, nil)
+		assert.ErrorIs(t, err, denied)
+
+		empty := func(next QueryFunc) QueryFunc {
+			return func(ctx context.Context, query string, params ...interface{}) (Rows, error) {
+				return &nullRows{}, nil
+			}
+		}
+		var n int64
+		err = New(&MockDB{}, Postgres).Use(empty).conn().QueryRow(ctx, "SELECT 1").Scan(&n)
+		assert.ErrorIs(t, err, ErrNoRows)
+
+		nothing := func(next QueryFunc) QueryFunc {
+			return func(ctx context.Context, query string, params ...interface{}) (Rows, error) {
+				return nil, nil
+			}
+		}
+		err = New(&MockDB{}, Postgres).Use(nothing).conn().QueryRow(ctx, "SELECT 1").Scan(&n)
+		assert.ErrorIs(t, err, ErrNoRows)
+		assert.ErrorIs(t, err, sql.ErrNoRows)
+	})
+
+	t.Run("applies to transactions", func(t *testing.T) {
+		calls := 0
+		q := New(&MockDB{}, Postgres).Use(func(next QueryFunc) QueryFunc {
+			return func(ctx context.Context, query string, params ...interface{}) (Rows, error) {
+				calls++
+				return next(ctx, query, params...)
+			}
+		})
+
+		db := &fakeBeginner{}
+		err := NewTxManager(db, Postgres).WithTransaction(ctx, func(tx *Tx) error {
+			expectEmptyQuery(&db.tx.MockDB, "SELECT id, name FROM users WHERE status = 'active' ")
+			_, err := NewExecutor[testUser](q.WithTx(tx)).QueryAll(ctx, query, nil, nil, nil, 0)
+			return err
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 1, calls)
+	})
+}
@@ -1,7 +1,9 @@
 package sqld
 
 import (
+	"errors"
 	"fmt"
+	"regexp"
 	"strings"
 )
 
@@ -13,15 +15,43 @@ const (
 	SortDesc SortDirection = "DESC"
 )
 
+// NullsOrder controls where NULL values sort
+type NullsOrder string
+
+const (
+	// NullsDefault leaves NULL placement to the database
+	NullsDefault NullsOrder = ""
+	NullsFirst   NullsOrder = "FIRST"
+	NullsLast    NullsOrder = "LAST"
+)
+
 // SortField represents a single field to sort by
 type SortField struct {
 	Field     string        `json:"field"`
 	Direction SortDirection `json:"direction"`
+	Nulls     NullsOrder    `json:"nulls,omitempty"`
+	Collation string        `json:"collation,omitempty"`
+
+	raw    bool   // Field is a complete ORDER BY item added with AddRaw
+	random bool   // sort in random order, see Random
+	seed   *int64 // seed for a repeatable random order, see RandomSeed
 }
 
-// OrderByBuilder builds ORDER BY clauses dynamically
+// RandomSort is the sort field requesting random order, e.g. ?sort=random.
+// Config only accepts it when AllowRandomSort is enabled.
+const RandomSort = "random"
+
+// collationPattern matches collation names such as "und-x-icu", utf8mb4_unicode_ci or NOCASE
+var collationPattern = regexp.MustCompile(`^[a-zA-Z0-9_][a-zA-Z0-9_.@-]*$`)
+
+// OrderByBuilder builds ORDER BY clauses dynamically. Like WhereBuilder it is
+// not safe for concurrent use; share a frozen builder and Clone it instead.
 type OrderByBuilder struct {
-	fields []SortField
+	fields  []SortField
+	dialect *Dialect // target dialect, when known
+	quote   bool     // quote field names with the dialect's identifier quote
+	frozen  bool     // set by Freeze; any further change panics
+	errs    []error
 }
 
 // NewOrderByBuilder creates a new OrderByBuilder
@@ -31,8 +61,27 @@ func NewOrderByBuilder() *OrderByBuilder {
 	}
 }
 
+// ForDialect sets the dialect the clause is built for. It is needed to
+// emulate NULLS FIRST/LAST on dialects without native support; the
+// annotation processor sets it automatically.
+func (ob *OrderByBuilder) ForDialect(dialect Dialect) *OrderByBuilder {
+	ob.mutate()
+	ob.dialect = &dialect
+	return ob
+}
+
+// QuoteIdentifiers quotes field names with the dialect's identifier quote
+// when building, so reserved words such as "order" can be sorted on
+func (ob *OrderByBuilder) QuoteIdentifiers(dialect Dialect) *OrderByBuilder {
+	ob.mutate()
+	ob.dialect = &dialect
+	ob.quote = true
+	return ob
+}
+
 // Add adds a sort field with the specified direction
 func (ob *OrderByBuilder) Add(field string, direction SortDirection) *OrderByBuilder {
+	ob.mutate()
 	ob.fields = append(ob.fields, SortField{
 		Field:     field,
 		Direction: direction,
@@ -40,6 +89,140 @@ func (ob *OrderByBuilder) Add(field string, direction SortDirection) *OrderByBui
 	return ob
 }
 
+// AddExpression adds a SQL expression to sort by, e.g. "length(name)".
+// Expressions that fail ValidateExpression are not added; the error is
+// reported by Err.
+func (ob *OrderByBuilder) AddExpression(expression string, direction SortDirection) *OrderByBuilder {
+	ob.mutate()
+	if err := ValidateExpression(expression); err != nil {
+		ob.errs = append(ob.errs, err)
+		return ob
+	}
+	return ob.Add(expression, direction)
+}
+
+// AddRaw adds a complete ORDER BY item written as-is, e.g.
+// "array_position(ARRAY['high','low'], priority)". It is validated like
+// AddExpression; direction, NULLS and collation options do not apply to it.
+func (ob *OrderByBuilder) AddRaw(sql string) *OrderByBuilder {
+	ob.mutate()
+	if err := ValidateExpression(sql); err != nil {
+		ob.errs = append(ob.errs, err)
+		return ob
+	}
+	ob.fields = append(ob.fields, SortField{Field: sql, raw: true})
+	return ob
+}
+
+// Random sorts rows in random order using the dialect's random function,
+// RANDOM() or RAND(). Random order is usually combined with a LIMIT to take a
+// sample; fields added after it only matter for ties, which are rare.
+func (ob *OrderByBuilder) Random() *OrderByBuilder {
+	ob.mutate()
+	ob.fields = append(ob.fields, SortField{Field: RandomSort, random: true})
+	return ob
+}
+
+// RandomSeed sorts rows in a random order that repeats for the same seed, so
+// a random sample can be paginated. Only dialects with SupportsSeededRandom
+// (MySQL) accept a seed; on others the annotation processor rejects the query.
+func (ob *OrderByBuilder) RandomSeed(seed int64) *OrderByBuilder {
+	ob.mutate()
+	ob.fields = append(ob.fields, SortField{Field: RandomSort, random: true, seed: &seed})
+	if ob.dialect != nil {
+		if err := ob.checkDialect(*ob.dialect); err != nil {
+			ob.errs = append(ob.errs, err)
+		}
+	}
+	return ob
+}
+
+// checkDialect reports features used by the builder that dialect cannot render
+func (ob *OrderByBuilder) checkDialect(dialect Dialect) error {
+	if ob.dialect != nil {
+		dialect = *ob.dialect
+	}
+	if dialect.Capabilities().SupportsSeededRandom {
+		return nil
+	}
+	for _, field := range ob.fields {
+		if field.seed != nil {
+			return fmt.Errorf("%w: %s does not support seeded random order", ErrUnsupportedDialect, dialect)
+		}
+	}
+	return nil
+}
+
+// Collate sorts the most recently added field with the given collation,
+// e.g. Asc("name").Collate("und-x-icu")
+func (ob *OrderByBuilder) Collate(collation string) *OrderByBuilder {
+	ob.mutate()
+	if collation == "" || len(ob.fields) == 0 {
+		return ob
+	}
+	if !collationPattern.MatchString(collation) {
+		ob.errs = append(ob.errs, &ValidationError{
+			Field:   "collation",
+			Value:   collation,
+			Message: "invalid collation name",
+		})
+		return ob
+	}
+	ob.fields[len(ob.fields)-1].Collation = collation
+	return ob
+}
+
+// Clone returns an independent copy of the builder that is not frozen, so a
+// shared default ordering can be extended per request
+func (ob *OrderByBuilder) Clone() *OrderByBuilder {
+	clone := *ob
+	clone.fields = append(make([]SortField, 0, len(ob.fields)), ob.fields...)
+	clone.errs = append([]error(nil), ob.errs...)
+	if ob.dialect != nil {
+		dialect := *ob.dialect
+		clone.dialect = &dialect
+	}
+	clone.frozen = false
+	return &clone
+}
+
+// Freeze marks the builder as read-only; adding or changing sort fields
+// afterwards panics. See WhereBuilder.Freeze.
+func (ob *OrderByBuilder) Freeze() *OrderByBuilder {
+	ob.frozen = true
+	return ob
+}
+
+// mutate panics if the builder has been frozen
+func (ob *OrderByBuilder) mutate() {
+	if ob.frozen {
+		panic("sqld: frozen OrderByBuilder modified, use Clone to derive a new builder")
+	}
+}
+
+// Err returns the errors from rejected expressions and collations, or nil
+func (ob *OrderByBuilder) Err() error {
+	return errors.Join(ob.errs...)
+}
+
+// NullsFirst sorts NULL values of the most recently added field first
+func (ob *OrderByBuilder) NullsFirst() *OrderByBuilder {
+	return ob.setNulls(NullsFirst)
+}
+
+// NullsLast sorts NULL values of the most recently added field last
+func (ob *OrderByBuilder) NullsLast() *OrderByBuilder {
+	return ob.setNulls(NullsLast)
+}
+
+func (ob *OrderByBuilder) setNulls(nulls NullsOrder) *OrderByBuilder {
+	ob.mutate()
+	if len(ob.fields) > 0 {
+		ob.fields[len(ob.fields)-1].Nulls = nulls
+	}
+	return ob
+}
+
 // Asc adds a field to sort by in ascending order
 func (ob *OrderByBuilder) Asc(field string) *OrderByBuilder {
 	return ob.Add(field, SortAsc)
@@ -52,6 +235,7 @@ func (ob *OrderByBuilder) Desc(field string) *OrderByBuilder {
 
 // Clear removes all sort fields
 func (ob *OrderByBuilder) Clear() *OrderByBuilder {
+	ob.mutate()
 	ob.fields = make([]SortField, 0)
 	return ob
 }
@@ -68,16 +252,95 @@ func (ob *OrderByBuilder) GetFields() []SortField {
 	return result
 }
 
+// SortSpec returns a canonical description of the sort, such as
+// "created_at:desc,id:asc". Cursors record it so a page can only be continued
+// under the ordering that produced it.
+func (ob *OrderByBuilder) SortSpec() string {
+	if ob == nil {
+		return ""
+	}
+	specs := make([]string, 0, len(ob.fields))
+	for _, field := range ob.fields {
+		switch {
+		case field.raw:
+			specs = append(specs, field.Field)
+		case field.random && field.seed != nil:
+			specs = append(specs, fmt.Sprintf("%s:%d", RandomSort, *field.seed))
+		case field.random:
+			specs = append(specs, RandomSort)
+		default:
+			spec := field.Field + ":" + strings.ToLower(string(field.Direction))
+			if field.Nulls != NullsDefault {
+				spec += ":nulls" + strings.ToLower(string(field.Nulls))
+			}
+			specs = append(specs, spec)
+		}
+	}
+	return strings.Join(specs, ",")
+}
+
 // Build generates the ORDER BY SQL clause
 func (ob *OrderByBuilder) Build() string {
+	if ob.dialect != nil {
+		return ob.buildFor(*ob.dialect)
+	}
+	return ob.buildFor("")
+}
+
+// buildFor generates the ORDER BY clause for dialect, unless ForDialect or
+// QuoteIdentifiers already fixed one. An empty dialect emits standard SQL.
+func (ob *OrderByBuilder) buildFor(dialect Dialect) string {
 	if len(ob.fields) == 0 {
 		return ""
 	}
+	if ob.dialect != nil {
+		dialect = *ob.dialect
+	}
 
 	var clauses []string
 	for _, field := range ob.fields {
-		clause := fmt.Sprintf("%s %s", field.Field, field.Direction)
-		clauses = append(clauses, clause)
+		if field.raw {
+			clauses = append(clauses, field.Field)
+			continue
+		}
+		if field.random {
+			caps := dialect.Capabilities()
+			if field.seed != nil && caps.SupportsSeededRandom {
+				clauses = append(clauses, fmt.Sprintf("%s(%d)", caps.RandomFunction, *field.seed))
+			} else {
+				clauses = append(clauses, caps.RandomFunction+"()")
+			}
+			continue
+		}
+
+		name := field.Field
+		if ob.quote {
+			name = quoteColumn(name, dialect)
+		}
+		key := name
+		if field.Collation != "" {
+			key += " COLLATE " + dialect.QuoteIdentifier(field.Collation)
+		}
+
+		if field.Nulls == NullsDefault {
+			clauses = append(clauses, fmt.Sprintf("%s %s", key, field.Direction))
+			continue
+		}
+
+		if dialect == "" || dialect.Capabilities().SupportsNullsOrder {
+			clauses = append(clauses, fmt.Sprintf("%s %s NULLS %s", key, field.Direction, field.Nulls))
+			continue
+		}
+
+		// Emulate with a leading key: "x IS NULL" is 1 for NULLs and 0 otherwise
+		nullsKey := SortAsc
+		if field.Nulls == NullsFirst {
+			nullsKey = SortDesc
+		}
+		clauses = append(clauses,
+			fmt.Sprintf("%s IS NULL %s", name, nullsKey),
+			fmt.Sprintf("%s %s", key, field.Direction),
+		)
 	}
 
 	return strings.Join(clauses, ", ")
@@ -102,42 +365,67 @@ func ParseSortDirection(dir string) SortDirection {
 	}
 }
 
+// ParseNullsOrder converts "nullsfirst"/"nulls_first"/"first" (and the
+// "last" equivalents) to a NullsOrder; anything else yields NullsDefault
+func ParseNullsOrder(s string) NullsOrder {
+	switch strings.ToLower(strings.ReplaceAll(strings.TrimSpace(s), "_", "")) {
+	case "nullsfirst", "first":
+		return NullsFirst
+	case "nullslast", "last":
+		return NullsLast
+	default:
+		return NullsDefault
+	}
+}
+
 // SortFieldFromString parses a sort field from string formats like:
 // - "name" (ascending)
 // - "name:desc"
 // - "name:asc"
 // - "-name" (descending)
 // - "+name" (ascending)
+// - "ended_at:desc:nullslast", "-ended_at:nullsfirst" (NULL placement)
 func SortFieldFromString(s string) SortField {
 	s = strings.TrimSpace(s)
 
 	// Handle prefix notation: -name, +name
-	if strings.HasPrefix(s, "-") {
-		return SortField{
-			Field:     s[1:],
-			Direction: SortDesc,
+	if strings.HasPrefix(s, "-") || strings.HasPrefix(s, "+") {
+		direction := SortAsc
+		if s[0] == '-' {
+			direction = SortDesc
 		}
-	}
-	if strings.HasPrefix(s, "+") {
-		return SortField{
-			Field:     s[1:],
-			Direction: SortAsc,
+
+		parts := strings.Split(s[1:], ":")
+		field := SortField{
+			Field:     parts[0],
+			Direction: direction,
+		}
+		if len(parts) > 1 {
+			field.Nulls = ParseNullsOrder(parts[1])
 		}
+		return field
 	}
 
-	// Handle colon notation: name:desc, name:asc
+	// Handle colon notation: name:desc, name:asc, name:desc:nullslast
 	parts := strings.Split(s, ":")
-	field := parts[0]
-	direction := SortAsc
+	field := SortField{
+		Field:     parts[0],
+		Direction: SortAsc,
+	}
 
 	if len(parts) > 1 {
-		direction = ParseSortDirection(parts[1])
+		if nulls := ParseNullsOrder(parts[1]); nulls != NullsDefault {
+			// name:nullslast
+			field.Nulls = nulls
+		} else {
+			field.Direction = ParseSortDirection(parts[1])
+		}
 	}
-
-	return SortField{
-		Field:     field,
-		Direction: direction,
+	if len(parts) > 2 {
+		field.Nulls = ParseNullsOrder(parts[2])
 	}
+
+	return field
 }
 
 // ParseSortFields parses multiple sort fields from common formats:
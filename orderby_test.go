@@ -1,6 +1,7 @@
 package sqld
 
 import (
+	"context"
 	"net/http"
 	"net/url"
 	"testing"
@@ -53,13 +54,17 @@ func TestSortFieldFromString(t *testing.T) {
 		input    string
 		expected SortField
 	}{
-		{"name", SortField{"name", SortAsc}},
-		{"name:asc", SortField{"name", SortAsc}},
-		{"name:desc", SortField{"name", SortDesc}},
-		{"-name", SortField{"name", SortDesc}},
-		{"+name", SortField{"name", SortAsc}},
-		{"email:DESC", SortField{"email", SortDesc}},
-		{"created_at:descending", SortField{"created_at", SortDesc}},
+		{"name", SortField{Field: "name", Direction: SortAsc}},
+		{"name:asc", SortField{Field: "name", Direction: SortAsc}},
+		{"name:desc", SortField{Field: "name", Direction: SortDesc}},
+		{"-name", SortField{Field: "name", Direction: SortDesc}},
+		{"+name", SortField{Field: "name", Direction: SortAsc}},
+		{"email:DESC", SortField{Field: "email", Direction: SortDesc}},
+		{"created_at:descending", SortField{Field: "created_at", Direction: SortDesc}},
+		{"ended_at:desc:nullslast", SortField{Field: "ended_at", Direction: SortDesc, Nulls: NullsLast}},
+		{"ended_at:asc:nulls_first", SortField{Field: "ended_at", Direction: SortAsc, Nulls: NullsFirst}},
+		{"ended_at:nullslast", SortField{Field: "ended_at", Direction: SortAsc, Nulls: NullsLast}},
+		{"-ended_at:nullsfirst", SortField{Field: "ended_at", Direction: SortDesc, Nulls: NullsFirst}},
 	}
 
 	for _, test := range tests {
@@ -76,9 +81,9 @@ func TestParseSortFields(t *testing.T) {
 		result := ParseSortFields(input)
 
 		expected := []SortField{
-			{"name", SortDesc},
-			{"email", SortAsc},
-			{"created_at", SortAsc},
+			{Field: "name", Direction: SortDesc},
+			{Field: "email", Direction: SortAsc},
+			{Field: "created_at", Direction: SortAsc},
 		}
 
 		assert.Equal(t, expected, result)
@@ -89,9 +94,9 @@ func TestParseSortFields(t *testing.T) {
 		result := ParseSortFields(input)
 
 		expected := []SortField{
-			{"name", SortDesc},
-			{"email", SortAsc},
-			{"created_at", SortDesc},
+			{Field: "name", Direction: SortDesc},
+			{Field: "email", Direction: SortAsc},
+			{Field: "created_at", Direction: SortDesc},
 		}
 
 		assert.Equal(t, expected, result)
@@ -115,8 +120,8 @@ func TestOrderByConfig(t *testing.T) {
 
 		// Valid fields
 		fields := []SortField{
-			{"name", SortDesc},
-			{"email", SortAsc},
+			{Field: "name", Direction: SortDesc},
+			{Field: "email", Direction: SortAsc},
 		}
 
 		builder, err := config.ValidateAndBuild(fields)
@@ -136,8 +141,8 @@ func TestOrderByConfig(t *testing.T) {
 		}
 
 		fields := []SortField{
-			{"name", SortAsc},
-			{"forbidden_field", SortDesc},
+			{Field: "name", Direction: SortAsc},
+			{Field: "forbidden_field", Direction: SortDesc},
 		}
 
 		_, err := config.ValidateAndBuild(fields)
@@ -156,9 +161,9 @@ func TestOrderByConfig(t *testing.T) {
 		}
 
 		fields := []SortField{
-			{"field1", SortAsc},
-			{"field2", SortDesc},
-			{"field3", SortAsc},
+			{Field: "field1", Direction: SortAsc},
+			{Field: "field2", Direction: SortDesc},
+			{Field: "field3", Direction: SortAsc},
 		}
 
 		_, err := config.ValidateAndBuild(fields)
@@ -180,8 +185,8 @@ func TestOrderByConfig(t *testing.T) {
 		}
 
 		fields := []SortField{
-			{"user_name", SortDesc},
-			{"signup", SortAsc},
+			{Field: "user_name", Direction: SortDesc},
+			{Field: "signup", Direction: SortAsc},
 		}
 
 		builder, err := config.ValidateAndBuild(fields)
@@ -198,8 +203,8 @@ func TestOrderByConfig(t *testing.T) {
 				"id":         true,
 			},
 			DefaultSort: []SortField{
-				{"created_at", SortDesc},
-				{"id", SortAsc},
+				{Field: "created_at", Direction: SortDesc},
+				{Field: "id", Direction: SortAsc},
 			},
 			MaxSortFields: 3,
 		}
@@ -316,3 +321,263 @@ func TestFromRequestWithSort(t *testing.T) {
 		assert.Equal(t, "name DESC, email ASC", orderSQL)
 	})
 }
+
+func TestValidateAndBuildContext_Roles(t *testing.T) {
+	config := DefaultConfig().
+		WithAllowedFields(map[string]bool{"name": true, "salary": true}).
+		WithFieldRoles("salary", "admin").
+		WithDefaultSort([]SortField{
+			{Field: "salary", Direction: SortDesc},
+			{Field: "name", Direction: SortAsc},
+		})
+
+	t.Run("restricted sort field rejected", func(t *testing.T) {
+		_, err := config.ValidateAndBuild([]SortField{{Field: "salary", Direction: SortDesc}})
+		assert.ErrorIs(t, err, ErrPermissionDenied)
+	})
+
+	t.Run("restricted sort field allowed with role", func(t *testing.T) {
+		ctx := WithRoles(context.Background(), "admin")
+		builder, err := config.ValidateAndBuildContext(ctx, []SortField{{Field: "salary", Direction: SortDesc}})
+		assert.NoError(t, err)
+		assert.Equal(t, "salary DESC", builder.Build())
+	})
+
+	t.Run("restricted default sort fields are skipped", func(t *testing.T) {
+		builder, err := config.ValidateAndBuild(nil)
+		assert.NoError(t, err)
+		assert.Equal(t, "name ASC", builder.Build())
+	})
+}
+
+func TestValidateAndBuild_DBColumn(t *testing.T) {
+	config := DefaultConfig().
+		WithAllowedFields(map[string]bool{"name": true, "created_at": true}).
+		WithDBColumn("name", "u.name").
+		WithDefaultSort([]SortField{{Field: "created_at", Direction: SortDesc}}).
+		WithDBColumn("created_at", "u.created_at")
+
+	builder, err := config.ValidateAndBuild([]SortField{{Field: "name", Direction: SortAsc}})
+	assert.NoError(t, err)
+	assert.Equal(t, "u.name ASC", builder.Build())
+
+	builder, err = config.ValidateAndBuild(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "u.created_at DESC", builder.Build())
+}
+
+func TestOrderByBuilder_QuoteIdentifiers(t *testing.T) {
+	builder := NewOrderByBuilder().QuoteIdentifiers(MySQL)
+	builder.Desc("order").Asc("u.name")
+
+	assert.Equal(t, "`order` DESC, `u`.`name` ASC", builder.Build())
+}
+
+func TestValidateAndBuild_Expression(t *testing.T) {
+	config := DefaultConfig().
+		WithAllowedFields(map[string]bool{"order_total": true, "bad": true}).
+		WithExpression("order_total", "price * quantity").
+		WithExpression("bad", "price; DROP TABLE orders")
+
+	builder, err := config.ValidateAndBuild([]SortField{{Field: "order_total", Direction: SortDesc}})
+	assert.NoError(t, err)
+	assert.Equal(t, "(price * quantity) DESC", builder.Build())
+
+	_, err = config.ValidateAndBuild([]SortField{{Field: "bad", Direction: SortAsc}})
+	var vErr *ValidationError
+	assert.ErrorAs(t, err, &vErr)
+}
+
+func TestOrderByBuilder_Nulls(t *testing.T) {
+	build := func() *OrderByBuilder {
+		return NewOrderByBuilder().Desc("ended_at").NullsLast().Asc("name").NullsFirst().Asc("id")
+	}
+
+	t.Run("native", func(t *testing.T) {
+		assert.Equal(t, "ended_at DESC NULLS LAST, name ASC NULLS FIRST, id ASC", build().Build())
+		assert.Equal(t, "ended_at DESC NULLS LAST, name ASC NULLS FIRST, id ASC", build().ForDialect(Postgres).Build())
+	})
+
+	t.Run("emulated", func(t *testing.T) {
+		assert.Equal(t,
+			"ended_at IS NULL ASC, ended_at DESC, name IS NULL DESC, name ASC, id ASC",
+			build().ForDialect(MySQL).Build(),
+		)
+	})
+
+	t.Run("annotation processor uses its dialect", func(t *testing.T) {
+		query, _, err := NewAnnotationProcessor(SQLite).ProcessQuery(
+			"SELECT * FROM jobs ORDER BY id /* sqld:orderby */", nil, nil, NewOrderByBuilder().Desc("ended_at").NullsLast(), 0,
+		)
+		assert.NoError(t, err)
+		assert.Contains(t, query, "ORDER BY ended_at IS NULL ASC, ended_at DESC")
+	})
+
+	t.Run("config keeps requested null order", func(t *testing.T) {
+		config := DefaultConfig().WithAllowedFields(map[string]bool{"ended_at": true})
+		builder, err := config.ValidateAndBuild(ParseSortFields("ended_at:desc:nullslast"))
+		assert.NoError(t, err)
+		assert.Equal(t, "ended_at DESC NULLS LAST", builder.Build())
+	})
+}
+
+func TestOrderByBuilder_Expressions(t *testing.T) {
+	builder := NewOrderByBuilder().
+		AddExpression("length(name)", SortDesc).
+		AddRaw("array_position(ARRAY['high','low'], priority)").
+		Asc("id")
+
+	assert.NoError(t, builder.Err())
+	assert.Equal(t, "length(name) DESC, array_position(ARRAY['high','low'], priority), id ASC", builder.Build())
+
+	t.Run("invalid expressions are rejected", func(t *testing.T) {
+		builder := NewOrderByBuilder().
+			AddExpression("name; DROP TABLE users", SortAsc).
+			AddRaw("id = $1").
+			Asc("id")
+
+		assert.Error(t, builder.Err())
+		assert.Equal(t, "id ASC", builder.Build())
+
+		_, _, err := NewAnnotationProcessor(Postgres).ProcessQuery(
+			"SELECT * FROM users ORDER BY id /* sqld:orderby */", nil, nil, builder, 0,
+		)
+		assert.Error(t, err)
+	})
+}
+
+func TestOrderByBuilder_Collate(t *testing.T) {
+	builder := NewOrderByBuilder().Asc("name").Collate("und-x-icu").NullsLast().Desc("id")
+	assert.NoError(t, builder.Err())
+	assert.Equal(t, `name COLLATE "und-x-icu" ASC NULLS LAST, id DESC`, builder.Build())
+
+	assert.Equal(t,
+		"name IS NULL ASC, name COLLATE `utf8mb4_unicode_ci` ASC",
+		NewOrderByBuilder().Asc("name").Collate("utf8mb4_unicode_ci").NullsLast().ForDialect(MySQL).Build(),
+	)
+
+	invalid := NewOrderByBuilder().Asc("name").Collate(`C" ; DROP TABLE users`)
+	var vErr *ValidationError
+	assert.ErrorAs(t, invalid.Err(), &vErr)
+	assert.Equal(t, "name ASC", invalid.Build())
+
+	t.Run("config collation", func(t *testing.T) {
+		config := DefaultConfig().
+			WithAllowedFields(map[string]bool{"name": true, "bad": true}).
+			WithCollation("name", "und-x-icu").
+			WithCollation("bad", "x'y")
+
+		builder, err := config.ValidateAndBuild(ParseSortFields("-name"))
+		assert.NoError(t, err)
+		assert.Equal(t, `name COLLATE "und-x-icu" DESC`, builder.Build())
+
+		_, err = config.ValidateAndBuild(ParseSortFields("bad"))
+		assert.ErrorAs(t, err, &vErr)
+	})
+}
+
+func TestValidateAndBuild_Tiebreaker(t *testing.T) {
+	config := DefaultConfig().
+		WithAllowedFields(map[string]bool{"status": true, "created_at": true, "id": true}).
+		WithDefaultSort([]SortField{{Field: "created_at", Direction: SortDesc}}).
+		WithTiebreaker("id", SortAsc)
+
+	tests := []struct {
+		name     string
+		sort     string
+		expected string
+	}{
+		{"appended to requested sort", "status", "status ASC, id ASC"},
+		{"appended to default sort", "", "created_at DESC, id ASC"},
+		{"not duplicated", "status,-id", "status ASC, id DESC"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			builder, err := config.ValidateAndBuild(ParseSortFields(tt.sort))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, builder.Build())
+		})
+	}
+
+	t.Run("uses mapped column", func(t *testing.T) {
+		config := DefaultConfig().
+			WithAllowedFields(map[string]bool{"name": true}).
+			WithDBColumn("id", "u.id").
+			WithTiebreaker("id", SortAsc)
+
+		builder, err := config.ValidateAndBuild(ParseSortFields("name"))
+		assert.NoError(t, err)
+		assert.Equal(t, "name ASC, u.id ASC", builder.Build())
+	})
+
+	t.Run("sort key", func(t *testing.T) {
+		assert.Equal(t, []SortField{
+			{Field: "status", Direction: SortAsc},
+			{Field: "id", Direction: SortAsc},
+		}, config.SortKey(ParseSortFields("status")))
+		assert.Equal(t, []SortField{
+			{Field: "created_at", Direction: SortDesc},
+			{Field: "id", Direction: SortAsc},
+		}, config.SortKey(nil))
+		assert.Len(t, config.SortKey(ParseSortFields("-id")), 1)
+	})
+}
+
+func TestOrderByBuilder_Random(t *testing.T) {
+	tests := []struct {
+		dialect  Dialect
+		expected string
+	}{
+		{Postgres, "RANDOM()"},
+		{MySQL, "RAND()"},
+		{SQLite, "RANDOM()"},
+		{ClickHouse, "rand()"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.dialect), func(t *testing.T) {
+			assert.Equal(t, tt.expected, NewOrderByBuilder().Random().ForDialect(tt.dialect).Build())
+		})
+	}
+
+	t.Run("seeded", func(t *testing.T) {
+		builder := NewOrderByBuilder().ForDialect(MySQL).RandomSeed(42)
+		assert.NoError(t, builder.Err())
+		assert.Equal(t, "RAND(42)", builder.Build())
+
+		builder = NewOrderByBuilder().ForDialect(Postgres).RandomSeed(42)
+		assert.ErrorIs(t, builder.Err(), ErrUnsupportedDialect)
+
+		_, _, err := NewAnnotationProcessor(SQLite).ProcessQuery(
+			"SELECT * FROM users ORDER BY id /* sqld:orderby */", nil, nil, NewOrderByBuilder().RandomSeed(42), 0,
+		)
+		assert.ErrorIs(t, err, ErrUnsupportedDialect)
+	})
+
+	t.Run("guarded by config", func(t *testing.T) {
+		config := DefaultConfig().
+			WithAllowedFields(map[string]bool{"name": true}).
+			WithTiebreaker("id", SortAsc)
+
+		_, err := config.ValidateAndBuild(ParseSortFields("random"))
+		assert.Error(t, err)
+
+		builder, err := config.WithRandomSort(true).ValidateAndBuild(ParseSortFields("random"))
+		assert.NoError(t, err)
+		assert.Equal(t, "RAND()", builder.ForDialect(MySQL).Build())
+	})
+}
+
+func TestOrderByBuilder_CloneAndFreeze(t *testing.T) {
+	base := NewOrderByBuilder().Desc("created_at").Freeze()
+
+	byName := base.Clone().Asc("name").Collate("C")
+	assert.Equal(t, "created_at DESC, name COLLATE \"C\" ASC", byName.Build())
+	assert.Equal(t, "created_at DESC", base.Build())
+
+	assert.Panics(t, func() { base.Asc("name") })
+	assert.Panics(t, func() { base.NullsLast() })
+	assert.Panics(t, func() { base.Clear() })
+	assert.Panics(t, func() { base.ForDialect(MySQL) })
+}
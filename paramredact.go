@@ -0,0 +1,289 @@
+package sqld
+
+import (
+	"crypto/sha256"
+	"database/sql/driver"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// RedactMode is how ParamRedaction hides a query parameter
+type RedactMode int
+
+const (
+	// RedactNone keeps the parameter as is
+	RedactNone RedactMode = iota
+
+	// RedactHash replaces the parameter with a short SHA-256 digest, so
+	// equal values can still be correlated across log lines
+	RedactHash
+
+	// RedactTruncate keeps the first ParamRedaction.MaxLength characters of
+	// text parameters; other parameters are kept
+	RedactTruncate
+
+	// RedactOmit replaces the parameter with a placeholder
+	RedactOmit
+)
+
+// redactedParam replaces parameters hidden with RedactOmit
+const redactedParam = "[REDACTED]"
+
+// defaultRedactLength is the number of characters RedactTruncate keeps when
+// ParamRedaction.MaxLength is zero
+const defaultRedactLength = 8
+
+// ParamRedaction hides the parameters of failed queries, which can contain
+// personal data, when a QueryError is formatted with %+v or logged with
+// log/slog. Register it with Queries.WithParamRedaction.
+//
+// Fields are matched against the column a parameter is compared with in the
+// query, e.g. email in "email = $1" or "u.email IN ($2, $3)"; parameters
+// whose column cannot be told, such as LIMIT values, get Mode.
+type ParamRedaction struct {
+	// Mode applies to parameters whose column has no entry in Fields
+	Mode RedactMode
+
+	// Fields sets the mode by column name
+	Fields map[string]RedactMode
+
+	// MaxLength is the number of characters RedactTruncate keeps (default 8)
+	MaxLength int
+}
+
+// Apply returns a copy of params, the parameters of query, with the policy
+// applied. Nil parameters are kept.
+func (p *ParamRedaction) Apply(query string, params []interface{}) []interface{} {
+	if p == nil || params == nil {
+		return params
+	}
+
+	var columns map[int]string
+	if len(p.Fields) > 0 {
+		columns = paramColumns(query)
+	}
+
+	redacted := make([]interface{}, len(params))
+	for i, param := range params {
+		mode := p.Mode
+		if column, ok := columns[i]; ok {
+			if fieldMode, ok := p.Fields[column]; ok {
+				mode = fieldMode
+			}
+		}
+		redacted[i] = p.redact(param, mode)
+	}
+	return redacted
+}
+
+// redact applies mode to one parameter
+func (p *ParamRedaction) redact(param interface{}, mode RedactMode) interface{} {
+	if mode == RedactNone {
+		return param
+	}
+	value, ok := paramValue(param)
+	if !ok {
+		return param
+	}
+
+	switch mode {
+	case RedactHash:
+		sum := sha256.Sum256([]byte(fmt.Sprint(value)))
+		return "sha256:" + hex.EncodeToString(sum[:6])
+	case RedactTruncate:
+		text, isText := value.(string)
+		if !isText {
+			return param
+		}
+		length := p.MaxLength
+		if length <= 0 {
+			length = defaultRedactLength
+		}
+		if runes := []rune(text); len(runes) > length {
+			return string(runes[:length]) + "…"
+		}
+		return text
+	default:
+		return redactedParam
+	}
+}
+
+// paramValue returns the value a parameter sends to the database, following
+// pointers and driver.Valuer types, with byte slices as strings. It reports
+// false for NULL.
+func paramValue(param interface{}) (interface{}, bool) {
+	if valuer, ok := param.(driver.Valuer); ok {
+		value, err := valuer.Value()
+		if err != nil {
+			return param, true
+		}
+		param = value
+	}
+	v := reflect.ValueOf(param)
+	for v.IsValid() && v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil, false
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return nil, false
+	}
+	if data, ok := v.Interface().([]byte); ok {
+		return string(data), true
+	}
+	return v.Interface(), true
+}
+
+// paramTokenPattern splits a query into string literals, quoted and bare
+// identifiers, placeholders and single other characters
+var paramTokenPattern = regexp.MustCompile("'(?:[^']|'')*'|\"[^\"]*\"|`[^`]*`|\\$\\d+|\\?|[A-Za-z_][A-Za-z0-9_]*|\\S")
+
+// paramSkipTokens may stand between a column and its parameter, as in
+// "age BETWEEN $1 AND $2", "id = ANY($1)" or "name NOT ILIKE $1"
+var paramSkipTokens = map[string]bool{
+	"(": true, ",": true, "=": true, "<": true, ">": true, "!": true, "%": true, "~": true,
+	"IN": true, "NOT": true, "LIKE": true, "ILIKE": true, "BETWEEN": true, "AND": true,
+	"ANY": true, "ALL": true, "IS": true, "DISTINCT": true, "FROM": true, "SIMILAR": true, "TO": true,
+}
+
+// paramStopWords are keywords that precede a parameter without a column
+var paramStopWords = map[string]bool{
+	"SELECT": true, "WHERE": true, "OR": true, "ON": true, "VALUES": true, "SET": true,
+	"LIMIT": true, "OFFSET": true, "CAST": true, "CASE": true, "WHEN": true, "THEN": true,
+	"ELSE": true, "RETURNING": true, "HAVING": true, "BY": true,
+}
+
+// paramColumns maps the index of each parameter of query to the column it
+// is compared with or, in INSERT ... VALUES, inserted into, for the
+// parameters where that can be told. Numbered placeholders ($1) are indexed
+// by number, ? placeholders by position.
+func paramColumns(query string) map[int]string {
+	tokens := paramTokenPattern.FindAllString(query, -1)
+	columns := make(map[int]string)
+	position := 0
+
+	// The column list of an INSERT and, inside its VALUES, the parenthesis
+	// depth and the index of the current value in its row
+	var insertColumns []string
+	inValues := false
+	depth, value := 0, 0
+
+	for i, token := range tokens {
+		upper := strings.ToUpper(token)
+		switch {
+		case upper == "INTO":
+			insertColumns = insertColumnList(tokens[i+1:])
+			continue
+		case upper == "VALUES" && insertColumns != nil:
+			inValues, depth = true, 0
+			continue
+		case inValues && token == "(":
+			if depth == 0 {
+				value = 0
+			}
+			depth++
+			continue
+		case inValues && token == ")":
+			depth--
+			continue
+		case inValues && token == "," && depth == 1:
+			value++
+			continue
+		case inValues && depth == 0 && token != ",":
+			inValues = false
+		}
+
+		var index int
+		switch {
+		case token == "?":
+			index = position
+			position++
+		case strings.HasPrefix(token, "$"):
+			n, err := strconv.Atoi(token[1:])
+			if err != nil {
+				continue
+			}
+			index = n - 1
+		default:
+			continue
+		}
+
+		if inValues {
+			if value < len(insertColumns) {
+				columns[index] = insertColumns[value]
+			}
+		} else if column := paramColumn(tokens[:i]); column != "" {
+			columns[index] = column
+		}
+	}
+	return columns
+}
+
+// insertColumnList returns the column list following the table name of an
+// INSERT INTO, or nil if there is none
+func insertColumnList(tokens []string) []string {
+	i := 0
+	for i < len(tokens) && tokens[i] != "(" {
+		if strings.EqualFold(tokens[i], "VALUES") || strings.EqualFold(tokens[i], "SELECT") {
+			return nil
+		}
+		i++
+	}
+
+	var columns []string
+	for i++; i < len(tokens) && tokens[i] != ")"; i++ {
+		if token := tokens[i]; token != "," {
+			columns = append(columns, strings.ToLower(strings.Trim(token, "\"`")))
+		}
+	}
+	return columns
+}
+
+// paramColumn returns the column compared with a parameter following
+// tokens, or "" if there is none
+func paramColumn(tokens []string) string {
+	for i := len(tokens) - 1; i >= 0; i-- {
+		token := tokens[i]
+		upper := strings.ToUpper(token)
+		switch {
+		case token == "?" || strings.HasPrefix(token, "$") || paramSkipTokens[upper]:
+			continue
+		case paramStopWords[upper]:
+			return ""
+		case strings.HasPrefix(token, `"`) || strings.HasPrefix(token, "`"):
+			return token[1 : len(token)-1]
+		case token[0] == '_' || token[0] >= 'A' && token[0] <= 'Z' || token[0] >= 'a' && token[0] <= 'z':
+			return strings.ToLower(token)
+		default:
+			return ""
+		}
+	}
+	return ""
+}
+
+// WithParamRedaction sets the policy hiding the parameters of QueryErrors
+// returned by Executors created from these Queries
+//
+// Example:
+//
+//	q := sqld.New(database, sqld.Postgres).WithParamRedaction(&sqld.ParamRedaction{
+//		Mode:   sqld.RedactTruncate,
+//		Fields: map[string]sqld.RedactMode{"email": sqld.RedactHash, "ssn": sqld.RedactOmit},
+//	})
+func (q *Queries) WithParamRedaction(policy *ParamRedaction) *Queries {
+	q.redaction = policy
+	return q
+}
+
+// withParamRedaction sets policy on the QueryError in err, if any
+func withParamRedaction(err error, policy *ParamRedaction) error {
+	if queryErr, ok := err.(*QueryError); ok && policy != nil {
+		queryErr.redaction = policy
+	}
+	return err
+}
@@ -0,0 +1,122 @@
+package sqld
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestParamColumns(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  map[int]string
+	}{
+		{
+			"comparisons",
+			"SELECT * FROM users WHERE u.email = $1 AND age BETWEEN $2 AND $3 AND status NOT ILIKE $4 LIMIT $5",
+			map[int]string{0: "email", 1: "age", 2: "age", 3: "status"},
+		},
+		{
+			"lists and arrays",
+			`SELECT * FROM users WHERE id = ANY($2) AND "Role" IN ($1, $3)`,
+			map[int]string{0: "Role", 1: "id", 2: "Role"},
+		},
+		{
+			"question marks",
+			"SELECT * FROM users WHERE name = ? AND note = 'a = ?' AND `email` <> ?",
+			map[int]string{0: "name", 1: "email"},
+		},
+		{
+			"functions are not columns",
+			"SELECT * FROM users WHERE lower(email) = $1 OR $2 = 1",
+			map[int]string{},
+		},
+		{
+			"insert",
+			"INSERT INTO users (name, email) VALUES ($1, now(), $2), (?, ?) ON CONFLICT (email) DO UPDATE SET name = $3",
+			map[int]string{0: "name", 1: "email", 2: "name"},
+		},
+		{
+			"multi-row insert",
+			"INSERT INTO users (name, email) VALUES (?, ?), (?, ?)",
+			map[int]string{0: "name", 1: "email", 2: "name", 3: "email"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, paramColumns(tt.query))
+		})
+	}
+}
+
+func TestParamRedaction_Apply(t *testing.T) {
+	email := "ann@example.com"
+	policy := &ParamRedaction{
+		Mode: RedactTruncate,
+		Fields: map[string]RedactMode{
+			"email":  RedactHash,
+			"ssn":    RedactOmit,
+			"status": RedactNone,
+		},
+		MaxLength: 3,
+	}
+	query := "SELECT * FROM users WHERE email = $1 AND ssn = $2 AND status = $3 AND name = $4 AND age > $5 AND nickname = $6 LIMIT $7"
+	params := []interface{}{&email, sql.NullString{String: "123-45-6789", Valid: true}, "active", "Annabel", 42, nil, 10}
+
+	redacted := policy.Apply(query, params)
+	assert.Equal(t, []interface{}{
+		policy.Apply("SELECT 1 WHERE email = $1", []interface{}{email})[0],
+		"[REDACTED]",
+		"active",
+		"Ann…",
+		42,
+		nil,
+		10,
+	}, redacted)
+	assert.Regexp(t, `^sha256:[0-9a-f]{12}$`, redacted[0])
+	assert.Equal(t, "Annabel", params[3], "the original parameters are kept")
+
+	var none *ParamRedaction
+	assert.Equal(t, params, none.Apply(query, params))
+}
+
+func TestQueryError_Redaction(t *testing.T) {
+	policy := &ParamRedaction{Fields: map[string]RedactMode{"email": RedactOmit}}
+	err := withParamRedaction(WrapQueryError(errors.New("boom"), "SELECT * FROM users WHERE email = $1 AND age > $2", []interface{}{"ann@example.com", 18}, "executing query"), policy)
+
+	var queryErr *QueryError
+	assert.ErrorAs(t, err, &queryErr)
+	assert.Equal(t, []interface{}{"ann@example.com", 18}, queryErr.Params)
+	assert.Equal(t, []interface{}{"[REDACTED]", 18}, queryErr.RedactedParams())
+
+	assert.Equal(t, err.Error(), fmt.Sprintf("%v", err))
+	assert.Equal(t, err.Error()+" (params: [[REDACTED] 18])", fmt.Sprintf("%+v", err))
+	assert.NotContains(t, fmt.Sprintf("%+v", fmt.Errorf("listing users: %w", err)), "ann@example.com")
+
+	var logs bytes.Buffer
+	slog.New(slog.NewTextHandler(&logs, nil)).Error("query failed", "err", err)
+	assert.Contains(t, logs.String(), "err.params=\"[[REDACTED] 18]\"")
+	assert.NotContains(t, logs.String(), "ann@example.com")
+}
+
+func TestExecutor_ParamRedaction(t *testing.T) {
+	mockDB := &MockDB{}
+	mockDB.On("Query", mock.Anything, mock.Anything, mock.Anything).Return((*MockRows)(nil), errors.New("connection reset"))
+
+	q := New(mockDB, Postgres).WithParamRedaction(&ParamRedaction{Mode: RedactOmit})
+	where := NewWhereBuilder(Postgres)
+	where.Equal("email", "ann@example.com")
+
+	_, err := NewExecutor[testUser](q).QueryAll(context.Background(), "SELECT id, name FROM users WHERE 1=1 /* sqld:where */", where, nil, nil, 0)
+	assert.Error(t, err)
+	assert.Contains(t, fmt.Sprintf("%+v", err), "params: [[REDACTED]]")
+}
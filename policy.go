@@ -0,0 +1,60 @@
+package sqld
+
+import "context"
+
+// Policy supplies row-level authorization predicates for the current request.
+// An Executor consults its policies on every query and ANDs the returned
+// conditions with the caller's filters, so user-supplied filters can only
+// narrow what the policy allows.
+type Policy interface {
+	Conditions(ctx context.Context) (*WhereBuilder, error)
+}
+
+// PolicyFunc adapts an ordinary function to the Policy interface
+//
+// Example:
+//
+//	ownerOrPublic := sqld.PolicyFunc(func(ctx context.Context) (*sqld.WhereBuilder, error) {
+//		userID, ok := auth.UserID(ctx)
+//		if !ok {
+//			return nil, errUnauthenticated
+//		}
+//		where := sqld.NewWhereBuilder(sqld.Postgres)
+//		where.Or(func(or sqld.ConditionBuilder) {
+//			or.Equal("owner_id", userID)
+//			or.Equal("visibility", "public")
+//		})
+//		return where, nil
+//	})
+type PolicyFunc func(ctx context.Context) (*WhereBuilder, error)
+
+// Conditions implements the Policy interface
+func (f PolicyFunc) Conditions(ctx context.Context) (*WhereBuilder, error) {
+	return f(ctx)
+}
+
+// WithPolicy returns a copy of the executor that applies the given policies
+// in addition to any already registered. A policy returning an error aborts
+// the query; a nil or empty builder adds no conditions.
+func (e *Executor[T]) WithPolicy(policies ...Policy) *Executor[T] {
+	clone := *e
+	clone.policies = make([]Policy, 0, len(e.policies)+len(policies))
+	clone.policies = append(clone.policies, e.policies...)
+	clone.policies = append(clone.policies, policies...)
+	return &clone
+}
+
+// policyConditions evaluates the executor's policies for the given context
+func (e *Executor[T]) policyConditions(ctx context.Context) ([]*WhereBuilder, error) {
+	var conditions []*WhereBuilder
+	for _, policy := range e.policies {
+		where, err := policy.Conditions(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if where != nil && where.HasConditions() {
+			conditions = append(conditions, where)
+		}
+	}
+	return conditions, nil
+}
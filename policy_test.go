@@ -0,0 +1,88 @@
+package sqld
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type userKey struct{}
+
+// ownerOrPublic allows rows owned by the current user or marked public
+var ownerOrPublic = PolicyFunc(func(ctx context.Context) (*WhereBuilder, error) {
+	userID, ok := ctx.Value(userKey{}).(int)
+	if !ok {
+		return nil, errors.New("unauthenticated")
+	}
+	where := NewWhereBuilder(Postgres)
+	where.Or(func(or ConditionBuilder) {
+		or.Equal("owner_id", userID)
+		or.Equal("visibility", "public")
+	})
+	return where, nil
+})
+
+func TestExecutorPolicy(t *testing.T) {
+	const query = "SELECT id, name FROM documents WHERE archived = false /* sqld:where */"
+	ctx := context.WithValue(context.Background(), userKey{}, 7)
+
+	t.Run("policy composes with user filters", func(t *testing.T) {
+		mockDB := &MockDB{}
+		expectEmptyQuery(mockDB,
+			"SELECT id, name FROM documents WHERE archived = false  AND name = $1 AND size > $2 AND (owner_id = $3 OR visibility = $4)",
+			"report", 10, 7, "public")
+
+		exec := NewExecutor[testUser](New(mockDB, Postgres)).WithPolicy(ownerOrPublic)
+		where := NewWhereBuilder(Postgres)
+		where.Equal("name", "report")
+		where.GreaterThan("size", 10)
+
+		_, err := exec.QueryAll(ctx, query, where, nil, nil, 0)
+		assert.NoError(t, err)
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("policy combines with tenant scope", func(t *testing.T) {
+		mockDB := &MockDB{}
+		expectEmptyQuery(mockDB,
+			"SELECT id, name FROM documents WHERE archived = false  AND (owner_id = $1 OR visibility = $2) AND tenant_id = $3",
+			7, "public", 42)
+
+		tenantCtx := context.WithValue(ctx, tenantKey{}, 42)
+		q := New(mockDB, Postgres).WithTenantScope("tenant_id", tenantFromContext)
+		exec := NewExecutor[testUser](q).WithPolicy(ownerOrPublic)
+
+		_, err := exec.QueryAll(tenantCtx, query, nil, nil, nil, 0)
+		assert.NoError(t, err)
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("policy error aborts the query", func(t *testing.T) {
+		mockDB := &MockDB{}
+		exec := NewExecutor[testUser](New(mockDB, Postgres)).WithPolicy(ownerOrPublic)
+
+		_, err := exec.QueryAll(context.Background(), query, nil, nil, nil, 0)
+		assert.EqualError(t, err, "unauthenticated")
+		mockDB.AssertNotCalled(t, "Query")
+	})
+
+	t.Run("empty policy adds nothing", func(t *testing.T) {
+		mockDB := &MockDB{}
+		expectEmptyQuery(mockDB, "SELECT id, name FROM documents WHERE archived = false ")
+
+		allowAll := PolicyFunc(func(ctx context.Context) (*WhereBuilder, error) { return nil, nil })
+		exec := NewExecutor[testUser](New(mockDB, Postgres)).WithPolicy(allowAll)
+
+		_, err := exec.QueryAll(ctx, query, nil, nil, nil, 0)
+		assert.NoError(t, err)
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("WithPolicy does not mutate the base executor", func(t *testing.T) {
+		base := NewExecutor[testUser](New(&MockDB{}, Postgres)).WithPolicy(ownerOrPublic)
+		_ = base.WithPolicy(ownerOrPublic)
+		assert.Len(t, base.policies, 1)
+	})
+}
@@ -0,0 +1,61 @@
+package sqld
+
+import "sync"
+
+// WherePool reuses WhereBuilders across requests to cut allocations on hot
+// paths. Builders are not safe for concurrent use, so each request takes its
+// own builder with Get and returns it with Put once the query has run:
+//
+//	pool := sqld.NewWherePool(sqld.Postgres, func(w *sqld.WhereBuilder) {
+//		w.QuoteIdentifiers(true)
+//	})
+//
+//	where := pool.Get()
+//	defer pool.Put(where)
+//	where.Equal("status", status)
+//
+// A WherePool is safe for concurrent use.
+type WherePool struct {
+	dialect   Dialect
+	configure func(*WhereBuilder)
+	pool      sync.Pool
+}
+
+// NewWherePool creates a pool of builders for dialect. configure, if not
+// nil, is applied to every builder handed out by Get, e.g. to set options.
+func NewWherePool(dialect Dialect, configure func(*WhereBuilder)) *WherePool {
+	p := &WherePool{dialect: dialect, configure: configure}
+	p.pool.New = func() interface{} {
+		return NewWhereBuilder(dialect)
+	}
+	return p
+}
+
+// Get returns an empty builder with the pool's dialect and options
+func (p *WherePool) Get() *WhereBuilder {
+	w := p.pool.Get().(*WhereBuilder)
+	if p.configure != nil {
+		p.configure(w)
+	}
+	return w
+}
+
+// Put resets w and returns it to the pool. w must not be used afterwards;
+// the parameters returned by its Build stay valid. Frozen builders are
+// shared by definition and are not pooled.
+func (p *WherePool) Put(w *WhereBuilder) {
+	if w == nil || w.frozen || w.dialect != p.dialect {
+		return
+	}
+	w.reset()
+	p.pool.Put(w)
+}
+
+// reset returns w to the state of NewWhereBuilder, keeping the capacity of
+// its condition slice. params is replaced rather than truncated because
+// Build hands it out to callers.
+func (w *WhereBuilder) reset() {
+	conditions := w.conditions[:0]
+	*w = *NewWhereBuilder(w.dialect)
+	w.conditions = conditions
+}
@@ -0,0 +1,84 @@
+package sqld
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWherePool(t *testing.T) {
+	pool := NewWherePool(Postgres, func(w *WhereBuilder) {
+		w.QuoteIdentifiers(true)
+	})
+
+	first := pool.Get()
+	first.Equal("status", "active")
+	sql, params := first.Build()
+	pool.Put(first)
+
+	assert.Equal(t, `"status" = $1`, sql)
+	assert.Equal(t, []interface{}{"active"}, params)
+
+	second := pool.Get()
+	assert.False(t, second.HasConditions())
+	second.Equal("order", 1)
+	sql, params = second.Build()
+	assert.Equal(t, `"order" = $1`, sql)
+	assert.Equal(t, []interface{}{1}, params)
+
+	// Params handed out before Put are not overwritten by later use
+	pool.Put(second)
+	third := pool.Get()
+	third.Equal("other", 2)
+	assert.Equal(t, []interface{}{1}, params)
+}
+
+func TestWherePool_IgnoresForeignBuilders(t *testing.T) {
+	pool := NewWherePool(Postgres, nil)
+	pool.Put(nil)
+	pool.Put(NewWhereBuilder(MySQL))
+
+	frozen := NewWhereBuilder(Postgres).Freeze()
+	pool.Put(frozen)
+	assert.True(t, frozen.frozen)
+
+	assert.Equal(t, Postgres, pool.Get().dialect)
+}
+
+// TestConcurrentBuilders shows the supported ways of sharing builders between
+// goroutines; run with -race to check them.
+func TestConcurrentBuilders(t *testing.T) {
+	base := NewWhereBuilder(Postgres)
+	base.IsNull("deleted_at")
+	base.Equal("tenant_id", 42)
+	base.Freeze()
+	sort := NewOrderByBuilder().Desc("created_at").Freeze()
+	pool := NewWherePool(Postgres, nil)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			where := base.Clone()
+			where.Equal("status", fmt.Sprint("s", i))
+			sql, params := where.Build()
+			assert.Equal(t, "deleted_at IS NULL AND tenant_id = $1 AND status = $2", sql)
+			assert.Equal(t, []interface{}{42, fmt.Sprint("s", i)}, params)
+
+			orderBy := sort.Clone().Asc("id")
+			assert.Equal(t, "created_at DESC, id ASC", orderBy.Build())
+
+			pooled := pool.Get()
+			pooled.Equal("id", i)
+			sql, params = pooled.Build()
+			assert.Equal(t, "id = $1", sql)
+			assert.Equal(t, []interface{}{i}, params)
+			pool.Put(pooled)
+		}(i)
+	}
+	wg.Wait()
+}
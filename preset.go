@@ -0,0 +1,134 @@
+package sqld
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// PresetParam is the query parameter selecting presets, e.g.
+// ?preset=active_adults or ?preset=active_adults,verified
+const PresetParam = "preset"
+
+// Preset is a named set of filters defined on the server
+type Preset struct {
+	// Name is the value clients pass in PresetParam
+	Name string `json:"name"`
+
+	// Query holds the filters in query string syntax, e.g.
+	// "status=active&age[gte]=18"
+	Query string `json:"query"`
+}
+
+// PresetRegistry holds named filter presets that clients select with
+// ?preset=name, combined with any other filters in the request. Presets are
+// parsed with the request's Config, so they may only use fields and
+// operators the Config allows.
+//
+// Example:
+//
+//	presets := sqld.NewPresetRegistry()
+//	presets.MustRegister("active_adults", "status=active&age[gte]=18")
+//	config.WithPresets(presets)
+//
+//	// GET /users?preset=active_adults&name[contains]=jo
+type PresetRegistry struct {
+	mu      sync.RWMutex
+	presets map[string]*Preset
+}
+
+// NewPresetRegistry creates an empty registry
+func NewPresetRegistry() *PresetRegistry {
+	return &PresetRegistry{presets: make(map[string]*Preset)}
+}
+
+// Register stores the filters in query under name. Names must be unique and
+// presets cannot select other presets.
+func (r *PresetRegistry) Register(name, query string) (*Preset, error) {
+	if name == "" || strings.ContainsAny(name, ", ") {
+		return nil, fmt.Errorf("invalid preset name %q", name)
+	}
+	for _, param := range strings.Split(query, "&") {
+		key, _, ok := strings.Cut(param, "=")
+		if !ok {
+			return nil, fmt.Errorf("preset %s: parameter %q must be of the form field[op]=value", name, param)
+		}
+		if key == PresetParam {
+			return nil, fmt.Errorf("preset %s: presets cannot select other presets", name)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.presets[name]; exists {
+		return nil, fmt.Errorf("preset %s is already registered", name)
+	}
+	preset := &Preset{Name: name, Query: query}
+	r.presets[name] = preset
+	return preset, nil
+}
+
+// MustRegister is like Register but panics on error, for use in package
+// variable initialization
+func (r *PresetRegistry) MustRegister(name, query string) *Preset {
+	preset, err := r.Register(name, query)
+	if err != nil {
+		panic(err)
+	}
+	return preset
+}
+
+// Get returns the preset registered under name
+func (r *PresetRegistry) Get(name string) (*Preset, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	preset, ok := r.presets[name]
+	return preset, ok
+}
+
+// Presets returns the registered presets sorted by name
+func (r *PresetRegistry) Presets() []*Preset {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	presets := make([]*Preset, 0, len(r.presets))
+	for _, preset := range r.presets {
+		presets = append(presets, preset)
+	}
+	sort.Slice(presets, func(i, j int) bool { return presets[i].Name < presets[j].Name })
+	return presets
+}
+
+// parsePresets expands the comma-separated preset names in value into
+// their filters
+func parsePresets(ctx context.Context, config *Config, value string, position int) ([]Filter, FilterErrors) {
+	var filters []Filter
+	var errs FilterErrors
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		preset, ok := config.Presets.Get(name)
+		if !ok {
+			errs = append(errs, &FilterError{
+				Field:    PresetParam,
+				Value:    name,
+				Reason:   "unknown preset",
+				Position: position,
+				Err:      &ValidationError{Field: PresetParam, Value: name, Message: "unknown preset " + name},
+			})
+			continue
+		}
+
+		for _, param := range strings.Split(preset.Query, "&") {
+			key, val, _ := strings.Cut(param, "=")
+			parsed, filterErrs := parseParam(ctx, config, key, val, position)
+			errs = append(errs, filterErrs...)
+			filters = append(filters, parsed...)
+		}
+	}
+	return filters, errs
+}
@@ -0,0 +1,87 @@
+package sqld
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPresetRegistry(t *testing.T) {
+	presets := NewPresetRegistry()
+	presets.MustRegister("active_adults", "status=active&age[gte]=18")
+
+	_, err := presets.Register("active_adults", "status=active")
+	assert.Error(t, err, "duplicate name")
+	_, err = presets.Register("nested", "preset=active_adults")
+	assert.Error(t, err, "nested preset")
+	_, err = presets.Register("malformed", "status")
+	assert.Error(t, err, "malformed query")
+	_, err = presets.Register("a,b", "status=active")
+	assert.Error(t, err, "invalid name")
+
+	preset, ok := presets.Get("active_adults")
+	require.True(t, ok)
+	assert.Equal(t, "status=active&age[gte]=18", preset.Query)
+}
+
+func TestPresets(t *testing.T) {
+	presets := NewPresetRegistry()
+	presets.MustRegister("active_adults", "status=active&age[gte]=18")
+	presets.MustRegister("verified", "is_verified=true")
+	presets.MustRegister("admins", "role=admin")
+
+	config := DefaultConfig().
+		WithAllowedFields(map[string]bool{"status": true, "age": true, "is_verified": true, "name": true}).
+		WithStrictFields(true).
+		WithPresets(presets)
+
+	t.Run("composes with ad-hoc filters", func(t *testing.T) {
+		where, err := FromQueryString("preset=active_adults&name[contains]=jo", Postgres, config)
+		require.NoError(t, err)
+
+		sql, params := where.Build()
+		assert.Equal(t, "status = $1 AND age >= $2 AND name ILIKE $3", sql)
+		assert.Equal(t, []interface{}{"active", 18, "%jo%"}, params)
+	})
+
+	t.Run("several presets", func(t *testing.T) {
+		filters, err := ParseURLValues(url.Values{PresetParam: {"active_adults,verified"}}, config)
+		require.NoError(t, err)
+		assert.Len(t, filters, 3)
+	})
+
+	t.Run("unknown preset", func(t *testing.T) {
+		_, err := ParseQueryString("preset=nope", config)
+
+		var filterErr *FilterError
+		require.True(t, errors.As(err, &filterErr))
+		assert.Equal(t, PresetParam, filterErr.Field)
+		assert.Equal(t, "unknown preset", filterErr.Reason)
+	})
+
+	t.Run("presets obey the config", func(t *testing.T) {
+		_, err := ParseQueryString("preset=admins", config)
+
+		var filterErr *FilterError
+		require.True(t, errors.As(err, &filterErr))
+		assert.Equal(t, "role", filterErr.Field)
+		assert.Equal(t, "unknown field", filterErr.Reason)
+	})
+
+	t.Run("preset is a field without a registry", func(t *testing.T) {
+		config := DefaultConfig().WithAllowedFields(map[string]bool{"preset": true})
+		filters, err := ParseQueryString("preset=draft", config)
+		require.NoError(t, err)
+		require.Len(t, filters, 1)
+		assert.Equal(t, "preset", filters[0].Field)
+	})
+
+	t.Run("schema lists presets", func(t *testing.T) {
+		schema := GenerateSchema(config)
+		require.Len(t, schema.Presets, 3)
+		assert.Equal(t, "active_adults", schema.Presets[0].Name)
+	})
+}
@@ -1,12 +1,16 @@
 package sqld
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"net/url"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 )
 
 // Operator represents a filter operator for query string parsing
@@ -35,13 +39,47 @@ const (
 	OpNotIn            Operator = "notIn"
 	OpIsNull           Operator = "isNull"
 	OpIsNotNull        Operator = "isNotNull"
+
+	// OpNear matches geography values within a radius in meters of a point,
+	// written lat,lng,radius. It compiles to PostGIS ST_DWithin.
+	OpNear Operator = "near"
+
+	// OpWithin matches geography values inside a bounding box, written
+	// minLat,minLng,maxLat,maxLng. It compiles to PostGIS ST_Intersects.
+	OpWithin Operator = "within"
+
+	// OpOverlaps matches range values overlapping lower,upper (PostgreSQL
+	// &&). On range fields OpContains matches ranges containing a value or
+	// a lower,upper range (@>).
+	OpOverlaps Operator = "overlaps"
+
+	// OpSimilar fuzzy-matches text with pg_trgm on PostgreSQL and falls back
+	// to a contains match elsewhere (see WhereBuilder.Similar)
+	OpSimilar Operator = "similar"
+
+	// OpOr marks a Filter whose Or conditions are combined with OR
+	OpOr Operator = "or"
+
+	// OpExists marks a Filter whose Related conditions must match at least
+	// one row of the related table named by Field
+	OpExists Operator = "exists"
 )
 
-// Filter represents a single filter condition from query parameters
+// OrParam is the query parameter holding an OR group, e.g.
+// ?or=(name[contains]=john,email[contains]=john)
+const OrParam = "or"
+
+// Filter represents a single filter condition from query parameters.
+// A Filter with Operator OpOr has no field of its own; its Or conditions
+// are combined with OR. A Filter with Operator OpExists names a relation
+// in Field and holds the conditions on that relation in Related.
 type Filter struct {
 	Field    string      `json:"field"`
 	Operator Operator    `json:"operator"`
 	Value    interface{} `json:"value"`
+	Or       []Filter    `json:"or,omitempty"`
+	Relation *Relation   `json:"relation,omitempty"`
+	Related  []Filter    `json:"related,omitempty"`
 }
 
 // MapOperator converts string operators to Operator constants
@@ -87,6 +125,14 @@ func MapOperator(op string) Operator {
 		return OpLike
 	case "ilike":
 		return OpILike
+	case "near":
+		return OpNear
+	case "within":
+		return OpWithin
+	case "overlaps":
+		return OpOverlaps
+	case "similar":
+		return OpSimilar
 	default:
 		return OpEq
 	}
@@ -94,12 +140,20 @@ func MapOperator(op string) Operator {
 
 // ParseQueryString parses URL query parameters into Filter objects
 func ParseQueryString(queryString string, config *Config) ([]Filter, error) {
+	return ParseQueryStringContext(context.Background(), queryString, config)
+}
+
+// ParseQueryStringContext is like ParseQueryString but also enforces per-field
+// role requirements against the roles carried by ctx
+func ParseQueryStringContext(ctx context.Context, queryString string, config *Config) ([]Filter, error) {
 	if config == nil {
 		config = DefaultConfig()
 	}
 
 	// Parse manually to preserve order of parameters
 	var filters []Filter
+	var errs FilterErrors
+	var count int
 
 	if queryString == "" {
 		return filters, nil
@@ -108,8 +162,8 @@ func ParseQueryString(queryString string, config *Config) ([]Filter, error) {
 	// Split by & to get individual parameters
 	params := strings.Split(queryString, "&")
 
-	for _, param := range params {
-		if len(filters) >= config.MaxFilters {
+	for position, param := range params {
+		if count >= config.MaxFilters {
 			return nil, fmt.Errorf("too many filters, maximum allowed: %d", config.MaxFilters)
 		}
 
@@ -129,90 +183,496 @@ func ParseQueryString(queryString string, config *Config) ([]Filter, error) {
 			continue
 		}
 
-		// Skip empty values
-		if value == "" {
+		parsed, filterErrs := parseParam(ctx, config, key, value, position)
+		if len(filterErrs) > 0 {
+			errs = append(errs, filterErrs...)
 			continue
 		}
-
-		// Parse the field and operator from the key
-		field, operator := parseFieldOperator(key, config.DefaultOperator)
-
-		// Map field name if configured
-		if mapped, exists := config.FieldMappings[field]; exists {
-			field = mapped
-		}
-
-		// Check if field is allowed
-		if len(config.AllowedFields) > 0 && !config.AllowedFields[field] {
-			continue // Skip disallowed fields
-		}
-
-		// Convert value based on operator
-		convertedValue, err := convertValue(value, operator, config.DateLayout)
-		if err != nil {
-			return nil, fmt.Errorf("invalid value for field %s: %w", field, err)
+		if count += filterCount(key, parsed); count > config.MaxFilters {
+			return nil, fmt.Errorf("too many filters, maximum allowed: %d", config.MaxFilters)
 		}
+		filters = append(filters, parsed...)
+	}
 
-		filters = append(filters, Filter{
-			Field:    field,
-			Operator: operator,
-			Value:    convertedValue,
-		})
+	if len(errs) > 0 {
+		return nil, errs
 	}
 
+	filters = groupRelationFilters(config, filters)
+	if err := config.checkBudget(ctx, filters); err != nil {
+		return nil, err
+	}
 	return filters, nil
 }
 
-// ParseRequest parses filters from an HTTP request
+// ParseRequest parses filters from an HTTP request, using the request
+// context for per-field role checks
 func ParseRequest(r *http.Request, config *Config) ([]Filter, error) {
-	return ParseQueryString(r.URL.RawQuery, config)
+	return ParseQueryStringContext(r.Context(), r.URL.RawQuery, config)
 }
 
 // ParseURLValues parses url.Values into Filter objects
 func ParseURLValues(values url.Values, config *Config) ([]Filter, error) {
+	return ParseURLValuesContext(context.Background(), values, config)
+}
+
+// ParseURLValuesContext is like ParseURLValues but also enforces per-field
+// role requirements against the roles carried by ctx
+func ParseURLValuesContext(ctx context.Context, values url.Values, config *Config) ([]Filter, error) {
 	if config == nil {
 		config = DefaultConfig()
 	}
 
 	var filters []Filter
+	var errs FilterErrors
+	var count int
+
+	// url.Values carries no parameter order, so iterate keys in sorted order
+	// to keep results and error reports deterministic
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
 
-	for key, vals := range values {
-		if len(filters) >= config.MaxFilters {
+	for _, key := range keys {
+		vals := values[key]
+
+		if count >= config.MaxFilters {
 			return nil, fmt.Errorf("too many filters, maximum allowed: %d", config.MaxFilters)
 		}
 
 		// Skip empty values
-		if len(vals) == 0 || vals[0] == "" {
+		if len(vals) == 0 {
 			continue
 		}
 
-		// Parse the field and operator from the key
-		field, operator := parseFieldOperator(key, config.DefaultOperator)
+		parsed, filterErrs := parseParam(ctx, config, key, vals[0], -1)
+		if len(filterErrs) > 0 {
+			errs = append(errs, filterErrs...)
+			continue
+		}
+		if count += filterCount(key, parsed); count > config.MaxFilters {
+			return nil, fmt.Errorf("too many filters, maximum allowed: %d", config.MaxFilters)
+		}
+		filters = append(filters, parsed...)
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
 
-		// Map field name if configured
-		if mapped, exists := config.FieldMappings[field]; exists {
-			field = mapped
+	filters = groupRelationFilters(config, filters)
+	if err := config.checkBudget(ctx, filters); err != nil {
+		return nil, err
+	}
+	return filters, nil
+}
+
+// groupRelationFilters collects filters on related fields (e.g. orders.total)
+// into one OpExists filter per relation, so that all conditions on a relation
+// must hold for the same related row. Filters inside OR groups are wrapped
+// individually.
+func groupRelationFilters(config *Config, filters []Filter) []Filter {
+	if len(config.Relations) == 0 {
+		return filters
+	}
+
+	result := make([]Filter, 0, len(filters))
+	groups := make(map[string]int)
+
+	for _, filter := range filters {
+		if filter.Operator == OpOr {
+			for i, item := range filter.Or {
+				filter.Or[i] = groupRelationFilters(config, []Filter{item})[0]
+			}
+			result = append(result, filter)
+			continue
 		}
 
-		// Check if field is allowed
-		if len(config.AllowedFields) > 0 && !config.AllowedFields[field] {
-			continue // Skip disallowed fields
+		name, ok := relationName(config, filter.Field)
+		if !ok {
+			result = append(result, filter)
+			continue
+		}
+
+		if idx, exists := groups[name]; exists {
+			result[idx].Related = append(result[idx].Related, filter)
+			continue
 		}
 
-		// Convert value based on operator
-		value, err := convertValue(vals[0], operator, config.DateLayout)
+		relation := config.Relations[name]
+		groups[name] = len(result)
+		result = append(result, Filter{
+			Field:    name,
+			Operator: OpExists,
+			Relation: &relation,
+			Related:  []Filter{filter},
+		})
+	}
+
+	return result
+}
+
+// relationName returns the relation a qualified field such as "orders.total" belongs to
+func relationName(config *Config, field string) (string, bool) {
+	dot := strings.Index(field, ".")
+	if dot <= 0 {
+		return "", false
+	}
+	name := field[:dot]
+	_, ok := config.Relations[name]
+	return name, ok
+}
+
+// parseParam turns a single query parameter into filters, dispatching OR
+// groups, presets and free-text search to their own parsers. It returns
+// (nil, nil) for skipped parameters.
+func parseParam(ctx context.Context, config *Config, key, value string, position int) ([]Filter, FilterErrors) {
+	var filter *Filter
+	switch {
+	case key == OrParam:
+		var errs FilterErrors
+		if filter, errs = parseOrGroup(ctx, config, value, position); len(errs) > 0 {
+			return nil, errs
+		}
+	case config.Presets != nil && key == PresetParam:
+		return parsePresets(ctx, config, value, position)
+	case config.SearchParam != "" && key == config.SearchParam:
+		filter = parseSearch(ctx, config, value)
+	default:
+		var err *FilterError
+		if filter, err = parseFilterParam(ctx, config, key, value, position); err != nil {
+			return nil, FilterErrors{err}
+		}
+	}
+
+	if filter == nil {
+		return nil, nil
+	}
+	return []Filter{*filter}, nil
+}
+
+// filterCount is the number of filters a parameter adds toward MaxFilters.
+// Each item of an OR group counts on its own; a search parameter counts once
+// however many columns it expands to.
+func filterCount(key string, parsed []Filter) int {
+	if key == OrParam && len(parsed) == 1 {
+		return len(parsed[0].Or)
+	}
+	return len(parsed)
+}
+
+// parseSearch builds an OR group matching value against every configured
+// search column the caller is permitted to use
+func parseSearch(ctx context.Context, config *Config, value string) *Filter {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil
+	}
+
+	var group []Filter
+	for _, column := range config.SearchColumns {
+		if !config.IsFieldPermitted(ctx, column) {
+			continue
+		}
+		group = append(group, Filter{Field: config.ColumnFor(column), Operator: OpContains, Value: value})
+	}
+
+	if len(group) == 0 {
+		return nil
+	}
+	return &Filter{Operator: OpOr, Or: group}
+}
+
+// parseOrGroup parses an OR group such as "(name[contains]=john,email[contains]=john)".
+// Items are separated by commas; a segment without "=" continues the previous
+// item's value, so list operators like status[in]=a,b keep working. A
+// backslash makes the next character literal, so "\," and "\=" put a comma
+// or equals sign into a value and "\\" a backslash.
+func parseOrGroup(ctx context.Context, config *Config, value string, position int) (*Filter, FilterErrors) {
+	if !strings.HasPrefix(value, "(") || !strings.HasSuffix(value, ")") {
+		return nil, FilterErrors{{
+			Field:    OrParam,
+			Value:    value,
+			Reason:   "or group must be wrapped in parentheses",
+			Position: position,
+		}}
+	}
+
+	var items []string
+	for _, segment := range splitUnescaped(value[1:len(value)-1], ',') {
+		if len(items) > 0 && len(splitUnescaped(segment, '=')) == 1 {
+			items[len(items)-1] += "," + segment
+			continue
+		}
+		items = append(items, segment)
+	}
+
+	var group []Filter
+	var errs FilterErrors
+	for _, item := range items {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		parts := splitUnescaped(item, '=')
+		if len(parts) < 2 {
+			errs = append(errs, &FilterError{
+				Field:    OrParam,
+				Value:    item,
+				Reason:   "or group items must be of the form field[op]=value",
+				Position: position,
+			})
+			continue
+		}
+
+		key := unescapeOrItem(parts[0])
+		val := unescapeOrItem(strings.Join(parts[1:], "="))
+		filter, err := parseFilterParam(ctx, config, key, val, position)
 		if err != nil {
-			return nil, fmt.Errorf("invalid value for field %s: %w", field, err)
+			errs = append(errs, err)
+			continue
+		}
+		if filter != nil {
+			group = append(group, *filter)
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	if len(group) == 0 {
+		return nil, nil
+	}
+
+	return &Filter{Operator: OpOr, Or: group}, nil
+}
+
+// splitUnescaped splits s around each sep that is not preceded by a
+// backslash. Escapes are kept in the parts.
+func splitUnescaped(s string, sep byte) []string {
+	var parts []string
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case sep:
+			parts = append(parts, s[start:i])
+			start = i + 1
 		}
+	}
+	return append(parts, s[start:])
+}
+
+// unescapeOrItem drops the backslash from each escape in an OR group item
+func unescapeOrItem(s string) string {
+	if !strings.Contains(s, "\\") {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// parseFilterParam turns a single key/value pair into a Filter. It returns
+// (nil, nil) for parameters that are skipped, such as empty values or, in
+// lenient mode, fields that are not allowed.
+func parseFilterParam(ctx context.Context, config *Config, key, value string, position int) (*Filter, *FilterError) {
+	// Skip empty values
+	if value == "" {
+		return nil, nil
+	}
+
+	// Parse the field and operator from the key
+	field, operator := parseFieldOperator(key, config.DefaultOperator)
+	requested := field
+	field = config.canonicalField(field)
+
+	// Reject unknown operators instead of falling back to the default
+	if config.StrictOperators {
+		if opStr, ok := bracketOperator(key); ok && !isValidOperator(opStr) {
+			return nil, &FilterError{
+				Field:    requested,
+				Operator: opStr,
+				Value:    value,
+				Reason:   "unknown operator",
+				Position: position,
+				Err:      &ValidationError{Field: key, Value: value, Message: "unknown operator " + opStr},
+			}
+		}
+	}
 
-		filters = append(filters, Filter{
-			Field:    field,
-			Operator: operator,
+	// Map field name if configured
+	if mapped, exists := config.FieldMappings[field]; exists {
+		field = mapped
+	}
+
+	// Check if field is allowed
+	if len(config.AllowedFields) > 0 && !config.AllowedFields[field] {
+		if !config.StrictFields || config.IsReservedParam(key) {
+			return nil, nil // Skip disallowed fields
+		}
+		return nil, &FilterError{
+			Field:    requested,
+			Operator: string(operator),
 			Value:    value,
-		})
+			Reason:   "unknown field",
+			Position: position,
+			Err:      &ValidationError{Field: key, Value: value, Message: "unknown field " + requested},
+		}
 	}
 
-	return filters, nil
+	// Check if the caller may use the field
+	if !config.IsFieldPermitted(ctx, field) {
+		return nil, &FilterError{
+			Field:    requested,
+			Operator: string(operator),
+			Value:    value,
+			Reason:   "permission denied",
+			Position: position,
+			Err:      ErrPermissionDenied,
+		}
+	}
+
+	// Refuse to emit a computed field whose expression is unsafe
+	if err := config.validateFieldExpression(field); err != nil {
+		return nil, &FilterError{
+			Field:    requested,
+			Operator: string(operator),
+			Value:    value,
+			Reason:   "invalid field expression",
+			Position: position,
+			Err:      err,
+		}
+	}
+
+	// Check the raw value against the field's constraints
+	if err := checkConstraints(config.Fields[field], operator, value); err != nil {
+		return nil, &FilterError{
+			Field:    requested,
+			Operator: string(operator),
+			Value:    truncateValue(value),
+			Reason:   err.Error(),
+			Position: position,
+			Err:      &ValidationError{Field: key, Value: truncateValue(value), Message: err.Error()},
+		}
+	}
+
+	// Convert value based on operator
+	convertedValue, err := convertValue(value, operator, config.DateLayout)
+	if err != nil {
+		return nil, &FilterError{
+			Field:    requested,
+			Operator: string(operator),
+			Value:    value,
+			Reason:   err.Error(),
+			Position: position,
+			Err:      err,
+		}
+	}
+
+	// Validate and normalize UUIDs before they reach the database
+	if config.Fields[field].Type == "uuid" {
+		parsed, invalid, err := config.parseUUIDValue(operator, convertedValue)
+		if err != nil {
+			reason := "invalid uuid"
+			if invalid == "" {
+				reason = "operator not supported"
+				invalid = value
+			}
+			return nil, &FilterError{
+				Field:    requested,
+				Operator: string(operator),
+				Value:    value,
+				Reason:   reason,
+				Position: position,
+				Err:      &ValidationError{Field: key, Value: invalid, Message: err.Error()},
+			}
+		}
+		convertedValue = parsed
+	}
+
+	// Geo operators apply only to geography fields, which accept nothing else
+	if isGeography := config.Fields[field].Type == "geography"; isGeography || geoOperators[operator] {
+		coordinates, reason, err := parseGeoValue(isGeography, operator, value)
+		if err != nil {
+			return nil, &FilterError{
+				Field:    requested,
+				Operator: string(operator),
+				Value:    value,
+				Reason:   reason,
+				Position: position,
+				Err:      &ValidationError{Field: key, Value: value, Message: err.Error()},
+			}
+		}
+		if coordinates != nil {
+			convertedValue = coordinates
+		}
+	}
+
+	// Range operators apply only to range fields, which accept nothing else
+	if rangeType := config.rangeType(field); rangeType != "" || operator == OpOverlaps {
+		parsed, reason, err := parseRangeValue(rangeType, operator, value)
+		if err != nil {
+			return nil, &FilterError{
+				Field:    requested,
+				Operator: string(operator),
+				Value:    value,
+				Reason:   reason,
+				Position: position,
+				Err:      &ValidationError{Field: key, Value: value, Message: err.Error()},
+			}
+		}
+		if parsed != nil {
+			convertedValue = parsed
+		}
+	}
+
+	// Reject values outside the field's allow-list
+	if invalid, ok := disallowedValue(config.Fields[field].Values, operator, convertedValue); !ok {
+		return nil, &FilterError{
+			Field:    requested,
+			Operator: string(operator),
+			Value:    value,
+			Reason:   "value not allowed",
+			Position: position,
+			Err: &ValidationError{
+				Field:   key,
+				Value:   invalid,
+				Message: fmt.Sprintf("value %q is not one of %s", invalid, strings.Join(config.Fields[field].Values, ", ")),
+			},
+		}
+	}
+
+	// Refuse patterns that would scan the whole table
+	if config.Fields[field].NoLeadingWildcard && hasLeadingWildcard(operator, convertedValue) {
+		return nil, &FilterError{
+			Field:    requested,
+			Operator: string(operator),
+			Value:    value,
+			Reason:   "leading wildcard not allowed, use startsWith",
+			Position: position,
+			Err: &ValidationError{
+				Field:   key,
+				Value:   value,
+				Message: "patterns with a leading wildcard are not allowed on " + requested + "; use startsWith instead",
+			},
+		}
+	}
+
+	return &Filter{
+		Field:    config.ColumnFor(field),
+		Operator: operator,
+		Value:    convertedValue,
+	}, nil
 }
 
 // isValidOperator checks if a string is a valid operator
@@ -224,6 +684,7 @@ func isValidOperator(op string) bool {
 		"notstartswith", "doesnotstartswith", "notendswith", "doesnotendwith",
 		"between", "before", "after", "in", "notin", "notIn",
 		"isnull", "null", "isnotnull", "notnull", "like", "ilike",
+		"near", "within", "overlaps", "similar",
 	}
 
 	opLower := strings.ToLower(op)
@@ -235,13 +696,20 @@ func isValidOperator(op string) bool {
 	return false
 }
 
+// bracketOperator returns the raw operator of a name[op] style key
+func bracketOperator(key string) (string, bool) {
+	if !strings.Contains(key, "[") || !strings.HasSuffix(key, "]") {
+		return "", false
+	}
+	parts := strings.SplitN(key, "[", 2)
+	return strings.TrimSuffix(parts[1], "]"), true
+}
+
 // parseFieldOperator extracts field name and operator from query parameter key
 func parseFieldOperator(key string, defaultOp Operator) (string, Operator) {
 	// Support syntax like: name[eq], age[gt], email[contains]
-	if strings.Contains(key, "[") && strings.HasSuffix(key, "]") {
-		parts := strings.SplitN(key, "[", 2)
-		field := parts[0]
-		opStr := strings.TrimSuffix(parts[1], "]")
+	if opStr, ok := bracketOperator(key); ok {
+		field := strings.SplitN(key, "[", 2)[0]
 		return field, MapOperator(opStr)
 	}
 
@@ -263,6 +731,88 @@ func parseFieldOperator(key string, defaultOp Operator) (string, Operator) {
 	return key, defaultOp
 }
 
+// patternOperators match a fragment of the column rather than a whole value
+var patternOperators = map[Operator]bool{
+	OpLike: true, OpILike: true, OpContains: true, OpIncludes: true, OpDoesNotContain: true,
+	OpStartsWith: true, OpEndsWith: true, OpDoesNotStartWith: true, OpDoesNotEndWith: true, OpSimilar: true,
+}
+
+// checkConstraints checks each value of a raw filter parameter against the
+// length, pattern and range constraints of its field
+func checkConstraints(field FieldConfig, op Operator, value string) error {
+	if field.MaxLength <= 0 && field.Pattern == nil && field.Min == nil && field.Max == nil {
+		return nil
+	}
+	if op == OpIsNull || op == OpIsNotNull {
+		return nil
+	}
+
+	values := []string{value}
+	if op == OpIn || op == OpNotIn || op == OpBetween {
+		values = strings.Split(value, ",")
+	}
+
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if field.MaxLength > 0 && utf8.RuneCountInString(v) > field.MaxLength {
+			return fmt.Errorf("value longer than %d characters", field.MaxLength)
+		}
+		if patternOperators[op] {
+			continue
+		}
+		if field.Pattern != nil && !field.Pattern.MatchString(v) {
+			return fmt.Errorf("value does not match the expected format")
+		}
+		if field.Min != nil || field.Max != nil {
+			n, err := strconv.ParseFloat(v, 64)
+			switch {
+			case err != nil:
+				return fmt.Errorf("value is not a number")
+			case field.Min != nil && n < *field.Min:
+				return fmt.Errorf("value below the minimum of %v", *field.Min)
+			case field.Max != nil && n > *field.Max:
+				return fmt.Errorf("value above the maximum of %v", *field.Max)
+			}
+		}
+	}
+	return nil
+}
+
+// maxReportedValue bounds the raw value echoed back in errors
+const maxReportedValue = 100
+
+// truncateValue shortens a rejected value for error reports, so an
+// oversized parameter is not echoed back in full
+func truncateValue(value string) string {
+	if len(value) <= maxReportedValue {
+		return value
+	}
+	return value[:maxReportedValue] + "..."
+}
+
+// disallowedValue checks the values of an equality or list filter against
+// allowed, returning the first value not in it. Other operators and fields
+// without an allow-list always pass.
+func disallowedValue(allowed []string, op Operator, value interface{}) (string, bool) {
+	if len(allowed) == 0 {
+		return "", true
+	}
+
+	var values []string
+	switch op {
+	case OpEq, OpNe:
+		values = []string{fmt.Sprint(value)}
+	case OpIn, OpNotIn:
+		values, _ = value.([]string)
+	}
+	for _, v := range values {
+		if !slices.Contains(allowed, v) {
+			return v, false
+		}
+	}
+	return "", true
+}
+
 // convertValue converts string values to appropriate types based on operator
 func convertValue(value string, op Operator, dateLayout string) (interface{}, error) {
 	switch op {
@@ -323,12 +873,48 @@ func ApplyFiltersToBuilder(filters []Filter, builder *WhereBuilder) error {
 	return nil
 }
 
-// applyFilter applies a single filter to the WhereBuilder
-func applyFilter(filter Filter, builder *WhereBuilder) error {
+// applyFilter applies a single filter to the builder
+func applyFilter(filter Filter, builder ConditionBuilder) error {
 	field := filter.Field
 	value := filter.Value
 
 	switch filter.Operator {
+	case OpOr:
+		var groupErr error
+		builder.Or(func(or ConditionBuilder) {
+			for _, item := range filter.Or {
+				if err := applyFilter(item, or); err != nil && groupErr == nil {
+					groupErr = fmt.Errorf("failed to apply filter for field %s: %w", item.Field, err)
+				}
+			}
+		})
+		return groupErr
+
+	case OpExists:
+		relation := filter.Relation
+		if relation == nil {
+			return fmt.Errorf("exists filter for %s requires a relation", field)
+		}
+
+		from := relation.Table
+		if field != "" && field != relation.Table {
+			from += " " + field
+		}
+
+		ext, err := extended(builder, "exists filter")
+		if err != nil {
+			return err
+		}
+		var groupErr error
+		ext.ExistsWhere(from, relation.ForeignColumn+" = "+relation.LocalColumn, func(sub ConditionBuilder) {
+			for _, item := range filter.Related {
+				if err := applyFilter(item, sub); err != nil && groupErr == nil {
+					groupErr = fmt.Errorf("failed to apply filter for field %s: %w", item.Field, err)
+				}
+			}
+		})
+		return groupErr
+
 	case OpEq:
 		builder.Equal(field, value)
 
@@ -339,13 +925,21 @@ func applyFilter(filter Filter, builder *WhereBuilder) error {
 		builder.GreaterThan(field, value)
 
 	case OpGte:
-		builder.Raw(field+" >= ?", value)
+		ext, err := extended(builder, "gte operator")
+		if err != nil {
+			return err
+		}
+		ext.GreaterThanOrEqual(field, value)
 
 	case OpLt:
 		builder.LessThan(field, value)
 
 	case OpLte:
-		builder.Raw(field+" <= ?", value)
+		ext, err := extended(builder, "lte operator")
+		if err != nil {
+			return err
+		}
+		ext.LessThanOrEqual(field, value)
 
 	case OpLike:
 		if str, ok := value.(string); ok {
@@ -362,6 +956,9 @@ func applyFilter(filter Filter, builder *WhereBuilder) error {
 		}
 
 	case OpContains, OpIncludes:
+		if rv, ok := value.(rangeValue); ok {
+			return applyRangeFilter(filter, rv, builder)
+		}
 		if str, ok := value.(string); ok {
 			builder.ILike(field, SearchPattern(str, "contains"))
 		} else {
@@ -375,6 +972,17 @@ func applyFilter(filter Filter, builder *WhereBuilder) error {
 			return fmt.Errorf("doesNotContain operator requires string value")
 		}
 
+	case OpSimilar:
+		if str, ok := value.(string); ok {
+			ext, err := extended(builder, "similar operator")
+			if err != nil {
+				return err
+			}
+			ext.Similar(field, str)
+		} else {
+			return fmt.Errorf("similar operator requires string value")
+		}
+
 	case OpStartsWith:
 		if str, ok := value.(string); ok {
 			builder.ILike(field, SearchPattern(str, "prefix"))
@@ -418,22 +1026,18 @@ func applyFilter(filter Filter, builder *WhereBuilder) error {
 
 	case OpIn:
 		if vals, ok := value.([]string); ok {
-			interfaces := make([]interface{}, len(vals))
-			for i, v := range vals {
-				interfaces[i] = v
-			}
-			builder.In(field, interfaces)
+			InT(builder, field, vals)
 		} else {
 			return fmt.Errorf("in operator requires array value")
 		}
 
 	case OpNotIn:
 		if vals, ok := value.([]string); ok {
-			interfaces := make([]interface{}, len(vals))
-			for i, v := range vals {
-				interfaces[i] = v
+			ext, err := extended(builder, "notIn operator")
+			if err != nil {
+				return err
 			}
-			builder.Raw("NOT "+field+" IN (?"+strings.Repeat(",?", len(vals)-1)+")", interfaces...)
+			NotInT(ext, field, vals)
 		} else {
 			return fmt.Errorf("notIn operator requires array value")
 		}
@@ -444,6 +1048,16 @@ func applyFilter(filter Filter, builder *WhereBuilder) error {
 	case OpIsNotNull:
 		builder.IsNotNull(field)
 
+	case OpNear, OpWithin:
+		return applyGeoFilter(filter, builder)
+
+	case OpOverlaps:
+		rv, ok := value.(rangeValue)
+		if !ok {
+			return fmt.Errorf("overlaps operator requires a range value")
+		}
+		return applyRangeFilter(filter, rv, builder)
+
 	default:
 		return fmt.Errorf("unsupported operator: %s", filter.Operator)
 	}
@@ -451,6 +1065,19 @@ func applyFilter(filter Filter, builder *WhereBuilder) error {
 	return nil
 }
 
+// newFilterBuilder creates the WhereBuilder that parsed filters are applied
+// to, with the SQL generation options from config
+func newFilterBuilder(dialect Dialect, config *Config) *WhereBuilder {
+	builder := NewWhereBuilder(dialect)
+	if config != nil {
+		builder.QuoteIdentifiers(config.QuoteIdentifiers).
+			ChunkInLists(config.InChunkSize).
+			ArrayIn(config.ArrayInLists).
+			SimilarityThreshold(config.SimilarityThreshold)
+	}
+	return builder
+}
+
 // FromRequest creates a WhereBuilder from HTTP request
 func FromRequest(r *http.Request, dialect Dialect, config *Config) (*WhereBuilder, error) {
 	filters, err := ParseRequest(r, config)
@@ -458,7 +1085,7 @@ func FromRequest(r *http.Request, dialect Dialect, config *Config) (*WhereBuilde
 		return nil, err
 	}
 
-	builder := NewWhereBuilder(dialect)
+	builder := newFilterBuilder(dialect, config)
 	err = ApplyFiltersToBuilder(filters, builder)
 	if err != nil {
 		return nil, err
@@ -474,7 +1101,7 @@ func FromQueryString(queryString string, dialect Dialect, config *Config) (*Wher
 		return nil, err
 	}
 
-	builder := NewWhereBuilder(dialect)
+	builder := newFilterBuilder(dialect, config)
 	err = ApplyFiltersToBuilder(filters, builder)
 	if err != nil {
 		return nil, err
@@ -489,11 +1116,17 @@ func ParseSortFromRequest(r *http.Request, config *Config) (*OrderByBuilder, err
 		config = DefaultConfig()
 	}
 
-	return ParseSortFromValues(r.URL.Query(), config)
+	return ParseSortFromValuesContext(r.Context(), r.URL.Query(), config)
 }
 
 // ParseSortFromValues extracts sorting parameters from url.Values
 func ParseSortFromValues(values url.Values, config *Config) (*OrderByBuilder, error) {
+	return ParseSortFromValuesContext(context.Background(), values, config)
+}
+
+// ParseSortFromValuesContext is like ParseSortFromValues but also enforces
+// per-field role requirements against the roles carried by ctx
+func ParseSortFromValuesContext(ctx context.Context, values url.Values, config *Config) (*OrderByBuilder, error) {
 	if config == nil {
 		config = DefaultConfig()
 	}
@@ -531,7 +1164,7 @@ func ParseSortFromValues(values url.Values, config *Config) (*OrderByBuilder, er
 		}
 	}
 
-	return config.ValidateAndBuild(sortFields)
+	return config.ValidateAndBuildContext(ctx, sortFields)
 }
 
 // FromRequestWithSort parses both filters and sorting from HTTP request
@@ -545,6 +1178,10 @@ func FromRequestWithSort(r *http.Request, dialect Dialect, config *Config) (*Whe
 	if err != nil {
 		return nil, nil, err
 	}
+	orderBy.ForDialect(dialect)
+	if config != nil && config.QuoteIdentifiers {
+		orderBy.QuoteIdentifiers(dialect)
+	}
 
 	return where, orderBy, nil
 }
@@ -1,8 +1,12 @@
 package sqld
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"net/url"
+	"regexp"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -310,6 +314,24 @@ func TestApplyFiltersToBuilder(t *testing.T) {
 			expected: "role IN ($1, $2)",
 			params:   []interface{}{"admin", "user"},
 		},
+		{
+			name: "range filters",
+			filters: []Filter{
+				{Field: "age", Operator: OpGte, Value: 18},
+				{Field: "age", Operator: OpLte, Value: 65},
+			},
+			expected: "age >= $1 AND age <= $2",
+			params:   []interface{}{18, 65},
+		},
+		{
+			name: "not in filter keeps numbering",
+			filters: []Filter{
+				{Field: "role", Operator: OpNotIn, Value: []string{"guest", "banned"}},
+				{Field: "name", Operator: OpEq, Value: "john"},
+			},
+			expected: "role NOT IN ($1, $2) AND name = $3",
+			params:   []interface{}{"guest", "banned", "john"},
+		},
 		{
 			name: "null filter",
 			filters: []Filter{
@@ -516,3 +538,669 @@ func TestComplexQueryFiltering(t *testing.T) {
 	assert.True(t, containsPending, "Should contain 'pending' parameter")
 	assert.True(t, containsDate, "Should contain '2024-01-01' parameter")
 }
+
+func TestFieldRolePermissions(t *testing.T) {
+	config := DefaultConfig().
+		WithAllowedFields(map[string]bool{"name": true, "salary": true}).
+		WithFieldRoles("salary", "admin", "hr")
+
+	values := url.Values{}
+	values.Add("salary[gt]", "100000")
+
+	t.Run("caller without role is rejected", func(t *testing.T) {
+		_, err := ParseURLValuesContext(WithRoles(context.Background(), "user"), values, config)
+		assert.ErrorIs(t, err, ErrPermissionDenied)
+
+		_, err = ParseURLValues(values, config)
+		assert.ErrorIs(t, err, ErrPermissionDenied)
+	})
+
+	t.Run("caller with role is allowed", func(t *testing.T) {
+		filters, err := ParseURLValuesContext(WithRoles(context.Background(), "hr"), values, config)
+		require.NoError(t, err)
+		require.Len(t, filters, 1)
+		assert.Equal(t, "salary", filters[0].Field)
+	})
+
+	t.Run("request context roles are used", func(t *testing.T) {
+		req, err := http.NewRequest("GET", "/users?name=john&salary[gt]=100000", nil)
+		require.NoError(t, err)
+
+		_, err = ParseRequest(req, config)
+		assert.ErrorIs(t, err, ErrPermissionDenied)
+
+		req = req.WithContext(WithRoles(req.Context(), "admin"))
+		filters, err := ParseRequest(req, config)
+		require.NoError(t, err)
+		assert.Len(t, filters, 2)
+	})
+
+	t.Run("unrestricted fields need no roles", func(t *testing.T) {
+		filters, err := ParseQueryString("name=john", config)
+		require.NoError(t, err)
+		assert.Len(t, filters, 1)
+	})
+}
+
+func TestFilterErrors(t *testing.T) {
+	config := DefaultConfig().WithAllowedFields(map[string]bool{
+		"name": true, "age": true, "created_at": true,
+	})
+
+	t.Run("all invalid values are reported with positions", func(t *testing.T) {
+		_, err := ParseQueryString("name=john&age[between]=18&created_at[between]=2024-01-01", config)
+		require.Error(t, err)
+
+		var errs FilterErrors
+		require.True(t, errors.As(err, &errs))
+		require.Len(t, errs, 2)
+
+		assert.Equal(t, "age", errs[0].Field)
+		assert.Equal(t, string(OpBetween), errs[0].Operator)
+		assert.Equal(t, "18", errs[0].Value)
+		assert.Equal(t, 1, errs[0].Position)
+		assert.Contains(t, errs[0].Reason, "exactly 2")
+
+		assert.Equal(t, "created_at", errs[1].Field)
+		assert.Equal(t, 2, errs[1].Position)
+	})
+
+	t.Run("url values report unknown position", func(t *testing.T) {
+		values := url.Values{}
+		values.Add("age[between]", "18")
+
+		_, err := ParseURLValues(values, config)
+
+		var filterErr *FilterError
+		require.True(t, errors.As(err, &filterErr))
+		assert.Equal(t, -1, filterErr.Position)
+	})
+
+	t.Run("lenient mode skips unknown fields", func(t *testing.T) {
+		filters, err := ParseQueryString("nmae=john", config)
+		require.NoError(t, err)
+		assert.Empty(t, filters)
+	})
+
+	t.Run("strict mode rejects unknown fields", func(t *testing.T) {
+		strict := DefaultConfig().
+			WithAllowedFields(map[string]bool{"name": true}).
+			WithStrictFields(true)
+
+		_, err := ParseQueryString("nmae=john", strict)
+
+		var errs FilterErrors
+		require.True(t, errors.As(err, &errs))
+		require.Len(t, errs, 1)
+		assert.Equal(t, "nmae", errs[0].Field)
+		assert.Equal(t, "unknown field", errs[0].Reason)
+		assert.Equal(t, 0, errs[0].Position)
+	})
+
+	t.Run("strict mode ignores reserved parameters", func(t *testing.T) {
+		strict := DefaultConfig().
+			WithAllowedFields(map[string]bool{"name": true}).
+			WithStrictFields(true).
+			WithReservedParams("fields")
+
+		filters, err := ParseQueryString("name=john&sort=-name&sort_age=asc&limit=10&cursor=abc&fields=id", strict)
+		require.NoError(t, err)
+		assert.Len(t, filters, 1)
+	})
+
+	t.Run("permission errors remain matchable", func(t *testing.T) {
+		restricted := DefaultConfig().
+			WithAllowedFields(map[string]bool{"salary": true}).
+			WithFieldRoles("salary", "admin")
+
+		_, err := ParseQueryString("salary[gt]=1", restricted)
+		assert.ErrorIs(t, err, ErrPermissionDenied)
+	})
+}
+
+func TestStrictModes(t *testing.T) {
+	allowed := map[string]bool{"name": true}
+
+	t.Run("lenient operators fall back to the default operator", func(t *testing.T) {
+		filters, err := ParseQueryString("name[contians]=john", DefaultConfig().WithAllowedFields(allowed))
+		require.NoError(t, err)
+		require.Len(t, filters, 1)
+		assert.Equal(t, OpEq, filters[0].Operator)
+	})
+
+	t.Run("strict operators reject unknown operators", func(t *testing.T) {
+		config := DefaultConfig().WithAllowedFields(allowed).WithStrictOperators(true)
+
+		_, err := ParseQueryString("name[contians]=john", config)
+
+		var validationErr *ValidationError
+		require.True(t, errors.As(err, &validationErr))
+		assert.Equal(t, "name[contians]", validationErr.Field)
+		assert.Equal(t, "john", validationErr.Value)
+
+		var filterErr *FilterError
+		require.True(t, errors.As(err, &filterErr))
+		assert.Equal(t, "contians", filterErr.Operator)
+		assert.Equal(t, "unknown operator", filterErr.Reason)
+	})
+
+	t.Run("strict operators accept known operators", func(t *testing.T) {
+		config := DefaultConfig().WithAllowedFields(allowed).WithStrictOperators(true)
+
+		filters, err := ParseQueryString("name[contains]=john&name_sw=jo", config)
+		require.NoError(t, err)
+		assert.Len(t, filters, 2)
+	})
+
+	t.Run("strict fields report the offending key", func(t *testing.T) {
+		config := DefaultConfig().WithAllowedFields(allowed).WithStrictFields(true)
+
+		values := url.Values{}
+		values.Add("nmae[contains]", "john")
+		_, err := ParseURLValues(values, config)
+
+		var validationErr *ValidationError
+		require.True(t, errors.As(err, &validationErr))
+		assert.Equal(t, "nmae[contains]", validationErr.Field)
+	})
+}
+
+func TestOrGroups(t *testing.T) {
+	config := DefaultConfig().WithAllowedFields(map[string]bool{
+		"name": true, "email": true, "status": true,
+	})
+
+	t.Run("parses and applies an or group", func(t *testing.T) {
+		filters, err := ParseQueryString("or=(name[contains]=john,email[contains]=john)&status=active", config)
+		require.NoError(t, err)
+		require.Len(t, filters, 2)
+		assert.Equal(t, OpOr, filters[0].Operator)
+		assert.Len(t, filters[0].Or, 2)
+
+		builder := NewWhereBuilder(Postgres)
+		require.NoError(t, ApplyFiltersToBuilder(filters, builder))
+
+		sql, params := builder.Build()
+		assert.Equal(t, "(name ILIKE $1 OR email ILIKE $2) AND status = $3", sql)
+		assert.Equal(t, []interface{}{"%john%", "%john%", "active"}, params)
+	})
+
+	t.Run("list values keep their commas", func(t *testing.T) {
+		filters, err := ParseQueryString("or=(status[in]=active,pending,name[eq]=john)", config)
+		require.NoError(t, err)
+		require.Len(t, filters, 1)
+		require.Len(t, filters[0].Or, 2)
+		assert.Equal(t, []string{"active", "pending"}, filters[0].Or[0].Value)
+	})
+
+	t.Run("disallowed fields are dropped from the group", func(t *testing.T) {
+		filters, err := ParseQueryString("or=(secret=x,name=john)", config)
+		require.NoError(t, err)
+		require.Len(t, filters, 1)
+		require.Len(t, filters[0].Or, 1)
+		assert.Equal(t, "name", filters[0].Or[0].Field)
+	})
+
+	t.Run("groups are checked in strict mode", func(t *testing.T) {
+		strict := DefaultConfig().WithAllowedFields(map[string]bool{"name": true}).WithStrictFields(true)
+
+		_, err := ParseQueryString("or=(name=john,secret=x)", strict)
+
+		var filterErrs FilterErrors
+		require.True(t, errors.As(err, &filterErrs))
+		require.Len(t, filterErrs, 1)
+		assert.Equal(t, "secret", filterErrs[0].Field)
+	})
+
+	t.Run("malformed groups are rejected", func(t *testing.T) {
+		_, err := ParseQueryString("or=name=john", config)
+		assert.Error(t, err)
+
+		_, err = ParseQueryString("or=(john)", config)
+		assert.Error(t, err)
+	})
+
+	t.Run("each item counts toward MaxFilters", func(t *testing.T) {
+		limited := DefaultConfig().WithAllowedFields(map[string]bool{"name": true, "email": true, "status": true}).WithMaxFilters(2)
+
+		_, err := ParseQueryString("or=(name=a,email=b)", limited)
+		assert.NoError(t, err)
+
+		_, err = ParseQueryString("or=(name=a,email=b,status=c)", limited)
+		assert.ErrorContains(t, err, "too many filters")
+
+		_, err = ParseQueryString("or=(name=a,email=b)&status=c", limited)
+		assert.ErrorContains(t, err, "too many filters")
+
+		_, err = ParseURLValues(url.Values{"or": {"(name=a,email=b,status=c)"}}, limited)
+		assert.ErrorContains(t, err, "too many filters")
+	})
+
+	t.Run("escaped commas and equals signs are literal", func(t *testing.T) {
+		filters, err := ParseURLValues(url.Values{"or": {`(name=doe\, jane\=x=1,email=a\\b)`}}, config)
+		require.NoError(t, err)
+		require.Len(t, filters, 1)
+		require.Len(t, filters[0].Or, 2)
+		assert.Equal(t, "doe, jane=x=1", filters[0].Or[0].Value)
+		assert.Equal(t, `a\b`, filters[0].Or[1].Value)
+	})
+}
+
+func TestSearchParam(t *testing.T) {
+	config := DefaultConfig().
+		WithAllowedFields(map[string]bool{"status": true}).
+		WithSearchParam("q", []string{"name", "email", "company"})
+
+	t.Run("builds an or group over the search columns", func(t *testing.T) {
+		filters, err := ParseQueryString("q=acme&status=active", config)
+		require.NoError(t, err)
+		require.Len(t, filters, 2)
+
+		builder := NewWhereBuilder(Postgres)
+		require.NoError(t, ApplyFiltersToBuilder(filters, builder))
+
+		sql, params := builder.Build()
+		assert.Equal(t, "(name ILIKE $1 OR email ILIKE $2 OR company ILIKE $3) AND status = $4", sql)
+		assert.Equal(t, []interface{}{"%acme%", "%acme%", "%acme%", "active"}, params)
+	})
+
+	t.Run("empty search is ignored", func(t *testing.T) {
+		filters, err := ParseQueryString("q=", config)
+		require.NoError(t, err)
+		assert.Empty(t, filters)
+	})
+
+	t.Run("search is not rejected in strict mode", func(t *testing.T) {
+		strict := DefaultConfig().
+			WithAllowedFields(map[string]bool{"status": true}).
+			WithStrictFields(true).
+			WithSearchParam("q", []string{"name"})
+
+		filters, err := ParseQueryString("q=acme", strict)
+		require.NoError(t, err)
+		assert.Len(t, filters, 1)
+	})
+
+	t.Run("restricted columns are left out", func(t *testing.T) {
+		restricted := DefaultConfig().
+			WithSearchParam("q", []string{"name", "salary"}).
+			WithFieldRoles("salary", "admin")
+
+		filters, err := ParseQueryString("q=100", restricted)
+		require.NoError(t, err)
+		require.Len(t, filters, 1)
+		require.Len(t, filters[0].Or, 1)
+		assert.Equal(t, "name", filters[0].Or[0].Field)
+	})
+}
+
+func TestRelationFilters(t *testing.T) {
+	config := DefaultConfig().
+		WithAllowedFields(map[string]bool{
+			"status": true, "orders.total": true, "orders.status": true, "items.sku": true,
+		}).
+		WithRelation("orders", Relation{Table: "orders", LocalColumn: "users.id", ForeignColumn: "orders.user_id"}).
+		WithRelation("items", Relation{Table: "order_items", LocalColumn: "users.id", ForeignColumn: "items.user_id"})
+
+	t.Run("conditions on one relation share an exists subquery", func(t *testing.T) {
+		builder, err := FromQueryString("status=active&orders.total[gt]=100&orders.status=paid", Postgres, config)
+		require.NoError(t, err)
+
+		sql, params := builder.Build()
+		assert.Equal(t, "status = $1 AND EXISTS (SELECT 1 FROM orders WHERE orders.user_id = users.id AND orders.total > $2 AND orders.status = $3)", sql)
+		assert.Equal(t, []interface{}{"active", 100, "paid"}, params)
+	})
+
+	t.Run("relation name is used as alias", func(t *testing.T) {
+		builder, err := FromQueryString("items.sku=A1", MySQL, config)
+		require.NoError(t, err)
+
+		sql, params := builder.Build()
+		assert.Equal(t, "EXISTS (SELECT 1 FROM order_items items WHERE items.user_id = users.id AND items.sku = ?)", sql)
+		assert.Equal(t, []interface{}{"A1"}, params)
+	})
+
+	t.Run("relation filters inside or groups", func(t *testing.T) {
+		builder, err := FromQueryString("or=(status=vip,orders.total[gte]=1000)", Postgres, config)
+		require.NoError(t, err)
+
+		sql, params := builder.Build()
+		assert.Equal(t, "(status = $1 OR EXISTS (SELECT 1 FROM orders WHERE orders.user_id = users.id AND orders.total >= $2))", sql)
+		assert.Equal(t, []interface{}{"vip", 1000}, params)
+	})
+
+	t.Run("related fields must be allowed", func(t *testing.T) {
+		filters, err := ParseQueryString("orders.secret=x", config)
+		require.NoError(t, err)
+		assert.Empty(t, filters)
+	})
+}
+
+func TestDBColumnOverrides(t *testing.T) {
+	config := DefaultConfig().
+		WithAllowedFields(map[string]bool{"name": true, "org_name": true}).
+		WithFieldMappings(map[string]string{"org": "org_name"}).
+		WithDBColumn("name", "u.name").
+		WithDBColumn("org_name", "o.name")
+
+	builder, err := FromQueryString("name[contains]=jo&org=acme", Postgres, config)
+	require.NoError(t, err)
+
+	sql, params := builder.Build()
+	assert.Equal(t, "u.name ILIKE $1 AND o.name = $2", sql)
+	assert.Equal(t, []interface{}{"%jo%", "acme"}, params)
+}
+
+func TestFromRequestWithSort_QuoteIdentifiers(t *testing.T) {
+	config := DefaultConfig().
+		WithAllowedFields(map[string]bool{"order": true, "group": true}).
+		WithQuoteIdentifiers(true)
+
+	req, err := http.NewRequest("GET", "/items?order[gt]=5&sort=-group", nil)
+	require.NoError(t, err)
+
+	where, orderBy, err := FromRequestWithSort(req, Postgres, config)
+	require.NoError(t, err)
+
+	sql, params := where.Build()
+	assert.Equal(t, `"order" > $1`, sql)
+	assert.Equal(t, []interface{}{5}, params)
+	assert.Equal(t, `"group" DESC`, orderBy.Build())
+}
+
+func TestInListConfig(t *testing.T) {
+	config := DefaultConfig().
+		WithAllowedFields(map[string]bool{"status": true}).
+		WithInChunkSize(2)
+
+	builder, err := FromQueryString("status[in]=a,b,c", MySQL, config)
+	require.NoError(t, err)
+	sql, _ := builder.Build()
+	assert.Equal(t, "(status IN (?, ?) OR status IN (?))", sql)
+
+	builder, err = FromQueryString("status[in]=a,b,c", Postgres, config)
+	require.NoError(t, err)
+	sql, _ = builder.Build()
+	assert.Equal(t, "(status IN ($1, $2) OR status IN ($3))", sql)
+
+	builder, err = FromQueryString("status[in]=a,b,c", Postgres, config.WithArrayInLists(true))
+	require.NoError(t, err)
+	sql, params := builder.Build()
+	assert.Equal(t, "status = ANY($1)", sql)
+	assert.Equal(t, []interface{}{[]string{"a", "b", "c"}}, params)
+}
+
+func TestExpressionFields(t *testing.T) {
+	config := DefaultConfig().
+		WithAllowedFields(map[string]bool{"full_name": true, "order_total": true, "bad": true}).
+		WithExpression("full_name", "first_name || ' ' || last_name").
+		WithExpression("order_total", "price * quantity").
+		WithExpression("bad", "price = ?")
+
+	t.Run("expressions are used in conditions", func(t *testing.T) {
+		builder, err := FromQueryString("full_name[contains]=ann&order_total[gte]=100", Postgres, config)
+		require.NoError(t, err)
+
+		sql, params := builder.Build()
+		assert.Equal(t, "(first_name || ' ' || last_name) ILIKE $1 AND (price * quantity) >= $2", sql)
+		assert.Equal(t, []interface{}{"%ann%", 100}, params)
+	})
+
+	t.Run("unsafe expressions are rejected", func(t *testing.T) {
+		_, err := ParseQueryString("bad=1", config)
+
+		var filterErr *FilterError
+		require.True(t, errors.As(err, &filterErr))
+		assert.Equal(t, "invalid field expression", filterErr.Reason)
+	})
+}
+
+func TestLeadingWildcardRestriction(t *testing.T) {
+	config := DefaultConfig().
+		WithAllowedFields(map[string]bool{"email": true, "name": true}).
+		WithoutLeadingWildcards("email")
+
+	for _, query := range []string{"email[contains]=acme", "email[endsWith]=acme.com", "email[like]=%25acme", "or=(name=ann,email[contains]=acme)"} {
+		t.Run(query, func(t *testing.T) {
+			_, err := ParseQueryString(query, config)
+
+			var filterErr *FilterError
+			require.True(t, errors.As(err, &filterErr))
+			assert.Equal(t, "email", filterErr.Field)
+
+			var validationErr *ValidationError
+			require.True(t, errors.As(err, &validationErr))
+			assert.Contains(t, validationErr.Message, "use startsWith instead")
+		})
+	}
+
+	t.Run("prefix patterns and other fields are allowed", func(t *testing.T) {
+		filters, err := ParseQueryString("email[startsWith]=ann&email[like]=ann%25&name[contains]=ann", config)
+		require.NoError(t, err)
+		assert.Len(t, filters, 3)
+	})
+	t.Run("schema does not advertise the operators", func(t *testing.T) {
+		for _, field := range GenerateSchema(config).Fields {
+			if field.Name == "email" {
+				assert.NotContains(t, field.Operators, "contains")
+				assert.NotContains(t, field.Operators, "endswith")
+				assert.Contains(t, field.Operators, "startswith")
+			}
+		}
+	})
+}
+
+func TestFieldValues(t *testing.T) {
+	config := DefaultConfig().
+		WithAllowedFields(map[string]bool{"status": true, "name": true}).
+		WithFieldValues("status", "active", "pending", "banned")
+
+	t.Run("allowed values", func(t *testing.T) {
+		filters, err := ParseQueryString("status=active&status[in]=pending,banned&status[ne]=banned&name=junk", config)
+		require.NoError(t, err)
+		assert.Len(t, filters, 4)
+	})
+
+	for _, query := range []string{"status=junk", "status[in]=active,junk", "status[notIn]=junk", "or=(name=ann,status=junk)"} {
+		t.Run(query, func(t *testing.T) {
+			_, err := ParseQueryString(query, config)
+
+			var filterErr *FilterError
+			require.True(t, errors.As(err, &filterErr))
+			assert.Equal(t, "status", filterErr.Field)
+			assert.Equal(t, "value not allowed", filterErr.Reason)
+
+			var validationErr *ValidationError
+			require.True(t, errors.As(err, &validationErr))
+			assert.Equal(t, "junk", validationErr.Value)
+		})
+	}
+
+	t.Run("schema advertises the enum", func(t *testing.T) {
+		for _, field := range GenerateSchema(config).Fields {
+			if field.Name == "status" {
+				assert.Equal(t, []string{"active", "pending", "banned"}, field.Enum)
+			} else {
+				assert.Nil(t, field.Enum)
+			}
+		}
+	})
+}
+
+func TestValueConstraints(t *testing.T) {
+	config := DefaultConfig().
+		WithAllowedFields(map[string]bool{"name": true, "account_id": true, "age": true}).
+		WithMaxLength("name", 5).
+		WithPattern("account_id", regexp.MustCompile(`^[0-9a-f]{8}$`)).
+		WithRange("age", 0, 150)
+
+	t.Run("valid values", func(t *testing.T) {
+		filters, err := ParseQueryString("name=ann&name[contains]=jo&account_id[in]=deadbeef,0badf00d&age[between]=18,65&account_id[startsWith]=dead", config)
+		require.NoError(t, err)
+		assert.Len(t, filters, 5)
+	})
+
+	tests := []struct {
+		query  string
+		field  string
+		reason string
+	}{
+		{"name=annabelle", "name", "value longer than 5 characters"},
+		{"name[contains]=annabelle", "name", "value longer than 5 characters"},
+		{"account_id=not-a-hex", "account_id", "value does not match the expected format"},
+		{"account_id[in]=deadbeef,nope", "account_id", "value does not match the expected format"},
+		{"age[gt]=-5", "age", "value below the minimum of 0"},
+		{"age[between]=18,200", "age", "value above the maximum of 150"},
+		{"age=old", "age", "value is not a number"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			_, err := ParseQueryString(tt.query, config)
+
+			var filterErr *FilterError
+			require.True(t, errors.As(err, &filterErr))
+			assert.Equal(t, tt.field, filterErr.Field)
+			assert.Equal(t, tt.reason, filterErr.Reason)
+
+			var validationErr *ValidationError
+			assert.True(t, errors.As(err, &validationErr))
+		})
+	}
+
+	t.Run("oversized values are truncated in errors", func(t *testing.T) {
+		_, err := ParseURLValues(map[string][]string{"name": {strings.Repeat("x", 10000)}}, config)
+
+		var filterErr *FilterError
+		require.True(t, errors.As(err, &filterErr))
+		assert.Len(t, filterErr.Value, maxReportedValue+len("..."))
+	})
+
+	t.Run("schema advertises the constraints", func(t *testing.T) {
+		for _, field := range GenerateSchema(config).Fields {
+			switch field.Name {
+			case "name":
+				assert.Equal(t, 5, field.MaxLength)
+			case "account_id":
+				assert.Equal(t, "^[0-9a-f]{8}$", field.Pattern)
+			case "age":
+				require.NotNil(t, field.Minimum)
+				require.NotNil(t, field.Maximum)
+				assert.Equal(t, 0.0, *field.Minimum)
+				assert.Equal(t, 150.0, *field.Maximum)
+			}
+		}
+	})
+}
+
+func TestSimilarOperator(t *testing.T) {
+	config := DefaultConfig().
+		WithAllowedFields(map[string]bool{"name": true}).
+		WithPattern("name", regexp.MustCompile(`^[A-Z]`)).
+		WithSimilarityThreshold(0.5)
+
+	where, err := FromQueryString("name[similar]=jon", Postgres, config)
+	require.NoError(t, err)
+
+	sql, params := where.Build()
+	assert.Equal(t, "similarity(name, $1) >= $2", sql)
+	assert.Equal(t, []interface{}{"jon", 0.5}, params)
+}
+
+func TestCaseInsensitiveFields(t *testing.T) {
+	config := DefaultConfig().
+		WithAllowedFields(map[string]bool{"name": true, "created_at": true, "full_name": true}).
+		WithFieldMappings(map[string]string{"fullName": "full_name"}).
+		WithCaseInsensitiveFields(true)
+
+	t.Run("filters", func(t *testing.T) {
+		filters, err := ParseQueryString("Name=john&CREATED_AT[after]=2024-01-01&FULLNAME[startsWith]=J", config)
+		require.NoError(t, err)
+		require.Len(t, filters, 3)
+		assert.Equal(t, "name", filters[0].Field)
+		assert.Equal(t, "created_at", filters[1].Field)
+		assert.Equal(t, OpAfter, filters[1].Operator)
+		assert.Equal(t, "full_name", filters[2].Field)
+	})
+
+	t.Run("sorting", func(t *testing.T) {
+		builder, err := config.ValidateAndBuild([]SortField{{Field: "Created_At", Direction: SortDesc}})
+		require.NoError(t, err)
+		assert.Equal(t, "created_at DESC", builder.Build())
+	})
+
+	t.Run("field selection", func(t *testing.T) {
+		fields, err := bindFields(context.Background(), url.Values{FieldsParam: {"NAME,Created_At"}}, config)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"name", "created_at"}, fields)
+	})
+
+	t.Run("disabled by default", func(t *testing.T) {
+		config := DefaultConfig().WithAllowedFields(map[string]bool{"name": true})
+		filters, err := ParseQueryString("Name=john", config)
+		require.NoError(t, err)
+		assert.Empty(t, filters)
+
+		_, err = config.ValidateAndBuild([]SortField{{Field: "NAME"}})
+		assert.Error(t, err)
+	})
+}
+
+func TestSnakeCase(t *testing.T) {
+	tests := map[string]string{
+		"ID":                  "id",
+		"UserID":              "user_id",
+		"CreatedAt":           "created_at",
+		"HTTPCode":            "http_code",
+		"Name":                "name",
+		"createdAt":           "created_at",
+		"userName":            "user_name",
+		"profile.displayName": "profile.display_name",
+		"already_snake":       "already_snake",
+	}
+	for in, expected := range tests {
+		assert.Equal(t, expected, SnakeCase(in), in)
+	}
+}
+
+func TestSnakeCaseFields(t *testing.T) {
+	config := DefaultConfig().
+		WithAllowedFields(map[string]bool{"created_at": true, "user_name": true, "profile.display_name": true, "email": true}).
+		WithFieldMappings(map[string]string{"mail": "email"}).
+		WithSnakeCaseFields(true)
+
+	t.Run("filters", func(t *testing.T) {
+		filters, err := ParseQueryString("createdAt[after]=2024-01-01&userName=ann&profile.displayName[startsWith]=A&mail=a@example.com", config)
+		require.NoError(t, err)
+		require.Len(t, filters, 4)
+		assert.Equal(t, "created_at", filters[0].Field)
+		assert.Equal(t, "user_name", filters[1].Field)
+		assert.Equal(t, "profile.display_name", filters[2].Field)
+		assert.Equal(t, "email", filters[3].Field)
+	})
+
+	t.Run("sorting", func(t *testing.T) {
+		builder, err := config.ValidateAndBuild([]SortField{{Field: "createdAt", Direction: SortDesc}})
+		require.NoError(t, err)
+		assert.Equal(t, "created_at DESC", builder.Build())
+	})
+
+	t.Run("all fields allowed", func(t *testing.T) {
+		filters, err := ParseQueryString("createdAt[after]=2024-01-01", DefaultConfig().WithSnakeCaseFields(true))
+		require.NoError(t, err)
+		require.Len(t, filters, 1)
+		assert.Equal(t, "created_at", filters[0].Field)
+	})
+
+	t.Run("combines with case-insensitive matching", func(t *testing.T) {
+		config := DefaultConfig().
+			WithAllowedFields(map[string]bool{"created_at": true}).
+			WithSnakeCaseFields(true).
+			WithCaseInsensitiveFields(true)
+
+		filters, err := ParseQueryString("CreatedAT[after]=2024-01-01", config)
+		require.NoError(t, err)
+		require.Len(t, filters, 1)
+		assert.Equal(t, "created_at", filters[0].Field)
+	})
+}
@@ -0,0 +1,141 @@
+package sqld
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// rangeElementTypes maps the built-in PostgreSQL range types, which double
+// as field types, to the type of their elements
+var rangeElementTypes = map[string]string{
+	"int4range": "integer",
+	"int8range": "bigint",
+	"numrange":  "numeric",
+	"tsrange":   "timestamp",
+	"tstzrange": "timestamptz",
+	"daterange": "date",
+}
+
+// rangeValue is the parsed value of an overlaps or contains filter on a
+// range field: two bounds, either of which may be nil for unbounded, or a
+// single element for contains
+type rangeValue struct {
+	rangeType string
+	values    []interface{}
+}
+
+// parseRangeValue checks a filter involving a range field or the overlaps
+// operator and returns its parsed value, or a rejection reason and an
+// error. It returns a nil value for null checks, which need none.
+func parseRangeValue(rangeType string, op Operator, value string) (interface{}, string, error) {
+	if rangeType == "" {
+		return nil, "operator not supported", fmt.Errorf("operator %s requires a range field", op)
+	}
+	switch op {
+	case OpIsNull, OpIsNotNull:
+		return nil, "", nil
+	case OpOverlaps, OpContains:
+	default:
+		return nil, "operator not supported", fmt.Errorf("operator %s not supported for %s fields", op, rangeType)
+	}
+
+	parts := strings.Split(value, ",")
+	if len(parts) > 2 || (op == OpOverlaps && len(parts) != 2) {
+		return nil, "invalid range", fmt.Errorf("%s requires lower,upper bounds", op)
+	}
+
+	values := make([]interface{}, len(parts))
+	for i, part := range parts {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			if len(parts) == 1 {
+				return nil, "invalid range", fmt.Errorf("%s requires a value", op)
+			}
+			continue // unbounded
+		}
+		v, err := parseRangeElement(rangeType, part)
+		if err != nil {
+			return nil, "invalid range", err
+		}
+		values[i] = v
+	}
+	return rangeValue{rangeType: rangeType, values: values}, "", nil
+}
+
+// parseRangeElement converts a bound of a numeric range to a number; other
+// bounds are passed to the database as strings
+func parseRangeElement(rangeType, s string) (interface{}, error) {
+	switch rangeType {
+	case "int4range", "int8range":
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not an integer", s)
+		}
+		return n, nil
+	case "numrange":
+		n, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a number", s)
+		}
+		return n, nil
+	default:
+		return s, nil
+	}
+}
+
+// applyRangeFilter adds the condition for an overlaps or contains filter on
+// a range field
+func applyRangeFilter(filter Filter, value rangeValue, builder ConditionBuilder) error {
+	elementType, ok := rangeElementTypes[value.rangeType]
+	if !ok {
+		return fmt.Errorf("unsupported range type %s", value.rangeType)
+	}
+
+	operator := "@>"
+	if filter.Operator == OpOverlaps {
+		operator = "&&"
+	}
+
+	switch len(value.values) {
+	case 1:
+		builder.Raw(filter.Field+" "+operator+" ?::"+elementType, value.values[0])
+	case 2:
+		builder.Raw(filter.Field+" "+operator+" "+value.rangeType+"(?, ?)", value.values[0], value.values[1])
+	default:
+		return fmt.Errorf("%s operator requires one or two values", filter.Operator)
+	}
+	return nil
+}
+
+// rangeValueJSON is the JSON form of a rangeValue
+type rangeValueJSON struct {
+	Range  string        `json:"range"`
+	Values []interface{} `json:"values"`
+}
+
+// MarshalJSON implements json.Marshaler, so filters on range fields
+// survive a FilterNode round trip
+func (v rangeValue) MarshalJSON() ([]byte, error) {
+	return json.Marshal(rangeValueJSON{Range: v.rangeType, Values: v.values})
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (v *rangeValue) UnmarshalJSON(data []byte) error {
+	var raw rangeValueJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if rangeElementTypes[raw.Range] == "" {
+		return fmt.Errorf("unsupported range type %q", raw.Range)
+	}
+	for i, value := range raw.Values {
+		// JSON numbers decode as float64; integer ranges bind integers
+		if n, ok := value.(float64); ok && (raw.Range == "int4range" || raw.Range == "int8range") {
+			raw.Values[i] = int64(n)
+		}
+	}
+	*v = rangeValue{rangeType: raw.Range, values: raw.Values}
+	return nil
+}
@@ -0,0 +1,107 @@
+package sqld
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRangeFields(t *testing.T) {
+	config := DefaultConfig().
+		WithAllowedFields(map[string]bool{"booked": true, "floors": true, "name": true}).
+		WithFieldType("booked", "tstzrange").
+		WithFieldType("floors", "int4range")
+
+	tests := []struct {
+		name   string
+		query  string
+		sql    string
+		params []interface{}
+	}{
+		{
+			name:   "overlaps",
+			query:  "booked[overlaps]=2024-01-01,2024-01-08",
+			sql:    "booked && tstzrange($1, $2)",
+			params: []interface{}{"2024-01-01", "2024-01-08"},
+		},
+		{
+			name:   "unbounded",
+			query:  "booked[overlaps]=2024-01-01,",
+			sql:    "booked && tstzrange($1, $2)",
+			params: []interface{}{"2024-01-01", nil},
+		},
+		{
+			name:   "contains element",
+			query:  "booked[contains]=2024-01-03T10:00:00Z",
+			sql:    "booked @> $1::timestamptz",
+			params: []interface{}{"2024-01-03T10:00:00Z"},
+		},
+		{
+			name:   "contains range",
+			query:  "floors[contains]=2,5",
+			sql:    "floors @> int4range($1, $2)",
+			params: []interface{}{int64(2), int64(5)},
+		},
+		{
+			name:   "other fields keep contains",
+			query:  "name[contains]=ann",
+			sql:    "name ILIKE $1",
+			params: []interface{}{"%ann%"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			where, err := FromQueryString(tt.query, Postgres, config)
+			require.NoError(t, err)
+
+			sql, params := where.Build()
+			assert.Equal(t, tt.sql, sql)
+			assert.Equal(t, tt.params, params)
+		})
+	}
+
+	rejected := []struct {
+		query  string
+		field  string
+		reason string
+	}{
+		{"booked[overlaps]=2024-01-01", "booked", "invalid range"},
+		{"floors[contains]=two", "floors", "invalid range"},
+		{"booked=2024-01-01", "booked", "operator not supported"},
+		{"name[overlaps]=a,b", "name", "operator not supported"},
+	}
+
+	for _, tt := range rejected {
+		t.Run(tt.query, func(t *testing.T) {
+			_, err := ParseQueryString(tt.query, config)
+
+			var filterErr *FilterError
+			require.True(t, errors.As(err, &filterErr))
+			assert.Equal(t, tt.field, filterErr.Field)
+			assert.Equal(t, tt.reason, filterErr.Reason)
+		})
+	}
+
+	t.Run("contains is not a leading wildcard", func(t *testing.T) {
+		config := DefaultConfig().
+			WithAllowedFields(map[string]bool{"booked": true}).
+			WithFieldType("booked", "tstzrange").
+			WithoutLeadingWildcards("booked").
+			WithBudget(QueryBudget{MaxLeadingWildcards: 1})
+
+		_, err := ParseQueryString("booked[contains]=2024-01-03&booked[overlaps]=2024-01-01,2024-01-08", config)
+		assert.NoError(t, err)
+	})
+
+	t.Run("schema", func(t *testing.T) {
+		for _, field := range GenerateSchema(config).Fields {
+			if field.Name == "booked" {
+				assert.Equal(t, "tstzrange", field.Type)
+				assert.Equal(t, []string{"overlaps", "contains", "isnull", "isnotnull"}, field.Operators)
+			}
+		}
+	})
+}
@@ -0,0 +1,115 @@
+package sqld
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+)
+
+// RawQuery runs custom SQL the builders cannot express, such as recursive
+// CTEs or window functions, and scans the rows into T. Unlike running the
+// SQL on the database directly, it keeps the executor's guardrails: the
+// query is checked with AuditRawQuery, runs through the middleware
+// registered with Queries.Use, is scanned by the executor's
+// ReflectionScanner and row transformers, and fails with the usual
+// QueryErrors.
+//
+// Values must be passed as parameters. When the executor has mandatory
+// conditions, such as a tenant scope, policies or soft-delete filtering,
+// the query needs a /* sqld:where */ annotation to receive them, as with
+// QueryAll; other annotations are expanded too.
+//
+// Example:
+//
+//	users, err := userExec.RawQuery(ctx, `
+//		WITH RECURSIVE team AS (
+//			SELECT id, name, manager_id FROM users WHERE id = $1
+//			UNION ALL
+//			SELECT u.id, u.name, u.manager_id FROM users u JOIN team t ON u.manager_id = t.id
+//		)
+//		SELECT id, name FROM team`, managerID)
+func (e *Executor[T]) RawQuery(ctx context.Context, query string, params ...interface{}) ([]T, error) {
+	query, params, err := e.rawQuery(ctx, query, params)
+	if err != nil {
+		return nil, err
+	}
+	items, _, err := e.rowScanner().scanAll(ctx, e.queries.conn(), e.queryOptions(), query, params...)
+	if err != nil {
+		return nil, err
+	}
+	if err := e.transformRows(ctx, items); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// RawQueryOne is RawQuery for a single row. It returns ErrNoRows when the
+// query returns no rows.
+func (e *Executor[T]) RawQueryOne(ctx context.Context, query string, params ...interface{}) (T, error) {
+	var zero T
+	query, params, err := e.rawQuery(ctx, query, params)
+	if err != nil {
+		return zero, err
+	}
+	item, err := e.rowScanner().scanOne(ctx, e.queries.conn(), e.queryOptions(), query, params...)
+	if err != nil {
+		return zero, err
+	}
+	return e.transformRow(ctx, item)
+}
+
+// rawQuery audits a raw query and adds the executor's mandatory conditions
+func (e *Executor[T]) rawQuery(ctx context.Context, query string, params []interface{}) (string, []interface{}, error) {
+	if err := AuditRawQuery(query, e.queries.dialect, params...); err != nil {
+		return "", nil, err
+	}
+	where, err := e.scopedWhere(ctx, query, nil)
+	if err != nil {
+		return "", nil, err
+	}
+	return e.queryOptions().processor.ProcessQuery(query, where, nil, nil, 0, params...)
+}
+
+// AuditRawQuery checks custom SQL before it is run: it must pass
+// ValidateQuery, bind its values through placeholders of the dialect
+// rather than named sqlc parameters, use exactly the parameters given (for
+// numbered placeholders, each of $1 to $n), and every parameter must pass
+// ValidateValue. A mismatch between placeholders and parameters usually
+// means a value was formatted into the SQL text instead of bound.
+func AuditRawQuery(query string, dialect Dialect, params ...interface{}) error {
+	if err := ValidateQuery(query, dialect); err != nil {
+		return err
+	}
+
+	used := requiredParams(query)
+	numbered := dialect.Capabilities().NumberedPlaceholders
+	for _, name := range used {
+		if name[0] != '$' && name[0] != '?' {
+			return fmt.Errorf("%w: raw queries take placeholders, not the named parameter %q", ErrInvalidQuery, name)
+		}
+		if (name[0] == '$') != numbered {
+			return fmt.Errorf("%w: placeholder %q does not match the %s dialect", ErrInvalidQuery, name, dialect)
+		}
+	}
+	if len(used) != len(params) {
+		return fmt.Errorf("%w: query uses %d placeholders but %d parameters were given", ErrInvalidQuery, len(used), len(params))
+	}
+	if numbered {
+		seen := make(map[string]bool, len(used))
+		for _, name := range used {
+			seen[name] = true
+		}
+		for i := 1; i <= len(params); i++ {
+			if !seen["$"+strconv.Itoa(i)] {
+				return fmt.Errorf("%w: parameter $%d is not used by the query", ErrInvalidQuery, i)
+			}
+		}
+	}
+
+	for i, param := range params {
+		if err := ValidateValue(param); err != nil {
+			return fmt.Errorf("parameter %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
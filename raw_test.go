@@ -0,0 +1,108 @@
+package sqld
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditRawQuery(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		dialect Dialect
+		params  []interface{}
+		wantErr bool
+	}{
+		{"numbered", "SELECT * FROM users WHERE id = $1 AND name = $2", Postgres, []interface{}{1, "ann"}, false},
+		{"numbered reused", "SELECT * FROM users WHERE a = $1 OR b = $1", Postgres, []interface{}{1}, false},
+		{"positional", "SELECT * FROM users WHERE id = ? AND name = ?", MySQL, []interface{}{1, "ann"}, false},
+		{"placeholders in literals are ignored", "SELECT '$1 or ?' FROM users", Postgres, nil, false},
+		{"too few parameters", "SELECT * FROM users WHERE id = $1 AND name = $2", Postgres, []interface{}{1}, true},
+		{"too many parameters", "SELECT * FROM users WHERE name = 'ann'", Postgres, []interface{}{"ann"}, true},
+		{"gap in numbering", "SELECT * FROM users WHERE id = $2", Postgres, []interface{}{1}, true},
+		{"wrong placeholder style", "SELECT * FROM users WHERE id = ?", Postgres, []interface{}{1}, true},
+		{"named parameter", "SELECT * FROM users WHERE id = @id", Postgres, []interface{}{1}, true},
+		{"multiple statements", "SELECT 1; DROP TABLE users", Postgres, nil, true},
+		{"unbindable value", "SELECT * FROM users WHERE id = $1", Postgres, []interface{}{func() {}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := AuditRawQuery(tt.query, tt.dialect, tt.params...)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestExecutor_RawQuery(t *testing.T) {
+	ctx := context.Background()
+	const query = "WITH ranked AS (SELECT id, name, rank() OVER (ORDER BY score) AS r FROM users) SELECT id, name FROM ranked WHERE r <= $1"
+
+	t.Run("runs through middleware", func(t *testing.T) {
+		mockDB := &MockDB{}
+		expectEmptyQuery(mockDB, query, 10)
+
+		var seen []string
+		q := New(mockDB, Postgres).Use(func(next QueryFunc) QueryFunc {
+			return func(ctx context.Context, query string, params ...interface{}) (Rows, error) {
+				seen = append(seen, query)
+				return next(ctx, query, params...)
+			}
+		})
+
+		users, err := NewExecutor[testUser](q).RawQuery(ctx, query, 10)
+		require.NoError(t, err)
+		assert.Empty(t, users)
+		assert.Equal(t, []string{query}, seen)
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("rejects queries failing the audit", func(t *testing.T) {
+		mockDB := &MockDB{}
+		_, err := NewExecutor[testUser](New(mockDB, Postgres)).RawQuery(ctx, "SELECT id, name FROM users WHERE name = 'ann' AND id = $1")
+		assert.ErrorIs(t, err, ErrInvalidQuery)
+		mockDB.AssertNotCalled(t, "Query")
+	})
+
+	t.Run("applies mandatory conditions", func(t *testing.T) {
+		mockDB := &MockDB{}
+		expectEmptyQuery(mockDB, "SELECT id, name FROM users WHERE score > $1  AND deleted_at IS NULL", 5)
+
+		exec := NewExecutor[testUser](New(mockDB, Postgres)).WithConfig(DefaultConfig().WithSoftDelete("deleted_at"))
+		_, err := exec.RawQuery(ctx, "SELECT id, name FROM users WHERE score > $1 /* sqld:where */", 5)
+		require.NoError(t, err)
+		mockDB.AssertExpectations(t)
+
+		_, err = exec.RawQuery(ctx, "SELECT id, name FROM users WHERE score > $1", 5)
+		assert.ErrorIs(t, err, ErrInvalidQuery, "no annotation to receive the soft-delete condition")
+	})
+
+	t.Run("wraps errors", func(t *testing.T) {
+		mockDB := &MockDB{}
+		failure := errors.New("connection reset")
+		mockDB.On("Query", mock.Anything, query, 10).Return((*MockRows)(nil), failure)
+
+		_, err := NewExecutor[testUser](New(mockDB, Postgres)).RawQuery(ctx, query, 10)
+		var queryErr *QueryError
+		require.ErrorAs(t, err, &queryErr)
+		assert.Equal(t, query, queryErr.Query)
+		assert.ErrorIs(t, err, failure)
+	})
+
+	t.Run("one row", func(t *testing.T) {
+		mockDB := &MockDB{}
+		expectEmptyQuery(mockDB, query, 10)
+
+		_, err := NewExecutor[testUser](New(mockDB, Postgres)).RawQueryOne(ctx, query, 10)
+		assert.ErrorIs(t, err, ErrNoRows)
+	})
+}
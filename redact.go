@@ -0,0 +1,123 @@
+package sqld
+
+import (
+	"context"
+	"reflect"
+	"strings"
+)
+
+// Redactor zeroes the struct fields of T the caller may not see, following
+// the per-field roles of a Config (WithFieldRoles). The roles that restrict
+// filtering and sorting on a field then also hide it in responses.
+//
+// Struct fields are matched to config fields by their db tag, their json tag
+// or their snake_case name, as sqlc generates them; a config field with a
+// DBColumn also matches its unqualified column. Redacted pointers, slices
+// and maps become nil.
+type Redactor[T any] struct {
+	fields []redactedField
+}
+
+// redactedField is a struct field restricted to roles
+type redactedField struct {
+	index int
+	roles []string
+}
+
+// NewRedactor returns a Redactor for T built from the field roles of config.
+// Use its Redact method with Executor.WithTransform, or WithRedaction.
+//
+// Example:
+//
+//	config := sqld.DefaultConfig().WithFieldRoles("email", "admin")
+//	redactor := sqld.NewRedactor[db.User](config)
+//	user, _ = redactor.Redact(ctx, user)
+func NewRedactor[T any](config *Config) *Redactor[T] {
+	r := &Redactor[T]{}
+	structType := reflect.TypeOf((*T)(nil)).Elem()
+	if config == nil || structType.Kind() != reflect.Struct {
+		return r
+	}
+
+	// Roles by the column name a struct field would carry
+	roles := make(map[string][]string)
+	for name, field := range config.Fields {
+		if len(field.Roles) == 0 {
+			continue
+		}
+		roles[name] = field.Roles
+		if column := field.DBColumn; column != "" {
+			roles[column[strings.LastIndex(column, ".")+1:]] = field.Roles
+		}
+	}
+	if len(roles) == 0 {
+		return r
+	}
+
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		if required, ok := roles[structFieldColumn(field)]; ok {
+			r.fields = append(r.fields, redactedField{index: i, roles: required})
+		}
+	}
+	return r
+}
+
+// structFieldColumn returns the column a struct field holds, taken from its
+// db or json tag and falling back to the snake_case field name
+func structFieldColumn(field reflect.StructField) string {
+	for _, key := range []string{"db", "json"} {
+		if name, _, _ := strings.Cut(field.Tag.Get(key), ","); name != "" && name != "-" {
+			return name
+		}
+	}
+	return SnakeCase(field.Name)
+}
+
+// Redact returns row with the fields the roles in ctx do not permit set to
+// their zero value. It has the signature of a RowTransformer.
+func (r *Redactor[T]) Redact(ctx context.Context, row T) (T, error) {
+	if len(r.fields) == 0 {
+		return row, nil
+	}
+
+	callerRoles := RolesFromContext(ctx)
+	value := reflect.ValueOf(&row).Elem()
+	for _, field := range r.fields {
+		if !hasAnyRole(callerRoles, field.roles) {
+			target := value.Field(field.index)
+			target.Set(reflect.Zero(target.Type()))
+		}
+	}
+	return row, nil
+}
+
+// hasAnyRole reports whether have contains one of want
+func hasAnyRole(have, want []string) bool {
+	for _, h := range have {
+		for _, w := range want {
+			if h == w {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// WithRedaction returns a copy of the executor that redacts every row it
+// returns with a Redactor for the config bound with WithConfig, before any
+// transformers run, so fields restricted with WithFieldRoles are hidden
+// from callers without the roles
+//
+// Example:
+//
+//	config := sqld.DefaultConfig().WithFieldRoles("email", "admin")
+//	userExec := sqld.NewExecutor[db.User](q).WithConfig(config).WithRedaction()
+func (e *Executor[T]) WithRedaction() *Executor[T] {
+	clone := *e
+	clone.redact = true
+	return &clone
+}
@@ -0,0 +1,79 @@
+package sqld
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type redactedUser struct {
+	ID       int64
+	Email    string  `json:"email"`
+	Phone    *string `db:"phone_number"`
+	Salary   int
+	Internal string `json:"-"`
+	note     string
+}
+
+func TestRedactor(t *testing.T) {
+	phone := "555-0100"
+	user := redactedUser{ID: 1, Email: "a@example.com", Phone: &phone, Salary: 100, Internal: "x", note: "n"}
+
+	config := DefaultConfig().
+		WithFieldRoles("email", "admin", "support").
+		WithFieldRoles("phone", "admin").
+		WithDBColumn("phone", "u.phone_number").
+		WithFieldRoles("salary", "hr")
+	redactor := NewRedactor[redactedUser](config)
+
+	t.Run("zeroes fields without a role", func(t *testing.T) {
+		redacted, err := redactor.Redact(context.Background(), user)
+		require.NoError(t, err)
+		assert.Equal(t, redactedUser{ID: 1, Internal: "x", note: "n"}, redacted)
+		// The input row is not modified
+		assert.Equal(t, "a@example.com", user.Email)
+	})
+
+	t.Run("keeps fields the roles permit", func(t *testing.T) {
+		redacted, err := redactor.Redact(WithRoles(context.Background(), "support", "hr"), user)
+		require.NoError(t, err)
+		assert.Equal(t, "a@example.com", redacted.Email)
+		assert.Nil(t, redacted.Phone)
+		assert.Equal(t, 100, redacted.Salary)
+	})
+
+	t.Run("no restricted fields", func(t *testing.T) {
+		redacted, err := NewRedactor[redactedUser](DefaultConfig()).Redact(context.Background(), user)
+		require.NoError(t, err)
+		assert.Equal(t, user, redacted)
+
+		redacted, err = NewRedactor[redactedUser](nil).Redact(context.Background(), user)
+		require.NoError(t, err)
+		assert.Equal(t, user, redacted)
+	})
+}
+
+func TestExecutor_WithRedaction(t *testing.T) {
+	const query = "SELECT id, name FROM users"
+	db := fixedRowsDB{rows: [][]interface{}{{int32(1), "alice"}}}
+	config := DefaultConfig().WithFieldRoles("name", "admin")
+
+	var seen string
+	exec := NewExecutor[testUser](New(db, Postgres)).WithConfig(config).WithRedaction().
+		WithTransform(func(ctx context.Context, u testUser) (testUser, error) {
+			seen = u.Name
+			return u, nil
+		})
+
+	users, err := exec.QueryAll(context.Background(), query, nil, nil, nil, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []testUser{{ID: 1}}, users)
+	// Transformers see the redacted row
+	assert.Empty(t, seen)
+
+	user, err := exec.QueryOne(WithRoles(context.Background(), "admin"), query, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "alice", user.Name)
+}
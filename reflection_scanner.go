@@ -2,44 +2,102 @@ package sqld
 
 import (
 	"context"
+	"database/sql"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"strings"
+	"sync"
+	"time"
 )
 
 // ReflectionScanner uses reflection to automatically scan database rows into structs
 // This eliminates the need to write manual scan functions
 type ReflectionScanner[T any] struct {
-	structType reflect.Type
+	structType  reflect.Type
+	converters  *TypeConverterRegistry
+	nullsAsZero bool
+
+	// generated is the ScanFunc registered for T, used instead of reflection
+	generated ScanFunc[T]
 }
 
-// NewReflectionScanner creates a new reflection-based scanner for type T
+// NewReflectionScanner creates a new reflection-based scanner for type T.
+// If a ScanFunc is registered for T, the scanner uses it instead.
 func NewReflectionScanner[T any]() *ReflectionScanner[T] {
 	var zero T
 	return &ReflectionScanner[T]{
 		structType: reflect.TypeOf(zero),
+		generated:  lookupScanFunc[T](),
 	}
 }
 
-// ScanRow scans a database row into a struct using reflection
+// WithTypeConverters returns a copy of the scanner that looks up field
+// converters in registry instead of DefaultTypeConverters
+func (rs *ReflectionScanner[T]) WithTypeConverters(registry *TypeConverterRegistry) *ReflectionScanner[T] {
+	clone := *rs
+	clone.converters = registry
+	clone.generated = nil
+	return &clone
+}
+
+// WithNullsAsZero returns a copy of the scanner that leaves plain fields
+// such as string or int64 at their zero value when the column is NULL,
+// instead of failing the scan. Pointer fields (*string, *int64) receive nil
+// for NULL either way, and sql.Scanner fields handle NULL themselves.
+func (rs *ReflectionScanner[T]) WithNullsAsZero() *ReflectionScanner[T] {
+	clone := *rs
+	clone.nullsAsZero = true
+	clone.generated = nil
+	return &clone
+}
+
+// ScanRow scans a database row into a struct using reflection. Fields whose
+// type has a registered TypeConverter are scanned into interface{} and
+// converted afterwards; fields implementing sql.Scanner, directly or behind
+// a pointer, are scanned through it. A ScanFunc registered for T is used
+// instead of reflection.
 func (rs *ReflectionScanner[T]) ScanRow(rows Rows) (T, error) {
+	if rs.generated != nil {
+		return rs.generated(rows)
+	}
+
 	var result T
 	resultValue := reflect.ValueOf(&result).Elem()
 
-	// Get the number of fields to scan
-	numFields := rs.structType.NumField()
-	scanDests := make([]interface{}, numFields)
+	plan := rs.plan()
+	scanDests := make([]interface{}, len(plan))
+	var deferred []func() error
 
 	// Create scan destinations for each field
-	for i := 0; i < numFields; i++ {
+	for i := range plan {
+		step := &plan[i]
 		field := resultValue.Field(i)
-		if field.CanSet() {
-			scanDests[i] = field.Addr().Interface()
-		} else {
+		switch step.kind {
+		case fieldSkip:
 			// Skip unexported fields by providing a dummy destination
 			var dummy interface{}
 			scanDests[i] = &dummy
+		case fieldConverted:
+			conversion := &convertedField{name: step.name, dst: field, convert: step.convert}
+			deferred = append(deferred, conversion.apply)
+			scanDests[i] = &conversion.src
+		case fieldNullTarget:
+			// Drivers scan NULL into **T as a nil *T, so the value is
+			// copied into the field only when there is one
+			target := reflect.New(step.ptrType)
+			deferred = append(deferred, func() error {
+				if ptr := target.Elem(); !ptr.IsNil() {
+					field.Set(ptr.Elem())
+				}
+				return nil
+			})
+			scanDests[i] = target.Interface()
+		case fieldNullableScanner:
+			scanDests[i] = &nullableScanner{field: field}
+		default:
+			scanDests[i] = field.Addr().Interface()
 		}
 	}
 
@@ -48,52 +106,189 @@ func (rs *ReflectionScanner[T]) ScanRow(rows Rows) (T, error) {
 		return result, err
 	}
 
+	for _, apply := range deferred {
+		if err := apply(); err != nil {
+			return result, err
+		}
+	}
+
 	return result, nil
 }
 
+// fieldKind is how ScanRow fills a struct field
+type fieldKind uint8
+
+const (
+	fieldDirect          fieldKind = iota // scan into the field's address
+	fieldSkip                             // unexported, scan into a dummy
+	fieldConverted                        // scan into interface{} and convert
+	fieldNullTarget                       // scan into **T, zero value for NULL
+	fieldNullableScanner                  // *T where *T implements sql.Scanner
+)
+
+// fieldPlan is the precomputed scan step for one struct field
+type fieldPlan struct {
+	kind    fieldKind
+	name    string
+	convert TypeConverter
+	ptrType reflect.Type // *T for fieldNullTarget
+}
+
+// scanPlanKey identifies a scan plan. The converter registry version is part
+// of the key, so registering a converter invalidates the plans using it.
+type scanPlanKey struct {
+	structType  reflect.Type
+	converters  *TypeConverterRegistry
+	version     uint64
+	nullsAsZero bool
+}
+
+// scanPlans caches scan plans across scanners, since QueryAll and the
+// Executor create a new scanner for every query
+var scanPlans sync.Map // scanPlanKey -> []fieldPlan
+
+// plan returns the cached scan plan for the scanner's struct type and
+// options, building it on first use. Columns are matched to fields by
+// position, so the plan does not depend on the query's columns.
+func (rs *ReflectionScanner[T]) plan() []fieldPlan {
+	converters := rs.converters
+	if converters == nil {
+		converters = DefaultTypeConverters
+	}
+	key := scanPlanKey{
+		structType:  rs.structType,
+		converters:  converters,
+		version:     converters.version.Load(),
+		nullsAsZero: rs.nullsAsZero,
+	}
+	if plan, ok := scanPlans.Load(key); ok {
+		return plan.([]fieldPlan)
+	}
+
+	plan := make([]fieldPlan, rs.structType.NumField())
+	for i := range plan {
+		field := rs.structType.Field(i)
+		plan[i].name = field.Name
+		switch convert, ok := converters.Lookup(field.Type); {
+		case !field.IsExported():
+			plan[i].kind = fieldSkip
+		case ok:
+			plan[i].kind = fieldConverted
+			plan[i].convert = convert
+		case reflect.PointerTo(field.Type).Implements(scannerType):
+			plan[i].kind = fieldDirect
+		case field.Type.Kind() == reflect.Ptr && field.Type.Implements(scannerType):
+			plan[i].kind = fieldNullableScanner
+		case rs.nullsAsZero && needsNullTarget(field.Type):
+			plan[i].kind = fieldNullTarget
+			plan[i].ptrType = reflect.PointerTo(field.Type)
+		default:
+			plan[i].kind = fieldDirect
+		}
+	}
+	scanPlans.Store(key, plan)
+	return plan
+}
+
+var scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+
+// needsNullTarget reports whether a NULL column scanned into a field of type
+// typ needs WithNullsAsZero: types that are not pointers, slices, maps or
+// interfaces and do not implement sql.Scanner
+func needsNullTarget(typ reflect.Type) bool {
+	switch typ.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Map, reflect.Interface:
+		return false
+	}
+	return !reflect.PointerTo(typ).Implements(scannerType)
+}
+
+// nullableScanner scans into a pointer field whose element type implements
+// sql.Scanner, leaving it nil for NULL
+type nullableScanner struct {
+	field reflect.Value
+}
+
+// Scan implements sql.Scanner
+func (n *nullableScanner) Scan(src interface{}) error {
+	if src == nil {
+		n.field.Set(reflect.Zero(n.field.Type()))
+		return nil
+	}
+	value := reflect.New(n.field.Type().Elem())
+	if err := value.Interface().(sql.Scanner).Scan(src); err != nil {
+		return err
+	}
+	n.field.Set(value)
+	return nil
+}
+
 // ScanAll executes a query and scans all results using reflection
 func (rs *ReflectionScanner[T]) ScanAll(ctx context.Context, db DBTX, query string, params ...interface{}) ([]T, error) {
+	results, _, err := rs.scanAll(ctx, db, queryOptions{}, query, params...)
+	return results, err
+}
+
+// scanAll is ScanAll with the row limit of opts applied. It reports whether
+// rows were left unread because of the limit.
+func (rs *ReflectionScanner[T]) scanAll(ctx context.Context, db DBTX, opts queryOptions, query string, params ...interface{}) ([]T, bool, error) {
+	start := time.Now()
 	rows, err := db.Query(ctx, query, params...)
 	if err != nil {
-		return nil, WrapQueryError(err, query, params, "executing query")
+		return nil, false, opts.queryError(ctx, start, err, query, params, "executing query")
 	}
 	defer rows.Close()
+	if opts.total != nil {
+		rows = &totalRows{Rows: rows, total: opts.total}
+	}
 
 	var results []T
 	for rows.Next() {
+		if opts.maxRows > 0 && len(results) == opts.maxRows {
+			if opts.truncate {
+				return results, true, nil
+			}
+			return nil, false, opts.queryError(ctx, start, ErrTooManyRows, query, params, fmt.Sprintf("reading more than %d rows", opts.maxRows))
+		}
 		item, err := rs.ScanRow(rows)
 		if err != nil {
-			return nil, WrapQueryError(err, query, params, "scanning row")
+			return nil, false, opts.queryError(ctx, start, err, query, params, "scanning row")
 		}
 		results = append(results, item)
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, WrapQueryError(err, query, params, "iterating rows")
+		return nil, false, opts.queryError(ctx, start, err, query, params, "iterating rows")
 	}
 
-	return results, nil
+	return results, false, nil
 }
 
 // ScanOne executes a query and scans a single result using reflection
 func (rs *ReflectionScanner[T]) ScanOne(ctx context.Context, db DBTX, query string, params ...interface{}) (T, error) {
+	return rs.scanOne(ctx, db, queryOptions{}, query, params...)
+}
+
+// scanOne is ScanOne with the error handling of opts
+func (rs *ReflectionScanner[T]) scanOne(ctx context.Context, db DBTX, opts queryOptions, query string, params ...interface{}) (T, error) {
 	var zero T
+	start := time.Now()
 	rows, err := db.Query(ctx, query, params...)
 	if err != nil {
-		return zero, WrapQueryError(err, query, params, "executing query")
+		return zero, opts.queryError(ctx, start, err, query, params, "executing query")
 	}
 	defer rows.Close()
 
 	if !rows.Next() {
 		if err := rows.Err(); err != nil {
-			return zero, WrapQueryError(err, query, params, "no rows found")
+			return zero, opts.queryError(ctx, start, err, query, params, "no rows found")
 		}
 		return zero, ErrNoRows
 	}
 
 	result, err := rs.ScanRow(rows)
 	if err != nil {
-		return zero, WrapQueryError(err, query, params, "scanning row")
+		return zero, opts.queryError(ctx, start, err, query, params, "scanning row")
 	}
 
 	return result, nil
@@ -101,6 +296,37 @@ func (rs *ReflectionScanner[T]) ScanOne(ctx context.Context, db DBTX, query stri
 
 // Generic helper functions that use reflection
 
+// queryOptions carries the Executor settings used to build and scan queries
+type queryOptions struct {
+	processor *AnnotationProcessor
+
+	// maxRows fails (or with truncate, stops) scanning after this many rows
+	maxRows  int
+	truncate bool
+
+	// windowCount selects the total count with COUNT(*) OVER(), see
+	// Executor.WithWindowCount
+	windowCount bool
+
+	// total receives the extra sqld_total column of each row instead of
+	// the struct
+	total *int64
+
+	// redaction hides the parameters of returned QueryErrors
+	redaction *ParamRedaction
+}
+
+// queryError wraps an error of a query started at start, see
+// wrapExecutorError
+func (o queryOptions) queryError(ctx context.Context, start time.Time, err error, query string, params []interface{}, operation string) error {
+	return wrapExecutorError(ctx, start, o.redaction, err, query, params, operation)
+}
+
+// defaultQueryOptions are the options of the free query functions
+func defaultQueryOptions(dialect Dialect) queryOptions {
+	return queryOptions{processor: NewAnnotationProcessor(dialect)}
+}
+
 // QueryAll executes a query and scans all results automatically using reflection
 func QueryAll[T any](
 	ctx context.Context,
@@ -112,16 +338,28 @@ func QueryAll[T any](
 	orderBy *OrderByBuilder,
 	limit int,
 	originalParams ...interface{},
+) ([]T, error) {
+	return NewReflectionScanner[T]().queryAll(ctx, db, sqlcQuery, defaultQueryOptions(dialect), where, cursor, orderBy, LimitFromInt(limit), originalParams...)
+}
+
+func (rs *ReflectionScanner[T]) queryAll(
+	ctx context.Context,
+	db DBTX,
+	sqlcQuery string,
+	opts queryOptions,
+	where *WhereBuilder,
+	cursor *Cursor,
+	orderBy *OrderByBuilder,
+	limit Limit,
+	originalParams ...interface{},
 ) ([]T, error) {
 	// Build the query with annotations
-	query, params, err := SearchQuery(sqlcQuery, dialect, where, cursor, orderBy, limit, originalParams...)
+	query, params, err := opts.processor.ProcessQueryLimit(sqlcQuery, where, cursor, orderBy, limit, originalParams...)
 	if err != nil {
 		return nil, err
 	}
-
-	// Use reflection scanner
-	scanner := NewReflectionScanner[T]()
-	return scanner.ScanAll(ctx, db, query, params...)
+	results, _, err := rs.scanAll(ctx, db, opts, query, params...)
+	return results, err
 }
 
 // QueryOne executes a query and scans a single result automatically using reflection
@@ -132,17 +370,25 @@ func QueryOne[T any](
 	dialect Dialect,
 	where *WhereBuilder,
 	originalParams ...interface{},
+) (T, error) {
+	return NewReflectionScanner[T]().queryOne(ctx, db, sqlcQuery, defaultQueryOptions(dialect), where, originalParams...)
+}
+
+func (rs *ReflectionScanner[T]) queryOne(
+	ctx context.Context,
+	db DBTX,
+	sqlcQuery string,
+	opts queryOptions,
+	where *WhereBuilder,
+	originalParams ...interface{},
 ) (T, error) {
 	// Build the query with annotations
-	query, params, err := SearchQuery(sqlcQuery, dialect, where, nil, nil, 0, originalParams...)
+	query, params, err := opts.processor.ProcessQuery(sqlcQuery, where, nil, nil, 0, originalParams...)
 	if err != nil {
 		var zero T
 		return zero, err
 	}
-
-	// Use reflection scanner
-	scanner := NewReflectionScanner[T]()
-	return scanner.ScanOne(ctx, db, query, params...)
+	return rs.scanOne(ctx, db, opts, query, params...)
 }
 
 // QueryPaginated executes a paginated query with automatic scanning
@@ -158,20 +404,51 @@ func QueryPaginated[T any](
 	getCursorFields func(T) (interface{}, interface{}), // Returns (timestamp, id) for cursor
 	originalParams ...interface{},
 ) (*PaginatedResult[T], error) {
+	return NewReflectionScanner[T]().queryPaginated(ctx, db, sqlcQuery, defaultQueryOptions(dialect), where, cursor, orderBy, limit, getCursorFields, originalParams...)
+}
+
+func (rs *ReflectionScanner[T]) queryPaginated(
+	ctx context.Context,
+	db DBTX,
+	sqlcQuery string,
+	opts queryOptions,
+	where *WhereBuilder,
+	cursor *Cursor,
+	orderBy *OrderByBuilder,
+	limit int,
+	getCursorFields func(T) (interface{}, interface{}),
+	originalParams ...interface{},
+) (*PaginatedResult[T], error) {
+	effective, err := opts.processor.effectiveLimit(sqlcQuery, LimitFromInt(limit))
+	if err != nil {
+		return nil, err
+	}
+	limit, _ = effective.Value()
+
 	// Query for limit+1 to check for more results
-	items, err := QueryAll[T](
-		ctx, db, sqlcQuery, dialect, where, cursor, orderBy, limit+1, originalParams...,
-	)
+	processor := *opts.processor
+	processor.lookahead = true
+	query, params, err := processor.ProcessQuery(sqlcQuery, where, cursor, orderBy, limit, originalParams...)
+	if err != nil {
+		return nil, err
+	}
+	if limit > 0 && opts.maxRows >= limit {
+		// The LIMIT already keeps the page within bounds, and the lookahead
+		// row must not trip the row limit
+		opts.maxRows = 0
+	}
+	items, truncated, err := rs.scanAll(ctx, db, opts, query, params...)
 	if err != nil {
 		return nil, err
 	}
 
 	result := &PaginatedResult[T]{
-		Limit: limit,
+		Limit:     limit,
+		Truncated: truncated,
 	}
 
 	// Check if there are more results
-	if len(items) > limit {
+	if limit > 0 && len(items) > limit {
 		result.HasMore = true
 		result.Items = items[:limit]
 
@@ -179,7 +456,7 @@ func QueryPaginated[T any](
 		if getCursorFields != nil {
 			lastItem := items[limit-1]
 			timestamp, id := getCursorFields(lastItem)
-			cursorStr := EncodeCursor(timestamp, id)
+			cursorStr := EncodeCursorWithSort(timestamp, id, orderBy.SortSpec())
 			result.NextCursor = &cursorStr
 		}
 	} else {
@@ -190,25 +467,146 @@ func QueryPaginated[T any](
 	return result, nil
 }
 
+// QueryPaginatedOffset executes a page of a query with a limit annotation,
+// skipping offset rows, with automatic scanning
+func QueryPaginatedOffset[T any](
+	ctx context.Context,
+	db DBTX,
+	sqlcQuery string,
+	dialect Dialect,
+	where *WhereBuilder,
+	orderBy *OrderByBuilder,
+	limit int,
+	offset int,
+	originalParams ...interface{},
+) (*PaginatedResult[T], error) {
+	return NewReflectionScanner[T]().queryPaginatedOffset(ctx, db, sqlcQuery, defaultQueryOptions(dialect), where, orderBy, limit, offset, originalParams...)
+}
+
+func (rs *ReflectionScanner[T]) queryPaginatedOffset(
+	ctx context.Context,
+	db DBTX,
+	sqlcQuery string,
+	opts queryOptions,
+	where *WhereBuilder,
+	orderBy *OrderByBuilder,
+	limit int,
+	offset int,
+	originalParams ...interface{},
+) (*PaginatedResult[T], error) {
+	if !limitAnnotationPattern.MatchString(sqlcQuery) {
+		return nil, fmt.Errorf("%w: offset pagination requires a /* sqld:limit */ annotation", ErrInvalidQuery)
+	}
+	if opts.windowCount && !strings.Contains(sqlcQuery, "/* sqld:total */") {
+		return nil, fmt.Errorf("%w: window counts require a /* sqld:total */ annotation in the SELECT list", ErrInvalidQuery)
+	}
+	if offset < 0 {
+		return nil, fmt.Errorf("%w: offset must not be negative", ErrInvalidParameter)
+	}
+
+	effective, err := opts.processor.effectiveLimit(sqlcQuery, LimitFromInt(limit))
+	if err != nil {
+		return nil, err
+	}
+	limit, _ = effective.Value()
+	if offset > 0 && limit == 0 {
+		return nil, fmt.Errorf("%w: offset requires a limit", ErrInvalidParameter)
+	}
+
+	// Query for limit+1 to check for more results
+	processor := *opts.processor
+	processor.lookahead = true
+	processor.offset = offset
+	processor.windowCount = opts.windowCount
+	query, params, err := processor.ProcessQuery(sqlcQuery, where, nil, orderBy, limit, originalParams...)
+	if err != nil {
+		return nil, err
+	}
+	if limit > 0 && opts.maxRows >= limit {
+		opts.maxRows = 0
+	}
+	var total int64
+	if opts.windowCount {
+		opts.total = &total
+	}
+	items, truncated, err := rs.scanAll(ctx, db, opts, query, params...)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &PaginatedResult[T]{
+		Items:     items,
+		Limit:     limit,
+		Truncated: truncated,
+	}
+	if limit > 0 && len(items) > limit {
+		result.HasMore = true
+		result.Items = items[:limit]
+	}
+
+	// Every row carries the total; a page past the end has no row to carry
+	// it, unless the query matched nothing at all
+	if opts.windowCount && (len(items) > 0 || offset == 0) {
+		result.Total = &total
+	}
+
+	return result, nil
+}
+
+// totalRows routes the last column of each row, added by the total
+// annotation, to total and the remaining columns to the scanner
+type totalRows struct {
+	Rows
+	total *int64
+}
+
+func (r *totalRows) Scan(dest ...interface{}) error {
+	return r.Rows.Scan(append(dest, r.total)...)
+}
+
 // PaginatedResult wraps results with pagination metadata
 type PaginatedResult[T any] struct {
 	Items      []T     `json:"items"`
 	NextCursor *string `json:"next_cursor,omitempty"`
 	HasMore    bool    `json:"has_more"`
 	Limit      int     `json:"limit"`
+
+	// Truncated reports that an Executor configured with WithMaxRows and
+	// truncation dropped rows the query returned
+	Truncated bool `json:"truncated,omitempty"`
+
+	// Total is the number of rows matching the query across all pages,
+	// set by QueryPaginatedOffset on an Executor with WithWindowCount
+	Total *int64 `json:"total,omitempty"`
 }
 
-// CursorData represents the data stored in a pagination cursor
+// CursorData represents the data stored in a pagination cursor by
+// EncodeCursorWithSort
+//
+// Deprecated: Use KeysetCursor, encoded with a CursorCodec, for cursors
+// over keys of your own type.
 type CursorData struct {
 	Timestamp interface{} `json:"timestamp"`
 	ID        interface{} `json:"id"`
+	Sort      string      `json:"sort,omitempty"`
 }
 
 // EncodeCursor creates a cursor string from timestamp and ID
+//
+// Deprecated: Use EncodeCursorWithSort, which binds the cursor to its
+// ordering, or a CursorCodec.
 func EncodeCursor(timestamp interface{}, id interface{}) string {
+	return EncodeCursorWithSort(timestamp, id, "")
+}
+
+// EncodeCursorWithSort creates a cursor string that also records the sort it
+// was generated under (see OrderByBuilder.SortSpec), so it cannot be reused
+// with a different ordering
+func EncodeCursorWithSort(timestamp interface{}, id interface{}, sort string) string {
 	cursor := CursorData{
 		Timestamp: timestamp,
 		ID:        id,
+		Sort:      sort,
 	}
 	data, _ := json.Marshal(cursor)
 	return base64.URLEncoding.EncodeToString(data)
@@ -232,6 +630,7 @@ func DecodeCursor(encoded string) (*Cursor, error) {
 
 	cursor := &Cursor{
 		CreatedAt: cursorData.Timestamp,
+		Sort:      cursorData.Sort,
 	}
 
 	if id, ok := cursorData.ID.(float64); ok {
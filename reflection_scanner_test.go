@@ -0,0 +1,252 @@
+package sqld
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// point scans "x,y" text columns
+type point struct{ X, Y int }
+
+func (p *point) Scan(src interface{}) error {
+	s, ok := src.(string)
+	if !ok {
+		return fmt.Errorf("cannot scan %T into point", src)
+	}
+	_, err := fmt.Sscanf(s, "%d,%d", &p.X, &p.Y)
+	return err
+}
+
+type place struct {
+	Name     string
+	Location point
+	Entrance *point
+}
+
+func TestReflectionScanner_SQLScanner(t *testing.T) {
+	query := "SELECT name, location, entrance FROM places"
+	mockDB := &MockDB{}
+	mockDB.On("Query", mock.Anything, query).Return(&scannerRows{rows: [][]interface{}{
+		{"office", "1,2", "3,4"},
+		{"park", "5,6", nil},
+	}}, nil)
+
+	places, err := NewReflectionScanner[place]().ScanAll(context.Background(), mockDB, query)
+	require.NoError(t, err)
+	assert.Equal(t, []place{
+		{Name: "office", Location: point{1, 2}, Entrance: &point{3, 4}},
+		{Name: "park", Location: point{5, 6}},
+	}, places)
+}
+
+// scannerRows behaves like a driver that only understands basic
+// destinations and sql.Scanner, without looking through pointers
+type scannerRows struct {
+	rows [][]interface{}
+	next int
+}
+
+func (r *scannerRows) Close() error { return nil }
+func (r *scannerRows) Err() error   { return nil }
+
+func (r *scannerRows) Next() bool {
+	r.next++
+	return r.next <= len(r.rows)
+}
+
+func (r *scannerRows) Scan(dest ...interface{}) error {
+	for i, value := range r.rows[r.next-1] {
+		switch d := dest[i].(type) {
+		case interface{ Scan(interface{}) error }:
+			if err := d.Scan(value); err != nil {
+				return err
+			}
+		case *string:
+			*d = value.(string)
+		default:
+			return fmt.Errorf("unsupported destination %T", dest[i])
+		}
+	}
+	return nil
+}
+
+// nullRows scans like database/sql: NULL goes only into pointers, and a
+// pointer destination to a pointer is allocated for other values
+type nullRows struct {
+	rows [][]interface{}
+	next int
+}
+
+func (r *nullRows) Close() error { return nil }
+func (r *nullRows) Err() error   { return nil }
+
+func (r *nullRows) Next() bool {
+	r.next++
+	return r.next <= len(r.rows)
+}
+
+func (r *nullRows) Scan(dest ...interface{}) error {
+	for i, value := range r.rows[r.next-1] {
+		target := reflect.ValueOf(dest[i]).Elem()
+		switch {
+		case value == nil && target.Kind() == reflect.Ptr:
+			target.Set(reflect.Zero(target.Type()))
+		case value == nil:
+			return fmt.Errorf("converting NULL to %s is unsupported", target.Type())
+		case target.Kind() == reflect.Ptr:
+			target.Set(reflect.New(target.Type().Elem()))
+			target.Elem().Set(reflect.ValueOf(value))
+		default:
+			target.Set(reflect.ValueOf(value))
+		}
+	}
+	return nil
+}
+
+type contact struct {
+	ID    int64
+	Email string
+	Phone *string
+}
+
+func TestReflectionScanner_NullsAsZero(t *testing.T) {
+	query := "SELECT id, email, phone FROM contacts"
+	phone := "555-0100"
+	newDB := func() *MockDB {
+		mockDB := &MockDB{}
+		mockDB.On("Query", mock.Anything, query).Return(&nullRows{rows: [][]interface{}{
+			{int64(1), "ann@example.com", phone},
+			{int64(2), nil, nil},
+		}}, nil)
+		return mockDB
+	}
+
+	_, err := NewReflectionScanner[contact]().ScanAll(context.Background(), newDB(), query)
+	assert.ErrorContains(t, err, "converting NULL to string")
+
+	expected := []contact{
+		{ID: 1, Email: "ann@example.com", Phone: &phone},
+		{ID: 2},
+	}
+	contacts, err := NewReflectionScanner[contact]().WithNullsAsZero().ScanAll(context.Background(), newDB(), query)
+	require.NoError(t, err)
+	assert.Equal(t, expected, contacts)
+
+	exec := NewExecutor[contact](New(newDB(), Postgres)).WithScanner(NewReflectionScanner[contact]().WithNullsAsZero())
+	contacts, err = exec.QueryAll(context.Background(), query, nil, nil, nil, 0)
+	require.NoError(t, err)
+	assert.Equal(t, expected, contacts)
+}
+
+type benchmarkUser struct {
+	ID        int64
+	Name      string
+	Email     string
+	Status    string
+	Age       int64
+	Score     float64
+	Verified  bool
+	CreatedAt string
+}
+
+// benchmarkRows serves the same row n times without reflection, so the
+// benchmark measures the scanner rather than the fake driver
+type benchmarkRows struct {
+	n, next int
+}
+
+func (r *benchmarkRows) Close() error { return nil }
+func (r *benchmarkRows) Err() error   { return nil }
+
+func (r *benchmarkRows) Next() bool {
+	r.next++
+	return r.next <= r.n
+}
+
+func (r *benchmarkRows) Scan(dest ...interface{}) error {
+	*dest[0].(*int64) = int64(r.next)
+	*dest[1].(*string) = "Ann"
+	*dest[2].(*string) = "ann@example.com"
+	*dest[3].(*string) = "active"
+	*dest[4].(*int64) = 42
+	*dest[5].(*float64) = 9.5
+	*dest[6].(*bool) = true
+	*dest[7].(*string) = "2024-01-01T00:00:00Z"
+	return nil
+}
+
+func BenchmarkReflectionScanner_ScanRow(b *testing.B) {
+	scanner := NewReflectionScanner[benchmarkUser]()
+	rows := &benchmarkRows{n: b.N}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for rows.Next() {
+		if _, err := scanner.ScanRow(rows); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkQueryAll covers the common path of a list endpoint: a new scanner
+// per query, 50 rows each
+func BenchmarkQueryAll(b *testing.B) {
+	db := benchmarkDB{rows: 50}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := QueryAll[benchmarkUser](context.Background(), db, "SELECT * FROM users", Postgres, nil, nil, nil, 0); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+type benchmarkDB struct{ rows int }
+
+func (db benchmarkDB) Query(ctx context.Context, query string, args ...interface{}) (Rows, error) {
+	return &benchmarkRows{n: db.rows}, nil
+}
+
+func (db benchmarkDB) QueryRow(ctx context.Context, query string, args ...interface{}) Row {
+	return nil
+}
+
+type generatedRow struct {
+	ID   int64
+	Name string
+}
+
+func TestRegisterScanFunc(t *testing.T) {
+	calls := 0
+	RegisterScanFunc(func(rows Rows) (generatedRow, error) {
+		calls++
+		var i generatedRow
+		err := rows.Scan(&i.ID, &i.Name)
+		return i, err
+	})
+
+	query := "SELECT id, name FROM things"
+	newDB := func() *MockDB {
+		mockDB := &MockDB{}
+		mockDB.On("Query", mock.Anything, query).Return(&nullRows{rows: [][]interface{}{
+			{int64(1), "one"},
+			{int64(2), "two"},
+		}}, nil)
+		return mockDB
+	}
+
+	rows, err := NewExecutor[generatedRow](New(newDB(), Postgres)).QueryAll(context.Background(), query, nil, nil, nil, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []generatedRow{{1, "one"}, {2, "two"}}, rows)
+	assert.Equal(t, 2, calls)
+
+	// Options the generated function cannot honor fall back to reflection
+	rows, err = NewReflectionScanner[generatedRow]().WithNullsAsZero().ScanAll(context.Background(), newDB(), query)
+	require.NoError(t, err)
+	assert.Len(t, rows, 2)
+	assert.Equal(t, 2, calls)
+}
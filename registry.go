@@ -0,0 +1,155 @@
+package sqld
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// RegisteredQuery is an annotated query registered under a name
+type RegisteredQuery struct {
+	Name        string
+	SQL         string
+	Annotations *AnnotatedQuery
+}
+
+// QueryRegistry holds named annotated queries so they can be checked against
+// the database at startup, before traffic reaches them.
+//
+// Example:
+//
+//	var SearchUsers = sqld.MustRegisterQuery("SearchUsers", db.SearchUsers)
+//
+//	users, err := exec.QueryAll(ctx, SearchUsers.SQL, where, cursor, orderBy, limit)
+type QueryRegistry struct {
+	mu      sync.RWMutex
+	queries map[string]*RegisteredQuery
+}
+
+// NewQueryRegistry creates an empty registry
+func NewQueryRegistry() *QueryRegistry {
+	return &QueryRegistry{queries: make(map[string]*RegisteredQuery)}
+}
+
+// DefaultRegistry is the registry used by RegisterQuery, MustRegisterQuery and Verify
+var DefaultRegistry = NewQueryRegistry()
+
+// Register parses the annotations of sql and stores it under name. Names
+// must be unique.
+func (r *QueryRegistry) Register(name, sql string) (*RegisteredQuery, error) {
+	annotations, err := ParseAnnotations(sql)
+	if err != nil {
+		return nil, fmt.Errorf("registering query %s: %w", name, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.queries[name]; exists {
+		return nil, fmt.Errorf("%w: query %s is already registered", ErrInvalidQuery, name)
+	}
+	query := &RegisteredQuery{Name: name, SQL: sql, Annotations: annotations}
+	r.queries[name] = query
+	return query, nil
+}
+
+// MustRegister is like Register but panics on error, for use in package
+// variable initialization
+func (r *QueryRegistry) MustRegister(name, sql string) *RegisteredQuery {
+	query, err := r.Register(name, sql)
+	if err != nil {
+		panic(err)
+	}
+	return query
+}
+
+// Get returns the query registered under name
+func (r *QueryRegistry) Get(name string) (*RegisteredQuery, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	query, ok := r.queries[name]
+	return query, ok
+}
+
+// Queries returns the registered queries sorted by name
+func (r *QueryRegistry) Queries() []*RegisteredQuery {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	queries := make([]*RegisteredQuery, 0, len(r.queries))
+	for _, query := range r.queries {
+		queries = append(queries, query)
+	}
+	sort.Slice(queries, func(i, j int) bool { return queries[i].Name < queries[j].Name })
+	return queries
+}
+
+// Verify runs EXPLAIN for every registered query with each of its annotations
+// exercised: a dummy condition, ordering, cursor and limit, and NULL for
+// every original parameter. It reports all failing queries at once.
+func (r *QueryRegistry) Verify(ctx context.Context, db DBTX, dialect Dialect) error {
+	var errs []error
+	for _, query := range r.Queries() {
+		if err := verifyQuery(ctx, db, dialect, query); err != nil {
+			errs = append(errs, fmt.Errorf("query %s: %w", query.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// verifyQuery expands the annotations of query with dummy values and asks the
+// database to plan the result
+func verifyQuery(ctx context.Context, db DBTX, dialect Dialect, query *RegisteredQuery) error {
+	annotations := query.Annotations
+
+	var where *WhereBuilder
+	if annotations.FilterEnabled {
+		where = NewWhereBuilder(dialect)
+		where.Raw("1 = 1")
+	}
+	var orderBy *OrderByBuilder
+	if annotations.OrderByEnabled {
+		orderBy = NewOrderByBuilder().Asc("1")
+	}
+	var cursor *Cursor
+	if annotations.CursorEnabled {
+		cursor = &Cursor{}
+		if orderBy != nil {
+			// A cursor continues only dynamic orderings led by created_at
+			orderBy = NewOrderByBuilder().Desc("created_at").Desc("id")
+		}
+	}
+
+	processor := NewAnnotationProcessor(dialect)
+	processor.windowCount = annotations.TotalEnabled
+
+	params := make([]interface{}, len(annotations.RequiredParams))
+	sql, params, err := processor.ProcessQuery(query.SQL, where, cursor, orderBy, 1, params...)
+	if err != nil {
+		return err
+	}
+
+	rows, err := db.Query(ctx, "EXPLAIN "+sql, params...)
+	if err != nil {
+		return WrapQueryError(err, sql, params, "explaining query")
+	}
+	defer rows.Close()
+	for rows.Next() {
+	}
+	return rows.Err()
+}
+
+// RegisterQuery registers a query in DefaultRegistry
+func RegisterQuery(name, sql string) (*RegisteredQuery, error) {
+	return DefaultRegistry.Register(name, sql)
+}
+
+// MustRegisterQuery registers a query in DefaultRegistry, panicking on error
+func MustRegisterQuery(name, sql string) *RegisteredQuery {
+	return DefaultRegistry.MustRegister(name, sql)
+}
+
+// Verify checks every query in DefaultRegistry against the database behind q
+func Verify(ctx context.Context, q *Queries) error {
+	return DefaultRegistry.Verify(ctx, q.db, q.dialect)
+}
@@ -0,0 +1,61 @@
+package sqld
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryRegistry(t *testing.T) {
+	registry := NewQueryRegistry()
+
+	query, err := registry.Register("SearchUsers", "SELECT * FROM users WHERE true /* sqld:where */ /* sqld:limit max=50 */")
+	require.NoError(t, err)
+	assert.Equal(t, "SearchUsers", query.Name)
+	assert.True(t, query.Annotations.FilterEnabled)
+	assert.Equal(t, 50, query.Annotations.MaxLimit)
+
+	got, ok := registry.Get("SearchUsers")
+	assert.True(t, ok)
+	assert.Same(t, query, got)
+
+	_, err = registry.Register("SearchUsers", "SELECT 1")
+	assert.ErrorIs(t, err, ErrInvalidQuery)
+
+	_, err = registry.Register("Broken", "SELECT * FROM users /* sqld:limit max=abc */")
+	assert.ErrorIs(t, err, ErrInvalidQuery)
+
+	assert.Panics(t, func() { registry.MustRegister("SearchUsers", "SELECT 1") })
+
+	registry.MustRegister("ListPosts", "SELECT * FROM posts")
+	names := []string{}
+	for _, q := range registry.Queries() {
+		names = append(names, q.Name)
+	}
+	assert.Equal(t, []string{"ListPosts", "SearchUsers"}, names)
+}
+
+func TestQueryRegistry_Verify(t *testing.T) {
+	registry := NewQueryRegistry()
+	registry.MustRegister("SearchUsers",
+		"SELECT * FROM users WHERE org_id = $1 /* sqld:where */ ORDER BY created_at DESC /* sqld:orderby */ /* sqld:cursor */ /* sqld:limit */")
+	registry.MustRegister("ListPosts", "SELECT * FROM posts /* sqld:limit */")
+
+	mockDB := &MockDB{}
+	expectEmptyQuery(mockDB,
+		"EXPLAIN SELECT * FROM users WHERE org_id = $1  AND (created_at < $2 OR (created_at = $2 AND id < $3)) AND 1 = 1 ORDER BY created_at DESC, id DESC    LIMIT $4",
+		nil, nil, int32(0), 1)
+	mockDB.On("Query", mock.Anything, "EXPLAIN SELECT * FROM posts  LIMIT $1", 1).
+		Return(&MockRows{}, errors.New(`relation "posts" does not exist`))
+
+	err := registry.Verify(context.Background(), mockDB, Postgres)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "query ListPosts")
+	assert.Contains(t, err.Error(), `relation "posts" does not exist`)
+	assert.NotContains(t, err.Error(), "SearchUsers")
+	mockDB.AssertExpectations(t)
+}
@@ -0,0 +1,188 @@
+package sqld
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// CursorParam is the query parameter carrying an encoded pagination cursor
+const CursorParam = "cursor"
+
+// FieldsParam is the query parameter selecting response fields, e.g. ?fields=id,name
+const FieldsParam = "fields"
+
+// QueryParams holds the query options parsed from an HTTP request, ready to
+// pass to an Executor
+type QueryParams struct {
+	Where   *WhereBuilder
+	OrderBy *OrderByBuilder
+	Cursor  *Cursor
+
+	// Limit is the page size after Config.DefaultLimit and Config.MaxLimit
+	// are applied; zero means no limit was requested or configured
+	Limit int
+
+	// Offset is the number of rows to skip, from ?offset= or computed from
+	// ?page= (one-based) and Limit
+	Offset int
+
+	// Fields lists the requested response fields, validated against the
+	// config. It is empty when the client did not ask for a subset.
+	Fields []string
+}
+
+// BindRequest parses everything a list endpoint needs from r in one call:
+// filters, sorting, the cursor, limit (also read from page_size and
+// per_page), offset or page, and fields. A cursor that cannot be decoded is
+// reported as ErrInvalidCursor; malformed pagination values and fields that
+// are not allowed are reported as *ValidationError.
+func BindRequest(r *http.Request, dialect Dialect, config *Config) (*QueryParams, error) {
+	if config == nil {
+		config = DefaultConfig()
+	}
+
+	where, orderBy, err := FromRequestWithSort(r, dialect, config)
+	if err != nil {
+		return nil, err
+	}
+
+	values := r.URL.Query()
+	cursor, err := DecodeCursor(values.Get(CursorParam))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+
+	params := &QueryParams{Where: where, OrderBy: orderBy, Cursor: cursor}
+
+	if params.Limit, err = bindLimit(values, config); err != nil {
+		return nil, err
+	}
+	if params.Offset, err = bindOffset(values, params.Limit); err != nil {
+		return nil, err
+	}
+	if params.Fields, err = bindFields(r.Context(), values, config); err != nil {
+		return nil, err
+	}
+
+	return params, nil
+}
+
+// bindLimit reads the first of limit, page_size and per_page and applies the
+// configured default and maximum
+func bindLimit(values url.Values, config *Config) (int, error) {
+	limit := 0
+	for _, key := range []string{"limit", "page_size", "per_page"} {
+		if !values.Has(key) {
+			continue
+		}
+		n, err := nonNegativeParam(values, key)
+		if err != nil {
+			return 0, err
+		}
+		limit = n
+		break
+	}
+
+	if limit == 0 {
+		limit = config.DefaultLimit
+	}
+	if config.MaxLimit > 0 && limit > config.MaxLimit {
+		limit = config.MaxLimit
+	}
+	return limit, nil
+}
+
+// bindOffset reads offset, or derives it from the one-based page number
+func bindOffset(values url.Values, limit int) (int, error) {
+	if values.Has("offset") {
+		return nonNegativeParam(values, "offset")
+	}
+	if !values.Has("page") {
+		return 0, nil
+	}
+
+	page, err := nonNegativeParam(values, "page")
+	if err != nil {
+		return 0, err
+	}
+	if page == 0 {
+		return 0, &ValidationError{Field: "page", Value: values.Get("page"), Message: "pages start at 1"}
+	}
+	if limit == 0 {
+		return 0, &ValidationError{Field: "page", Value: values.Get("page"), Message: "page requires a limit"}
+	}
+	return (page - 1) * limit, nil
+}
+
+// bindFields splits the fields parameter and checks every name against the
+// config, including field roles. Names must also be valid column names, since
+// callers commonly use them to build a SELECT list.
+func bindFields(ctx context.Context, values url.Values, config *Config) ([]string, error) {
+	raw := values.Get(FieldsParam)
+	if raw == "" {
+		return nil, nil
+	}
+
+	var fields []string
+	for _, field := range strings.Split(raw, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		if err := ValidateColumnName(field); err != nil {
+			return nil, err
+		}
+		field = config.canonicalField(field)
+		if !config.IsFieldAllowed(field) || !config.IsFieldPermitted(ctx, field) {
+			return nil, &ValidationError{Field: FieldsParam, Value: field, Message: "field is not allowed"}
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// nonNegativeParam parses the integer query parameter key
+func nonNegativeParam(values url.Values, key string) (int, error) {
+	value := values.Get(key)
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, &ValidationError{Field: key, Value: value, Message: "must be a non-negative integer"}
+	}
+	return n, nil
+}
+
+type queryParamsKey struct{}
+
+// WithQueryParams returns a copy of ctx carrying params
+func WithQueryParams(ctx context.Context, params *QueryParams) context.Context {
+	return context.WithValue(ctx, queryParamsKey{}, params)
+}
+
+// QueryParamsFromContext returns the params stored by WithQueryParams or
+// QueryParamsMiddleware
+func QueryParamsFromContext(ctx context.Context) (*QueryParams, bool) {
+	params, ok := ctx.Value(queryParamsKey{}).(*QueryParams)
+	return params, ok
+}
+
+// QueryParamsMiddleware parses every request with BindRequest and stores the
+// result in the request context for QueryParamsFromContext. Requests with
+// invalid parameters are answered with the status from HTTPStatus, e.g.
+// 400 Bad Request, or 403 Forbidden for fields the caller may not use.
+// Framework integrations live in the ginsqld, echosqld and chisqld packages.
+func QueryParamsMiddleware(dialect Dialect, config *Config) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			params, err := BindRequest(r, dialect, config)
+			if err != nil {
+				http.Error(w, err.Error(), HTTPStatus(err))
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(WithQueryParams(r.Context(), params)))
+		})
+	}
+}
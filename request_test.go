@@ -0,0 +1,102 @@
+package sqld
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBindRequest(t *testing.T) {
+	config := DefaultConfig().WithAllowedFields(map[string]bool{"status": true, "name": true})
+	cursor := EncodeCursor("2024-01-01T00:00:00Z", 42)
+
+	req := httptest.NewRequest("GET", "/users?status=active&sort=-name&cursor="+cursor, nil)
+	params, err := BindRequest(req, Postgres, config)
+	require.NoError(t, err)
+
+	sql, args := params.Where.Build()
+	assert.Equal(t, "status = $1", sql)
+	assert.Equal(t, []interface{}{"active"}, args)
+	assert.Equal(t, "name DESC", params.OrderBy.Build())
+	require.NotNil(t, params.Cursor)
+	assert.Equal(t, int32(42), params.Cursor.ID)
+
+	req = httptest.NewRequest("GET", "/users?cursor=not-a-cursor", nil)
+	_, err = BindRequest(req, Postgres, config)
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+}
+
+func TestBindRequest_Pagination(t *testing.T) {
+	config := DefaultConfig().
+		WithAllowedFields(map[string]bool{"id": true, "name": true, "salary": true}).
+		WithFieldRoles("salary", "admin").
+		WithLimits(20, 100)
+
+	tests := []struct {
+		name   string
+		query  string
+		limit  int
+		offset int
+		fields []string
+		err    string
+	}{
+		{name: "defaults", query: "", limit: 20},
+		{name: "explicit limit and offset", query: "limit=10&offset=30", limit: 10, offset: 30},
+		{name: "limit is capped", query: "limit=500", limit: 100},
+		{name: "page size alias", query: "per_page=5&page=3", limit: 5, offset: 10},
+		{name: "offset wins over page", query: "offset=7&page=3", limit: 20, offset: 7},
+		{name: "fields", query: "fields=id,%20name", limit: 20, fields: []string{"id", "name"}},
+		{name: "negative limit", query: "limit=-1", err: "limit"},
+		{name: "non-numeric offset", query: "offset=abc", err: "offset"},
+		{name: "page zero", query: "page=0", err: "page"},
+		{name: "unknown field", query: "fields=id,password", err: "fields"},
+		{name: "field needs role", query: "fields=salary", err: "fields"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params, err := BindRequest(httptest.NewRequest("GET", "/users?"+tt.query, nil), Postgres, config)
+			if tt.err != "" {
+				var validationErr *ValidationError
+				require.ErrorAs(t, err, &validationErr)
+				assert.Equal(t, tt.err, validationErr.Field)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.limit, params.Limit)
+			assert.Equal(t, tt.offset, params.Offset)
+			assert.Equal(t, tt.fields, params.Fields)
+			assert.False(t, params.Where.HasConditions())
+		})
+	}
+
+	t.Run("page without limit", func(t *testing.T) {
+		_, err := BindRequest(httptest.NewRequest("GET", "/users?page=2", nil), Postgres, DefaultConfig())
+		assert.Error(t, err)
+	})
+}
+
+func TestQueryParamsMiddleware(t *testing.T) {
+	config := DefaultConfig().WithAllowedFields(map[string]bool{"status": true}).WithStrictFields(true)
+
+	var got *QueryParams
+	handler := QueryParamsMiddleware(Postgres, config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, _ = QueryParamsFromContext(r.Context())
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/users?status=active", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	require.NotNil(t, got)
+	assert.True(t, got.Where.HasConditions())
+	assert.Nil(t, got.Cursor)
+
+	got = nil
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/users?secret=1", nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Nil(t, got)
+}
@@ -0,0 +1,111 @@
+package sqld
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"regexp"
+	"time"
+)
+
+// RetryOptions configures WithRetryableTransaction. Zero fields take the
+// defaults noted on them.
+type RetryOptions struct {
+	// MaxAttempts is how many times the transaction is run in total (default 3)
+	MaxAttempts int
+
+	// InitialBackoff is the wait before the first retry, doubled after every
+	// further attempt (default 10ms)
+	InitialBackoff time.Duration
+
+	// MaxBackoff caps the wait between attempts (default 1s)
+	MaxBackoff time.Duration
+
+	// Retryable reports whether an error is worth retrying
+	// (default IsRetryableError)
+	Retryable func(err error) bool
+}
+
+// withDefaults fills in the zero fields of o
+func (o RetryOptions) withDefaults() RetryOptions {
+	if o.MaxAttempts <= 0 {
+		o.MaxAttempts = 3
+	}
+	if o.InitialBackoff <= 0 {
+		o.InitialBackoff = 10 * time.Millisecond
+	}
+	if o.MaxBackoff <= 0 {
+		o.MaxBackoff = time.Second
+	}
+	if o.Retryable == nil {
+		o.Retryable = IsRetryableError
+	}
+	return o
+}
+
+// backoff returns the wait after the given (1-based) failed attempt: the
+// exponential delay with jitter, between half of it and all of it
+func (o RetryOptions) backoff(attempt int) time.Duration {
+	delay := o.InitialBackoff << (attempt - 1)
+	if delay > o.MaxBackoff || delay <= 0 {
+		delay = o.MaxBackoff
+	}
+	return delay/2 + rand.N(delay/2+1)
+}
+
+// retryableSQLStates are the SQLSTATE codes of a serialization failure and
+// a detected deadlock
+var retryableSQLStates = map[string]bool{
+	"40001": true,
+	"40P01": true,
+}
+
+// mysqlDeadlockPattern matches the message of MySQL error 1213 (deadlock),
+// whose driver error type carries no SQLState method
+var mysqlDeadlockPattern = regexp.MustCompile(`\bError 1213\b`)
+
+// IsRetryableError reports whether err is a serialization failure or a
+// deadlock, after which the whole transaction can be run again. It
+// recognizes ErrSerialization and ErrDeadlock, errors with a SQLState
+// method, such as pgx's *pgconn.PgError and lib/pq's *pq.Error, and MySQL
+// deadlock errors by their message.
+func IsRetryableError(err error) bool {
+	if errors.Is(err, ErrSerialization) || errors.Is(err, ErrDeadlock) {
+		return true
+	}
+	var stateErr interface{ SQLState() string }
+	if errors.As(err, &stateErr) && retryableSQLStates[stateErr.SQLState()] {
+		return true
+	}
+	return err != nil && mysqlDeadlockPattern.MatchString(err.Error())
+}
+
+// WithRetryableTransaction runs fn in a transaction like WithTransaction
+// and, when it fails with an error opts.Retryable accepts, runs it again in
+// a new transaction after an exponential backoff with jitter. fn may run
+// several times, so it must not have side effects outside the transaction.
+// The error of the last attempt is returned.
+//
+// Example:
+//
+//	err := txm.WithRetryableTransaction(ctx, sqld.RetryOptions{MaxAttempts: 5}, func(tx *sqld.Tx) error {
+//		return transfer(ctx, tx, from, to, amount)
+//	})
+func (m *TxManager) WithRetryableTransaction(ctx context.Context, opts RetryOptions, fn func(tx *Tx) error) error {
+	opts = opts.withDefaults()
+
+	for attempt := 1; ; attempt++ {
+		err := m.WithTransaction(ctx, fn)
+		if err == nil || attempt >= opts.MaxAttempts || !opts.Retryable(err) {
+			return err
+		}
+
+		timer := time.NewTimer(opts.backoff(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package sqld
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// stateError mimics driver errors that report a SQLSTATE
+type stateError string
+
+func (e stateError) Error() string    { return "ERROR: " + string(e) }
+func (e stateError) SQLState() string { return string(e) }
+
+func TestIsRetryableError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"serialization failure", stateError("40001"), true},
+		{"deadlock", stateError("40P01"), true},
+		{"wrapped", fmt.Errorf("transfer: %w", stateError("40001")), true},
+		{"mysql deadlock", errors.New("Error 1213 (40001): Deadlock found when trying to get lock"), true},
+		{"unique violation", stateError("23505"), false},
+		{"other", errors.New("connection refused"), false},
+		{"nil", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsRetryableError(tt.err))
+		})
+	}
+}
+
+func TestTxManager_WithRetryableTransaction(t *testing.T) {
+	ctx := context.Background()
+	opts := RetryOptions{MaxAttempts: 3, InitialBackoff: time.Microsecond}
+
+	t.Run("retries until success", func(t *testing.T) {
+		attempts := 0
+		err := NewTxManager(&fakeBeginner{}, Postgres).WithRetryableTransaction(ctx, opts, func(tx *Tx) error {
+			attempts++
+			if attempts < 3 {
+				return stateError("40001")
+			}
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 3, attempts)
+	})
+
+	t.Run("gives up after max attempts", func(t *testing.T) {
+		attempts := 0
+		err := NewTxManager(&fakeBeginner{}, Postgres).WithRetryableTransaction(ctx, opts, func(tx *Tx) error {
+			attempts++
+			return stateError("40P01")
+		})
+		assert.Equal(t, stateError("40P01"), err)
+		assert.Equal(t, 3, attempts)
+	})
+
+	t.Run("does not retry other errors", func(t *testing.T) {
+		attempts := 0
+		failure := errors.New("insufficient funds")
+		err := NewTxManager(&fakeBeginner{}, Postgres).WithRetryableTransaction(ctx, opts, func(tx *Tx) error {
+			attempts++
+			return failure
+		})
+		assert.ErrorIs(t, err, failure)
+		assert.Equal(t, 1, attempts)
+	})
+
+	t.Run("stops when the context is done", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(ctx)
+		cancel()
+		attempts := 0
+		err := NewTxManager(&fakeBeginner{}, Postgres).WithRetryableTransaction(ctx, RetryOptions{InitialBackoff: time.Hour}, func(tx *Tx) error {
+			attempts++
+			return stateError("40001")
+		})
+		assert.Error(t, err)
+		assert.Equal(t, 1, attempts)
+	})
+}
+
+func TestRetryOptions_Backoff(t *testing.T) {
+	opts := RetryOptions{InitialBackoff: 100 * time.Millisecond, MaxBackoff: 300 * time.Millisecond}.withDefaults()
+	for attempt, max := range []time.Duration{100, 200, 300, 300} {
+		delay := opts.backoff(attempt + 1)
+		assert.GreaterOrEqual(t, delay, max*time.Millisecond/2)
+		assert.LessOrEqual(t, delay, max*time.Millisecond)
+	}
+}
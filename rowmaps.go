@@ -0,0 +1,72 @@
+package sqld
+
+import (
+	"context"
+	"fmt"
+)
+
+// QueryAllMaps runs query and returns every row as a map from column name to
+// value, for ad-hoc and reporting queries that have no generated model.
+// The rows returned by db must implement ColumnRows. Byte slices are
+// converted to strings, since database/sql drivers return text columns as
+// []byte when scanning into interface{}. When several columns share a name
+// the last one wins; use QueryAllValues to keep them all.
+func QueryAllMaps(ctx context.Context, db DBTX, query string, params ...interface{}) ([]map[string]interface{}, error) {
+	columns, values, err := QueryAllValues(ctx, db, query, params...)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]map[string]interface{}, len(values))
+	for i, row := range values {
+		result := make(map[string]interface{}, len(columns))
+		for j, column := range columns {
+			result[column] = row[j]
+		}
+		results[i] = result
+	}
+	return results, nil
+}
+
+// QueryAllValues runs query and returns its column names and the values of
+// every row in column order. Like QueryAllMaps it requires ColumnRows and
+// converts byte slices to strings.
+func QueryAllValues(ctx context.Context, db DBTX, query string, params ...interface{}) ([]string, [][]interface{}, error) {
+	rows, err := db.Query(ctx, query, params...)
+	if err != nil {
+		return nil, nil, WrapQueryError(err, query, params, "executing query")
+	}
+	defer rows.Close()
+
+	columnRows, ok := rows.(ColumnRows)
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: rows of type %T do not report their columns", ErrInvalidQuery, rows)
+	}
+	columns, err := columnRows.Columns()
+	if err != nil {
+		return nil, nil, WrapQueryError(err, query, params, "reading columns")
+	}
+
+	var results [][]interface{}
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		dests := make([]interface{}, len(columns))
+		for i := range values {
+			dests[i] = &values[i]
+		}
+		if err := rows.Scan(dests...); err != nil {
+			return nil, nil, WrapQueryError(err, query, params, "scanning row")
+		}
+		for i, value := range values {
+			if b, ok := value.([]byte); ok {
+				values[i] = string(b)
+			}
+		}
+		results = append(results, values)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, WrapQueryError(err, query, params, "iterating rows")
+	}
+
+	return columns, results, nil
+}
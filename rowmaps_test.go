@@ -0,0 +1,75 @@
+package sqld
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// valueRows serves fixed rows and reports their columns
+type valueRows struct {
+	columns []string
+	rows    [][]interface{}
+	next    int
+}
+
+func (r *valueRows) Close() error               { return nil }
+func (r *valueRows) Err() error                 { return nil }
+func (r *valueRows) Columns() ([]string, error) { return r.columns, nil }
+
+func (r *valueRows) Next() bool {
+	r.next++
+	return r.next <= len(r.rows)
+}
+
+func (r *valueRows) Scan(dest ...interface{}) error {
+	for i, value := range r.rows[r.next-1] {
+		if target, ok := dest[i].(*interface{}); ok {
+			*target = value
+			continue
+		}
+		reflect.ValueOf(dest[i]).Elem().Set(reflect.ValueOf(value))
+	}
+	return nil
+}
+
+func TestQueryAllMaps(t *testing.T) {
+	query := "SELECT status, count(*) AS total FROM users GROUP BY status HAVING count(*) > $1"
+	newDB := func() *MockDB {
+		mockDB := &MockDB{}
+		mockDB.On("Query", mock.Anything, query, 1).Return(&valueRows{
+			columns: []string{"status", "total"},
+			rows: [][]interface{}{
+				{[]byte("active"), int64(12)},
+				{"banned", int64(3)},
+			},
+		}, nil)
+		return mockDB
+	}
+
+	maps, err := QueryAllMaps(context.Background(), newDB(), query, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []map[string]interface{}{
+		{"status": "active", "total": int64(12)},
+		{"status": "banned", "total": int64(3)},
+	}, maps)
+
+	columns, values, err := QueryAllValues(context.Background(), newDB(), query, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"status", "total"}, columns)
+	assert.Equal(t, [][]interface{}{{"active", int64(12)}, {"banned", int64(3)}}, values)
+}
+
+func TestQueryAllMaps_RequiresColumns(t *testing.T) {
+	rows := &MockRows{}
+	rows.On("Close").Return(nil)
+	mockDB := &MockDB{}
+	mockDB.On("Query", mock.Anything, "SELECT 1").Return(rows, nil)
+
+	_, err := QueryAllMaps(context.Background(), mockDB, "SELECT 1")
+	assert.ErrorIs(t, err, ErrInvalidQuery)
+}
@@ -0,0 +1,32 @@
+package sqld
+
+import (
+	"reflect"
+	"sync"
+)
+
+// ScanFunc scans the current row of rows into a T. sqldgen -scanners
+// generates one per selected struct and registers it with RegisterScanFunc.
+type ScanFunc[T any] func(rows Rows) (T, error)
+
+// scanFuncs holds the registered scan functions by row type
+var scanFuncs sync.Map // reflect.Type -> ScanFunc[T]
+
+// RegisterScanFunc makes scan the way rows of type T are scanned by
+// QueryAll, QueryOne, QueryPaginated, Executor and NewReflectionScanner,
+// bypassing reflection. Scanners configured with WithTypeConverters or
+// WithNullsAsZero keep using reflection, since scan cannot honor those
+// options; converters registered in DefaultTypeConverters are likewise not
+// applied to T. Register at init, before queries run.
+func RegisterScanFunc[T any](scan ScanFunc[T]) {
+	scanFuncs.Store(reflect.TypeOf((*T)(nil)).Elem(), scan)
+}
+
+// lookupScanFunc returns the scan function registered for T
+func lookupScanFunc[T any]() ScanFunc[T] {
+	scan, ok := scanFuncs.Load(reflect.TypeOf((*T)(nil)).Elem())
+	if !ok {
+		return nil
+	}
+	return scan.(ScanFunc[T])
+}
@@ -1,10 +1,15 @@
 package sqld
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"regexp"
+	"slices"
+	"sort"
 	"strings"
+	"sync"
 )
 
 // SchemaContentType is the content type for schema discovery requests
@@ -30,6 +35,16 @@ type FieldSchema struct {
 	// Operators lists the allowed filter operators for this field
 	Operators []string `json:"operators,omitempty"`
 
+	// Enum lists the values the field accepts, when it is restricted
+	Enum []string `json:"enum,omitempty"`
+
+	// MaxLength, Pattern, Minimum and Maximum advertise the constraints
+	// filter values must satisfy, so clients can validate before sending
+	MaxLength int      `json:"max_length,omitempty"`
+	Pattern   string   `json:"pattern,omitempty"`
+	Minimum   *float64 `json:"minimum,omitempty"`
+	Maximum   *float64 `json:"maximum,omitempty"`
+
 	// Description provides human-readable documentation for the field
 	Description string `json:"description,omitempty"`
 
@@ -56,6 +71,16 @@ type QuerySchema struct {
 
 	// Examples provides example query strings for documentation
 	Examples []QueryExample `json:"examples,omitempty"`
+
+	// Presets lists the named filter sets clients can select with ?preset=
+	Presets []*Preset `json:"presets,omitempty"`
+}
+
+// ApplyAnnotations sets the query-dependent parts of the schema, such as
+// SupportsCursor, from a query parsed with ParseAnnotations
+func (s *QuerySchema) ApplyAnnotations(query *AnnotatedQuery) *QuerySchema {
+	s.SupportsCursor = query.CursorEnabled
+	return s
 }
 
 // QueryExample provides an example query with description
@@ -64,52 +89,92 @@ type QueryExample struct {
 	Description string `json:"description"`
 }
 
+// fieldTypeOperators lists the filter operators advertised for each field type
+var fieldTypeOperators = map[string][]string{
+	"string":    {"eq", "ne", "like", "ilike", "contains", "startswith", "endswith", "similar", "in", "notin", "isnull", "isnotnull"},
+	"integer":   {"eq", "ne", "gt", "gte", "lt", "lte", "between", "in", "notin", "isnull", "isnotnull"},
+	"number":    {"eq", "ne", "gt", "gte", "lt", "lte", "between", "in", "notin", "isnull", "isnotnull"},
+	"boolean":   {"eq", "ne", "isnull", "isnotnull"},
+	"datetime":  {"eq", "ne", "gt", "gte", "lt", "lte", "between", "isnull", "isnotnull"},
+	"uuid":      {"eq", "ne", "in", "notin", "isnull", "isnotnull"},
+	"geography": {"near", "within", "isnull", "isnotnull"},
+	"range":     {"overlaps", "contains", "isnull", "isnotnull"},
+}
+
+// guessFieldType derives a field type from naming conventions, for fields
+// without a configured Type
+func guessFieldType(field string) string {
+	switch {
+	case strings.HasSuffix(field, "_uuid") || field == "uuid" || field == "guid":
+		return "uuid"
+	case strings.HasSuffix(field, "_id") || field == "id":
+		return "integer"
+	case strings.HasSuffix(field, "_at") || strings.Contains(field, "date") || strings.Contains(field, "time"):
+		return "datetime"
+	case strings.HasPrefix(field, "is_") || strings.HasPrefix(field, "has_") || field == "verified" || field == "active":
+		return "boolean"
+	case strings.Contains(field, "age") || strings.Contains(field, "count") || strings.Contains(field, "amount") || strings.Contains(field, "price"):
+		return "number"
+	default:
+		return "string"
+	}
+}
+
+// withoutOperators returns operators without the given ones
+func withoutOperators(operators []string, remove ...string) []string {
+	kept := make([]string, 0, len(operators))
+	for _, op := range operators {
+		if !slices.Contains(remove, op) {
+			kept = append(kept, op)
+		}
+	}
+	return kept
+}
+
 // GenerateSchema creates a QuerySchema from a Config
 func GenerateSchema(config *Config) *QuerySchema {
+	return GenerateSchemaContext(context.Background(), config)
+}
+
+// GenerateSchemaContext creates a QuerySchema advertising only the fields the
+// caller identified by the roles in ctx is permitted to use
+func GenerateSchemaContext(ctx context.Context, config *Config) *QuerySchema {
 	schema := &QuerySchema{
 		Fields:         make([]FieldSchema, 0),
 		MaxFilters:     config.MaxFilters,
 		MaxSortFields:  config.MaxSortFields,
 		DefaultSort:    config.DefaultSort,
-		SupportsCursor: false, // Can be set based on query annotations
+		SupportsCursor: false, // Set from query annotations with ApplyAnnotations
+	}
+	if config.Presets != nil {
+		schema.Presets = config.Presets.Presets()
 	}
-
-	// Determine common operators based on field types
-	textOperators := []string{"eq", "ne", "like", "ilike", "contains", "startswith", "endswith", "in", "notin", "isnull", "isnotnull"}
-	numberOperators := []string{"eq", "ne", "gt", "gte", "lt", "lte", "between", "in", "notin", "isnull", "isnotnull"}
-	boolOperators := []string{"eq", "ne", "isnull", "isnotnull"}
-	dateOperators := []string{"eq", "ne", "gt", "gte", "lt", "lte", "between", "isnull", "isnotnull"}
 
 	// Build fields from allowed fields
 	for field, allowed := range config.AllowedFields {
-		if !allowed {
+		if !allowed || !config.IsFieldPermitted(ctx, field) {
 			continue
 		}
 
-		// Get the database column name (this field is from AllowedFields, so it's the DB name)
-		dbColumn := field
-
-		// Determine field type and operators based on naming conventions
-		// This is a heuristic; real implementation might need type information
-		var fieldType string
-		var operators []string
-
-		switch {
-		case strings.HasSuffix(field, "_id") || field == "id":
-			fieldType = "integer"
-			operators = numberOperators
-		case strings.HasSuffix(field, "_at") || strings.Contains(field, "date") || strings.Contains(field, "time"):
-			fieldType = "datetime"
-			operators = dateOperators
-		case strings.HasPrefix(field, "is_") || strings.HasPrefix(field, "has_") || field == "verified" || field == "active":
-			fieldType = "boolean"
-			operators = boolOperators
-		case strings.Contains(field, "age") || strings.Contains(field, "count") || strings.Contains(field, "amount") || strings.Contains(field, "price"):
-			fieldType = "number"
-			operators = numberOperators
-		default:
-			fieldType = "string"
-			operators = textOperators
+		// Get the database column name (this field is from AllowedFields, so it's
+		// the DB name unless a DBColumn override qualifies it)
+		dbColumn := config.ColumnFor(field)
+
+		// Use the configured type, falling back to naming conventions
+		fieldType := config.Fields[field].Type
+		if fieldType == "" {
+			fieldType = guessFieldType(field)
+		}
+		operatorType := fieldType
+		if config.rangeType(field) != "" {
+			operatorType = "range"
+		}
+		operators, ok := fieldTypeOperators[operatorType]
+		if !ok {
+			operators = fieldTypeOperators["string"]
+		}
+		if config.Fields[field].NoLeadingWildcard {
+			operators = withoutOperators(operators, "contains", "endswith")
 		}
 
 		// Check if field is sortable (all allowed fields are sortable by default)
@@ -122,6 +187,13 @@ func GenerateSchema(config *Config) *QuerySchema {
 			Filterable: true,
 			Sortable:   sortable,
 			Operators:  operators,
+			Enum:       config.Fields[field].Values,
+			MaxLength:  config.Fields[field].MaxLength,
+			Minimum:    config.Fields[field].Min,
+			Maximum:    config.Fields[field].Max,
+		}
+		if pattern := config.Fields[field].Pattern; pattern != nil {
+			fieldSchema.Pattern = pattern.String()
 		}
 
 		// Add descriptions for common fields
@@ -146,6 +218,19 @@ func GenerateSchema(config *Config) *QuerySchema {
 			fieldSchema.Example = "2024-01-01T00:00:00Z"
 		}
 
+		if fieldSchema.Example == nil {
+			switch fieldType {
+			case "uuid":
+				fieldSchema.Example = "550e8400-e29b-41d4-a716-446655440000"
+			case "geography":
+				fieldSchema.Example = "52.52,13.405,5000"
+			case "tstzrange", "tsrange", "daterange":
+				fieldSchema.Example = "2024-01-01,2024-01-08"
+			case "int4range", "int8range", "numrange":
+				fieldSchema.Example = "10,20"
+			}
+		}
+
 		schema.Fields = append(schema.Fields, fieldSchema)
 	}
 
@@ -153,10 +238,13 @@ func GenerateSchema(config *Config) *QuerySchema {
 	examples := []QueryExample{}
 
 	// Generate examples only using fields that are actually allowed
-	hasName := config.AllowedFields["name"]
-	hasStatus := config.AllowedFields["status"]
-	hasAge := config.AllowedFields["age"]
-	hasCreatedAt := config.AllowedFields["created_at"]
+	visible := func(field string) bool {
+		return config.AllowedFields[field] && config.IsFieldPermitted(ctx, field)
+	}
+	hasName := visible("name")
+	hasStatus := visible("status")
+	hasAge := visible("age")
+	hasCreatedAt := visible("created_at")
 
 	if hasName && hasStatus {
 		examples = append(examples, QueryExample{
@@ -211,11 +299,11 @@ func SchemaMiddleware(config *Config) func(http.Handler) http.Handler {
 			acceptHeader := r.Header.Get("Accept")
 			if strings.Contains(acceptHeader, SchemaContentType) {
 				// Generate and return schema
-				schema := GenerateSchema(config)
+				schema := GenerateSchemaContext(r.Context(), config)
 
 				// Set response headers
 				w.Header().Set("Content-Type", SchemaContentType+"+json")
-				w.Header().Set("Cache-Control", "public, max-age=3600") // Cache for 1 hour
+				w.Header().Set("Cache-Control", schemaCacheControl(config)) // Cache for 1 hour
 
 				// Write schema response
 				if err := json.NewEncoder(w).Encode(schema); err != nil {
@@ -234,11 +322,11 @@ func SchemaMiddleware(config *Config) func(http.Handler) http.Handler {
 // SchemaHandler creates a standalone handler that returns schema information
 func SchemaHandler(config *Config) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		schema := GenerateSchema(config)
+		schema := GenerateSchemaContext(r.Context(), config)
 
 		// Set response headers
 		w.Header().Set("Content-Type", "application/json")
-		w.Header().Set("Cache-Control", "public, max-age=3600")
+		w.Header().Set("Cache-Control", schemaCacheControl(config))
 
 		// Write schema response
 		if err := json.NewEncoder(w).Encode(schema); err != nil {
@@ -248,6 +336,15 @@ func SchemaHandler(config *Config) http.HandlerFunc {
 	}
 }
 
+// schemaCacheControl returns the Cache-Control header for a schema response.
+// Schemas that vary by caller role must not be stored by shared caches.
+func schemaCacheControl(config *Config) string {
+	if config.hasRestrictedFields() {
+		return "private, max-age=3600"
+	}
+	return "public, max-age=3600"
+}
+
 // WithSchema wraps a handler function to support schema discovery
 func WithSchema(config *Config, handler http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -262,3 +359,155 @@ func WithSchema(config *Config, handler http.HandlerFunc) http.HandlerFunc {
 		handler(w, r)
 	}
 }
+
+// resourceNamePattern matches resource names usable as a URL path segment
+var resourceNamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+// SchemaIndex lists the queryable resources of a service
+type SchemaIndex struct {
+	Resources []SchemaIndexEntry `json:"resources"`
+}
+
+// SchemaIndexEntry points to the schema of one resource
+type SchemaIndexEntry struct {
+	Name      string `json:"name"`
+	SchemaURL string `json:"schema_url"`
+}
+
+// SchemaRegistry holds the Config of every list endpoint of a service under a
+// resource name, so clients and SDK generators can enumerate all queryable
+// resources from a single index document.
+type SchemaRegistry struct {
+	mu      sync.RWMutex
+	configs map[string]*Config
+}
+
+// NewSchemaRegistry creates an empty registry
+func NewSchemaRegistry() *SchemaRegistry {
+	return &SchemaRegistry{configs: make(map[string]*Config)}
+}
+
+// Register stores config under resource. Names must be unique and usable as
+// a URL path segment, e.g. "users" or "billing.invoices".
+func (r *SchemaRegistry) Register(resource string, config *Config) error {
+	if err := validateRegistration(resource, config); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.configs[resource]; exists {
+		return fmt.Errorf("%w: resource %s is already registered", ErrInvalidParameter, resource)
+	}
+	r.configs[resource] = config
+	return nil
+}
+
+// validateRegistration checks a resource name and config passed to
+// Register or Replace
+func validateRegistration(resource string, config *Config) error {
+	if !resourceNamePattern.MatchString(resource) {
+		return &ValidationError{Field: "resource", Value: resource, Message: "invalid resource name"}
+	}
+	if config == nil {
+		return &ValidationError{Field: "config", Value: resource, Message: "config is required"}
+	}
+	return nil
+}
+
+// MustRegister is like Register but panics on error
+func (r *SchemaRegistry) MustRegister(resource string, config *Config) *SchemaRegistry {
+	if err := r.Register(resource, config); err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// Config returns the config registered for resource
+func (r *SchemaRegistry) Config(resource string) (*Config, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	config, ok := r.configs[resource]
+	return config, ok
+}
+
+// Replace stores config under resource like Register, replacing a config
+// already registered, so a registry can hold configs reloaded at runtime.
+// Handlers and middleware reading from the registry as a ConfigProvider use
+// the new config from their next request.
+func (r *SchemaRegistry) Replace(resource string, config *Config) error {
+	if err := validateRegistration(resource, config); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.configs[resource] = config
+	return nil
+}
+
+// GetConfig implements ConfigProvider, returning ErrUnknownResource for
+// resources that are not registered
+func (r *SchemaRegistry) GetConfig(ctx context.Context, resource string) (*Config, error) {
+	config, ok := r.Config(resource)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownResource, resource)
+	}
+	return config, nil
+}
+
+// Resources returns the registered resource names in sorted order
+func (r *SchemaRegistry) Resources() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	resources := make([]string, 0, len(r.configs))
+	for resource := range r.configs {
+		resources = append(resources, resource)
+	}
+	sort.Strings(resources)
+	return resources
+}
+
+// Index returns the index document with schema URLs below basePath
+func (r *SchemaRegistry) Index(basePath string) *SchemaIndex {
+	basePath = strings.TrimSuffix(basePath, "/")
+	index := &SchemaIndex{Resources: make([]SchemaIndexEntry, 0)}
+	for _, resource := range r.Resources() {
+		index.Resources = append(index.Resources, SchemaIndexEntry{
+			Name:      resource,
+			SchemaURL: basePath + "/" + resource,
+		})
+	}
+	return index
+}
+
+// Handler serves the index at basePath and each resource's schema at
+// basePath/<resource>. Mount it on the base path and everything below it:
+//
+//	mux.Handle("/schemas/", registry.Handler("/schemas"))
+func (r *SchemaRegistry) Handler(basePath string) http.Handler {
+	basePath = strings.TrimSuffix(basePath, "/")
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet && req.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		resource := strings.Trim(strings.TrimPrefix(req.URL.Path, basePath), "/")
+		if resource == "" {
+			w.Header().Set("Content-Type", "application/json")
+			if err := json.NewEncoder(w).Encode(r.Index(basePath)); err != nil {
+				http.Error(w, "Failed to encode schema index", http.StatusInternalServerError)
+			}
+			return
+		}
+
+		config, ok := r.Config(resource)
+		if !ok {
+			http.NotFound(w, req)
+			return
+		}
+		SchemaHandler(config)(w, req)
+	})
+}
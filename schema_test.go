@@ -1,6 +1,7 @@
 package sqld
 
 import (
+	"context"
 	"encoding/json"
 	"io"
 	"net/http"
@@ -384,3 +385,94 @@ func TestMiddlewareErrorHandling(t *testing.T) {
 	require.NoError(t, err)
 	assert.NotNil(t, schema.Fields) // Should at least have an empty slice
 }
+
+func TestGenerateSchemaContext_Roles(t *testing.T) {
+	config := DefaultConfig().
+		WithAllowedFields(map[string]bool{"name": true, "salary": true}).
+		WithFieldRoles("salary", "admin")
+
+	fieldNames := func(schema *QuerySchema) []string {
+		names := make([]string, 0, len(schema.Fields))
+		for _, field := range schema.Fields {
+			names = append(names, field.Name)
+		}
+		return names
+	}
+
+	t.Run("restricted field hidden without role", func(t *testing.T) {
+		schema := GenerateSchema(config)
+		assert.Equal(t, []string{"name"}, fieldNames(schema))
+	})
+
+	t.Run("restricted field advertised with role", func(t *testing.T) {
+		schema := GenerateSchemaContext(WithRoles(context.Background(), "admin"), config)
+		assert.ElementsMatch(t, []string{"name", "salary"}, fieldNames(schema))
+	})
+
+	t.Run("role dependent schema is not publicly cacheable", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/schema", nil)
+		w := httptest.NewRecorder()
+
+		SchemaHandler(config)(w, req)
+
+		assert.Equal(t, "private, max-age=3600", w.Header().Get("Cache-Control"))
+	})
+}
+
+func TestGenerateSchema_DBColumn(t *testing.T) {
+	config := DefaultConfig().
+		WithAllowedFields(map[string]bool{"name": true}).
+		WithDBColumn("name", "u.name")
+
+	schema := GenerateSchema(config)
+	require.Len(t, schema.Fields, 1)
+	assert.Equal(t, "name", schema.Fields[0].Name)
+	assert.Equal(t, "u.name", schema.Fields[0].DBColumn)
+}
+
+func TestSchemaRegistry(t *testing.T) {
+	registry := NewSchemaRegistry().
+		MustRegister("users", DefaultConfig().WithAllowedFields(map[string]bool{"name": true})).
+		MustRegister("billing.invoices", DefaultConfig().WithAllowedFields(map[string]bool{"total": true, "id": true}))
+
+	assert.Error(t, registry.Register("users", DefaultConfig()))
+	assert.Error(t, registry.Register("../etc", DefaultConfig()))
+	assert.Error(t, registry.Register("orders", nil))
+	assert.Equal(t, []string{"billing.invoices", "users"}, registry.Resources())
+
+	mux := http.NewServeMux()
+	mux.Handle("/schemas/", registry.Handler("/schemas/"))
+
+	t.Run("index", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", "/schemas/", nil))
+
+		require.Equal(t, http.StatusOK, w.Code)
+		var index SchemaIndex
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &index))
+		assert.Equal(t, []SchemaIndexEntry{
+			{Name: "billing.invoices", SchemaURL: "/schemas/billing.invoices"},
+			{Name: "users", SchemaURL: "/schemas/users"},
+		}, index.Resources)
+	})
+
+	t.Run("resource schema", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", "/schemas/billing.invoices", nil))
+
+		require.Equal(t, http.StatusOK, w.Code)
+		var schema QuerySchema
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &schema))
+		assert.Len(t, schema.Fields, 2)
+	})
+
+	t.Run("unknown resource", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("GET", "/schemas/orders", nil))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		w = httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest("POST", "/schemas/users", nil))
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	})
+}
@@ -4,17 +4,23 @@ package sqld
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
 	"strconv"
 	"strings"
+	"unicode"
 )
 
 // Dialect represents the SQL database dialect
 type Dialect string
 
 const (
-	Postgres Dialect = "postgres"
-	MySQL    Dialect = "mysql"
-	SQLite   Dialect = "sqlite"
+	Postgres   Dialect = "postgres"
+	MySQL      Dialect = "mysql"
+	SQLite     Dialect = "sqlite"
+	ClickHouse Dialect = "clickhouse"
 )
 
 // DBTX is the interface that wraps the basic database operations
@@ -37,6 +43,14 @@ type Rows interface {
 	Err() error
 }
 
+// ColumnRows is implemented by Rows that report their column names, such as
+// *sql.Rows and the rows of the pgx adapter. QueryAllMaps and QueryAllValues
+// require it.
+type ColumnRows interface {
+	Rows
+	Columns() ([]string, error)
+}
+
 // Row represents a single query result row
 type Row interface {
 	Scan(dest ...interface{}) error
@@ -66,14 +80,69 @@ type ConditionBuilder interface {
 	HasConditions() bool
 }
 
-// WhereBuilder builds dynamic WHERE conditions
+// ExtendedConditionBuilder is implemented by *WhereBuilder and adds the
+// conditions beyond ConditionBuilder, which keeps its original method set so
+// that other implementations still satisfy it. Code holding a
+// ConditionBuilder, such as an Or callback, reaches these methods with a
+// type assertion:
+//
+//	where.Or(func(or sqld.ConditionBuilder) {
+//		or.Equal("role", "admin")
+//		or.(sqld.ExtendedConditionBuilder).GreaterThanOrEqual("level", 3)
+//	})
+type ExtendedConditionBuilder interface {
+	ConditionBuilder
+	EqualOrNull(column string, value interface{}) ConditionBuilder
+	GreaterThanOrEqual(column string, value interface{}) ConditionBuilder
+	LessThanOrEqual(column string, value interface{}) ConditionBuilder
+	Similar(column string, value string) ConditionBuilder
+	NotIn(column string, values []interface{}) ConditionBuilder
+	NotInNullSafe(column string, values []interface{}) ConditionBuilder
+	CompareColumns(left, operator, right string) ConditionBuilder
+	RawNamed(sql string, params map[string]interface{}) ConditionBuilder
+	Exists(subquery string, params ...interface{}) ConditionBuilder
+	NotExists(subquery string, params ...interface{}) ConditionBuilder
+	ExistsQuery(subquery *QueryBuilder) ConditionBuilder
+	ExistsWhere(from, correlation string, fn func(ConditionBuilder)) ConditionBuilder
+	NotExistsQuery(subquery *QueryBuilder) ConditionBuilder
+	And(fn func(ConditionBuilder)) ConditionBuilder
+	Not(fn func(ConditionBuilder)) ConditionBuilder
+}
+
+// extended returns builder as an ExtendedConditionBuilder, or an error
+// naming the operation that needs one
+func extended(builder ConditionBuilder, operation string) (ExtendedConditionBuilder, error) {
+	ext, ok := builder.(ExtendedConditionBuilder)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s needs an ExtendedConditionBuilder, got %T", ErrInvalidParameter, operation, builder)
+	}
+	return ext, nil
+}
+
+// WhereBuilder builds dynamic WHERE conditions and is the standard
+// ConditionBuilder.
+//
+// A WhereBuilder is not safe for concurrent use: every condition updates its
+// parameter numbering. Build one per request, either from scratch, with Clone
+// from a frozen base (see Freeze), or from a WherePool. A frozen builder may be
+// read and cloned from any number of goroutines.
 type WhereBuilder struct {
-	conditions []Condition
-	params     []interface{}
-	paramIndex int
-	dialect    Dialect
+	conditions  []Condition
+	params      []interface{}
+	paramIndex  int
+	dialect     Dialect
+	quoteIdents bool
+	validate    bool
+	strictNil   bool
+	inChunkSize int     // split IN lists longer than this into OR-ed groups
+	arrayIn     bool    // bind IN lists as one array parameter where supported
+	similarity  float64 // minimum similarity for Similar; 0 uses the % operator
+	frozen      bool    // set by Freeze; any further change panics
+	errs        []error
 }
 
+var _ ExtendedConditionBuilder = (*WhereBuilder)(nil)
+
 // NewWhereBuilder creates a new WHERE condition builder
 func NewWhereBuilder(dialect Dialect) *WhereBuilder {
 	return &WhereBuilder{
@@ -84,128 +153,448 @@ func NewWhereBuilder(dialect Dialect) *WhereBuilder {
 	}
 }
 
+// QuoteIdentifiers enables or disables quoting of column names with the
+// dialect's identifier quote, so reserved words such as "order" and
+// mixed-case columns can be used. Expressions and already quoted names
+// are left untouched.
+func (w *WhereBuilder) QuoteIdentifiers(enabled bool) *WhereBuilder {
+	w.mutate()
+	w.quoteIdents = enabled
+	return w
+}
+
+// WithValidation enables or disables strict column validation. When enabled,
+// conditions on column names that fail ValidateColumnName are not added and
+// the error is reported by Err and BuildChecked instead of being ignored.
+func (w *WhereBuilder) WithValidation(enabled bool) *WhereBuilder {
+	w.mutate()
+	w.validate = enabled
+	return w
+}
+
+// StrictNil makes Equal report nil values (including nil pointers) as an
+// error through Err and BuildChecked instead of silently dropping the
+// condition, which would otherwise widen the query to all rows.
+func (w *WhereBuilder) StrictNil(enabled bool) *WhereBuilder {
+	w.mutate()
+	w.strictNil = enabled
+	return w
+}
+
+// ChunkInLists splits IN and NOT IN lists with more than size values into
+// several groups, (col IN (...) OR col IN (...)), for databases that limit
+// the number of items in a list. Zero disables chunking.
+func (w *WhereBuilder) ChunkInLists(size int) *WhereBuilder {
+	w.mutate()
+	w.inChunkSize = size
+	return w
+}
+
+// ArrayIn binds IN and NOT IN lists as a single array parameter,
+// "col = ANY($1)" and "col <> ALL($1)", on dialects with
+// SupportsArrayParams (Postgres), so the statement text does not change with
+// the list length, keeping plan and prepared statement caches small. The
+// driver must be able to bind Go slices; pgx can, while lib/pq needs
+// pq.Array. Other dialects always use the expanded form.
+func (w *WhereBuilder) ArrayIn(enabled bool) *WhereBuilder {
+	w.mutate()
+	w.arrayIn = enabled
+	return w
+}
+
+// SimilarityThreshold sets the minimum pg_trgm similarity, between 0 and
+// 1, that Similar requires. Zero uses the % operator instead, which applies
+// the server's pg_trgm.similarity_threshold (0.3 by default) and can use a
+// trigram index.
+func (w *WhereBuilder) SimilarityThreshold(threshold float64) *WhereBuilder {
+	w.mutate()
+	w.similarity = threshold
+	return w
+}
+
+// Clone returns an independent copy of the builder, including its options,
+// parameter numbering and collected errors. Conditions added to the copy do
+// not affect the original, and the copy is never frozen.
+//
+// Example:
+//
+//	base := sqld.NewWhereBuilder(sqld.Postgres)
+//	base.IsNull("deleted_at").Equal("tenant_id", tenantID)
+//	base.Freeze()
+//
+//	where := base.Clone()
+//	where.Equal("status", status)
+func (w *WhereBuilder) Clone() *WhereBuilder {
+	clone := *w
+	clone.conditions = append(make([]Condition, 0, len(w.conditions)), w.conditions...)
+	clone.params = append(make([]interface{}, 0, len(w.params)), w.params...)
+	clone.errs = append([]error(nil), w.errs...)
+	clone.frozen = false
+	return &clone
+}
+
+// Freeze marks the builder as read-only: Build, Err and Clone keep working,
+// but adding conditions or changing options panics. Freeze a base builder
+// before sharing it so that accidental changes, which would leak into every
+// request using it, fail loudly instead.
+func (w *WhereBuilder) Freeze() *WhereBuilder {
+	w.frozen = true
+	return w
+}
+
+// mutate panics if the builder has been frozen
+func (w *WhereBuilder) mutate() {
+	if w.frozen {
+		panic("sqld: frozen WhereBuilder modified, use Clone to derive a new builder")
+	}
+}
+
+// Err returns the validation errors collected so far, or nil
+func (w *WhereBuilder) Err() error {
+	return errors.Join(w.errs...)
+}
+
+// BuildChecked is like Build but returns the collected validation errors,
+// so callers never run SQL built from rejected conditions unknowingly
+func (w *WhereBuilder) BuildChecked() (string, []interface{}, error) {
+	if err := w.Err(); err != nil {
+		return "", nil, err
+	}
+	sql, params := w.Build()
+	return sql, params, nil
+}
+
+// checkColumn reports whether a condition on column may be added. Invalid
+// columns are only rejected when validation is enabled.
+func (w *WhereBuilder) checkColumn(column string) bool {
+	if !w.validate {
+		return true
+	}
+	if err := ValidateColumnName(column); err != nil {
+		w.errs = append(w.errs, err)
+		return false
+	}
+	return true
+}
+
+// identifier returns column as it should appear in generated SQL
+func (w *WhereBuilder) identifier(column string) string {
+	if !w.quoteIdents {
+		return column
+	}
+	return quoteColumn(column, w.dialect)
+}
+
 // Equal adds an equality condition
 func (w *WhereBuilder) Equal(column string, value interface{}) ConditionBuilder {
+	w.mutate()
+	if w.strictNil && isNullParam(value) {
+		w.errs = append(w.errs, fmt.Errorf("%w: nil value for %s; use EqualOrNull or IsNull", ErrInvalidParameter, column))
+		return w
+	}
 	if value == nil {
 		return w
 	}
 
-	// Validate column name
-	if err := ValidateColumnName(column); err != nil {
-		// Skip validation for now to maintain compatibility
-		// In production, you might want to log this or handle it differently
+	if !w.checkColumn(column) {
+		return w
 	}
 
-	w.addCondition(column+" = "+w.placeholder(), value)
+	w.addCondition(w.identifier(column)+" = "+w.placeholder(), value)
 	return w
 }
 
+// EqualOrNull adds an equality condition, or "column IS NULL" when value is
+// nil, a nil pointer or a driver.Valuer that is NULL, such as an invalid
+// sql.NullString
+func (w *WhereBuilder) EqualOrNull(column string, value interface{}) ConditionBuilder {
+	w.mutate()
+	if isNullParam(value) {
+		return w.IsNull(column)
+	}
+	return w.Equal(column, value)
+}
+
 // NotEqual adds a not-equal condition
 func (w *WhereBuilder) NotEqual(column string, value interface{}) ConditionBuilder {
+	w.mutate()
 	if value == nil {
 		return w
 	}
 
-	// Validate column name
-	if err := ValidateColumnName(column); err != nil {
-		// Skip validation for now to maintain compatibility
+	if !w.checkColumn(column) {
+		return w
 	}
 
-	w.addCondition(column+" != "+w.placeholder(), value)
+	w.addCondition(w.identifier(column)+" != "+w.placeholder(), value)
 	return w
 }
 
 // GreaterThan adds a greater-than condition
 func (w *WhereBuilder) GreaterThan(column string, value interface{}) ConditionBuilder {
+	w.mutate()
 	if value == nil {
 		return w
 	}
 
-	// Validate column name
-	if err := ValidateColumnName(column); err != nil {
-		// Skip validation for now to maintain compatibility
+	if !w.checkColumn(column) {
+		return w
 	}
 
-	w.addCondition(column+" > "+w.placeholder(), value)
+	w.addCondition(w.identifier(column)+" > "+w.placeholder(), value)
+	return w
+}
+
+// GreaterThanOrEqual adds a greater-than-or-equal condition
+func (w *WhereBuilder) GreaterThanOrEqual(column string, value interface{}) ConditionBuilder {
+	w.mutate()
+	if value == nil {
+		return w
+	}
+
+	if !w.checkColumn(column) {
+		return w
+	}
+
+	w.addCondition(w.identifier(column)+" >= "+w.placeholder(), value)
 	return w
 }
 
 // LessThan adds a less-than condition
 func (w *WhereBuilder) LessThan(column string, value interface{}) ConditionBuilder {
+	w.mutate()
 	if value == nil {
 		return w
 	}
 
-	// Validate column name
-	if err := ValidateColumnName(column); err != nil {
-		// Skip validation for now to maintain compatibility
+	if !w.checkColumn(column) {
+		return w
 	}
 
-	w.addCondition(column+" < "+w.placeholder(), value)
+	w.addCondition(w.identifier(column)+" < "+w.placeholder(), value)
+	return w
+}
+
+// LessThanOrEqual adds a less-than-or-equal condition
+func (w *WhereBuilder) LessThanOrEqual(column string, value interface{}) ConditionBuilder {
+	w.mutate()
+	if value == nil {
+		return w
+	}
+
+	if !w.checkColumn(column) {
+		return w
+	}
+
+	w.addCondition(w.identifier(column)+" <= "+w.placeholder(), value)
 	return w
 }
 
 // Like adds a LIKE condition
 func (w *WhereBuilder) Like(column string, value string) ConditionBuilder {
+	w.mutate()
 	if value == "" {
 		return w
 	}
-	w.addCondition(column+" LIKE "+w.placeholder(), value)
+
+	if !w.checkColumn(column) {
+		return w
+	}
+
+	w.addCondition(w.identifier(column)+" LIKE "+w.placeholder(), value)
 	return w
 }
 
 // ILike adds an ILIKE condition (case-insensitive)
 func (w *WhereBuilder) ILike(column string, value string) ConditionBuilder {
+	w.mutate()
 	if value == "" {
 		return w
 	}
 
-	if w.dialect == Postgres {
-		w.addCondition(column+" ILIKE "+w.placeholder(), value)
+	if !w.checkColumn(column) {
+		return w
+	}
+
+	if w.dialect.Capabilities().SupportsIlike {
+		w.addCondition(w.identifier(column)+" ILIKE "+w.placeholder(), value)
 	} else {
-		// Fallback for MySQL/SQLite
-		w.addCondition("LOWER("+column+") LIKE LOWER("+w.placeholder()+")", value)
+		// Fallback for dialects without native ILIKE (MySQL/SQLite)
+		w.addCondition("LOWER("+w.identifier(column)+") LIKE LOWER("+w.placeholder()+")", value)
+	}
+	return w
+}
+
+// Similar adds a fuzzy match on dialects with SupportsTrigram, using the
+// pg_trgm extension: "column % value", or "similarity(column, value) >= t"
+// when a SimilarityThreshold is set. Other dialects fall back to a
+// case-insensitive contains match.
+func (w *WhereBuilder) Similar(column string, value string) ConditionBuilder {
+	w.mutate()
+	if value == "" {
+		return w
+	}
+
+	if !w.dialect.Capabilities().SupportsTrigram {
+		return w.ILike(column, SearchPattern(value, "contains"))
+	}
+
+	if !w.checkColumn(column) {
+		return w
+	}
+
+	if w.similarity > 0 {
+		w.addConditionWithParams(
+			"similarity("+w.identifier(column)+", "+w.placeholder()+") >= "+w.placeholder(),
+			value, w.similarity,
+		)
+	} else {
+		w.addCondition(w.identifier(column)+" % "+w.placeholder(), value)
 	}
 	return w
 }
 
 // In adds an IN condition
 func (w *WhereBuilder) In(column string, values []interface{}) ConditionBuilder {
+	w.mutate()
 	if len(values) == 0 {
 		return w
 	}
 
-	placeholders := make([]string, len(values))
-	for i := range values {
-		placeholders[i] = w.placeholder()
+	if !w.checkColumn(column) {
+		return w
 	}
 
-	sql := column + " IN (" + strings.Join(placeholders, ", ") + ")"
-	w.conditions = append(w.conditions, Condition{
-		SQL:        sql,
-		ParamCount: len(values),
-	})
-	w.params = append(w.params, values...)
+	sql, params := w.inCondition(column, false, values)
+	w.addConditionWithParams(sql, params...)
+	return w
+}
 
+// NotIn adds a NOT IN condition. As in SQL, rows where column is NULL never
+// match; use NotInNullSafe to include them.
+func (w *WhereBuilder) NotIn(column string, values []interface{}) ConditionBuilder {
+	w.mutate()
+	if len(values) == 0 {
+		return w
+	}
+
+	if !w.checkColumn(column) {
+		return w
+	}
+
+	sql, params := w.inCondition(column, true, values)
+	w.addConditionWithParams(sql, params...)
 	return w
 }
 
+// NotInNullSafe adds a NOT IN condition that also matches rows where column
+// is NULL: (column IS NULL OR column NOT IN (...))
+func (w *WhereBuilder) NotInNullSafe(column string, values []interface{}) ConditionBuilder {
+	w.mutate()
+	if len(values) == 0 {
+		return w
+	}
+
+	if !w.checkColumn(column) {
+		return w
+	}
+
+	sql, params := w.inCondition(column, true, values)
+	w.addConditionWithParams("("+w.identifier(column)+" IS NULL OR "+sql+")", params...)
+	return w
+}
+
+// inCondition builds "column IN (...)" or, when negate is set,
+// "column NOT IN (...)", honoring ArrayIn and ChunkInLists
+func (w *WhereBuilder) inCondition(column string, negate bool, values []interface{}) (string, []interface{}) {
+	name := w.identifier(column)
+
+	if w.arrayIn && w.dialect.Capabilities().SupportsArrayParams {
+		if negate {
+			return name + " <> ALL(" + w.placeholder() + ")", []interface{}{arrayParam(values)}
+		}
+		return name + " = ANY(" + w.placeholder() + ")", []interface{}{arrayParam(values)}
+	}
+
+	operator, joiner := " IN (", " OR "
+	if negate {
+		operator, joiner = " NOT IN (", " AND "
+	}
+
+	size := len(values)
+	if w.inChunkSize > 0 && size > w.inChunkSize {
+		size = w.inChunkSize
+	}
+	var groups []string
+	for start := 0; start < len(values); start += size {
+		end := min(start+size, len(values))
+		groups = append(groups, name+operator+w.placeholderList(end-start)+")")
+	}
+	if len(groups) == 1 {
+		return groups[0], values
+	}
+	return "(" + strings.Join(groups, joiner) + ")", values
+}
+
 // Between adds a BETWEEN condition
 func (w *WhereBuilder) Between(column string, start, end interface{}) ConditionBuilder {
+	w.mutate()
 	if start == nil || end == nil {
 		return w
 	}
+
+	if !w.checkColumn(column) {
+		return w
+	}
+
 	w.addConditionWithParams(
-		column+" BETWEEN "+w.placeholder()+" AND "+w.placeholder(),
+		w.identifier(column)+" BETWEEN "+w.placeholder()+" AND "+w.placeholder(),
 		start, end,
 	)
 	return w
 }
 
+// columnComparisonOperators are the operators accepted by CompareColumns
+var columnComparisonOperators = map[string]bool{
+	"=": true, "!=": true, "<>": true, "<": true, "<=": true, ">": true, ">=": true,
+}
+
+// CompareColumns adds a condition comparing two columns, e.g.
+// CompareColumns("updated_at", ">", "created_at"). Since neither side is
+// parameterized, an invalid column name or an operator that is not a
+// comparison operator is reported by Err even without WithValidation, and
+// the condition is skipped.
+func (w *WhereBuilder) CompareColumns(left, operator, right string) ConditionBuilder {
+	w.mutate()
+	if !columnComparisonOperators[operator] {
+		w.errs = append(w.errs, &ValidationError{Field: "operator", Value: operator, Message: "invalid column comparison operator"})
+		return w
+	}
+	if err := ValidateColumnName(left); err != nil {
+		w.errs = append(w.errs, err)
+		return w
+	}
+	if err := ValidateColumnName(right); err != nil {
+		w.errs = append(w.errs, err)
+		return w
+	}
+
+	w.conditions = append(w.conditions, Condition{
+		SQL:        w.identifier(left) + " " + operator + " " + w.identifier(right),
+		ParamCount: 0,
+	})
+	return w
+}
+
 // IsNull adds an IS NULL condition
 func (w *WhereBuilder) IsNull(column string) ConditionBuilder {
+	w.mutate()
+	if !w.checkColumn(column) {
+		return w
+	}
+
 	w.conditions = append(w.conditions, Condition{
-		SQL:        column + " IS NULL",
+		SQL:        w.identifier(column) + " IS NULL",
 		ParamCount: 0,
 	})
 	return w
@@ -213,8 +602,13 @@ func (w *WhereBuilder) IsNull(column string) ConditionBuilder {
 
 // IsNotNull adds an IS NOT NULL condition
 func (w *WhereBuilder) IsNotNull(column string) ConditionBuilder {
+	w.mutate()
+	if !w.checkColumn(column) {
+		return w
+	}
+
 	w.conditions = append(w.conditions, Condition{
-		SQL:        column + " IS NOT NULL",
+		SQL:        w.identifier(column) + " IS NOT NULL",
 		ParamCount: 0,
 	})
 	return w
@@ -222,6 +616,7 @@ func (w *WhereBuilder) IsNotNull(column string) ConditionBuilder {
 
 // Raw adds a raw SQL condition
 func (w *WhereBuilder) Raw(sql string, params ...interface{}) ConditionBuilder {
+	w.mutate()
 	processedSQL := w.processRawSQL(sql, len(params))
 	w.conditions = append(w.conditions, Condition{
 		SQL:        processedSQL,
@@ -232,21 +627,257 @@ func (w *WhereBuilder) Raw(sql string, params ...interface{}) ConditionBuilder {
 	return w
 }
 
+// RawNamed adds a raw SQL condition using :name parameters, e.g.
+// "created_at BETWEEN :start AND :end OR updated_at > :start". Each name is
+// rewritten to the dialect's placeholder and bound from params; a name used
+// twice binds the same value. Postgres casts (::date) and text inside quotes
+// and comments are left alone. A name missing from params is recorded as an
+// error (see Err) and the condition is skipped.
+func (w *WhereBuilder) RawNamed(sql string, params map[string]interface{}) ConditionBuilder {
+	w.mutate()
+	numbered := w.dialect.Capabilities().NumberedPlaceholders
+	index := w.paramIndex
+	assigned := make(map[string]string)
+	var values []interface{}
+	var missing []string
+
+	processed := replaceNamedParams(sql, func(name string) string {
+		value, ok := params[name]
+		if !ok {
+			missing = append(missing, name)
+			return ":" + name
+		}
+		if numbered {
+			if placeholder, ok := assigned[name]; ok {
+				return placeholder
+			}
+		}
+		index++
+		values = append(values, value)
+		placeholder := w.dialect.Placeholder(index)
+		assigned[name] = placeholder
+		return placeholder
+	})
+
+	if len(missing) > 0 {
+		w.errs = append(w.errs, fmt.Errorf("%w: no value for named parameter :%s", ErrInvalidParameter, strings.Join(missing, ", :")))
+		return w
+	}
+
+	w.conditions = append(w.conditions, Condition{
+		SQL:        processed,
+		ParamCount: len(values),
+	})
+	w.params = append(w.params, values...)
+	w.paramIndex = index
+	return w
+}
+
+// replaceNamedParams calls replace for every :name parameter in sql outside
+// string literals, quoted identifiers and comments, substituting its result
+func replaceNamedParams(sql string, replace func(name string) string) string {
+	runes := []rune(sql)
+	var b strings.Builder
+	var quote rune // current quote character, if inside a quoted section
+
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"' || r == '`':
+			quote = r
+		case r == '-' && i+1 < len(runes) && runes[i+1] == '-':
+			end := indexRunes(runes[i:], []rune("\n"))
+			if end < 0 {
+				end = len(runes)
+			} else {
+				end += i
+			}
+			b.WriteString(string(runes[i:end]))
+			i = end - 1
+			continue
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			end := indexRunes(runes[i+2:], []rune("*/"))
+			if end < 0 {
+				end = len(runes)
+			} else {
+				end += i + 4
+			}
+			b.WriteString(string(runes[i:end]))
+			i = end - 1
+			continue
+		case r == ':' && i+1 < len(runes) && runes[i+1] == ':':
+			// Postgres cast such as created_at::date
+			b.WriteString("::")
+			i++
+			continue
+		case r == ':' && i+1 < len(runes) && (unicode.IsLetter(runes[i+1]) || runes[i+1] == '_'):
+			end := i + 1
+			for end < len(runes) && (runes[end] == '_' || unicode.IsLetter(runes[end]) || unicode.IsDigit(runes[end])) {
+				end++
+			}
+			b.WriteString(replace(string(runes[i+1 : end])))
+			i = end - 1
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// Exists adds an EXISTS (subquery) condition. Like Raw, the subquery uses ?
+// placeholders, which are converted for dialects with numbered placeholders.
+func (w *WhereBuilder) Exists(subquery string, params ...interface{}) ConditionBuilder {
+	return w.Raw("EXISTS ("+subquery+")", params...)
+}
+
+// NotExists adds a NOT EXISTS (subquery) condition
+func (w *WhereBuilder) NotExists(subquery string, params ...interface{}) ConditionBuilder {
+	return w.Raw("NOT EXISTS ("+subquery+")", params...)
+}
+
+// ExistsQuery adds an EXISTS condition for a subquery built with its own
+// QueryBuilder, e.g. a correlated "SELECT 1 FROM orders o WHERE o.user_id = u.id"
+// with dynamic filters. The subquery's placeholders are renumbered to follow
+// the parameters already in this builder.
+func (w *WhereBuilder) ExistsQuery(subquery *QueryBuilder) ConditionBuilder {
+	return w.subqueryCondition("EXISTS", subquery)
+}
+
+// NotExistsQuery adds a NOT EXISTS condition for a subquery built with its own QueryBuilder
+func (w *WhereBuilder) NotExistsQuery(subquery *QueryBuilder) ConditionBuilder {
+	return w.subqueryCondition("NOT EXISTS", subquery)
+}
+
+// ExistsWhere adds "EXISTS (SELECT 1 FROM from WHERE correlation AND ...)"
+// where the trailing conditions are added by fn, as with Or. from and
+// correlation are written into the SQL verbatim and must not come from user input.
+func (w *WhereBuilder) ExistsWhere(from, correlation string, fn func(ConditionBuilder)) ConditionBuilder {
+	w.mutate()
+	subBuilder := w.subBuilder()
+	if correlation != "" {
+		subBuilder.Raw(correlation)
+	}
+	fn(subBuilder)
+	w.errs = append(w.errs, subBuilder.errs...)
+
+	sql := "EXISTS (SELECT 1 FROM " + from
+	if subBuilder.HasConditions() {
+		whereSQL, _ := subBuilder.Build()
+		sql += " WHERE " + whereSQL
+	}
+	sql += ")"
+
+	w.addConditionWithParams(sql, subBuilder.params...)
+	w.paramIndex = subBuilder.paramIndex
+	return w
+}
+
+func (w *WhereBuilder) subqueryCondition(keyword string, subquery *QueryBuilder) ConditionBuilder {
+	w.mutate()
+	if subquery == nil {
+		return w
+	}
+
+	if subquery.where != nil {
+		w.errs = append(w.errs, subquery.where.errs...)
+	}
+
+	sql, params := subquery.Build()
+	if w.dialect.Capabilities().NumberedPlaceholders {
+		sql = renumberPlaceholders(sql, w.paramIndex)
+	}
+	w.paramIndex += len(params)
+
+	w.addConditionWithParams(keyword+" ("+sql+")", params...)
+	return w
+}
+
+// arrayParam converts values to a typed slice, e.g. []string, when all
+// elements share one type, since drivers encode typed slices as arrays more
+// reliably than []interface{}. Elements implementing driver.Valuer are
+// replaced by their values first, because drivers call Value on a parameter
+// but not on the elements of an array. Mixed or nil elements are returned
+// unchanged.
+func arrayParam(values []interface{}) interface{} {
+	resolved := make([]interface{}, len(values))
+	for i, value := range values {
+		resolved[i] = value
+		if valuer, ok := value.(driver.Valuer); ok && !isNilValue(value) {
+			v, err := valuer.Value()
+			if err != nil {
+				// Leave the error for the driver to report
+				return values
+			}
+			resolved[i] = v
+		}
+	}
+
+	if resolved[0] == nil {
+		return values
+	}
+	elemType := reflect.TypeOf(resolved[0])
+	slice := reflect.MakeSlice(reflect.SliceOf(elemType), len(resolved), len(resolved))
+	for i, value := range resolved {
+		if value == nil || reflect.TypeOf(value) != elemType {
+			return values
+		}
+		slice.Index(i).Set(reflect.ValueOf(value))
+	}
+	return slice.Interface()
+}
+
+// subBuilder returns a builder for a nested group that continues this
+// builder's parameter numbering and inherits its options
+func (w *WhereBuilder) subBuilder() *WhereBuilder {
+	sub := NewWhereBuilder(w.dialect)
+	sub.paramIndex = w.paramIndex
+	sub.quoteIdents = w.quoteIdents
+	sub.validate = w.validate
+	sub.strictNil = w.strictNil
+	sub.inChunkSize = w.inChunkSize
+	sub.arrayIn = w.arrayIn
+	sub.similarity = w.similarity
+	return sub
+}
+
 // Or groups conditions with OR logic
 func (w *WhereBuilder) Or(fn func(ConditionBuilder)) ConditionBuilder {
-	subBuilder := NewWhereBuilder(w.dialect)
-	subBuilder.paramIndex = w.paramIndex
+	return w.group(fn, " OR ", "")
+}
+
+// And groups conditions with AND logic, for nesting inside Or and Not
+func (w *WhereBuilder) And(fn func(ConditionBuilder)) ConditionBuilder {
+	return w.group(fn, " AND ", "")
+}
+
+// Not negates the conditions added by fn, combined with AND:
+// NOT (a AND b)
+func (w *WhereBuilder) Not(fn func(ConditionBuilder)) ConditionBuilder {
+	return w.group(fn, " AND ", "NOT ")
+}
+
+// group adds the conditions added by fn as one parenthesized condition,
+// joined with joiner and preceded by prefix. Nothing is added when fn adds
+// no conditions.
+func (w *WhereBuilder) group(fn func(ConditionBuilder), joiner, prefix string) ConditionBuilder {
+	w.mutate()
+	subBuilder := w.subBuilder()
 	fn(subBuilder)
+	w.errs = append(w.errs, subBuilder.errs...)
 
 	if len(subBuilder.conditions) > 0 {
 		parts := make([]string, len(subBuilder.conditions))
 		for i, cond := range subBuilder.conditions {
 			parts[i] = cond.SQL
 		}
-		orSQL := "(" + strings.Join(parts, " OR ") + ")"
+		groupSQL := prefix + "(" + strings.Join(parts, joiner) + ")"
 
 		w.conditions = append(w.conditions, Condition{
-			SQL:        orSQL,
+			SQL:        groupSQL,
 			ParamCount: len(subBuilder.params),
 		})
 		w.params = append(w.params, subBuilder.params...)
@@ -262,12 +893,24 @@ func (w *WhereBuilder) Build() (string, []interface{}) {
 		return "", nil
 	}
 
-	parts := make([]string, len(w.conditions))
-	for i, cond := range w.conditions {
-		parts[i] = cond.SQL
+	if len(w.conditions) == 1 {
+		return w.conditions[0].SQL, w.params
+	}
+
+	size := (len(w.conditions) - 1) * len(" AND ")
+	for _, cond := range w.conditions {
+		size += len(cond.SQL)
 	}
 
-	return strings.Join(parts, " AND "), w.params
+	var b strings.Builder
+	b.Grow(size)
+	for i, cond := range w.conditions {
+		if i > 0 {
+			b.WriteString(" AND ")
+		}
+		b.WriteString(cond.SQL)
+	}
+	return b.String(), w.params
 }
 
 // HasConditions returns true if there are conditions to build
@@ -279,14 +922,7 @@ func (w *WhereBuilder) HasConditions() bool {
 
 func (w *WhereBuilder) placeholder() string {
 	w.paramIndex++
-	switch w.dialect {
-	case Postgres:
-		return "$" + strconv.Itoa(w.paramIndex)
-	case MySQL, SQLite:
-		return "?"
-	default:
-		return "?"
-	}
+	return w.dialect.Placeholder(w.paramIndex)
 }
 
 func (w *WhereBuilder) addCondition(sql string, param interface{}) {
@@ -307,21 +943,58 @@ func (w *WhereBuilder) addConditionWithParams(sql string, params ...interface{})
 	// Don't increment paramIndex here as it's already incremented in placeholder() calls
 }
 
-func (w *WhereBuilder) processRawSQL(sql string, paramCount int) string {
-	if w.dialect == Postgres {
-		// Replace ? with $N for PostgreSQL
-		result := sql
-		for i := 0; i < paramCount; i++ {
-			w.paramIndex++
-			placeholder := "$" + strconv.Itoa(w.paramIndex)
-			result = strings.Replace(result, "?", placeholder, 1)
+// placeholderList returns n comma-separated placeholders, e.g. "$1, $2"
+func (w *WhereBuilder) placeholderList(n int) string {
+	if n <= 0 {
+		return ""
+	}
+	if !w.dialect.Capabilities().NumberedPlaceholders {
+		w.paramIndex += n
+		return strings.Repeat("?, ", n-1) + "?"
+	}
+
+	// "$N, " with up to four digits per placeholder avoids regrowing
+	buf := make([]byte, 0, n*7)
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			buf = append(buf, ", "...)
 		}
-		return result
+		w.paramIndex++
+		buf = append(buf, '$')
+		buf = strconv.AppendInt(buf, int64(w.paramIndex), 10)
 	}
+	return string(buf)
+}
 
-	// For MySQL/SQLite, just update the counter
-	w.paramIndex += paramCount
-	return sql
+// processRawSQL numbers the ? placeholders of raw SQL for dialects using
+// numbered placeholders: the first paramCount are replaced in a single pass,
+// any beyond them are left as they are
+func (w *WhereBuilder) processRawSQL(sql string, paramCount int) string {
+	if !w.dialect.Capabilities().NumberedPlaceholders || paramCount == 0 {
+		// For positional dialects, just update the counter
+		w.paramIndex += paramCount
+		return sql
+	}
+
+	var b strings.Builder
+	b.Grow(len(sql) + paramCount*3)
+	replaced := 0
+	for replaced < paramCount {
+		i := strings.IndexByte(sql, '?')
+		if i < 0 {
+			break
+		}
+		b.WriteString(sql[:i])
+		w.paramIndex++
+		b.WriteByte('$')
+		b.WriteString(strconv.Itoa(w.paramIndex))
+		sql = sql[i+1:]
+		replaced++
+	}
+	// Parameters without a ? still take their number, as before
+	w.paramIndex += paramCount - replaced
+	b.WriteString(sql)
+	return b.String()
 }
 
 // QueryBuilder helps build complete dynamic queries
@@ -329,6 +1002,15 @@ type QueryBuilder struct {
 	baseQuery string
 	dialect   Dialect
 	where     *WhereBuilder
+	scope     *WhereBuilder // mandatory conditions that Where cannot replace
+	ctes      []commonTableExpression
+}
+
+// commonTableExpression is a named subquery of a WITH clause
+type commonTableExpression struct {
+	name   string
+	sql    string
+	params []interface{}
 }
 
 // NewQueryBuilder creates a new query builder
@@ -345,24 +1027,210 @@ func (qb *QueryBuilder) Where(conditions *WhereBuilder) *QueryBuilder {
 	return qb
 }
 
-// Build builds the final query
+// WithCTE adds a common table expression, "name AS (subquery)", to the
+// WITH clause the query starts with, so the base query can select from it.
+// The subquery numbers its own placeholders from $1; Build renumbers them
+// behind the CTEs added before it, and the WHERE conditions behind all CTEs.
+// A base query that already starts with WITH gets the CTEs ahead of its own.
+//
+// Example:
+//
+//	totals, params, _ := processor.ProcessQuery(db.OrderTotals, nil, nil, nil, 0, orgID)
+//	qb := sqld.NewQueryBuilder("SELECT * FROM totals", sqld.Postgres).
+//		WithCTE("totals", totals, params...).
+//		Where(where) // filters the aggregated rows
+func (qb *QueryBuilder) WithCTE(name, subquery string, params ...interface{}) *QueryBuilder {
+	qb.ctes = append(qb.ctes, commonTableExpression{name: name, sql: subquery, params: params})
+	return qb
+}
+
+// Build builds the final query. The conditions are added to the base
+// query's top-level WHERE clause, with a predicate containing OR put in
+// parentheses so the conditions apply to all of it, or a WHERE clause is
+// added. Either way they go before the clauses that follow WHERE, such as
+// GROUP BY, ORDER BY and LIMIT. A base query combined with UNION, INTERSECT
+// or EXCEPT has no single WHERE clause, so Build leaves the conditions out;
+// use BuildChecked to get the error instead.
 func (qb *QueryBuilder) Build() (string, []interface{}) {
+	query, params, _ := qb.build()
+	return query, params
+}
+
+// BuildChecked is like Build but returns ErrInvalidQuery when the
+// conditions cannot be added to the base query
+func (qb *QueryBuilder) BuildChecked() (string, []interface{}, error) {
+	query, params, err := qb.build()
+	if err != nil {
+		return "", nil, err
+	}
+	return query, params, nil
+}
+
+// build builds the query, leaving out conditions it could not add
+func (qb *QueryBuilder) build() (string, []interface{}, error) {
 	query := qb.baseQuery
 	var params []interface{}
+	for _, cte := range qb.ctes {
+		params = append(params, cte.params...)
+	}
+
+	where := qb.where
+	if qb.scope != nil && qb.scope.HasConditions() {
+		where = CombineConditions(qb.dialect, qb.where, qb.scope)
+	}
 
-	if qb.where != nil && qb.where.HasConditions() {
-		whereSQL, whereParams := qb.where.Build()
+	var err error
+	if where != nil && where.HasConditions() {
+		whereSQL, whereParams := where.Build()
 		if whereSQL != "" {
-			if strings.Contains(strings.ToUpper(query), "WHERE") {
-				query += " AND " + whereSQL
-			} else {
-				query += " WHERE " + whereSQL
+			if qb.dialect.Capabilities().NumberedPlaceholders {
+				whereSQL = renumberPlaceholders(whereSQL, len(params))
+			}
+			var conditioned string
+			if conditioned, err = addConditions(query, whereSQL); err == nil {
+				query = conditioned
+				params = append(params, whereParams...)
 			}
-			params = append(params, whereParams...)
 		}
 	}
 
-	return query, params
+	return qb.withClause(query), params, err
+}
+
+// clauseKeywords start the clauses that can follow WHERE at the top level
+// of a SELECT
+var clauseKeywords = map[string]bool{
+	"GROUP": true, "HAVING": true, "WINDOW": true, "ORDER": true, "LIMIT": true, "OFFSET": true,
+	"FETCH": true, "FOR": true, "RETURNING": true,
+}
+
+// compoundKeywords combine the results of queries
+var compoundKeywords = map[string]bool{"UNION": true, "INTERSECT": true, "EXCEPT": true}
+
+// addConditions adds conditions to the top-level WHERE clause of query, see
+// QueryBuilder.Build. Keywords in subqueries, literals, comments and
+// identifiers are ignored.
+func addConditions(query, conditions string) (string, error) {
+	whereEnd, clauseStart, or, compound := -1, len(query), false, ""
+	forEachTopLevelWord(maskSQL(query), func(word string, end int) {
+		switch {
+		case compoundKeywords[word]:
+			if compound == "" {
+				compound = word
+			}
+		case end > clauseStart:
+			// WHERE and OR only count before the clauses following WHERE
+		case clauseKeywords[word]:
+			clauseStart = end - len(word)
+		case word == "WHERE" && whereEnd < 0:
+			whereEnd = end
+		case word == "OR" && whereEnd >= 0:
+			or = true
+		}
+	})
+	if compound != "" {
+		return "", fmt.Errorf("%w: conditions cannot be added to a query combined with %s", ErrInvalidQuery, compound)
+	}
+
+	// Keep a newline ending a line comment before the following clause
+	head, tail := strings.TrimRight(query[:clauseStart], " \t"), query[clauseStart:]
+	switch {
+	case whereEnd < 0:
+		head += " WHERE " + conditions
+	case or:
+		head = head[:whereEnd] + " (" + strings.TrimSpace(head[whereEnd:]) + ") AND " + conditions
+	default:
+		head += " AND " + conditions
+	}
+	if tail == "" {
+		return head, nil
+	}
+	return head + " " + tail, nil
+}
+
+// forEachTopLevelWord calls fn with each word of masked SQL outside
+// parentheses, upper-cased, and the offset of its end
+func forEachTopLevelWord(masked string, fn func(word string, end int)) {
+	depth, start := 0, -1
+	for i := 0; i <= len(masked); i++ {
+		var c byte
+		if i < len(masked) {
+			c = masked[i]
+		}
+		if c != 0 && (c >= 0x80 || isIdentifierRune(rune(c))) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 {
+			if depth == 0 {
+				fn(strings.ToUpper(masked[start:i]), i)
+			}
+			start = -1
+		}
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		}
+	}
+}
+
+// withClause prepends the WITH clause of the CTEs to query, renumbering the
+// placeholders of each CTE behind those before it
+func (qb *QueryBuilder) withClause(query string) string {
+	if len(qb.ctes) == 0 {
+		return query
+	}
+
+	// Merge with the base query's own WITH clause, which must stay first and
+	// keep its RECURSIVE keyword
+	keyword, rest, merge := "WITH ", query, false
+	if word, after := cutWord(query); strings.EqualFold(word, "WITH") {
+		rest, merge = after, true
+		if word, after := cutWord(after); strings.EqualFold(word, "RECURSIVE") {
+			keyword, rest = "WITH RECURSIVE ", after
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(keyword)
+	offset := 0
+	for i, cte := range qb.ctes {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(cte.name)
+		b.WriteString(" AS (")
+		if qb.dialect.Capabilities().NumberedPlaceholders {
+			writeRenumbered(&b, cte.sql, offset)
+		} else {
+			b.WriteString(cte.sql)
+		}
+		b.WriteString(")")
+		offset += len(cte.params)
+	}
+
+	if merge {
+		b.WriteString(", ")
+	} else {
+		b.WriteString(" ")
+	}
+	b.WriteString(rest)
+	return b.String()
+}
+
+// cutWord returns the first whitespace-separated word of s and the text
+// after it, without the whitespace around the word
+func cutWord(s string) (word, rest string) {
+	s = strings.TrimLeftFunc(s, unicode.IsSpace)
+	end := strings.IndexFunc(s, unicode.IsSpace)
+	if end < 0 {
+		return s, ""
+	}
+	return s[:end], strings.TrimLeftFunc(s[end:], unicode.IsSpace)
 }
 
 // ParameterAdjuster helps adjust parameter indices for complex queries
@@ -377,42 +1245,105 @@ func NewParameterAdjuster(dialect Dialect) *ParameterAdjuster {
 
 // AdjustSQL adjusts parameter placeholders starting from the given offset
 func (pa *ParameterAdjuster) AdjustSQL(sql string, startIndex int) string {
-	if pa.dialect != Postgres {
-		return sql // MySQL/SQLite use ?, no adjustment needed
+	if !pa.dialect.Capabilities().NumberedPlaceholders {
+		return sql // Positional ? placeholders need no adjustment
 	}
 
-	// For PostgreSQL, renumber $1, $2, etc.
-	result := sql
-	placeholderCount := strings.Count(sql, "$")
+	// Renumber $1, $2, etc.
+	return renumberPlaceholders(sql, startIndex)
+}
 
-	for i := 1; i <= placeholderCount; i++ {
-		oldPlaceholder := "$" + strconv.Itoa(i)
-		newPlaceholder := "$" + strconv.Itoa(i+startIndex)
-		result = strings.Replace(result, oldPlaceholder, newPlaceholder, 1)
+// renumberPlaceholders shifts every $N placeholder in sql by offset in a
+// single pass, so already-renumbered placeholders are never touched again
+func renumberPlaceholders(sql string, offset int) string {
+	if offset == 0 || strings.IndexByte(sql, '$') < 0 {
+		return sql
 	}
+	var b strings.Builder
+	b.Grow(len(sql) + 8)
+	writeRenumbered(&b, sql, offset)
+	return b.String()
+}
 
-	return result
+// writeRenumbered writes sql to b with every $N placeholder shifted by
+// offset. Numbers too large for an int are copied unchanged.
+func writeRenumbered(b *strings.Builder, sql string, offset int) {
+	for {
+		i := strings.IndexByte(sql, '$')
+		if i < 0 {
+			b.WriteString(sql)
+			return
+		}
+		end := i + 1
+		for end < len(sql) && sql[end] >= '0' && sql[end] <= '9' {
+			end++
+		}
+		num, err := strconv.Atoi(sql[i+1 : end])
+		if end == i+1 || err != nil {
+			b.WriteString(sql[:end])
+		} else {
+			b.WriteString(sql[:i+1])
+			b.WriteString(strconv.Itoa(num + offset))
+		}
+		sql = sql[end:]
+	}
 }
 
 // Utility functions for common patterns
 
-// CombineConditions combines multiple condition builders with AND logic
+// isNilValue reports whether value is nil or a nil pointer, map, slice or interface
+func isNilValue(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface, reflect.Func, reflect.Chan:
+		return v.IsNil()
+	}
+	return false
+}
+
+// isNullParam reports whether a parameter value binds as NULL: nil, a nil
+// pointer, or a driver.Valuer whose value is nil
+func isNullParam(value interface{}) bool {
+	if isNilValue(value) {
+		return true
+	}
+	if valuer, ok := value.(driver.Valuer); ok {
+		v, err := valuer.Value()
+		return err == nil && v == nil
+	}
+	return false
+}
+
+// CombineConditions combines multiple condition builders with AND logic.
+// Nil builders are skipped; errors collected by the builders are carried
+// over. CombineBuilders takes other ConditionBuilders.
 func CombineConditions(dialect Dialect, builders ...*WhereBuilder) *WhereBuilder {
+	return CombineBuilders(dialect, builders...)
+}
+
+// CombineBuilders is CombineConditions for any ConditionBuilder, such as
+// the builder passed to an Or callback. Errors are carried over from
+// WhereBuilders.
+func CombineBuilders[B ConditionBuilder](dialect Dialect, builders ...B) *WhereBuilder {
 	combined := NewWhereBuilder(dialect)
 
 	for _, builder := range builders {
-		if builder != nil && builder.HasConditions() {
+		if isNilValue(builder) {
+			continue
+		}
+		if where, ok := any(builder).(*WhereBuilder); ok {
+			combined.errs = append(combined.errs, where.errs...)
+		}
+		if builder.HasConditions() {
 			sql, params := builder.Build()
 
 			// Adjust parameter placeholders if needed
-			if dialect == Postgres {
-				adjustedSQL := sql
-				// Replace $1, $2, etc. with proper indices based on current parameter count
-				for i := 1; i <= len(params); i++ {
-					oldPlaceholder := "$" + strconv.Itoa(i)
-					newPlaceholder := "$" + strconv.Itoa(combined.paramIndex+i)
-					adjustedSQL = strings.Replace(adjustedSQL, oldPlaceholder, newPlaceholder, 1)
-				}
+			if dialect.Capabilities().NumberedPlaceholders {
+				// Shift $1, $2, etc. past the parameters already combined
+				adjustedSQL := renumberPlaceholders(sql, combined.paramIndex)
 				combined.paramIndex += len(params)
 
 				combined.conditions = append(combined.conditions, Condition{
@@ -421,7 +1352,7 @@ func CombineConditions(dialect Dialect, builders ...*WhereBuilder) *WhereBuilder
 				})
 				combined.params = append(combined.params, params...)
 			} else {
-				// For MySQL/SQLite, just use Raw as it doesn't need parameter adjustment
+				// Positional placeholders need no adjustment, so Raw is enough
 				combined.Raw(sql, params...)
 			}
 		}
@@ -430,31 +1361,77 @@ func CombineConditions(dialect Dialect, builders ...*WhereBuilder) *WhereBuilder
 	return combined
 }
 
-// ConditionalWhere adds conditions only if the value is not empty/nil
-func ConditionalWhere(builder *WhereBuilder, column string, value interface{}) *WhereBuilder {
+// SkipFunc decides whether ConditionalWhereWith leaves out a value
+type SkipFunc func(value interface{}) bool
+
+// SkipZero is the skip rule of ConditionalWhere, which cannot express
+// filters such as age = 0 or an empty name. It skips empty strings, nil or
+// empty *string, and int zeros. ConditionalWhere compared values with the
+// untyped constant 0, which only an int equals, so int32(0), int64(0), other
+// typed zeros, false and pointers to zeros are filtered on. Nil values, nil
+// pointers and NULL driver.Valuers are skipped as well; before SkipFunc
+// existed, ConditionalWhere compared those with "= NULL", which matched no
+// rows.
+func SkipZero(value interface{}) bool {
+	if isNullParam(value) {
+		return true
+	}
+
 	switch v := value.(type) {
 	case string:
-		if v != "" {
-			builder.Equal(column, v)
-		}
+		return v == ""
 	case *string:
-		if v != nil && *v != "" {
-			builder.Equal(column, *v)
-		}
-	case int, int32, int64:
-		if v != 0 {
-			builder.Equal(column, v)
-		}
-	case *int, *int32, *int64:
-		if v != nil {
-			builder.Equal(column, v)
-		}
-	default:
-		if v != nil {
-			builder.Equal(column, v)
+		return *v == ""
+	case int:
+		return v == 0
+	}
+	return false
+}
+
+// SkipNil skips only nil values, nil pointers and driver.Valuers that are
+// NULL (e.g. sql.NullInt64{}), so zero values are filtered on
+func SkipNil(value interface{}) bool {
+	return isNullParam(value)
+}
+
+// ConditionalWhere adds an equality condition unless the value is empty/nil,
+// as decided by SkipZero
+func ConditionalWhere[B ConditionBuilder](builder B, column string, value interface{}) B {
+	return ConditionalWhereWith(builder, column, value, SkipZero)
+}
+
+// ConditionalWhereWith adds an equality condition unless skip reports that
+// the value is absent. Non-nil pointers are dereferenced unless they
+// implement driver.Valuer.
+func ConditionalWhereWith[B ConditionBuilder](builder B, column string, value interface{}, skip SkipFunc) B {
+	if skip(value) {
+		return builder
+	}
+
+	if _, ok := value.(driver.Valuer); !ok {
+		if v := reflect.ValueOf(value); v.Kind() == reflect.Ptr && !v.IsNil() {
+			value = v.Elem().Interface()
 		}
 	}
 
+	builder.Equal(column, value)
+	return builder
+}
+
+// WhereIfPresent adds an equality condition when value is non-nil. Unlike
+// ConditionalWhere, zero values such as 0 or "" are filtered on.
+func WhereIfPresent[T any, B ConditionBuilder](builder B, column string, value *T) B {
+	if value != nil {
+		builder.Equal(column, *value)
+	}
+	return builder
+}
+
+// WhereIfValid adds an equality condition when value is Valid, including zero values
+func WhereIfValid[T any, B ConditionBuilder](builder B, column string, value sql.Null[T]) B {
+	if value.Valid {
+		builder.Equal(column, value.V)
+	}
 	return builder
 }
 
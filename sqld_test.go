@@ -1,10 +1,14 @@
 package sqld
 
 import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
 	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestWhereBuilder_PostgreSQL(t *testing.T) {
@@ -40,6 +44,34 @@ func TestWhereBuilder_PostgreSQL(t *testing.T) {
 			expectedSQL:    "role IN ($1, $2, $3)",
 			expectedParams: []interface{}{"admin", "user", "manager"},
 		},
+		{
+			name: "range conditions",
+			buildCondition: func(b *WhereBuilder) {
+				b.GreaterThanOrEqual("age", 18)
+				b.LessThanOrEqual("age", 65)
+			},
+			expectedSQL:    "age >= $1 AND age <= $2",
+			expectedParams: []interface{}{18, 65},
+		},
+		{
+			name: "NOT IN condition",
+			buildCondition: func(b *WhereBuilder) {
+				b.Equal("status", "active")
+				b.NotIn("role", []interface{}{"guest", "banned"})
+				b.Equal("org", 7)
+			},
+			expectedSQL:    "status = $1 AND role NOT IN ($2, $3) AND org = $4",
+			expectedParams: []interface{}{"active", "guest", "banned", 7},
+		},
+		{
+			name: "NULL-safe NOT IN condition",
+			buildCondition: func(b *WhereBuilder) {
+				b.NotInNullSafe("role", []interface{}{"guest", "banned"})
+				b.Equal("org", 7)
+			},
+			expectedSQL:    "(role IS NULL OR role NOT IN ($1, $2)) AND org = $3",
+			expectedParams: []interface{}{"guest", "banned", 7},
+		},
 		{
 			name: "BETWEEN condition",
 			buildCondition: func(b *WhereBuilder) {
@@ -128,9 +160,13 @@ func TestNilValueHandling(t *testing.T) {
 	builder.Equal("name", nil)
 	builder.NotEqual("email", nil)
 	builder.GreaterThan("age", nil)
+	builder.GreaterThanOrEqual("age", nil)
+	builder.LessThanOrEqual("age", nil)
 	builder.Between("date", nil, "2024-12-31")
 	builder.Between("date", "2024-01-01", nil)
 	builder.In("role", []interface{}{})
+	builder.NotIn("role", []interface{}{})
+	builder.NotInNullSafe("role", []interface{}{})
 	builder.Like("text", "")
 	builder.ILike("text", "")
 
@@ -142,6 +178,242 @@ func TestNilValueHandling(t *testing.T) {
 	assert.Equal(t, []interface{}{"active"}, params)
 }
 
+func TestCompareColumns(t *testing.T) {
+	builder := NewWhereBuilder(Postgres)
+	builder.Equal("status", "active")
+	builder.CompareColumns("updated_at", ">", "created_at")
+	builder.CompareColumns("u.owner_id", "=", "o.user_id")
+	builder.Equal("org", 7)
+
+	sql, params := builder.Build()
+	assert.Equal(t, "status = $1 AND updated_at > created_at AND u.owner_id = o.user_id AND org = $2", sql)
+	assert.Equal(t, []interface{}{"active", 7}, params)
+
+	t.Run("invalid input is skipped and reported without validation", func(t *testing.T) {
+		builder := NewWhereBuilder(Postgres)
+		builder.CompareColumns("updated_at", "; DROP", "created_at")
+		builder.CompareColumns("updated_at", ">", "created_at; DROP TABLE users--")
+		builder.CompareColumns("", "=", "created_at")
+
+		assert.False(t, builder.HasConditions())
+		err := builder.Err()
+		require.Error(t, err)
+		var validationErr *ValidationError
+		assert.ErrorAs(t, err, &validationErr)
+
+		_, _, err = NewAnnotationProcessor(Postgres).ProcessQuery("SELECT * FROM t WHERE true /* sqld:where */", builder, nil, nil, 0)
+		assert.Error(t, err)
+	})
+}
+
+func TestExists(t *testing.T) {
+	t.Run("raw subquery", func(t *testing.T) {
+		builder := NewWhereBuilder(Postgres)
+		builder.Equal("status", "active")
+		builder.Exists("SELECT 1 FROM orders o WHERE o.user_id = u.id AND o.total > ?", 100)
+		builder.NotExists("SELECT 1 FROM bans b WHERE b.user_id = u.id")
+
+		sql, params := builder.Build()
+		assert.Equal(t, "status = $1 AND EXISTS (SELECT 1 FROM orders o WHERE o.user_id = u.id AND o.total > $2) AND NOT EXISTS (SELECT 1 FROM bans b WHERE b.user_id = u.id)", sql)
+		assert.Equal(t, []interface{}{"active", 100}, params)
+	})
+
+	t.Run("query builder subquery is renumbered", func(t *testing.T) {
+		orders := NewWhereBuilder(Postgres)
+		orders.GreaterThan("o.total", 100)
+		orders.Equal("o.status", "paid")
+		sub := NewQueryBuilder("SELECT 1 FROM orders o WHERE o.user_id = u.id", Postgres).Where(orders)
+
+		builder := NewWhereBuilder(Postgres)
+		builder.Equal("u.status", "active")
+		builder.ExistsQuery(sub)
+		builder.Equal("u.org", 7)
+
+		sql, params := builder.Build()
+		assert.Equal(t, "u.status = $1 AND EXISTS (SELECT 1 FROM orders o WHERE o.user_id = u.id AND o.total > $2 AND o.status = $3) AND u.org = $4", sql)
+		assert.Equal(t, []interface{}{"active", 100, "paid", 7}, params)
+	})
+
+	t.Run("exists where", func(t *testing.T) {
+		builder := NewWhereBuilder(Postgres)
+		builder.Equal("status", "active")
+		builder.ExistsWhere("orders", "orders.user_id = users.id", func(sub ConditionBuilder) {
+			sub.GreaterThan("orders.total", 100)
+		})
+
+		sql, params := builder.Build()
+		assert.Equal(t, "status = $1 AND EXISTS (SELECT 1 FROM orders WHERE orders.user_id = users.id AND orders.total > $2)", sql)
+		assert.Equal(t, []interface{}{"active", 100}, params)
+	})
+
+	t.Run("positional dialect", func(t *testing.T) {
+		orders := NewWhereBuilder(MySQL)
+		orders.GreaterThan("o.total", 100)
+		sub := NewQueryBuilder("SELECT 1 FROM orders o WHERE o.user_id = u.id", MySQL).Where(orders)
+
+		builder := NewWhereBuilder(MySQL)
+		builder.Equal("u.status", "active")
+		builder.NotExistsQuery(sub)
+
+		sql, params := builder.Build()
+		assert.Equal(t, "u.status = ? AND NOT EXISTS (SELECT 1 FROM orders o WHERE o.user_id = u.id AND o.total > ?)", sql)
+		assert.Equal(t, []interface{}{"active", 100}, params)
+	})
+}
+
+func TestWhereBuilder_QuoteIdentifiers(t *testing.T) {
+	t.Run("postgres", func(t *testing.T) {
+		builder := NewWhereBuilder(Postgres).QuoteIdentifiers(true)
+		builder.Equal("order", 1)
+		builder.In("u.Group", []interface{}{"a"})
+		builder.Or(func(or ConditionBuilder) {
+			or.IsNull("deletedAt")
+			or.(ExtendedConditionBuilder).CompareColumns("updated_at", ">", "created_at")
+		})
+		builder.Raw("LOWER(name) = ?", "john")
+
+		sql, params := builder.Build()
+		assert.Equal(t, `"order" = $1 AND "u"."Group" IN ($2) AND ("deletedAt" IS NULL OR "updated_at" > "created_at") AND LOWER(name) = $3`, sql)
+		assert.Equal(t, []interface{}{1, "a", "john"}, params)
+	})
+
+	t.Run("mysql", func(t *testing.T) {
+		builder := NewWhereBuilder(MySQL).QuoteIdentifiers(true)
+		builder.ILike("group", "%a%")
+
+		sql, _ := builder.Build()
+		assert.Equal(t, "LOWER(`group`) LIKE LOWER(?)", sql)
+	})
+
+	t.Run("expressions are left alone", func(t *testing.T) {
+		builder := NewWhereBuilder(Postgres).QuoteIdentifiers(true)
+		builder.Equal(`"already"`, 1)
+		builder.Equal("COALESCE(a, b)", 2)
+
+		sql, _ := builder.Build()
+		assert.Equal(t, `"already" = $1 AND COALESCE(a, b) = $2`, sql)
+	})
+}
+
+func TestWhereBuilder_Similar(t *testing.T) {
+	t.Run("trigram operator", func(t *testing.T) {
+		builder := NewWhereBuilder(Postgres)
+		builder.Similar("name", "jon")
+
+		sql, params := builder.Build()
+		assert.Equal(t, "name % $1", sql)
+		assert.Equal(t, []interface{}{"jon"}, params)
+	})
+
+	t.Run("threshold", func(t *testing.T) {
+		builder := NewWhereBuilder(Postgres).SimilarityThreshold(0.4)
+		builder.Equal("status", "active")
+		builder.Or(func(or ConditionBuilder) {
+			ext := or.(ExtendedConditionBuilder)
+			ext.Similar("name", "jon")
+			ext.Similar("email", "jon")
+		})
+
+		sql, params := builder.Build()
+		assert.Equal(t, "status = $1 AND (similarity(name, $2) >= $3 OR similarity(email, $4) >= $5)", sql)
+		assert.Equal(t, []interface{}{"active", "jon", 0.4, "jon", 0.4}, params)
+	})
+
+	t.Run("like fallback", func(t *testing.T) {
+		builder := NewWhereBuilder(MySQL).SimilarityThreshold(0.4)
+		builder.Similar("name", "jon")
+
+		sql, params := builder.Build()
+		assert.Equal(t, "LOWER(name) LIKE LOWER(?)", sql)
+		assert.Equal(t, []interface{}{"%jon%"}, params)
+	})
+}
+
+func TestWhereBuilder_WithValidation(t *testing.T) {
+	const injected = "name; DROP TABLE users--"
+
+	t.Run("lenient builder keeps legacy behavior", func(t *testing.T) {
+		builder := NewWhereBuilder(Postgres)
+		builder.Equal(injected, "x")
+
+		assert.NoError(t, builder.Err())
+		assert.True(t, builder.HasConditions())
+	})
+
+	t.Run("strict builder rejects invalid columns", func(t *testing.T) {
+		builder := NewWhereBuilder(Postgres).WithValidation(true)
+		builder.Equal("status", "active")
+		builder.Equal(injected, "x")
+		builder.In("role; --", []interface{}{"admin"})
+
+		_, _, err := builder.BuildChecked()
+		var validationErr *ValidationError
+		assert.ErrorAs(t, err, &validationErr)
+
+		sql, params := builder.Build()
+		assert.Equal(t, "status = $1", sql)
+		assert.Equal(t, []interface{}{"active"}, params)
+	})
+
+	t.Run("errors propagate from nested builders", func(t *testing.T) {
+		builder := NewWhereBuilder(Postgres).WithValidation(true)
+		builder.Or(func(or ConditionBuilder) {
+			or.Equal(injected, "x")
+		})
+		assert.Error(t, builder.Err())
+
+		combined := CombineConditions(Postgres, NewWhereBuilder(Postgres), builder)
+		assert.Error(t, combined.Err())
+
+		_, _, err := NewAnnotationProcessor(Postgres).ProcessQuery("SELECT * FROM users WHERE true /* sqld:where */", combined, nil, nil, 0)
+		assert.Error(t, err)
+	})
+
+	t.Run("valid strict builder", func(t *testing.T) {
+		builder := NewWhereBuilder(Postgres).WithValidation(true)
+		builder.Equal("u.name", "john")
+
+		sql, params, err := builder.BuildChecked()
+		assert.NoError(t, err)
+		assert.Equal(t, "u.name = $1", sql)
+		assert.Equal(t, []interface{}{"john"}, params)
+	})
+}
+
+func TestEqualNilHandling(t *testing.T) {
+	var missing *string
+	name := "john"
+
+	t.Run("equal or null", func(t *testing.T) {
+		builder := NewWhereBuilder(Postgres)
+		builder.EqualOrNull("manager_id", nil)
+		builder.EqualOrNull("nickname", missing)
+		builder.EqualOrNull("name", &name)
+
+		sql, params := builder.Build()
+		assert.Equal(t, "manager_id IS NULL AND nickname IS NULL AND name = $1", sql)
+		assert.Equal(t, []interface{}{&name}, params)
+	})
+
+	t.Run("strict nil reports an error", func(t *testing.T) {
+		builder := NewWhereBuilder(Postgres).StrictNil(true)
+		builder.Equal("status", "active")
+		builder.Equal("nickname", missing)
+
+		_, _, err := builder.BuildChecked()
+		assert.ErrorIs(t, err, ErrInvalidParameter)
+		assert.Contains(t, err.Error(), "nickname")
+	})
+
+	t.Run("lenient equal keeps dropping untyped nil", func(t *testing.T) {
+		builder := NewWhereBuilder(Postgres)
+		builder.Equal("nickname", nil)
+
+		assert.False(t, builder.HasConditions())
+		assert.NoError(t, builder.Err())
+	})
+}
+
 func TestQueryBuilder(t *testing.T) {
 	baseQuery := "SELECT * FROM users"
 
@@ -159,6 +431,132 @@ func TestQueryBuilder(t *testing.T) {
 	assert.Equal(t, []interface{}{"active", 18}, params)
 }
 
+func TestQueryBuilder_BaseWhere(t *testing.T) {
+	tests := []struct {
+		name     string
+		base     string
+		expected string
+	}{
+		{"existing predicate", "SELECT * FROM users WHERE active", "SELECT * FROM users WHERE active AND org = $1"},
+		{"OR predicate", "SELECT * FROM users WHERE a = 1 OR b = 2", "SELECT * FROM users WHERE (a = 1 OR b = 2) AND org = $1"},
+		{"OR in a subquery only", "SELECT * FROM users WHERE id IN (SELECT user_id FROM m WHERE a OR b)", "SELECT * FROM users WHERE id IN (SELECT user_id FROM m WHERE a OR b) AND org = $1"},
+		{"WHERE in a subquery only", "SELECT * FROM (SELECT * FROM users WHERE active) u", "SELECT * FROM (SELECT * FROM users WHERE active) u WHERE org = $1"},
+		{"WHERE in a literal and identifier", `SELECT 'WHERE' AS nowhere, "where" FROM users`, `SELECT 'WHERE' AS nowhere, "where" FROM users WHERE org = $1`},
+		{"clause after WHERE", "SELECT * FROM users WHERE active ORDER BY id LIMIT 10", "SELECT * FROM users WHERE active AND org = $1 ORDER BY id LIMIT 10"},
+		{"LIMIT without WHERE", "SELECT * FROM users LIMIT 10", "SELECT * FROM users WHERE org = $1 LIMIT 10"},
+		{"OR predicate before GROUP BY", "SELECT org, COUNT(*) FROM users WHERE a OR b GROUP BY org HAVING COUNT(*) > 1", "SELECT org, COUNT(*) FROM users WHERE (a OR b) AND org = $1 GROUP BY org HAVING COUNT(*) > 1"},
+		{"OR after the clauses", "SELECT * FROM users WHERE active ORDER BY a OR b", "SELECT * FROM users WHERE active AND org = $1 ORDER BY a OR b"},
+		{"line comment before a clause", "SELECT * FROM users WHERE active -- only active\nLIMIT 10", "SELECT * FROM users WHERE active -- only active\n AND org = $1 LIMIT 10"},
+		{"qualified ORDER BY", "SELECT u.* FROM users u JOIN orgs o ON o.id = u.org ORDER BY u.name", "SELECT u.* FROM users u JOIN orgs o ON o.id = u.org WHERE org = $1 ORDER BY u.name"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			where := NewWhereBuilder(Postgres)
+			where.Equal("org", 7)
+			query, params := NewQueryBuilder(tt.base, Postgres).Where(where).Build()
+			assert.Equal(t, tt.expected, query)
+			assert.Equal(t, []interface{}{7}, params)
+		})
+	}
+
+	t.Run("qualified condition column", func(t *testing.T) {
+		where := NewWhereBuilder(Postgres)
+		where.Equal("u.status", "active")
+		query, params, err := NewQueryBuilder("SELECT u.id FROM users u JOIN orgs o ON o.id = u.org_id WHERE o.active LIMIT 20", Postgres).
+			Where(where).
+			BuildChecked()
+		require.NoError(t, err)
+		assert.Equal(t, "SELECT u.id FROM users u JOIN orgs o ON o.id = u.org_id WHERE o.active AND u.status = $1 LIMIT 20", query)
+		assert.Equal(t, []interface{}{"active"}, params)
+	})
+
+	t.Run("compound query", func(t *testing.T) {
+		where := NewWhereBuilder(Postgres)
+		where.Equal("org", 7)
+		qb := NewQueryBuilder("SELECT id, org FROM users UNION SELECT id, org FROM admins", Postgres).Where(where)
+
+		_, _, err := qb.BuildChecked()
+		assert.ErrorIs(t, err, ErrInvalidQuery)
+
+		query, params := qb.Build()
+		assert.Equal(t, "SELECT id, org FROM users UNION SELECT id, org FROM admins", query)
+		assert.Empty(t, params)
+	})
+
+	t.Run("union in a subquery", func(t *testing.T) {
+		where := NewWhereBuilder(Postgres)
+		where.Equal("org", 7)
+		query, _, err := NewQueryBuilder("SELECT * FROM (SELECT id, org FROM users UNION SELECT id, org FROM admins) AS people", Postgres).
+			Where(where).
+			BuildChecked()
+		require.NoError(t, err)
+		assert.Equal(t, "SELECT * FROM (SELECT id, org FROM users UNION SELECT id, org FROM admins) AS people WHERE org = $1", query)
+	})
+}
+
+func TestQueryBuilder_WithCTE(t *testing.T) {
+	t.Run("renumbers CTEs and conditions", func(t *testing.T) {
+		where := NewWhereBuilder(Postgres)
+		where.GreaterThan("total", 100)
+
+		query, params := NewQueryBuilder("SELECT * FROM totals JOIN recent USING (user_id)", Postgres).
+			WithCTE("totals", "SELECT user_id, SUM(amount) AS total FROM orders WHERE org_id = $1 GROUP BY user_id", 7).
+			WithCTE("recent", "SELECT user_id FROM logins WHERE at > $1 AND kind = $2", "2024-01-01", "web").
+			Where(where).
+			Build()
+
+		assert.Equal(t, "WITH totals AS (SELECT user_id, SUM(amount) AS total FROM orders WHERE org_id = $1 GROUP BY user_id), "+
+			"recent AS (SELECT user_id FROM logins WHERE at > $2 AND kind = $3) "+
+			"SELECT * FROM totals JOIN recent USING (user_id) WHERE total > $4", query)
+		assert.Equal(t, []interface{}{7, "2024-01-01", "web", 100}, params)
+	})
+
+	t.Run("positional placeholders", func(t *testing.T) {
+		where := NewWhereBuilder(MySQL)
+		where.Equal("status", "paid")
+
+		query, params := NewQueryBuilder("SELECT * FROM t", MySQL).
+			WithCTE("t", "SELECT * FROM orders WHERE org_id = ?", 7).
+			Where(where).
+			Build()
+
+		assert.Equal(t, "WITH t AS (SELECT * FROM orders WHERE org_id = ?) SELECT * FROM t WHERE status = ?", query)
+		assert.Equal(t, []interface{}{7, "paid"}, params)
+	})
+
+	t.Run("merges with the base query's WITH", func(t *testing.T) {
+		query, _ := NewQueryBuilder("WITH RECURSIVE tree AS (SELECT id FROM nodes) SELECT * FROM tree JOIN t USING (id)", Postgres).
+			WithCTE("t", "SELECT id FROM tags").
+			Build()
+		assert.Equal(t, "WITH RECURSIVE t AS (SELECT id FROM tags), tree AS (SELECT id FROM nodes) SELECT * FROM tree JOIN t USING (id)", query)
+
+		query, _ = NewQueryBuilder("with a AS (SELECT 1) SELECT * FROM a, t", Postgres).
+			WithCTE("t", "SELECT 2").
+			Build()
+		assert.Equal(t, "WITH t AS (SELECT 2), a AS (SELECT 1) SELECT * FROM a, t", query)
+	})
+
+	t.Run("annotated query in a CTE", func(t *testing.T) {
+		annotated := "SELECT user_id, SUM(amount) AS total FROM orders WHERE org_id = $1 /* sqld:where */ GROUP BY user_id"
+		inner := NewWhereBuilder(Postgres)
+		inner.Equal("status", "paid")
+		totals, totalsParams, err := NewAnnotationProcessor(Postgres).ProcessQuery(annotated, inner, nil, nil, 0, 7)
+		require.NoError(t, err)
+
+		outer := NewWhereBuilder(Postgres)
+		outer.GreaterThan("total", 100)
+		query, params := NewQueryBuilder("SELECT * FROM totals", Postgres).
+			WithCTE("totals", totals, totalsParams...).
+			Where(outer).
+			Build()
+
+		assert.Equal(t, "WITH totals AS (SELECT user_id, SUM(amount) AS total FROM orders WHERE org_id = $1  AND status = $2 GROUP BY user_id) "+
+			"SELECT * FROM totals WHERE total > $3", query)
+		assert.Equal(t, []interface{}{7, "paid", 100}, params)
+	})
+}
+
 func TestSearchPattern(t *testing.T) {
 	tests := []struct {
 		text     string
@@ -207,6 +605,167 @@ func TestConditionalWhere(t *testing.T) {
 	assert.Contains(t, sql, "country2 = $3")
 }
 
+func TestConditionalWhereWith(t *testing.T) {
+	var nilInt *int
+	zero := 0
+	empty := ""
+
+	t.Run("skip nil keeps zero values", func(t *testing.T) {
+		builder := NewWhereBuilder(Postgres)
+		ConditionalWhereWith(builder, "age", 0, SkipNil)
+		ConditionalWhereWith(builder, "name", "", SkipNil)
+		ConditionalWhereWith(builder, "count", nilInt, SkipNil)
+		ConditionalWhereWith(builder, "score", &zero, SkipNil)
+
+		sql, params := builder.Build()
+		assert.Equal(t, "age = $1 AND name = $2 AND score = $3", sql)
+		assert.Equal(t, []interface{}{0, "", 0}, params)
+	})
+
+	t.Run("skip zero", func(t *testing.T) {
+		builder := NewWhereBuilder(Postgres)
+		ConditionalWhereWith(builder, "age", 0, SkipZero)
+		ConditionalWhereWith(builder, "name", &empty, SkipZero)
+		ConditionalWhereWith(builder, "count", nilInt, SkipZero)
+
+		assert.False(t, builder.HasConditions())
+	})
+
+	t.Run("skip zero keeps the original ConditionalWhere rules", func(t *testing.T) {
+		builder := NewWhereBuilder(Postgres)
+		ConditionalWhere(builder, "level", int8(0))
+		ConditionalWhere(builder, "flags", uint(0))
+		ConditionalWhere(builder, "score", &zero)
+		ConditionalWhere(builder, "org_id", int32(0))
+		ConditionalWhere(builder, "balance", int64(0))
+		ConditionalWhere(builder, "active", false)
+
+		sql, params := builder.Build()
+		assert.Equal(t, "level = $1 AND flags = $2 AND score = $3 AND org_id = $4 AND balance = $5 AND active = $6", sql)
+		assert.Equal(t, []interface{}{int8(0), uint(0), 0, int32(0), int64(0), false}, params)
+	})
+
+	t.Run("custom skip func", func(t *testing.T) {
+		builder := NewWhereBuilder(Postgres)
+		skipAny := func(value interface{}) bool { return value == "any" }
+		ConditionalWhereWith(builder, "status", "any", skipAny)
+		ConditionalWhereWith(builder, "role", "admin", skipAny)
+
+		sql, _ := builder.Build()
+		assert.Equal(t, "role = $1", sql)
+	})
+}
+
+func TestWhereIfPresent(t *testing.T) {
+	zero := 0
+	var missing *string
+
+	builder := NewWhereBuilder(Postgres)
+	WhereIfPresent(builder, "age", &zero)
+	WhereIfPresent(builder, "name", missing)
+	WhereIfValid(builder, "email", sql.Null[string]{V: "", Valid: true})
+	WhereIfValid(builder, "phone", sql.Null[string]{})
+
+	query, params := builder.Build()
+	assert.Equal(t, "age = $1 AND email = $2", query)
+	assert.Equal(t, []interface{}{0, ""}, params)
+}
+
+// centsValuer binds as a float of whole units and is NULL when negative
+type centsValuer int64
+
+func (c *centsValuer) Value() (driver.Value, error) {
+	if *c < 0 {
+		return nil, nil
+	}
+	return float64(*c) / 100, nil
+}
+
+func TestValuerParams(t *testing.T) {
+	price, unset := centsValuer(1250), centsValuer(-1)
+
+	t.Run("conditional helpers keep valuers", func(t *testing.T) {
+		builder := NewWhereBuilder(Postgres)
+		ConditionalWhere(builder, "price", &price)
+		ConditionalWhere(builder, "cost", &unset)
+		ConditionalWhereWith(builder, "deleted_at", sql.NullTime{}, SkipNil)
+
+		query, params := builder.Build()
+		assert.Equal(t, "price = $1", query)
+		assert.Equal(t, []interface{}{&price}, params)
+	})
+
+	t.Run("NULL valuers", func(t *testing.T) {
+		builder := NewWhereBuilder(Postgres)
+		builder.EqualOrNull("email", sql.NullString{})
+		query, params := builder.Build()
+		assert.Equal(t, "email IS NULL", query)
+		assert.Empty(t, params)
+
+		strict := NewWhereBuilder(Postgres).StrictNil(true)
+		strict.Equal("email", sql.NullString{})
+		assert.ErrorIs(t, strict.Err(), ErrInvalidParameter)
+	})
+
+	t.Run("array elements are resolved", func(t *testing.T) {
+		low, high := centsValuer(100), centsValuer(250)
+		builder := NewWhereBuilder(Postgres).ArrayIn(true)
+		builder.In("price", []interface{}{&low, &high})
+
+		query, params := builder.Build()
+		assert.Equal(t, "price = ANY($1)", query)
+		assert.Equal(t, []interface{}{[]float64{1, 2.5}}, params)
+	})
+}
+
+func TestHelpersOverConditionBuilder(t *testing.T) {
+	status := "active"
+	builder := NewWhereBuilder(Postgres)
+
+	// Helpers hand back the builder's own type, so no assertion is needed
+	var where *WhereBuilder = ConditionalWhere(builder, "name", "john")
+	where = WhereIfPresent(where, "status", &status)
+	where = EqualT(where, "org", 7).Freeze()
+
+	grouped := NewWhereBuilder(Postgres)
+	grouped.Or(func(or ConditionBuilder) {
+		ConditionalWhere(or, "role", "admin")
+		ConditionalWhere(or, "role", "")
+		WhereIfValid(or, "owner", sql.Null[bool]{V: true, Valid: true})
+	})
+
+	var parts []ConditionBuilder
+	parts = append(parts, where, nil, grouped)
+	combined := CombineBuilders(Postgres, parts...)
+
+	query, params := combined.Build()
+	assert.Equal(t, "name = $1 AND status = $2 AND org = $3 AND (role = $4 OR owner = $5)", query)
+	assert.Equal(t, []interface{}{"john", "active", 7, "admin", true}, params)
+
+	var nilBuilder *WhereBuilder
+	assert.False(t, CombineConditions(Postgres, nilBuilder).HasConditions())
+}
+
+// baseBuilder has only the methods of ConditionBuilder, like builders
+// implemented outside the package
+type baseBuilder struct{ ConditionBuilder }
+
+func TestExtendedConditionBuilder(t *testing.T) {
+	base := baseBuilder{NewWhereBuilder(Postgres)}
+	_, isExtended := interface{}(base).(ExtendedConditionBuilder)
+	assert.False(t, isExtended)
+
+	require.NoError(t, applyFilter(Filter{Field: "age", Operator: OpGt, Value: 18}, base))
+	assert.ErrorIs(t, applyFilter(Filter{Field: "age", Operator: OpGte, Value: 18}, base), ErrInvalidParameter)
+
+	not := FilterNode{Kind: NodeNot, Children: []FilterNode{{Kind: NodeCondition, Filter: &Filter{Field: "age", Operator: OpGt, Value: 18}}}}
+	assert.ErrorIs(t, not.Apply(base), ErrInvalidParameter)
+
+	query, params := base.Build()
+	assert.Equal(t, "age > $1", query)
+	assert.Equal(t, []interface{}{18}, params)
+}
+
 func TestCombineConditions(t *testing.T) {
 	where1 := NewWhereBuilder(Postgres)
 	where1.Equal("name", "John")
@@ -225,6 +784,10 @@ func TestCombineConditions(t *testing.T) {
 	assert.Equal(t, []interface{}{"John", "active"}, params)
 	assert.Contains(t, sql, "name = $1")
 	assert.Contains(t, sql, "status = $2")
+
+	// The signature stays usable as a function value and with untyped nil
+	var combine func(Dialect, ...*WhereBuilder) *WhereBuilder = CombineConditions
+	assert.False(t, combine(Postgres, nil, where3).HasConditions())
 }
 
 func TestRawSQL(t *testing.T) {
@@ -239,6 +802,116 @@ func TestRawSQL(t *testing.T) {
 	assert.Equal(t, []interface{}{"2024-01-01", "active"}, params)
 }
 
+func TestRawNamed(t *testing.T) {
+	named := map[string]interface{}{"start": "2024-01-01", "end": "2024-12-31"}
+	const condition = "(created_at::date BETWEEN :start AND :end OR updated_at > :start) AND note <> ':start' /* :end */"
+
+	t.Run("postgres reuses placeholders", func(t *testing.T) {
+		builder := NewWhereBuilder(Postgres)
+		builder.Equal("status", "active")
+		builder.RawNamed(condition, named)
+		builder.Equal("role", "admin")
+
+		sql, params, err := builder.BuildChecked()
+		require.NoError(t, err)
+		assert.Equal(t,
+			"status = $1 AND (created_at::date BETWEEN $2 AND $3 OR updated_at > $2) AND note <> ':start' /* :end */ AND role = $4",
+			sql)
+		assert.Equal(t, []interface{}{"active", "2024-01-01", "2024-12-31", "admin"}, params)
+	})
+
+	t.Run("positional dialects bind each use", func(t *testing.T) {
+		builder := NewWhereBuilder(MySQL)
+		builder.RawNamed(condition, named)
+
+		sql, params := builder.Build()
+		assert.Equal(t, "(created_at::date BETWEEN ? AND ? OR updated_at > ?) AND note <> ':start' /* :end */", sql)
+		assert.Equal(t, []interface{}{"2024-01-01", "2024-12-31", "2024-01-01"}, params)
+	})
+
+	t.Run("missing value", func(t *testing.T) {
+		builder := NewWhereBuilder(Postgres)
+		builder.RawNamed("created_at > :since AND owner = :owner", map[string]interface{}{"owner": 1})
+
+		assert.False(t, builder.HasConditions())
+		assert.ErrorIs(t, builder.Err(), ErrInvalidParameter)
+		assert.ErrorContains(t, builder.Err(), ":since")
+	})
+
+	t.Run("inside or group", func(t *testing.T) {
+		builder := NewWhereBuilder(Postgres)
+		builder.Equal("status", "active")
+		builder.Or(func(cb ConditionBuilder) {
+			cb.(ExtendedConditionBuilder).RawNamed("score > :min", map[string]interface{}{"min": 10})
+			cb.Equal("vip", true)
+		})
+
+		sql, params := builder.Build()
+		assert.Equal(t, "status = $1 AND (score > $2 OR vip = $3)", sql)
+		assert.Equal(t, []interface{}{"active", 10, true}, params)
+	})
+}
+
+func TestInLists(t *testing.T) {
+	values := []interface{}{1, 2, 3, 4, 5}
+
+	t.Run("chunked", func(t *testing.T) {
+		builder := NewWhereBuilder(Postgres).ChunkInLists(2)
+		builder.In("id", values)
+		builder.NotIn("owner_id", []interface{}{7, 8, 9})
+		builder.NotInNullSafe("team_id", []interface{}{1, 2})
+
+		sql, params := builder.Build()
+		assert.Equal(t,
+			"(id IN ($1, $2) OR id IN ($3, $4) OR id IN ($5)) AND "+
+				"(owner_id NOT IN ($6, $7) AND owner_id NOT IN ($8)) AND "+
+				"(team_id IS NULL OR team_id NOT IN ($9, $10))",
+			sql)
+		assert.Equal(t, []interface{}{1, 2, 3, 4, 5, 7, 8, 9, 1, 2}, params)
+	})
+
+	t.Run("array binding", func(t *testing.T) {
+		builder := NewWhereBuilder(Postgres).ArrayIn(true)
+		builder.Equal("status", "active")
+		builder.In("id", values)
+		builder.NotIn("owner_id", []interface{}{7, 8})
+
+		sql, params := builder.Build()
+		assert.Equal(t, "status = $1 AND id = ANY($2) AND owner_id <> ALL($3)", sql)
+		assert.Equal(t, []interface{}{"active", []int{1, 2, 3, 4, 5}, []int{7, 8}}, params)
+	})
+
+	t.Run("mixed types stay untyped", func(t *testing.T) {
+		mixed := []interface{}{1, "two"}
+		builder := NewWhereBuilder(Postgres).ArrayIn(true)
+		builder.In("id", mixed)
+
+		sql, params := builder.Build()
+		assert.Equal(t, "id = ANY($1)", sql)
+		assert.Equal(t, []interface{}{mixed}, params)
+	})
+
+	t.Run("array binding falls back on mysql", func(t *testing.T) {
+		builder := NewWhereBuilder(MySQL).ArrayIn(true).ChunkInLists(3)
+		builder.In("id", values)
+
+		sql, params := builder.Build()
+		assert.Equal(t, "(id IN (?, ?, ?) OR id IN (?, ?))", sql)
+		assert.Equal(t, values, params)
+	})
+
+	t.Run("options reach or groups", func(t *testing.T) {
+		builder := NewWhereBuilder(Postgres).ArrayIn(true)
+		builder.Or(func(cb ConditionBuilder) {
+			cb.In("id", values)
+			cb.Equal("vip", true)
+		})
+
+		sql, _ := builder.Build()
+		assert.Equal(t, "(id = ANY($1) OR vip = $2)", sql)
+	})
+}
+
 func TestParameterAdjuster(t *testing.T) {
 	adjuster := NewParameterAdjuster(Postgres)
 
@@ -250,6 +923,45 @@ func TestParameterAdjuster(t *testing.T) {
 	assert.Contains(t, adjustedSQL, "$7") // $2 + 5
 }
 
+func TestRenumberPlaceholders(t *testing.T) {
+	tests := []struct {
+		sql    string
+		offset int
+		want   string
+	}{
+		{"a = $1 AND b = $10", 2, "a = $3 AND b = $12"},
+		{"a = $1", 0, "a = $1"},
+		{"price = '$' || $1 AND $$x$$ = $2", 1, "price = '$' || $2 AND $$x$$ = $3"},
+		{"a = $99999999999999999999", 1, "a = $99999999999999999999"},
+		{"a = $1$2", 1, "a = $2$3"},
+		{"no placeholders", 3, "no placeholders"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, renumberPlaceholders(tt.sql, tt.offset), tt.sql)
+	}
+}
+
+func TestWhereBuilder_RawPlaceholders(t *testing.T) {
+	where := NewWhereBuilder(Postgres)
+	where.Equal("a", 1)
+	where.Raw("b = ? OR c = ?", 2, 3)
+	where.Raw("d = ?", 4, 5) // the unused parameter still takes $5
+	where.Equal("e", 6)
+
+	sql, params := where.Build()
+	assert.Equal(t, "a = $1 AND b = $2 OR c = $3 AND d = $4 AND e = $6", sql)
+	assert.Len(t, params, 6)
+}
+
+func TestReplaceAnnotations(t *testing.T) {
+	sql := "SELECT * /* sqld:total */ FROM t WHERE a /* sqld:where */ /* sqld:where */ /* sqld:limit max=5 */"
+	got := replaceAnnotations(sql, []annotationReplacement{
+		{annotation: "/* sqld:where */", replacement: " AND b"},
+		{annotation: "/* sqld:total */"},
+	})
+	assert.Equal(t, "SELECT *  FROM t WHERE a  AND b /* sqld:where */ /* sqld:limit max=5 */", got)
+}
+
 func TestDialectSpecificFeatures(t *testing.T) {
 	t.Run("PostgreSQL ILIKE", func(t *testing.T) {
 		builder := NewWhereBuilder(Postgres)
@@ -474,3 +1186,333 @@ func TestAnnotationProcessor_OrderByEdgeCases(t *testing.T) {
 		assert.Equal(t, 1, numReplacements)
 	})
 }
+
+func TestAnnotationProcessor_Union(t *testing.T) {
+	const union = `SELECT id, name, created_at FROM users WHERE org_id = %s /* sqld:where */ /* sqld:cursor */
+UNION ALL
+SELECT id, name, created_at FROM admins WHERE org_id = %s AND 'a?' <> '' /* sqld:where */ /* sqld:cursor */
+ORDER BY created_at DESC /* sqld:orderby */ /* sqld:limit */`
+
+	t.Run("numbered placeholders are shared by the branches", func(t *testing.T) {
+		where := NewWhereBuilder(Postgres)
+		where.Equal("name", "ann")
+		orderBy := NewOrderByBuilder().Asc("created_at").Asc("id")
+		cursor := &Cursor{CreatedAt: "2024-01-01", ID: 9}
+
+		sql, params, err := NewAnnotationProcessor(Postgres).ProcessQuery(
+			fmt.Sprintf(union, "$1", "$2"), where, cursor, orderBy, 10, 7, 8)
+		require.NoError(t, err)
+		assert.Equal(t, `SELECT id, name, created_at FROM users WHERE org_id = $1  AND (created_at > $3 OR (created_at = $3 AND id > $4)) AND name = $5 
+UNION ALL
+SELECT id, name, created_at FROM admins WHERE org_id = $2 AND 'a?' <> ''  AND (created_at > $3 OR (created_at = $3 AND id > $4)) AND name = $5 
+ORDER BY created_at ASC, id ASC   LIMIT $6`, sql)
+		assert.Equal(t, []interface{}{7, 8, "2024-01-01", int32(9), "ann", 10}, params)
+	})
+
+	t.Run("positional parameters are repeated per branch", func(t *testing.T) {
+		where := NewWhereBuilder(MySQL)
+		where.Equal("name", "ann")
+
+		sql, params, err := NewAnnotationProcessor(MySQL).ProcessQuery(
+			fmt.Sprintf(union, "?", "?"), where, nil, nil, 10, 7, 8)
+		require.NoError(t, err)
+		assert.Equal(t, `SELECT id, name, created_at FROM users WHERE org_id = ?  AND name = ? 
+UNION ALL
+SELECT id, name, created_at FROM admins WHERE org_id = ? AND 'a?' <> ''  AND name = ? 
+ORDER BY created_at DESC   LIMIT ?`, sql)
+		assert.Equal(t, []interface{}{7, "ann", 8, "ann", 10}, params)
+	})
+
+	t.Run("union in a subquery is not split", func(t *testing.T) {
+		where := NewWhereBuilder(Postgres)
+		where.Equal("name", "ann")
+		sql, params, err := NewAnnotationProcessor(Postgres).ProcessQuery(
+			"SELECT * FROM users WHERE id IN (SELECT id FROM a UNION SELECT id FROM b) /* sqld:where */ AND 1 = 1 /* sqld:where */",
+			where, nil, nil, 0)
+		require.NoError(t, err)
+		assert.Equal(t, "SELECT * FROM users WHERE id IN (SELECT id FROM a UNION SELECT id FROM b)  AND name = $1 AND 1 = 1 /* sqld:where */", sql)
+		assert.Equal(t, []interface{}{"ann"}, params)
+	})
+}
+
+func TestAnnotationProcessor_CursorSortMismatch(t *testing.T) {
+	processor := NewAnnotationProcessor(Postgres)
+	query := "SELECT * FROM users WHERE true /* sqld:cursor */ /* sqld:where */ ORDER BY created_at DESC /* sqld:orderby */"
+
+	orderBy := NewOrderByBuilder().Asc("created_at").Asc("id")
+	assert.Equal(t, "created_at:asc,id:asc", orderBy.SortSpec())
+
+	encoded := EncodeCursorWithSort("2024-01-01T00:00:00Z", 42, orderBy.SortSpec())
+	cursor, err := DecodeCursor(encoded)
+	require.NoError(t, err)
+	assert.Equal(t, "created_at:asc,id:asc", cursor.Sort)
+
+	t.Run("same sort", func(t *testing.T) {
+		sql, params, err := processor.ProcessQuery(query, nil, cursor, NewOrderByBuilder().Asc("created_at").Asc("id"), 0)
+		assert.NoError(t, err)
+		assert.Contains(t, sql, "(created_at > $1 OR (created_at = $1 AND id > $2))")
+		assert.Equal(t, []interface{}{"2024-01-01T00:00:00Z", int32(42)}, params)
+	})
+
+	t.Run("different sort", func(t *testing.T) {
+		_, _, err := processor.ProcessQuery(query, nil, cursor, NewOrderByBuilder().Asc("name"), 0)
+		assert.ErrorIs(t, err, ErrInvalidCursor)
+	})
+
+	t.Run("sort dropped", func(t *testing.T) {
+		_, _, err := processor.ProcessQuery(query, nil, cursor, nil, 0)
+		assert.ErrorIs(t, err, ErrInvalidCursor)
+	})
+
+	t.Run("legacy cursor without sort", func(t *testing.T) {
+		legacy, err := DecodeCursor(EncodeCursor("2024-01-01T00:00:00Z", 42))
+		require.NoError(t, err)
+		sql, _, err := processor.ProcessQuery(query, nil, legacy, nil, 0)
+		assert.NoError(t, err)
+		assert.Contains(t, sql, "(created_at < $1 OR (created_at = $1 AND id < $2))")
+
+		_, _, err = processor.ProcessQuery(query, nil, legacy, NewOrderByBuilder().Desc("created_at").Desc("id"), 0)
+		assert.NoError(t, err)
+	})
+
+	t.Run("seek direction follows the leading created_at", func(t *testing.T) {
+		legacy, err := DecodeCursor(EncodeCursor("2024-01-01T00:00:00Z", 42))
+		require.NoError(t, err)
+		tests := []struct {
+			name     string
+			orderBy  *OrderByBuilder
+			expected string
+		}{
+			{"created_at alone", NewOrderByBuilder().Desc("created_at"), "(created_at < $1 OR (created_at = $1 AND id < $2))"},
+			{"created_at ascending alone", NewOrderByBuilder().Asc("created_at"), "(created_at > $1 OR (created_at = $1 AND id > $2))"},
+			{"example config sort", NewOrderByBuilder().Desc("created_at").Asc("id"), "(created_at < $1 OR (created_at = $1 AND id > $2))"},
+			{"ascending with descending id", NewOrderByBuilder().Asc("created_at").Desc("id"), "(created_at > $1 OR (created_at = $1 AND id < $2))"},
+			{"trailing fields", NewOrderByBuilder().Desc("created_at").Desc("id").Asc("name"), "(created_at < $1 OR (created_at = $1 AND id < $2))"},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				sql, _, err := processor.ProcessQuery(query, nil, legacy, tt.orderBy, 0)
+				require.NoError(t, err)
+				assert.Contains(t, sql, tt.expected)
+			})
+		}
+	})
+
+	t.Run("sorts the cursor cannot continue", func(t *testing.T) {
+		legacy, err := DecodeCursor(EncodeCursor("2024-01-01T00:00:00Z", 42))
+		require.NoError(t, err)
+		for _, sort := range []*OrderByBuilder{
+			NewOrderByBuilder().Asc("name"),
+			NewOrderByBuilder().Desc("id").Desc("created_at"),
+		} {
+			_, _, err = processor.ProcessQuery(query, nil, legacy, sort, 0)
+			assert.ErrorIs(t, err, ErrInvalidCursor, sort.SortSpec())
+		}
+	})
+}
+
+func TestAnnotationProcessor_CursorPlaceholders(t *testing.T) {
+	cursor := &Cursor{CreatedAt: "2024-01-01T00:00:00Z", ID: 42}
+
+	tests := []struct {
+		dialect        Dialect
+		status         string
+		expectedSQL    []string
+		expectedParams []interface{}
+	}{
+		{
+			Postgres,
+			"$1",
+			[]string{"(created_at < $2 OR (created_at = $2 AND id < $3)) AND age = $4", "LIMIT $5"},
+			[]interface{}{"active", "2024-01-01T00:00:00Z", int32(42), 30, 10},
+		},
+		{
+			MySQL,
+			"?",
+			[]string{"(created_at < ? OR (created_at = ? AND id < ?)) AND age = ?", "LIMIT ?"},
+			[]interface{}{"active", "2024-01-01T00:00:00Z", "2024-01-01T00:00:00Z", int32(42), 30, 10},
+		},
+		{
+			SQLite,
+			"?",
+			[]string{"(created_at < ? OR (created_at = ? AND id < ?)) AND age = ?", "LIMIT ?"},
+			[]interface{}{"active", "2024-01-01T00:00:00Z", "2024-01-01T00:00:00Z", int32(42), 30, 10},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.dialect), func(t *testing.T) {
+			query := "SELECT * FROM users WHERE status = " + tt.status + " /* sqld:cursor */ /* sqld:where */ /* sqld:limit */"
+			where := NewWhereBuilder(tt.dialect)
+			where.Equal("age", 30)
+
+			sql, params, err := NewAnnotationProcessor(tt.dialect).ProcessQuery(query, where, cursor, nil, 10, "active")
+			require.NoError(t, err)
+			for _, expected := range tt.expectedSQL {
+				assert.Contains(t, sql, expected)
+			}
+			assert.Equal(t, tt.expectedParams, params)
+		})
+	}
+}
+
+func TestAnnotationProcessor_LimitOptions(t *testing.T) {
+	const query = "SELECT * FROM users ORDER BY id /* sqld:limit default=20 max=100 */"
+	processor := NewAnnotationProcessor(Postgres)
+
+	tests := []struct {
+		name     string
+		limit    int
+		expected int
+	}{
+		{"default applied", 0, 20},
+		{"within bounds", 50, 50},
+		{"clamped to max", 500, 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, params, err := processor.ProcessQuery(query, nil, nil, nil, tt.limit)
+			require.NoError(t, err)
+			assert.Equal(t, "SELECT * FROM users ORDER BY id  LIMIT $1", sql)
+			assert.Equal(t, []interface{}{tt.expected}, params)
+		})
+	}
+
+	t.Run("parse", func(t *testing.T) {
+		annotated, err := ParseAnnotations("SELECT * FROM users WHERE true /* sqld:where */ /* sqld:limit max=50 */")
+		require.NoError(t, err)
+		assert.True(t, annotated.FilterEnabled)
+		assert.False(t, annotated.CursorEnabled)
+		assert.Equal(t, 0, annotated.DefaultLimit)
+		assert.Equal(t, 50, annotated.MaxLimit)
+		assert.Equal(t, 0, annotated.EffectiveLimit(0))
+	})
+
+	t.Run("invalid options", func(t *testing.T) {
+		for _, annotation := range []string{
+			"/* sqld:limit default=abc */",
+			"/* sqld:limit size=10 */",
+			"/* sqld:limit default=200 max=100 */",
+		} {
+			_, _, err := processor.ProcessQuery("SELECT * FROM users "+annotation, nil, nil, nil, 10)
+			assert.ErrorIs(t, err, ErrInvalidQuery, annotation)
+		}
+	})
+}
+
+func TestAnnotationProcessor_WithMaxLimit(t *testing.T) {
+	processor := NewAnnotationProcessor(Postgres).WithMaxLimit(50)
+
+	tests := []struct {
+		name     string
+		query    string
+		limit    int
+		expected []interface{}
+	}{
+		{"clamped", "SELECT * FROM users /* sqld:limit */", 500, []interface{}{50}},
+		{"unlimited query gets the max", "SELECT * FROM users /* sqld:limit */", 0, []interface{}{50}},
+		{"annotation default within the max", "SELECT * FROM users /* sqld:limit default=20 */", 0, []interface{}{20}},
+		{"annotation max below the cap", "SELECT * FROM users /* sqld:limit max=10 */", 30, []interface{}{10}},
+		{"within bounds", "SELECT * FROM users /* sqld:limit */", 30, []interface{}{30}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, params, err := processor.ProcessQuery(tt.query, nil, nil, nil, tt.limit)
+			require.NoError(t, err)
+			assert.Equal(t, "SELECT * FROM users  LIMIT $1", sql)
+			assert.Equal(t, tt.expected, params)
+		})
+	}
+
+	t.Run("queries without a limit annotation are unchanged", func(t *testing.T) {
+		sql, params, err := processor.ProcessQuery("SELECT * FROM users", nil, nil, nil, 0)
+		require.NoError(t, err)
+		assert.Equal(t, "SELECT * FROM users", sql)
+		assert.Empty(t, params)
+	})
+}
+
+func TestParseAnnotations(t *testing.T) {
+	t.Run("generated query", func(t *testing.T) {
+		query, err := ParseAnnotations(`SELECT id, name FROM users
+WHERE status = $1 AND name <> 'a$9' /* sqld:where */
+ORDER BY created_at DESC /* sqld:orderby */ /* sqld:cursor */ /* sqld:limit default=20 max=100 */`)
+		require.NoError(t, err)
+		assert.True(t, query.FilterEnabled)
+		assert.True(t, query.OrderByEnabled)
+		assert.True(t, query.CursorEnabled)
+		assert.False(t, query.TotalEnabled)
+		assert.True(t, query.LimitEnabled)
+		assert.Equal(t, 20, query.DefaultLimit)
+		assert.Equal(t, 100, query.MaxLimit)
+		assert.Equal(t, []string{"$1"}, query.RequiredParams)
+	})
+
+	t.Run("named sqlc parameters", func(t *testing.T) {
+		query, err := ParseAnnotations("SELECT * FROM users WHERE status = sqlc.arg('status') AND org_id = @org_id AND email <> 'x@y.z'")
+		require.NoError(t, err)
+		assert.False(t, query.FilterEnabled)
+		assert.False(t, query.LimitEnabled)
+		assert.Equal(t, []string{"status", "org_id"}, query.RequiredParams)
+	})
+
+	t.Run("positional placeholders", func(t *testing.T) {
+		query, err := ParseAnnotations("SELECT * FROM users WHERE status = ? AND role = ? /* sqld:where */")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"?1", "?2"}, query.RequiredParams)
+	})
+
+	t.Run("schema", func(t *testing.T) {
+		query, err := ParseAnnotations("SELECT * FROM users /* sqld:cursor */")
+		require.NoError(t, err)
+		schema := GenerateSchema(DefaultConfig()).ApplyAnnotations(query)
+		assert.True(t, schema.SupportsCursor)
+	})
+}
+
+func TestCombineConditions_Renumbering(t *testing.T) {
+	where1 := NewWhereBuilder(Postgres)
+	where1.Equal("a", 1)
+
+	where2 := NewWhereBuilder(Postgres)
+	where2.Equal("b", 2)
+	where2.Equal("c", 3)
+
+	sql, params := CombineConditions(Postgres, where1, where2).Build()
+	assert.Equal(t, "a = $1 AND b = $2 AND c = $3", sql)
+	assert.Equal(t, []interface{}{1, 2, 3}, params)
+
+	adjusted := NewParameterAdjuster(Postgres).AdjustSQL("a = $1 AND b = $2", 1)
+	assert.Equal(t, "a = $2 AND b = $3", adjusted)
+}
+
+func TestWhereBuilder_CloneAndFreeze(t *testing.T) {
+	base := NewWhereBuilder(Postgres).QuoteIdentifiers(true)
+	base.IsNull("deleted_at")
+	base.Equal("tenant_id", 42)
+	base.Freeze()
+
+	first := base.Clone()
+	first.Equal("status", "active")
+	second := base.Clone()
+	second.In("role", []interface{}{"admin"})
+
+	sql, params := first.Build()
+	assert.Equal(t, `"deleted_at" IS NULL AND "tenant_id" = $1 AND "status" = $2`, sql)
+	assert.Equal(t, []interface{}{42, "active"}, params)
+
+	sql, params = second.Build()
+	assert.Equal(t, `"deleted_at" IS NULL AND "tenant_id" = $1 AND "role" IN ($2)`, sql)
+	assert.Equal(t, []interface{}{42, "admin"}, params)
+
+	sql, params = base.Build()
+	assert.Equal(t, `"deleted_at" IS NULL AND "tenant_id" = $1`, sql)
+	assert.Equal(t, []interface{}{42}, params)
+
+	assert.Panics(t, func() { base.Equal("status", "active") })
+	assert.Panics(t, func() { base.Or(func(ConditionBuilder) {}) })
+	assert.Panics(t, func() { base.StrictNil(true) })
+	assert.NotPanics(t, func() { first.Clone().Freeze().Clone().Equal("x", 1) })
+}
@@ -0,0 +1,86 @@
+// Package sqlverify checks that SQL parses for a sqld dialect, with the
+// parser of the database itself: libpg_query (through pg_query_go) for
+// PostgreSQL and the TiDB parser, which sqlc also uses, for MySQL. It is a
+// separate module so applications that do not verify SQL are spared the
+// parsers and cgo.
+//
+// Use VerifySQL in tests or CI to check queries, or Middleware to check
+// every query an Executor runs:
+//
+//	q := sqld.New(db, sqld.Postgres).Use(sqlverify.Middleware(sqld.Postgres))
+package sqlverify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/getangry/sqld"
+	pgquery "github.com/pganalyze/pg_query_go/v6"
+	"github.com/pingcap/tidb/pkg/parser"
+	_ "github.com/pingcap/tidb/pkg/parser/test_driver" // value expressions for the parser
+)
+
+// ErrUnsupportedDialect is returned for dialects without a parser:
+// SQLite and ClickHouse
+var ErrUnsupportedDialect = errors.New("sqlverify: no parser for dialect")
+
+// SyntaxError is a query the parser of its dialect rejected
+type SyntaxError struct {
+	Dialect sqld.Dialect
+	Query   string
+	Err     error
+}
+
+// Error implements the error interface
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("sqlverify: invalid %s SQL: %v: %s", e.Dialect, e.Err, e.Query)
+}
+
+// Unwrap returns the parser error
+func (e *SyntaxError) Unwrap() error {
+	return e.Err
+}
+
+// mysqlParsers holds MySQL parsers, which are not safe for concurrent use
+var mysqlParsers = sync.Pool{
+	New: func() any { return parser.New() },
+}
+
+// VerifySQL parses query for dialect and returns a *SyntaxError when it is
+// not valid SQL. Placeholders must be the dialect's: $1 for PostgreSQL, ?
+// for MySQL. Dialects without a parser return ErrUnsupportedDialect.
+func VerifySQL(query string, dialect sqld.Dialect) error {
+	var err error
+	switch dialect {
+	case sqld.Postgres:
+		_, err = pgquery.Parse(query)
+	case sqld.MySQL:
+		p := mysqlParsers.Get().(*parser.Parser)
+		_, err = p.ParseOneStmt(query, "", "")
+		mysqlParsers.Put(p)
+	default:
+		return fmt.Errorf("%w %q", ErrUnsupportedDialect, dialect)
+	}
+	if err != nil {
+		return &SyntaxError{Dialect: dialect, Query: query, Err: err}
+	}
+	return nil
+}
+
+// Middleware returns sqld middleware that verifies every query with
+// VerifySQL before running it. A query that does not parse fails with the
+// *SyntaxError and never reaches the database. It is meant for tests and
+// staging, where parsing each query is an affordable price for catching a
+// malformed one early.
+func Middleware(dialect sqld.Dialect) sqld.Middleware {
+	return func(next sqld.QueryFunc) sqld.QueryFunc {
+		return func(ctx context.Context, query string, params ...interface{}) (sqld.Rows, error) {
+			if err := VerifySQL(query, dialect); err != nil {
+				return nil, err
+			}
+			return next(ctx, query, params...)
+		}
+	}
+}
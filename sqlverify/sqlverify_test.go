@@ -0,0 +1,255 @@
+package sqlverify
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/getangry/sqld"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifySQL(t *testing.T) {
+	tests := []struct {
+		dialect sqld.Dialect
+		query   string
+		valid   bool
+	}{
+		{sqld.Postgres, "SELECT id FROM users WHERE name = $1 AND tags && $2", true},
+		{sqld.Postgres, "SELECT id FROM users WHERE", false},
+		{sqld.MySQL, "SELECT `id` FROM users WHERE name = ? ORDER BY RAND(42) LIMIT ?", true},
+		{sqld.MySQL, "SELECT id FROM users WHERE name = ? AND", false},
+	}
+	for _, tt := range tests {
+		err := VerifySQL(tt.query, tt.dialect)
+		if tt.valid {
+			assert.NoError(t, err, tt.query)
+			continue
+		}
+		var syntaxErr *SyntaxError
+		require.ErrorAs(t, err, &syntaxErr, tt.query)
+		assert.Equal(t, tt.dialect, syntaxErr.Dialect)
+		assert.Equal(t, tt.query, syntaxErr.Query)
+	}
+
+	assert.ErrorIs(t, VerifySQL("SELECT 1", sqld.SQLite), ErrUnsupportedDialect)
+}
+
+const annotatedQuery = `SELECT id, name, email /* sqld:total */
+FROM users
+WHERE deleted_at IS NULL AND org_id = %s /* sqld:where */
+/* sqld:cursor */
+ORDER BY created_at DESC /* sqld:orderby */ /* sqld:limit */`
+
+const unionQuery = `SELECT id, name, age, status, created_at FROM users WHERE org_id = %[1]s /* sqld:where */ /* sqld:cursor */
+UNION ALL
+SELECT id, name, age, status, created_at FROM admins WHERE org_id = %[1]s /* sqld:where */ /* sqld:cursor */
+ORDER BY created_at DESC /* sqld:orderby */ /* sqld:limit */`
+
+var columns = []string{"name", "age", "status", "created_at", "users.email", "order", "group"}
+
+// randomValues returns up to max parameter values, possibly none
+func randomValues(r *rand.Rand, max int) []interface{} {
+	values := make([]interface{}, r.IntN(max+1))
+	for i := range values {
+		if r.IntN(2) == 0 {
+			values[i] = r.IntN(1000)
+		} else {
+			values[i] = fmt.Sprintf("v%d", r.IntN(1000))
+		}
+	}
+	return values
+}
+
+// addRandomConditions adds n random conditions to builder, nesting groups
+// up to depth levels
+func addRandomConditions(r *rand.Rand, dialect sqld.Dialect, builder sqld.ExtendedConditionBuilder, n, depth int) {
+	for i := 0; i < n; i++ {
+		column := columns[r.IntN(len(columns))]
+		switch op := r.IntN(20); op {
+		case 0:
+			builder.Equal(column, r.IntN(100))
+		case 1:
+			builder.EqualOrNull(column, "x")
+		case 2:
+			builder.NotEqual(column, "x")
+		case 3:
+			builder.GreaterThan(column, r.IntN(100))
+		case 4:
+			builder.LessThanOrEqual(column, r.Float64())
+		case 5:
+			builder.Like(column, "%an%")
+		case 6:
+			builder.ILike(column, "an%")
+		case 7:
+			builder.In(column, randomValues(r, 6))
+		case 8:
+			builder.NotIn(column, randomValues(r, 6))
+		case 9:
+			builder.NotInNullSafe(column, randomValues(r, 4))
+		case 10:
+			builder.Between(column, r.IntN(10), 10+r.IntN(10))
+		case 11:
+			builder.IsNull(column)
+		case 12:
+			builder.IsNotNull(column)
+		case 13:
+			builder.Raw("(score > ? OR score IS NULL)", r.IntN(10))
+		case 14:
+			builder.Exists("SELECT 1 FROM orders o WHERE o.user_id = users.id AND o.total > ?", r.IntN(100))
+		case 15:
+			if dialect == sqld.Postgres {
+				builder.Similar("name", "ann")
+			}
+		case 16, 17, 18:
+			if depth == 0 {
+				continue
+			}
+			group := func(inner sqld.ConditionBuilder) {
+				addRandomConditions(r, dialect, inner.(sqld.ExtendedConditionBuilder), 1+r.IntN(3), depth-1)
+			}
+			switch op {
+			case 16:
+				builder.Or(group)
+			case 17:
+				builder.And(group)
+			default:
+				builder.Not(group)
+			}
+		case 19:
+			builder.CompareColumns("updated_at", ">", "created_at")
+		}
+	}
+}
+
+// randomOrderBy returns an ordering of up to three random fields, or nil
+func randomOrderBy(r *rand.Rand, dialect sqld.Dialect) *sqld.OrderByBuilder {
+	if r.IntN(4) == 0 {
+		return nil
+	}
+	orderBy := sqld.NewOrderByBuilder()
+	if r.IntN(5) == 0 {
+		if dialect.Capabilities().SupportsSeededRandom {
+			return orderBy.RandomSeed(int64(r.IntN(100)))
+		}
+		return orderBy.Random()
+	}
+	for i := 0; i <= r.IntN(3); i++ {
+		direction := sqld.SortAsc
+		if r.IntN(2) == 0 {
+			direction = sqld.SortDesc
+		}
+		orderBy.Add(columns[r.IntN(4)], direction)
+		switch r.IntN(4) {
+		case 0:
+			orderBy.NullsFirst()
+		case 1:
+			orderBy.NullsLast()
+		}
+	}
+	return orderBy
+}
+
+// TestGeneratedSQLParses builds random conditions, orderings, cursors and
+// limits through the annotation processor and asserts that every query it
+// produces parses for its dialect, also for WrapQuery, a UNION and in a CTE
+func TestGeneratedSQLParses(t *testing.T) {
+	for _, dialect := range []sqld.Dialect{sqld.Postgres, sqld.MySQL} {
+		t.Run(string(dialect), func(t *testing.T) {
+			r := rand.New(rand.NewPCG(1, 2))
+			query := fmt.Sprintf(annotatedQuery, dialect.Placeholder(1))
+			union := fmt.Sprintf(unionQuery, dialect.Placeholder(1))
+
+			for i := 0; i < 2000; i++ {
+				where := sqld.NewWhereBuilder(dialect).
+					QuoteIdentifiers(true).
+					ArrayIn(r.IntN(2) == 0).
+					ChunkInLists(r.IntN(4))
+				addRandomConditions(r, dialect, where, r.IntN(6), 2)
+
+				orderBy := randomOrderBy(r, dialect)
+				var cursor *sqld.Cursor
+				if r.IntN(3) == 0 {
+					// Cursors continue only orderings led by created_at
+					cursor = &sqld.Cursor{CreatedAt: "2024-01-01T00:00:00Z", ID: int32(r.IntN(1000))}
+					switch r.IntN(4) {
+					case 0:
+						orderBy = nil
+					case 1:
+						orderBy = sqld.NewOrderByBuilder().Desc("created_at")
+					case 2:
+						orderBy = sqld.NewOrderByBuilder().Desc("created_at").Asc("id")
+					default:
+						orderBy = sqld.NewOrderByBuilder().Asc("created_at").Asc("id")
+					}
+				}
+				if orderBy != nil {
+					orderBy.QuoteIdentifiers(dialect)
+				}
+
+				processor := sqld.NewAnnotationProcessor(dialect)
+				sql, params, err := processor.ProcessQuery(query, where, cursor, orderBy, r.IntN(3)*50, 7)
+				require.NoError(t, err)
+				require.NoError(t, VerifySQL(sql, dialect), "case %d with %d params", i, len(params))
+
+				// The same conditions over a query without annotations
+				legacy := fmt.Sprintf("SELECT id, name, created_at FROM users WHERE org_id = %s ORDER BY name LIMIT 100", dialect.Placeholder(1))
+				wrappedSQL, _, err := processor.ProcessQuery(sqld.WrapQuery(legacy), where, cursor, orderBy, r.IntN(3)*50, 7)
+				require.NoError(t, err)
+				require.NoError(t, VerifySQL(wrappedSQL, dialect), "case %d with WrapQuery", i)
+
+				// The same conditions in every branch of a UNION
+				unionSQL, _, err := processor.ProcessQuery(union, where, cursor, orderBy, r.IntN(3)*50, 7)
+				require.NoError(t, err)
+				require.NoError(t, VerifySQL(unionSQL, dialect), "case %d in a UNION", i)
+
+				// The same query wrapped in a CTE and filtered again
+				outer := sqld.NewWhereBuilder(dialect).QuoteIdentifiers(true)
+				addRandomConditions(r, dialect, outer, r.IntN(3), 1)
+				wrapped, _ := sqld.NewQueryBuilder("SELECT * FROM page", dialect).
+					WithCTE("page", sql, params...).
+					Where(outer).
+					Build()
+				require.NoError(t, VerifySQL(wrapped, dialect), "case %d wrapped in a CTE", i)
+			}
+		})
+	}
+}
+
+type rows struct{}
+
+func (rows) Next() bool                     { return false }
+func (rows) Scan(dest ...interface{}) error { return nil }
+func (rows) Close() error                   { return nil }
+func (rows) Err() error                     { return nil }
+
+type db struct{ queries int }
+
+func (d *db) Query(ctx context.Context, query string, args ...interface{}) (sqld.Rows, error) {
+	d.queries++
+	return rows{}, nil
+}
+
+func (d *db) QueryRow(ctx context.Context, query string, args ...interface{}) sqld.Row {
+	return nil
+}
+
+func TestMiddleware(t *testing.T) {
+	type user struct {
+		ID   int64
+		Name string
+	}
+	database := &db{}
+	exec := sqld.NewExecutor[user](sqld.New(database, sqld.Postgres).Use(Middleware(sqld.Postgres)))
+
+	_, err := exec.QueryAll(context.Background(), "SELECT id, name FROM users WHERE true /* sqld:where */", nil, nil, nil, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 1, database.queries)
+
+	_, err = exec.QueryAll(context.Background(), "SELECT id, name FROM users WHERE /* sqld:where */", nil, nil, nil, 0)
+	var syntaxErr *SyntaxError
+	assert.ErrorAs(t, err, &syntaxErr)
+	assert.Equal(t, 1, database.queries, "a query that does not parse never reaches the database")
+}
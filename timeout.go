@@ -0,0 +1,81 @@
+package sqld
+
+import (
+	"context"
+	"errors"
+	"net"
+	"regexp"
+	"time"
+)
+
+// mysqlTimeoutPattern matches the message of MySQL error 3024, a query
+// stopped by max_execution_time
+var mysqlTimeoutPattern = regexp.MustCompile(`\bError 3024\b`)
+
+// interruptedError is a driver error caused by a timeout or a cancellation.
+// It matches its kind, ErrQueryTimeout or ErrQueryCanceled, and unwraps to
+// the driver error, so context.DeadlineExceeded still matches.
+type interruptedError struct {
+	kind error
+	err  error
+}
+
+// Error implements the error interface
+func (e *interruptedError) Error() string {
+	return e.kind.Error() + ": " + e.err.Error()
+}
+
+// Unwrap returns the driver error
+func (e *interruptedError) Unwrap() error {
+	return e.err
+}
+
+// Is reports whether target is the kind of the error
+func (e *interruptedError) Is(target error) bool {
+	return target == e.kind
+}
+
+// interruptError marks err as ErrQueryTimeout or ErrQueryCanceled when the
+// query was stopped by the deadline or cancellation of ctx, or by a
+// statement timeout: SQLSTATE 57014 or MySQL error 3024. Other errors are
+// returned unchanged.
+func interruptError(ctx context.Context, err error) error {
+	if err == nil || errors.Is(err, ErrQueryTimeout) || errors.Is(err, ErrQueryCanceled) {
+		return err
+	}
+
+	var kind error
+	var stateErr interface{ SQLState() string }
+	var netErr net.Error
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded), errors.Is(err, context.DeadlineExceeded):
+		kind = ErrQueryTimeout
+	case errors.Is(ctx.Err(), context.Canceled), errors.Is(err, context.Canceled):
+		kind = ErrQueryCanceled
+	case errors.As(err, &netErr) && netErr.Timeout(),
+		errors.As(err, &stateErr) && stateErr.SQLState() == "57014",
+		mysqlTimeoutPattern.MatchString(err.Error()):
+		kind = ErrQueryTimeout
+	default:
+		return err
+	}
+	return &interruptedError{kind: kind, err: err}
+}
+
+// wrapExecutorError is WrapQueryError for queries run through an Executor:
+// timeouts and cancellations are marked with interruptError, and the
+// QueryError gets the time since start and the parameter redaction policy
+func wrapExecutorError(ctx context.Context, start time.Time, policy *ParamRedaction, err error, query string, params []interface{}, operation string) error {
+	err = WrapQueryError(interruptError(ctx, err), query, params, operation)
+	if queryErr, ok := err.(*QueryError); ok {
+		queryErr.Elapsed = time.Since(start)
+		queryErr.redaction = policy
+	}
+	return err
+}
+
+// queryError wraps an error of a query started at start with the parameter
+// redaction of q, see wrapExecutorError
+func (q *Queries) queryError(ctx context.Context, start time.Time, err error, query string, params []interface{}, operation string) error {
+	return wrapExecutorError(ctx, start, q.redaction, err, query, params, operation)
+}
@@ -0,0 +1,75 @@
+package sqld
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInterruptError(t *testing.T) {
+	expired, cancelExpired := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancelExpired()
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	driverErr := errors.New("conn closed")
+	tests := []struct {
+		name string
+		ctx  context.Context
+		err  error
+		want error
+	}{
+		{"deadline passed", expired, driverErr, ErrQueryTimeout},
+		{"context canceled", canceled, driverErr, ErrQueryCanceled},
+		{"deadline error", context.Background(), fmt.Errorf("read: %w", context.DeadlineExceeded), ErrQueryTimeout},
+		{"statement timeout", context.Background(), stateError("57014"), ErrQueryTimeout},
+		{"mysql max_execution_time", context.Background(), errors.New("Error 3024 (HY000): Query execution was interrupted, maximum statement execution time exceeded"), ErrQueryTimeout},
+		{"other", context.Background(), driverErr, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := interruptError(tt.ctx, tt.err)
+			assert.ErrorIs(t, err, tt.err)
+			if tt.want == nil {
+				assert.Equal(t, tt.err, err)
+				return
+			}
+			assert.ErrorIs(t, err, tt.want)
+		})
+	}
+
+	assert.Nil(t, interruptError(expired, nil))
+}
+
+func TestExecutor_QueryTimeout(t *testing.T) {
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+
+	// The deadline hits mid-scan: the driver reports it from rows.Err
+	rows := &MockRows{}
+	rows.On("Next").Return(false)
+	rows.On("Err").Return(errors.New("unexpected EOF"))
+	rows.On("Close").Return(nil)
+	mockDB := &MockDB{}
+	mockDB.On("Query", mock.Anything, mock.Anything, mock.Anything).Return(rows, nil)
+
+	_, err := NewExecutor[testUser](New(mockDB, Postgres)).QueryAll(ctx, "SELECT id, name FROM users", nil, nil, nil, 0)
+	assert.ErrorIs(t, err, ErrQueryTimeout)
+	assert.NotErrorIs(t, err, ErrQueryCanceled)
+	assert.Equal(t, http.StatusGatewayTimeout, HTTPStatus(err))
+
+	var queryErr *QueryError
+	require.ErrorAs(t, err, &queryErr)
+	assert.Equal(t, "iterating rows", queryErr.Context)
+	assert.Greater(t, queryErr.Elapsed, time.Duration(0))
+	assert.Contains(t, err.Error(), "query error in iterating rows after ")
+	assert.Contains(t, err.Error(), "query timed out: unexpected EOF")
+}
@@ -0,0 +1,72 @@
+package sqld
+
+import "context"
+
+// RowTransformer rewrites a scanned row before an Executor returns it, e.g.
+// to mask fields the caller may not see. The context is the query's, so
+// transformers can consult the caller's roles. An error fails the query.
+//
+// Example:
+//
+//	maskEmail := func(ctx context.Context, u db.User) (db.User, error) {
+//		if !slices.Contains(sqld.RolesFromContext(ctx), "admin") {
+//			u.Email = "hidden"
+//		}
+//		return u, nil
+//	}
+//	userExec := sqld.NewExecutor[db.User](q).WithTransform(maskEmail)
+type RowTransformer[T any] func(ctx context.Context, row T) (T, error)
+
+// WithTransform returns a copy of the executor that passes every row it
+// returns through the given transformers, in order, after any already
+// registered
+func (e *Executor[T]) WithTransform(transformers ...RowTransformer[T]) *Executor[T] {
+	clone := *e
+	clone.transformers = make([]RowTransformer[T], 0, len(e.transformers)+len(transformers))
+	clone.transformers = append(clone.transformers, e.transformers...)
+	clone.transformers = append(clone.transformers, transformers...)
+	return &clone
+}
+
+// rowTransformers returns the transformers to apply to the rows of one
+// query, starting with redaction if enabled
+func (e *Executor[T]) rowTransformers() []RowTransformer[T] {
+	if !e.redact {
+		return e.transformers
+	}
+	transformers := make([]RowTransformer[T], 0, len(e.transformers)+1)
+	transformers = append(transformers, NewRedactor[T](e.config).Redact)
+	return append(transformers, e.transformers...)
+}
+
+// transformRow applies the executor's transformers to row
+func (e *Executor[T]) transformRow(ctx context.Context, row T) (T, error) {
+	return applyTransformers(ctx, e.rowTransformers(), row)
+}
+
+// transformRows applies the executor's transformers to rows in place
+func (e *Executor[T]) transformRows(ctx context.Context, rows []T) error {
+	transformers := e.rowTransformers()
+	if len(transformers) == 0 {
+		return nil
+	}
+	for i := range rows {
+		row, err := applyTransformers(ctx, transformers, rows[i])
+		if err != nil {
+			return err
+		}
+		rows[i] = row
+	}
+	return nil
+}
+
+// applyTransformers passes row through transformers in order
+func applyTransformers[T any](ctx context.Context, transformers []RowTransformer[T], row T) (T, error) {
+	for _, transform := range transformers {
+		var err error
+		if row, err = transform(ctx, row); err != nil {
+			return row, err
+		}
+	}
+	return row, nil
+}
@@ -0,0 +1,72 @@
+package sqld
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecutor_WithTransform(t *testing.T) {
+	const query = "SELECT id, name FROM users ORDER BY id /* sqld:limit */"
+	db := fixedRowsDB{rows: [][]interface{}{{int32(1), "alice"}, {int32(2), "bob"}}}
+
+	maskName := func(ctx context.Context, u testUser) (testUser, error) {
+		if !slices.Contains(RolesFromContext(ctx), "admin") {
+			u.Name = "hidden"
+		}
+		return u, nil
+	}
+	upper := func(ctx context.Context, u testUser) (testUser, error) {
+		u.Name = strings.ToUpper(u.Name)
+		return u, nil
+	}
+	exec := NewExecutor[testUser](New(db, Postgres)).WithTransform(maskName).WithTransform(upper)
+
+	t.Run("applies transformers in order", func(t *testing.T) {
+		users, err := exec.QueryAll(context.Background(), query, nil, nil, nil, 0)
+		require.NoError(t, err)
+		assert.Equal(t, []testUser{{ID: 1, Name: "HIDDEN"}, {ID: 2, Name: "HIDDEN"}}, users)
+
+		user, err := exec.QueryOne(WithRoles(context.Background(), "admin"), query, nil)
+		require.NoError(t, err)
+		assert.Equal(t, testUser{ID: 1, Name: "ALICE"}, user)
+	})
+
+	t.Run("paginated results", func(t *testing.T) {
+		var seen []string
+		getCursorFields := func(u testUser) (interface{}, interface{}) {
+			seen = append(seen, u.Name)
+			return "2024-01-01", u.ID
+		}
+		result, err := exec.QueryPaginated(context.Background(), query, nil, nil, nil, 1, getCursorFields)
+		require.NoError(t, err)
+		assert.Equal(t, []testUser{{ID: 1, Name: "HIDDEN"}}, result.Items)
+		// Cursors are built from the rows as scanned
+		assert.Equal(t, []string{"alice"}, seen)
+
+		result, err = exec.QueryPaginatedOffset(context.Background(), query, nil, nil, 1, 1)
+		require.NoError(t, err)
+		assert.Equal(t, "HIDDEN", result.Items[0].Name)
+	})
+
+	t.Run("errors fail the query", func(t *testing.T) {
+		errDenied := errors.New("denied")
+		failing := exec.WithTransform(func(ctx context.Context, u testUser) (testUser, error) {
+			return u, errDenied
+		})
+		_, err := failing.QueryAll(context.Background(), query, nil, nil, nil, 0)
+		assert.ErrorIs(t, err, errDenied)
+
+		_, err = failing.QueryOne(context.Background(), query, nil)
+		assert.ErrorIs(t, err, errDenied)
+
+		// The original executor is unchanged
+		_, err = exec.QueryAll(context.Background(), query, nil, nil, nil, 0)
+		assert.NoError(t, err)
+	})
+}
@@ -0,0 +1,182 @@
+package sqld
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// TxConn is an open transaction as returned by an adapter
+type TxConn interface {
+	DBTXWithExec
+	Commit(ctx context.Context) error
+	Rollback(ctx context.Context) error
+}
+
+// TxBeginner is implemented by adapters that can start transactions
+type TxBeginner interface {
+	Begin(ctx context.Context) (TxConn, error)
+}
+
+// Tx is a transaction started by a TxManager. It implements DBTXWithExec, so
+// it can be passed wherever a database is expected, and adds savepoints for
+// rolling back part of the work.
+type Tx struct {
+	conn    TxConn
+	dialect Dialect
+
+	// savepoints numbers the savepoints created by WithNestedTransaction
+	savepoints int
+}
+
+// Query implements the DBTX interface
+func (tx *Tx) Query(ctx context.Context, query string, args ...interface{}) (Rows, error) {
+	return tx.conn.Query(ctx, query, args...)
+}
+
+// QueryRow implements the DBTX interface
+func (tx *Tx) QueryRow(ctx context.Context, query string, args ...interface{}) Row {
+	return tx.conn.QueryRow(ctx, query, args...)
+}
+
+// Exec implements the DBTXWithExec interface
+func (tx *Tx) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return tx.conn.Exec(ctx, query, args...)
+}
+
+// Commit commits the transaction
+func (tx *Tx) Commit(ctx context.Context) error {
+	return WrapTransactionError(tx.conn.Commit(ctx), "commit")
+}
+
+// Rollback aborts the transaction
+func (tx *Tx) Rollback(ctx context.Context) error {
+	return WrapTransactionError(tx.conn.Rollback(ctx), "rollback")
+}
+
+// Savepoint marks a point in the transaction that RollbackToSavepoint can
+// return to. name must be a bare identifier; reusing a name moves the
+// savepoint on Postgres and SQLite and replaces it on MySQL.
+func (tx *Tx) Savepoint(ctx context.Context, name string) error {
+	return tx.savepoint(ctx, "savepoint", "SAVEPOINT ", name)
+}
+
+// RollbackToSavepoint undoes the work done since the savepoint was created.
+// The savepoint stays in place, so it can be rolled back to again.
+func (tx *Tx) RollbackToSavepoint(ctx context.Context, name string) error {
+	return tx.savepoint(ctx, "rollback to savepoint", "ROLLBACK TO SAVEPOINT ", name)
+}
+
+// ReleaseSavepoint forgets the savepoint, keeping the work done since it
+// was created as part of the transaction
+func (tx *Tx) ReleaseSavepoint(ctx context.Context, name string) error {
+	return tx.savepoint(ctx, "release savepoint", "RELEASE SAVEPOINT ", name)
+}
+
+// savepoint runs a savepoint statement. Postgres, MySQL and SQLite share the
+// SQL standard syntax; dialects without transactions have no savepoints.
+func (tx *Tx) savepoint(ctx context.Context, operation, statement, name string) error {
+	if !tx.dialect.Capabilities().SupportsTransactions {
+		return fmt.Errorf("%w: %s has no savepoints", ErrUnsupportedDialect, tx.dialect)
+	}
+	if !bareIdentifierPattern.MatchString(name) {
+		return &ValidationError{
+			Field:   "savepoint",
+			Value:   name,
+			Message: "invalid savepoint name format",
+		}
+	}
+	_, err := tx.conn.Exec(ctx, statement+tx.dialect.QuoteIdentifier(name))
+	return WrapTransactionError(err, operation)
+}
+
+// TxManager starts transactions on an adapter and runs functions in them
+//
+// Usage:
+//
+//	txm := sqld.NewTxManager(adapter, sqld.Postgres)
+//	err := txm.WithTransaction(ctx, func(tx *sqld.Tx) error {
+//		_, err := tx.Exec(ctx, "UPDATE accounts SET balance = balance - $1 WHERE id = $2", amount, from)
+//		return err
+//	})
+type TxManager struct {
+	db      TxBeginner
+	dialect Dialect
+}
+
+// NewTxManager creates a transaction manager for db
+func NewTxManager(db TxBeginner, dialect Dialect) *TxManager {
+	return &TxManager{db: db, dialect: dialect}
+}
+
+// Begin starts a transaction. The caller must commit or roll it back.
+func (m *TxManager) Begin(ctx context.Context) (*Tx, error) {
+	if !m.dialect.Capabilities().SupportsTransactions {
+		return nil, fmt.Errorf("%w: %s has no transactions", ErrUnsupportedDialect, m.dialect)
+	}
+	conn, err := m.db.Begin(ctx)
+	if err != nil {
+		return nil, WrapTransactionError(err, "begin")
+	}
+	return &Tx{conn: conn, dialect: m.dialect}, nil
+}
+
+// WithTransaction runs fn in a new transaction. The transaction is committed
+// when fn returns nil and rolled back when it returns an error or panics.
+func (m *TxManager) WithTransaction(ctx context.Context, fn func(tx *Tx) error) error {
+	tx, err := m.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback(ctx)
+			panic(p)
+		}
+	}()
+
+	if err := fn(tx); err != nil {
+		if rollbackErr := tx.Rollback(ctx); rollbackErr != nil {
+			return fmt.Errorf("%w (%v)", err, rollbackErr)
+		}
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// WithNestedTransaction runs fn inside tx behind a savepoint. When fn returns
+// an error or panics only its own work is rolled back, and tx stays usable
+// so the caller can carry on or try something else:
+//
+//	err := txm.WithTransaction(ctx, func(tx *sqld.Tx) error {
+//		if err := createOrder(ctx, tx); err != nil {
+//			return err
+//		}
+//		if err := txm.WithNestedTransaction(ctx, tx, reserveStock); err != nil {
+//			return backorder(ctx, tx) // the order is kept
+//		}
+//		return nil
+//	})
+func (m *TxManager) WithNestedTransaction(ctx context.Context, tx *Tx, fn func(tx *Tx) error) error {
+	tx.savepoints++
+	name := fmt.Sprintf("sqld_savepoint_%d", tx.savepoints)
+	if err := tx.Savepoint(ctx, name); err != nil {
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.RollbackToSavepoint(ctx, name)
+			panic(p)
+		}
+	}()
+
+	if err := fn(tx); err != nil {
+		if rollbackErr := tx.RollbackToSavepoint(ctx, name); rollbackErr != nil {
+			return fmt.Errorf("%w (%v)", err, rollbackErr)
+		}
+		return err
+	}
+	return tx.ReleaseSavepoint(ctx, name)
+}
@@ -0,0 +1,153 @@
+package sqld
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTx records the statements and outcome of a transaction
+type fakeTx struct {
+	execDB
+	committed, rolledBack bool
+}
+
+func (tx *fakeTx) Commit(ctx context.Context) error {
+	tx.committed = true
+	return nil
+}
+
+func (tx *fakeTx) Rollback(ctx context.Context) error {
+	tx.rolledBack = true
+	return nil
+}
+
+// fakeBeginner hands out fakeTx transactions
+type fakeBeginner struct {
+	tx  *fakeTx
+	err error
+}
+
+func (db *fakeBeginner) Begin(ctx context.Context) (TxConn, error) {
+	if db.err != nil {
+		return nil, db.err
+	}
+	db.tx = &fakeTx{}
+	return db.tx, nil
+}
+
+func TestTxManager_WithTransaction(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("commits", func(t *testing.T) {
+		db := &fakeBeginner{}
+		err := NewTxManager(db, Postgres).WithTransaction(ctx, func(tx *Tx) error {
+			_, err := tx.Exec(ctx, "DELETE FROM sessions")
+			return err
+		})
+		require.NoError(t, err)
+		assert.True(t, db.tx.committed)
+		assert.False(t, db.tx.rolledBack)
+		assert.Equal(t, []string{"DELETE FROM sessions"}, db.tx.queries)
+	})
+
+	t.Run("rolls back on error", func(t *testing.T) {
+		db := &fakeBeginner{}
+		failure := errors.New("insufficient funds")
+		err := NewTxManager(db, Postgres).WithTransaction(ctx, func(tx *Tx) error {
+			return failure
+		})
+		assert.ErrorIs(t, err, failure)
+		assert.True(t, db.tx.rolledBack)
+		assert.False(t, db.tx.committed)
+	})
+
+	t.Run("rolls back on panic", func(t *testing.T) {
+		db := &fakeBeginner{}
+		assert.Panics(t, func() {
+			_ = NewTxManager(db, Postgres).WithTransaction(ctx, func(tx *Tx) error {
+				panic("boom")
+			})
+		})
+		assert.True(t, db.tx.rolledBack)
+	})
+
+	t.Run("begin error", func(t *testing.T) {
+		db := &fakeBeginner{err: errors.New("connection refused")}
+		err := NewTxManager(db, Postgres).WithTransaction(ctx, func(tx *Tx) error { return nil })
+		var txErr *TransactionError
+		require.ErrorAs(t, err, &txErr)
+		assert.Equal(t, "begin", txErr.Operation)
+	})
+
+	t.Run("unsupported dialect", func(t *testing.T) {
+		err := NewTxManager(&fakeBeginner{}, ClickHouse).WithTransaction(ctx, func(tx *Tx) error { return nil })
+		assert.ErrorIs(t, err, ErrUnsupportedDialect)
+	})
+}
+
+func TestTxManager_WithNestedTransaction(t *testing.T) {
+	ctx := context.Background()
+	db := &fakeBeginner{}
+	txm := NewTxManager(db, MySQL)
+
+	failure := errors.New("out of stock")
+	err := txm.WithTransaction(ctx, func(tx *Tx) error {
+		if err := txm.WithNestedTransaction(ctx, tx, func(tx *Tx) error { return nil }); err != nil {
+			return err
+		}
+		err := txm.WithNestedTransaction(ctx, tx, func(tx *Tx) error { return failure })
+		assert.ErrorIs(t, err, failure)
+		return nil
+	})
+	require.NoError(t, err)
+
+	assert.True(t, db.tx.committed)
+	assert.Equal(t, []string{
+		"SAVEPOINT `sqld_savepoint_1`",
+		"RELEASE SAVEPOINT `sqld_savepoint_1`",
+		"SAVEPOINT `sqld_savepoint_2`",
+		"ROLLBACK TO SAVEPOINT `sqld_savepoint_2`",
+	}, db.tx.queries)
+}
+
+func TestTx_Savepoint(t *testing.T) {
+	ctx := context.Background()
+	tx, err := NewTxManager(&fakeBeginner{}, Postgres).Begin(ctx)
+	require.NoError(t, err)
+
+	require.NoError(t, tx.Savepoint(ctx, "before_import"))
+	require.NoError(t, tx.RollbackToSavepoint(ctx, "before_import"))
+	assert.Equal(t, []string{
+		`SAVEPOINT "before_import"`,
+		`ROLLBACK TO SAVEPOINT "before_import"`,
+	}, tx.conn.(*fakeTx).queries)
+
+	var validationErr *ValidationError
+	assert.ErrorAs(t, tx.Savepoint(ctx, "x; DROP TABLE users"), &validationErr)
+}
+
+func TestExecutor_WithTx(t *testing.T) {
+	ctx := context.Background()
+	base := &execDB{}
+	exec := NewExecutor[testUser](New(base, Postgres)).WithConfig(DefaultConfig().WithSoftDelete("deleted_at"))
+
+	db := &fakeBeginner{}
+	err := NewTxManager(db, Postgres).WithTransaction(ctx, func(tx *Tx) error {
+		expectEmptyQuery(&db.tx.MockDB, "SELECT id, name FROM users WHERE status = 'active'  AND deleted_at IS NULL")
+		if _, err := exec.WithTx(tx).QueryAll(ctx, "SELECT id, name FROM users WHERE status = 'active' /* sqld:where */", nil, nil, nil, 0); err != nil {
+			return err
+		}
+		_, err := exec.WithTx(tx).BulkInsert(ctx, "users", []string{"name"}, [][]interface{}{{"Ada"}})
+		return err
+	})
+	require.NoError(t, err)
+
+	db.tx.AssertExpectations(t)
+	assert.Equal(t, []string{"INSERT INTO users (name) VALUES ($1)"}, db.tx.queries)
+	assert.Empty(t, base.queries, "the base executor is not bound to the transaction")
+	assert.Same(t, base, exec.queries.DB())
+}
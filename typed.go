@@ -0,0 +1,45 @@
+package sqld
+
+// Typed condition helpers. Go methods cannot take type parameters, so these
+// are functions over a ConditionBuilder; they do the boxing into interface{}
+// that the builder methods need. They return the builder they were given with
+// its own type, so a *WhereBuilder stays a *WhereBuilder.
+
+// EqualT adds an equality condition for a typed value
+func EqualT[T comparable, B ConditionBuilder](b B, column string, value T) B {
+	b.Equal(column, value)
+	return b
+}
+
+// NotEqualT adds a not-equal condition for a typed value
+func NotEqualT[T comparable, B ConditionBuilder](b B, column string, value T) B {
+	b.NotEqual(column, value)
+	return b
+}
+
+// InT adds an IN condition for a typed slice, e.g. InT(b, "country", []string{"US", "CA"})
+func InT[T any, B ConditionBuilder](b B, column string, values []T) B {
+	b.In(column, toInterfaces(values))
+	return b
+}
+
+// NotInT adds a NOT IN condition for a typed slice
+func NotInT[T any, B ExtendedConditionBuilder](b B, column string, values []T) B {
+	b.NotIn(column, toInterfaces(values))
+	return b
+}
+
+// BetweenT adds a BETWEEN condition for typed bounds
+func BetweenT[T any, B ConditionBuilder](b B, column string, start, end T) B {
+	b.Between(column, start, end)
+	return b
+}
+
+// toInterfaces boxes a typed slice into []interface{}
+func toInterfaces[T any](values []T) []interface{} {
+	result := make([]interface{}, len(values))
+	for i, v := range values {
+		result[i] = v
+	}
+	return result
+}
@@ -0,0 +1,39 @@
+package sqld
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTypedHelpers(t *testing.T) {
+	builder := NewWhereBuilder(Postgres)
+	EqualT(builder, "status", "active")
+	NotEqualT(builder, "age", int32(0))
+	InT(builder, "country", []string{"US", "CA"})
+	NotInT(builder, "id", []int64{1, 2})
+	BetweenT(builder, "score", 1.5, 9.5)
+
+	sql, params := builder.Build()
+	assert.Equal(t, "status = $1 AND age != $2 AND country IN ($3, $4) AND id NOT IN ($5, $6) AND score BETWEEN $7 AND $8", sql)
+	assert.Equal(t, []interface{}{"active", int32(0), "US", "CA", int64(1), int64(2), 1.5, 9.5}, params)
+}
+
+func TestTypedHelpers_OrGroup(t *testing.T) {
+	builder := NewWhereBuilder(MySQL)
+	builder.Or(func(or ConditionBuilder) {
+		InT(or, "role", []string{"admin"})
+		EqualT(or, "owner", true)
+	})
+
+	sql, params := builder.Build()
+	assert.Equal(t, "(role IN (?) OR owner = ?)", sql)
+	assert.Equal(t, []interface{}{"admin", true}, params)
+}
+
+func TestTypedHelpers_EmptySlice(t *testing.T) {
+	builder := NewWhereBuilder(Postgres)
+	InT(builder, "country", []string{})
+
+	assert.False(t, builder.HasConditions())
+}
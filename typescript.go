@@ -0,0 +1,181 @@
+package sqld
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// typeScriptRuntime is emitted once per generated file. SqldQuery builds the
+// query strings parsed by FromRequest and ParseSortFromRequest.
+const typeScriptRuntime = `type Scalar = string | number | boolean | Date;
+
+function encodeValue(value: Scalar | Scalar[]): string {
+  const one = (v: Scalar) => (v instanceof Date ? v.toISOString() : String(v));
+  return Array.isArray(value) ? value.map(one).join(",") : one(value);
+}
+
+export class SqldQuery<Types, Ops extends Record<keyof Types, string>, Sort extends string> {
+  protected params = new URLSearchParams();
+  private sorts: string[] = [];
+
+  where<F extends keyof Types & string>(field: F, op: Ops[F], value: Types[F] | Types[F][] | boolean): this {
+    this.params.append(op === "eq" ? field : field + "[" + op + "]", encodeValue(value as unknown as Scalar | Scalar[]));
+    return this;
+  }
+
+  sort(field: Sort, direction: "asc" | "desc" = "asc"): this {
+    this.sorts.push(direction === "desc" ? "-" + field : field);
+    return this;
+  }
+
+  limit(limit: number): this {
+    this.params.set("limit", String(limit));
+    return this;
+  }
+
+  cursor(cursor: string): this {
+    this.params.set("cursor", cursor);
+    return this;
+  }
+
+  toString(): string {
+    const params = new URLSearchParams(this.params);
+    if (this.sorts.length > 0) {
+      params.set("sort", this.sorts.join(","));
+    }
+    return params.toString();
+  }
+}
+`
+
+// typeScriptTypes maps schema field types to TypeScript types
+var typeScriptTypes = map[string]string{
+	"integer":   "number",
+	"number":    "number",
+	"boolean":   "boolean",
+	"datetime":  "string | Date",
+	"uuid":      "string",
+	"geography": "string",
+	"string":    "string",
+}
+
+// GenerateTypeScript renders TypeScript types and a query builder for each
+// schema, keyed by resource name. For a resource "users" it declares
+// UsersField, UsersSortField, UsersFieldTypes, UsersOperators and a
+// UsersQuery class, so frontend code gets compile errors instead of silently
+// ignored filters when the backend config changes:
+//
+//	new UsersQuery().where("age", "gte", 18).sort("created_at", "desc").toString()
+//	// "age%5Bgte%5D=18&sort=-created_at"
+func GenerateTypeScript(schemas map[string]*QuerySchema) ([]byte, error) {
+	resources := make([]string, 0, len(schemas))
+	for resource := range schemas {
+		resources = append(resources, resource)
+	}
+	sort.Strings(resources)
+
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by sqld. DO NOT EDIT.\n\n")
+	buf.WriteString(typeScriptRuntime)
+
+	seen := make(map[string]string)
+	for _, resource := range resources {
+		name := typeScriptName(resource)
+		if name == "" {
+			return nil, fmt.Errorf("%w: resource %q has no usable type name", ErrInvalidParameter, resource)
+		}
+		if other, ok := seen[name]; ok {
+			return nil, fmt.Errorf("%w: resources %q and %q both map to %s", ErrInvalidParameter, other, resource, name)
+		}
+		seen[name] = resource
+		writeTypeScriptResource(&buf, name, schemas[resource])
+	}
+	return buf.Bytes(), nil
+}
+
+func writeTypeScriptResource(buf *bytes.Buffer, name string, schema *QuerySchema) {
+	fields := make([]FieldSchema, 0, len(schema.Fields))
+	for _, field := range schema.Fields {
+		if field.Filterable || field.Sortable {
+			fields = append(fields, field)
+		}
+	}
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Name < fields[j].Name })
+
+	var filterable, sortable []string
+	for _, field := range fields {
+		if field.Filterable {
+			filterable = append(filterable, field.Name)
+		}
+		if field.Sortable {
+			sortable = append(sortable, field.Name)
+		}
+	}
+
+	fmt.Fprintf(buf, "\nexport type %sField = %s;\n", name, typeScriptUnion(filterable))
+	fmt.Fprintf(buf, "export type %sSortField = %s;\n\n", name, typeScriptUnion(sortable))
+
+	fmt.Fprintf(buf, "export interface %sFieldTypes {\n", name)
+	for _, field := range fields {
+		if !field.Filterable {
+			continue
+		}
+		tsType, ok := typeScriptTypes[field.Type]
+		if !ok {
+			tsType = "string"
+		}
+		if tsType == "string" && len(field.Enum) > 0 {
+			tsType = typeScriptUnion(field.Enum)
+		}
+		fmt.Fprintf(buf, "  %q: %s;\n", field.Name, tsType)
+	}
+	buf.WriteString("}\n\n")
+
+	fmt.Fprintf(buf, "export interface %sOperators {\n", name)
+	for _, field := range fields {
+		if !field.Filterable {
+			continue
+		}
+		fmt.Fprintf(buf, "  %q: %s;\n", field.Name, typeScriptUnion(field.Operators))
+	}
+	buf.WriteString("}\n\n")
+
+	fmt.Fprintf(buf, "export class %[1]sQuery extends SqldQuery<%[1]sFieldTypes, %[1]sOperators, %[1]sSortField> {}\n", name)
+}
+
+// typeScriptUnion renders values as a union of string literals
+func typeScriptUnion(values []string) string {
+	if len(values) == 0 {
+		return "never"
+	}
+	quoted := make([]string, len(values))
+	for i, value := range values {
+		quoted[i] = fmt.Sprintf("%q", value)
+	}
+	return strings.Join(quoted, " | ")
+}
+
+// typeScriptName turns a resource name such as "billing.line_items" into a
+// type name prefix, "BillingLineItems"
+func typeScriptName(resource string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range resource {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if b.Len() == 0 && unicode.IsDigit(r) {
+			b.WriteByte('_')
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,49 @@
+package sqld
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateTypeScript(t *testing.T) {
+	config := DefaultConfig().
+		WithAllowedFields(map[string]bool{"name": true, "age": true, "created_at": true, "is_active": true, "status": true}).
+		WithFieldType("age", "integer").
+		WithFieldValues("status", "active", "pending")
+
+	out, err := GenerateTypeScript(map[string]*QuerySchema{
+		"billing.invoices": {},
+		"users":            GenerateSchema(config),
+	})
+	require.NoError(t, err)
+	src := string(out)
+
+	assert.Contains(t, src, "// Code generated by sqld. DO NOT EDIT.")
+	assert.Contains(t, src, "export class SqldQuery<")
+	assert.Contains(t, src, `export type UsersField = "age" | "created_at" | "is_active" | "name" | "status";`)
+	assert.Contains(t, src, `export type UsersSortField = "age" | "created_at" | "is_active" | "name" | "status";`)
+	assert.Contains(t, src, `  "age": number;`)
+	assert.Contains(t, src, `  "created_at": string | Date;`)
+	assert.Contains(t, src, `  "is_active": boolean;`)
+	assert.Contains(t, src, `  "status": "active" | "pending";`)
+	assert.Contains(t, src, `  "is_active": "eq" | "ne" | "isnull" | "isnotnull";`)
+	assert.Contains(t, src, "export class UsersQuery extends SqldQuery<UsersFieldTypes, UsersOperators, UsersSortField> {}")
+
+	// Resources without fields still type-check
+	assert.Contains(t, src, "export type BillingInvoicesField = never;")
+	assert.Less(t, strings.Index(src, "BillingInvoicesQuery"), strings.Index(src, "UsersQuery"))
+}
+
+func TestGenerateTypeScript_NameClash(t *testing.T) {
+	_, err := GenerateTypeScript(map[string]*QuerySchema{"line_items": {}, "line-items": {}})
+	assert.ErrorIs(t, err, ErrInvalidParameter)
+}
+
+func TestTypeScriptName(t *testing.T) {
+	assert.Equal(t, "Users", typeScriptName("users"))
+	assert.Equal(t, "BillingLineItems", typeScriptName("billing.line_items"))
+	assert.Equal(t, "_2fa", typeScriptName("2fa"))
+}
@@ -0,0 +1,68 @@
+package sqld
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// uuidOperators are the operators accepted on fields of type "uuid"
+var uuidOperators = map[Operator]bool{
+	OpEq:        true,
+	OpNe:        true,
+	OpIn:        true,
+	OpNotIn:     true,
+	OpIsNull:    true,
+	OpIsNotNull: true,
+}
+
+// ParseUUID checks that s is a UUID in the canonical 8-4-4-4-12 hex form,
+// optionally wrapped in braces or prefixed with urn:uuid:, and returns it
+// in lowercase canonical form. It is the default Config.UUIDParser.
+func ParseUUID(s string) (string, error) {
+	u := strings.TrimPrefix(strings.ToLower(s), "urn:uuid:")
+	if strings.HasPrefix(u, "{") && strings.HasSuffix(u, "}") {
+		u = u[1 : len(u)-1]
+	}
+	if len(u) != 36 || u[8] != '-' || u[13] != '-' || u[18] != '-' || u[23] != '-' {
+		return "", fmt.Errorf("invalid UUID %q", s)
+	}
+	if _, err := hex.DecodeString(u[0:8] + u[9:13] + u[14:18] + u[19:23] + u[24:]); err != nil {
+		return "", fmt.Errorf("invalid UUID %q", s)
+	}
+	return u, nil
+}
+
+// parseUUIDValue validates the converted value of a filter on a uuid field
+// and returns it normalized, or the offending value and an error
+func (c *Config) parseUUIDValue(op Operator, value interface{}) (interface{}, string, error) {
+	if !uuidOperators[op] {
+		return nil, "", fmt.Errorf("operator %s not supported for uuid fields", op)
+	}
+
+	parse := c.UUIDParser
+	if parse == nil {
+		parse = ParseUUID
+	}
+
+	switch v := value.(type) {
+	case string:
+		parsed, err := parse(v)
+		if err != nil {
+			return nil, v, err
+		}
+		return parsed, "", nil
+	case []string:
+		parsed := make([]string, len(v))
+		for i, s := range v {
+			p, err := parse(s)
+			if err != nil {
+				return nil, s, err
+			}
+			parsed[i] = p
+		}
+		return parsed, "", nil
+	default:
+		return value, "", nil
+	}
+}
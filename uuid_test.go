@@ -0,0 +1,102 @@
+package sqld
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseUUID(t *testing.T) {
+	const canonical = "550e8400-e29b-41d4-a716-446655440000"
+
+	for _, input := range []string{
+		canonical,
+		"550E8400-E29B-41D4-A716-446655440000",
+		"{550e8400-e29b-41d4-a716-446655440000}",
+		"urn:uuid:550e8400-e29b-41d4-a716-446655440000",
+	} {
+		parsed, err := ParseUUID(input)
+		require.NoError(t, err, input)
+		assert.Equal(t, canonical, parsed)
+	}
+
+	for _, input := range []string{
+		"",
+		"42",
+		"550e8400e29b41d4a716446655440000",
+		"550e8400-e29b-41d4-a716-44665544000g",
+		"550e8400-e29b-41d4-a716_446655440000",
+	} {
+		_, err := ParseUUID(input)
+		assert.Error(t, err, input)
+	}
+}
+
+func TestUUIDFields(t *testing.T) {
+	config := DefaultConfig().
+		WithAllowedFields(map[string]bool{"account_id": true}).
+		WithFieldType("account_id", "uuid")
+
+	t.Run("normalizes values", func(t *testing.T) {
+		filters, err := ParseQueryString("account_id=550E8400-E29B-41D4-A716-446655440000&account_id[in]=550e8400-e29b-41d4-a716-446655440000,{6ba7b810-9dad-11d1-80b4-00c04fd430c8}&account_id[isNull]=true", config)
+		require.NoError(t, err)
+		require.Len(t, filters, 3)
+		assert.Equal(t, "550e8400-e29b-41d4-a716-446655440000", filters[0].Value)
+		assert.Equal(t, []string{"550e8400-e29b-41d4-a716-446655440000", "6ba7b810-9dad-11d1-80b4-00c04fd430c8"}, filters[1].Value)
+	})
+
+	tests := []struct {
+		query   string
+		reason  string
+		invalid string
+	}{
+		{"account_id=42", "invalid uuid", "42"},
+		{"account_id[in]=550e8400-e29b-41d4-a716-446655440000,nope", "invalid uuid", "nope"},
+		{"account_id[contains]=550e", "operator not supported", "550e"},
+		{"account_id[gt]=550e8400-e29b-41d4-a716-446655440000", "operator not supported", "550e8400-e29b-41d4-a716-446655440000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			_, err := ParseQueryString(tt.query, config)
+
+			var filterErr *FilterError
+			require.True(t, errors.As(err, &filterErr))
+			assert.Equal(t, "account_id", filterErr.Field)
+			assert.Equal(t, tt.reason, filterErr.Reason)
+
+			var validationErr *ValidationError
+			require.True(t, errors.As(err, &validationErr))
+			assert.Equal(t, tt.invalid, validationErr.Value)
+		})
+	}
+
+	t.Run("custom parser", func(t *testing.T) {
+		config := DefaultConfig().
+			WithAllowedFields(map[string]bool{"account_id": true}).
+			WithFieldType("account_id", "uuid")
+		config.UUIDParser = func(s string) (string, error) {
+			if !strings.HasPrefix(s, "acct_") {
+				return "", errors.New("not an account id")
+			}
+			return s, nil
+		}
+
+		_, err := ParseQueryString("account_id=acct_123", config)
+		require.NoError(t, err)
+		_, err = ParseQueryString("account_id=550e8400-e29b-41d4-a716-446655440000", config)
+		assert.Error(t, err)
+	})
+
+	t.Run("schema", func(t *testing.T) {
+		schema := GenerateSchema(config)
+		require.Len(t, schema.Fields, 1)
+		field := schema.Fields[0]
+		assert.Equal(t, "uuid", field.Type)
+		assert.Equal(t, []string{"eq", "ne", "in", "notin", "isnull", "isnotnull"}, field.Operators)
+		assert.Equal(t, "550e8400-e29b-41d4-a716-446655440000", field.Example)
+	})
+}
@@ -2,45 +2,43 @@ package sqld
 
 import (
 	"fmt"
+	"reflect"
 	"regexp"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
-// SQL injection detection patterns
+// Identifier allow-lists. Validation accepts only shapes that are known to
+// be safe instead of searching for known-bad substrings, which both flagged
+// legitimate names and missed real injection vectors.
 var (
-	// Common SQL injection patterns
-	sqlInjectionPatterns = []*regexp.Regexp{
-		// Comments that might be used to bypass validation
-		regexp.MustCompile(`(?i)(--|#|/\*|\*/)`),
-		// Union-based injection
-		regexp.MustCompile(`(?i)\bUNION\b.*\bSELECT\b`),
-		// Stacked queries
-		regexp.MustCompile(`;\s*(SELECT|INSERT|UPDATE|DELETE|DROP|CREATE|ALTER)`),
-		// Time-based blind injection
-		regexp.MustCompile(`(?i)(SLEEP|WAITFOR|BENCHMARK|pg_sleep)`),
-		// Boolean-based blind injection (simplified pattern)
-		regexp.MustCompile(`(?i)(\bOR\b|\bAND\b)\s+(['"]?)[\w\s]+['"]?\s*=\s*['"]?[\w\s]+['"]?`),
-		// SQL functions that might be exploited
-		regexp.MustCompile(`(?i)(CONCAT|CHAR|ASCII|SUBSTRING|LENGTH|HEX|UNHEX)`),
-		// System information functions
-		regexp.MustCompile(`(?i)(VERSION|DATABASE|USER|CURRENT_USER|SESSION_USER|@@version)`),
-		// File operations
-		regexp.MustCompile(`(?i)(LOAD_FILE|INTO\s+OUTFILE|INTO\s+DUMPFILE)`),
-		// XP commands (SQL Server)
-		regexp.MustCompile(`(?i)(xp_cmdshell|sp_configure|sp_addextendedproc)`),
-	}
+	// A bare identifier, or one quoted with " or ` (which cannot contain the quote itself)
+	identifierPart = "(?:[a-zA-Z_][a-zA-Z0-9_]*|\"[^\"]+\"|`[^`]+`)"
+
+	// A column reference: column, table.column or schema.table.column
+	columnRef = identifierPart + `(?:\.` + identifierPart + `){0,2}`
+
+	// columnRefPattern matches a plain or qualified column reference
+	columnRefPattern = regexp.MustCompile(`^` + columnRef + `$`)
+
+	// functionColumnPattern matches a single function call over column
+	// references, such as LOWER(name), COALESCE(a, b) or COUNT(*)
+	functionColumnPattern = regexp.MustCompile(
+		`^[a-zA-Z_][a-zA-Z0-9_]*\(\s*(?:\*|` + columnRef + `(?:\s*,\s*` + columnRef + `)*)?\s*\)$`,
+	)
 
 	// Patterns that are generally safe in column names
 	safeColumnPattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*(\.[a-zA-Z_][a-zA-Z0-9_]*)?$`)
 
 	// Pattern for safe table names (including schema)
 	safeTablePattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*(\.[a-zA-Z_][a-zA-Z0-9_]*)?$`)
-
-	// Pattern for safe identifiers (with optional quotes)
-	safeIdentifierPattern = regexp.MustCompile(`^"?[a-zA-Z_][a-zA-Z0-9_]*"?$`)
 )
 
-// ValidateQuery validates a query for potential SQL injection
+// ValidateQuery performs structural checks on a final SQL query: it must
+// hold a single statement, and every string literal, quoted identifier,
+// comment and parenthesis must be closed. Values are expected to be passed
+// as parameters, so the query text itself is not searched for keywords.
 func ValidateQuery(query string, dialect Dialect) error {
 	if query == "" {
 		return &ValidationError{
@@ -49,8 +47,20 @@ func ValidateQuery(query string, dialect Dialect) error {
 		}
 	}
 
-	// Check for common SQL injection patterns in the query structure
-	// Note: This is a basic check and should not be the only defense
+	cleaned, terminated := scanSQL(query)
+	if !terminated {
+		return &ValidationError{
+			Field:   "query",
+			Message: "unterminated string literal, quoted identifier or comment",
+		}
+	}
+
+	if !parenthesesBalanced(cleaned) {
+		return &ValidationError{
+			Field:   "query",
+			Message: "unbalanced parentheses",
+		}
+	}
 
 	// Check for multiple statements (not counting subqueries)
 	if countStatements(query) > 1 {
@@ -63,7 +73,40 @@ func ValidateQuery(query string, dialect Dialect) error {
 	return nil
 }
 
-// ValidateColumnName validates a column name for safety
+// expressionPlaceholderPattern matches parameter placeholders outside literals
+var expressionPlaceholderPattern = regexp.MustCompile(`\?|\$\d+`)
+
+// ValidateExpression validates a SQL expression used for a computed field.
+// Expressions are written into queries verbatim, so they must be a single
+// well-formed fragment: no statement separators, no unterminated literals or
+// unbalanced parentheses, and no placeholders, which would break parameter
+// numbering.
+func ValidateExpression(expression string) error {
+	if strings.TrimSpace(expression) == "" {
+		return &ValidationError{
+			Field:   "expression",
+			Message: "expression cannot be empty",
+		}
+	}
+
+	cleaned, terminated := scanSQL(expression)
+	switch {
+	case !terminated:
+		return &ValidationError{Field: "expression", Value: expression, Message: "unterminated string literal, quoted identifier or comment"}
+	case !parenthesesBalanced(cleaned):
+		return &ValidationError{Field: "expression", Value: expression, Message: "unbalanced parentheses"}
+	case strings.Contains(cleaned, ";"):
+		return &ValidationError{Field: "expression", Value: expression, Message: "expression cannot contain statement separators"}
+	case expressionPlaceholderPattern.MatchString(cleaned):
+		return &ValidationError{Field: "expression", Value: expression, Message: "expression cannot contain placeholders"}
+	}
+
+	return nil
+}
+
+// ValidateColumnName validates a column name against an allow-list of safe
+// shapes: a plain, quoted or qualified column reference (name, "Name",
+// u.name) or a single function call over such references (LOWER(name)).
 func ValidateColumnName(column string) error {
 	if column == "" {
 		return &ValidationError{
@@ -72,34 +115,15 @@ func ValidateColumnName(column string) error {
 		}
 	}
 
-	// Allow quoted identifiers
-	cleanColumn := strings.Trim(column, `"`)
-
-	// Check if it matches safe pattern
-	if !safeColumnPattern.MatchString(cleanColumn) {
-		// Check for SQL injection patterns
-		for _, pattern := range sqlInjectionPatterns {
-			if pattern.MatchString(column) {
-				return &ValidationError{
-					Field:   "column",
-					Value:   column,
-					Message: "potential SQL injection detected in column name",
-				}
-			}
-		}
-
-		// If it doesn't match safe pattern but no injection detected,
-		// it might be a complex expression which we'll allow with caution
-		if strings.ContainsAny(column, ";--/*") {
-			return &ValidationError{
-				Field:   "column",
-				Value:   column,
-				Message: "unsafe characters in column name",
-			}
-		}
+	if columnRefPattern.MatchString(column) || functionColumnPattern.MatchString(column) {
+		return nil
 	}
 
-	return nil
+	return &ValidationError{
+		Field:   "column",
+		Value:   column,
+		Message: "invalid column name format",
+	}
 }
 
 // ValidateTableName validates a table name for safety
@@ -185,25 +209,32 @@ func ValidateOrderBy(orderBy string) error {
 	return nil
 }
 
-// ValidateValue validates a parameter value
+// ValidateValue validates a parameter value. Values are always bound as
+// parameters, so their content is never interpreted as SQL; this only
+// rejects values no driver can bind: functions, channels, complex numbers
+// and strings containing NUL bytes.
 func ValidateValue(value interface{}) error {
-	// Check for string values that might contain SQL
+	if value == nil {
+		return nil
+	}
+
 	if strVal, ok := value.(string); ok {
-		// Check for SQL keywords in string values
-		upperVal := strings.ToUpper(strVal)
-		suspiciousKeywords := []string{
-			"SELECT", "INSERT", "UPDATE", "DELETE", "DROP",
-			"CREATE", "ALTER", "EXEC", "EXECUTE", "UNION",
-		}
-
-		for _, keyword := range suspiciousKeywords {
-			if strings.Contains(upperVal, keyword) {
-				// It's OK if it's in a proper string context
-				// This is handled by parameterized queries
-				// We just log a warning internally
-				break
+		if strings.ContainsRune(strVal, 0) {
+			return &ValidationError{
+				Field:   "value",
+				Message: "string value contains a NUL byte",
 			}
 		}
+		return nil
+	}
+
+	switch reflect.TypeOf(value).Kind() {
+	case reflect.Func, reflect.Chan, reflect.Complex64, reflect.Complex128, reflect.UnsafePointer:
+		return &ValidationError{
+			Field:   "value",
+			Value:   fmt.Sprintf("%T", value),
+			Message: "unsupported parameter type",
+		}
 	}
 
 	return nil
@@ -215,27 +246,34 @@ func SanitizeIdentifier(identifier string, dialect Dialect) string {
 	cleaned := regexp.MustCompile(`[^a-zA-Z0-9_.]`).ReplaceAllString(identifier, "")
 
 	// Quote the identifier based on dialect
-	switch dialect {
-	case Postgres:
-		return fmt.Sprintf(`"%s"`, cleaned)
-	case MySQL:
-		return fmt.Sprintf("`%s`", cleaned)
-	case SQLite:
-		return fmt.Sprintf(`"%s"`, cleaned)
-	default:
-		return fmt.Sprintf(`"%s"`, cleaned)
+	quote := dialect.Capabilities().IdentifierQuote
+	return quote + cleaned + quote
+}
+
+// quoteColumn quotes a plain column reference such as "order" or "u.Name"
+// part by part using SanitizeIdentifier. Expressions and names that are
+// already quoted are returned unchanged.
+func quoteColumn(column string, dialect Dialect) string {
+	if !safeColumnPattern.MatchString(column) {
+		return column
+	}
+
+	parts := strings.Split(column, ".")
+	for i, part := range parts {
+		parts[i] = SanitizeIdentifier(part, dialect)
 	}
+	return strings.Join(parts, ".")
 }
 
-// countStatements counts the number of SQL statements in a query
+// countStatements counts the number of SQL statements in a query. Empty
+// statements, such as the one after a trailing semicolon, are not counted.
 func countStatements(query string) int {
 	// Remove string literals and comments to avoid false positives
 	cleaned := removeStringLiteralsAndComments(query)
 
-	// Count semicolons that might indicate multiple statements
-	// This is a simple heuristic and not foolproof
-	count := 1
+	count := 0
 	inParens := 0
+	statement := false
 
 	for _, char := range cleaned {
 		switch char {
@@ -245,28 +283,94 @@ func countStatements(query string) int {
 			inParens--
 		case ';':
 			if inParens == 0 {
-				count++
+				if statement {
+					count++
+				}
+				statement = false
+				continue
 			}
 		}
+		if !unicode.IsSpace(char) {
+			statement = true
+		}
+	}
+	if statement || count == 0 {
+		count++
 	}
 
 	return count
 }
 
+// parenthesesBalanced reports whether every parenthesis in cleaned SQL is closed
+func parenthesesBalanced(cleaned string) bool {
+	depth := 0
+	for _, char := range cleaned {
+		switch char {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth < 0 {
+				return false
+			}
+		}
+	}
+	return depth == 0
+}
+
 // removeStringLiteralsAndComments removes string literals and comments from SQL
 func removeStringLiteralsAndComments(query string) string {
+	cleaned, _ := scanSQL(query)
+	return cleaned
+}
+
+// scanSQL removes string literals, quoted identifiers and comments from SQL.
+// It also reports whether all of them were terminated. Postgres escape
+// strings (E'it\'s') and dollar-quoted strings ($$...$$, $body$...$body$)
+// are recognized, so semicolons inside function bodies are not mistaken
+// for statement separators.
+func scanSQL(query string) (string, bool) {
+	return scanSQLMasked(query, false)
+}
+
+// maskSQL is scanSQL with string literals, quoted identifiers and comments
+// replaced by spaces instead of removed, so byte offsets in the result are
+// offsets in query (which must be valid UTF-8)
+func maskSQL(query string) string {
+	masked, _ := scanSQLMasked(query, true)
+	return masked
+}
+
+// scanSQLMasked implements scanSQL and, with mask set, maskSQL
+func scanSQLMasked(query string, mask bool) (string, bool) {
 	result := []rune{}
+	runes := []rune(query)
+
+	// With mask, skipped runes are written as one space per byte up to end
+	next := 0
+	pad := func(end int) {
+		if !mask {
+			return
+		}
+		for _, char := range runes[next:end] {
+			for n := utf8.RuneLen(char); n > 0; n-- {
+				result = append(result, ' ')
+			}
+		}
+		next = end
+	}
+
 	inString := false
 	inComment := false
 	inBlockComment := false
+	backslashEscapes := false
 	stringDelimiter := '\x00'
 
-	runes := []rune(query)
 	for i := 0; i < len(runes); i++ {
 		char := runes[i]
 
 		// Handle block comments
-		if !inString && !inComment && i < len(runes)-1 {
+		if !inString && !inComment && !inBlockComment && i < len(runes)-1 {
 			if char == '/' && runes[i+1] == '*' {
 				inBlockComment = true
 				i++ // Skip next character
@@ -283,7 +387,7 @@ func removeStringLiteralsAndComments(query string) string {
 		}
 
 		// Handle line comments
-		if !inString && !inBlockComment {
+		if !inString {
 			if char == '-' && i < len(runes)-1 && runes[i+1] == '-' {
 				inComment = true
 				i++ // Skip next character
@@ -298,33 +402,98 @@ func removeStringLiteralsAndComments(query string) string {
 			continue
 		}
 
-		// Handle string literals
-		if !inComment && !inBlockComment {
-			if !inString && (char == '\'' || char == '"') {
-				inString = true
-				stringDelimiter = char
+		atWordStart := i == 0 || !isIdentifierRune(runes[i-1])
+
+		// Handle dollar-quoted strings; $1 placeholders are not tags
+		if !inString && char == '$' && atWordStart {
+			if tag, ok := dollarQuoteTag(runes[i:]); ok {
+				end := indexRunes(runes[i+len(tag):], tag)
+				if end < 0 {
+					pad(len(runes))
+					return string(result), false
+				}
+				i += len(tag) + end + len(tag) - 1
 				continue
 			}
+		}
+
+		// Handle escape strings, where a backslash escapes the next character
+		if !inString && (char == 'E' || char == 'e') && atWordStart && i < len(runes)-1 && runes[i+1] == '\'' {
+			inString = true
+			backslashEscapes = true
+			stringDelimiter = '\''
+			i++ // Skip the opening quote
+			continue
+		}
+
+		// Handle string literals and quoted identifiers
+		if !inString && (char == '\'' || char == '"' || char == '`') {
+			inString = true
+			stringDelimiter = char
+			continue
+		}
 
-			if inString && char == stringDelimiter {
+		if inString {
+			if backslashEscapes && char == '\\' {
+				i++ // Skip escaped character
+				continue
+			}
+			if char == stringDelimiter {
 				// Check for escaped quotes
 				if i < len(runes)-1 && runes[i+1] == stringDelimiter {
 					i++ // Skip escaped quote
 					continue
 				}
 				inString = false
+				backslashEscapes = false
 				stringDelimiter = '\x00'
-				continue
 			}
+			continue
 		}
 
-		// Add character if not in string or comment
-		if !inString && !inComment && !inBlockComment {
-			result = append(result, char)
+		pad(i)
+		result = append(result, char)
+		next = i + 1
+	}
+
+	pad(len(runes))
+	return string(result), !inString && !inBlockComment
+}
+
+// dollarQuoteTag returns the opening tag ($$ or $tag$) at the start of runes
+func dollarQuoteTag(runes []rune) ([]rune, bool) {
+	for j := 1; j < len(runes); j++ {
+		char := runes[j]
+		if char == '$' {
+			return runes[:j+1], true
+		}
+		if !isIdentifierRune(char) || (j == 1 && unicode.IsDigit(char)) {
+			return nil, false
+		}
+	}
+	return nil, false
+}
+
+// indexRunes returns the index of the first occurrence of sub in runes, or -1
+func indexRunes(runes, sub []rune) int {
+	for i := 0; i+len(sub) <= len(runes); i++ {
+		match := true
+		for j := range sub {
+			if runes[i+j] != sub[j] {
+				match = false
+				break
+			}
+		}
+		if match {
+			return i
 		}
 	}
+	return -1
+}
 
-	return string(result)
+// isIdentifierRune reports whether char can appear in an unquoted identifier
+func isIdentifierRune(char rune) bool {
+	return char == '_' || char == '$' || unicode.IsLetter(char) || unicode.IsDigit(char)
 }
 
 // SecureQueryBuilder provides additional validation for query building
@@ -343,7 +512,10 @@ func NewSecureQueryBuilder(baseQuery string, dialect Dialect) *SecureQueryBuilde
 
 // Build builds the query with validation
 func (sqb *SecureQueryBuilder) Build() (string, []interface{}, error) {
-	query, params := sqb.QueryBuilder.Build()
+	query, params, err := sqb.QueryBuilder.BuildChecked()
+	if err != nil {
+		return "", nil, err
+	}
 
 	if sqb.validationEnabled {
 		if err := ValidateQuery(query, sqb.dialect); err != nil {
@@ -0,0 +1,213 @@
+package sqld
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// AnnotationIssue is a problem VetAnnotations found in a query
+type AnnotationIssue struct {
+	// Annotation is the annotation as written, e.g. "/* sqld:orderby */"
+	Annotation string
+
+	// Offset is the byte offset of the annotation in the query
+	Offset int
+
+	Message string
+}
+
+func (i AnnotationIssue) String() string {
+	return i.Annotation + ": " + i.Message
+}
+
+// anyAnnotationPattern matches anything that looks like a sqld annotation,
+// including misspelled ones
+var anyAnnotationPattern = regexp.MustCompile(`/\*\s*sqld:(\w*)[^*]*\*/`)
+
+// canonicalAnnotations are the annotations ProcessQuery recognizes, by name
+var canonicalAnnotations = map[string]*regexp.Regexp{
+	"where":   regexp.MustCompile(`^/\* sqld:where \*/$`),
+	"orderby": regexp.MustCompile(`^/\* sqld:orderby \*/$`),
+	"cursor":  regexp.MustCompile(`^/\* sqld:cursor \*/$`),
+	"total":   regexp.MustCompile(`^/\* sqld:total \*/$`),
+	"limit":   regexp.MustCompile(`^` + limitAnnotationPattern.String() + `$`),
+}
+
+var (
+	// clauseKeywordPattern finds the clauses an annotation can follow
+	clauseKeywordPattern = regexp.MustCompile(`(?i)\b(WHERE|GROUP\s+BY|HAVING|ORDER\s+BY|LIMIT)\b`)
+
+	// setOperationPattern finds the operators separating UNION branches
+	setOperationPattern = regexp.MustCompile(`(?i)\b(UNION|INTERSECT|EXCEPT)\b`)
+
+	limitClausePattern  = regexp.MustCompile(`(?i)\bLIMIT\b`)
+	selectClausePattern = regexp.MustCompile(`(?i)\bSELECT\b`)
+	fromClausePattern   = regexp.MustCompile(`(?i)\bFROM\b`)
+	createdAtPattern    = regexp.MustCompile(`(?i)\bcreated_at\b`)
+	idColumnPattern     = regexp.MustCompile(`(?i)\bid\b`)
+	selectAllPattern    = regexp.MustCompile(`(?i)\bSELECT\s+(\w+\.)?\*`)
+)
+
+// VetAnnotations checks the sqld annotations of a query for mistakes that
+// ProcessQuery would otherwise ignore silently or turn into invalid SQL:
+// unknown or misspelled annotations, duplicates, an orderby annotation
+// without an ORDER BY clause to replace, a where annotation outside a WHERE
+// clause, a cursor annotation without the where annotation it is expanded
+// into or without created_at and id columns, a total annotation outside the
+// SELECT list, and a limit annotation in a query that already has a LIMIT.
+// In a UNION, each branch takes a where and a cursor annotation, and
+// branches without one are reported. It returns nil for a clean query.
+func VetAnnotations(sql string) []AnnotationIssue {
+	var issues []AnnotationIssue
+	report := func(annotation string, offset int, message string) {
+		issues = append(issues, AnnotationIssue{Annotation: annotation, Offset: offset, Message: message})
+	}
+
+	cleaned := removeStringLiteralsAndComments(sql)
+	seen := make(map[string]bool)
+	branches := setOperations(sql) + 1
+	wheres := 0
+
+	for _, loc := range anyAnnotationPattern.FindAllStringSubmatchIndex(sql, -1) {
+		text, name := sql[loc[0]:loc[1]], sql[loc[2]:loc[3]]
+		canonical, known := canonicalAnnotations[name]
+		switch {
+		case !known:
+			report(text, loc[0], "unknown annotation")
+			continue
+		case !canonical.MatchString(text):
+			if name == "limit" {
+				report(text, loc[0], "is not recognized; write it as /* sqld:limit */ or /* sqld:limit default=N max=N */")
+			} else {
+				report(text, loc[0], "is not recognized; write it as /* sqld:"+name+" */")
+			}
+			continue
+		case name == "where" || name == "cursor":
+			// Every branch of a UNION has its own
+			if name == "where" {
+				wheres++
+			}
+			if seen[name] && branches == 1 {
+				report(text, loc[0], "duplicate annotation; only the first one is expanded")
+				continue
+			}
+		case seen[name]:
+			report(text, loc[0], "duplicate annotation; only the first one is expanded")
+			continue
+		}
+		seen[name] = true
+
+		// Clauses are looked up in the (sub)query the annotation is in
+		prefix := scopeBefore(removeStringLiteralsAndComments(sql[:loc[0]]))
+		suffix := scopeAfter(removeStringLiteralsAndComments(sql[loc[1]:]))
+		switch name {
+		case "where":
+			// Only the clauses of the annotation's own UNION branch count
+			if ops := setOperationPattern.FindAllStringIndex(prefix, -1); len(ops) > 0 {
+				prefix = prefix[ops[len(ops)-1][1]:]
+			}
+			if clause := lastClause(prefix); clause != "WHERE" {
+				if clause == "" {
+					report(text, loc[0], "query has no WHERE clause; conditions are appended with AND")
+				} else {
+					report(text, loc[0], "must be inside the WHERE clause, not after "+clause)
+				}
+			}
+		case "orderby":
+			start, ok := orderByClause(sql, loc[0])
+			switch {
+			case ok && strings.TrimSpace(sql[start+len("ORDER BY"):loc[0]]) == "":
+				report(text, loc[0], "needs a default ORDER BY list for requests without a sort")
+			case ok:
+			case lastClause(prefix) == "ORDER BY":
+				report(text, loc[0], "ORDER BY must be written in upper case to be replaced")
+			default:
+				report(text, loc[0], "query has no ORDER BY clause to replace")
+			}
+		case "limit":
+			if err := parseLimitAnnotation(text, &AnnotatedQuery{}); err != nil {
+				report(text, loc[0], err.Error())
+			}
+			if limitClausePattern.MatchString(prefix) || limitClausePattern.MatchString(suffix) {
+				report(text, loc[0], "query already has a LIMIT clause")
+			}
+		case "total":
+			if !selectClausePattern.MatchString(prefix) || fromClausePattern.MatchString(prefix) {
+				report(text, loc[0], "must be at the end of the SELECT list")
+			}
+		case "cursor":
+			if !selectAllPattern.MatchString(cleaned) &&
+				(!createdAtPattern.MatchString(cleaned) || !idColumnPattern.MatchString(cleaned)) {
+				report(text, loc[0], "cursor pagination needs the created_at and id columns")
+			}
+		}
+	}
+
+	if wheres > 0 && wheres < branches {
+		loc := strings.Index(sql, "/* sqld:where */")
+		report("/* sqld:where */", loc, fmt.Sprintf("only %d of the %d UNION branches have a where annotation; the others are not filtered", wheres, branches))
+	}
+	if seen["cursor"] && !seen["where"] {
+		loc := strings.Index(sql, "/* sqld:cursor */")
+		report("/* sqld:cursor */", loc, "has no effect without /* sqld:where */, which the cursor condition is added to")
+	}
+	return issues
+}
+
+// lastClause returns the last clause keyword in sql, normalized to upper
+// case with single spaces, or "" if there is none
+func lastClause(sql string) string {
+	matches := clauseKeywordPattern.FindAllString(sql, -1)
+	if len(matches) == 0 {
+		return ""
+	}
+	return strings.ToUpper(strings.Join(strings.Fields(matches[len(matches)-1]), " "))
+}
+
+// scopeBefore returns the end of sql that is at the same parenthesis depth as
+// its last character, with nested parentheses removed
+func scopeBefore(sql string) string {
+	var kept []byte
+	depth := 0
+	for i := len(sql) - 1; i >= 0; i-- {
+		switch c := sql[i]; {
+		case c == ')':
+			depth++
+		case c == '(' && depth == 0:
+			return reverse(kept)
+		case c == '(':
+			depth--
+		case depth == 0:
+			kept = append(kept, c)
+		}
+	}
+	return reverse(kept)
+}
+
+// scopeAfter returns the start of sql up to the parenthesis closing the
+// current scope, with nested parentheses removed
+func scopeAfter(sql string) string {
+	var kept []byte
+	depth := 0
+	for i := 0; i < len(sql); i++ {
+		switch c := sql[i]; {
+		case c == '(':
+			depth++
+		case c == ')' && depth == 0:
+			return string(kept)
+		case c == ')':
+			depth--
+		case depth == 0:
+			kept = append(kept, c)
+		}
+	}
+	return string(kept)
+}
+
+func reverse(b []byte) string {
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+	return string(b)
+}
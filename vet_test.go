@@ -0,0 +1,115 @@
+package sqld
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVetAnnotations(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+		want []string // expected messages, in order
+	}{
+		{
+			name: "clean query",
+			sql: `SELECT * FROM users WHERE status = $1 /* sqld:where */ /* sqld:cursor */
+ORDER BY created_at DESC, id DESC /* sqld:orderby */ /* sqld:limit default=20 max=100 */`,
+		},
+		{
+			name: "subqueries do not count as clauses",
+			sql: `SELECT * FROM users WHERE org_id IN (SELECT id FROM orgs WHERE plan = 'pro' ORDER BY id LIMIT 10) /* sqld:where */
+ORDER BY id /* sqld:orderby */ /* sqld:limit */`,
+		},
+		{
+			name: "unknown and misspelled annotations",
+			sql:  `SELECT * FROM users WHERE 1=1 /* sqld:filter */ /*sqld:where*/ /* sqld:limit 10 */`,
+			want: []string{"unknown annotation", "is not recognized; write it as /* sqld:where */",
+				"is not recognized; write it as /* sqld:limit */ or /* sqld:limit default=N max=N */"},
+		},
+		{
+			name: "duplicate annotation",
+			sql:  `SELECT * FROM users WHERE 1=1 /* sqld:where */ AND 2=2 /* sqld:where */`,
+			want: []string{"duplicate annotation; only the first one is expanded"},
+		},
+		{
+			name: "where in every UNION branch",
+			sql: `SELECT id, created_at FROM users WHERE active /* sqld:where */ /* sqld:cursor */
+UNION ALL
+SELECT id, created_at FROM admins WHERE true /* sqld:where */ /* sqld:cursor */
+ORDER BY created_at DESC /* sqld:orderby */ /* sqld:limit */`,
+		},
+		{
+			name: "UNION branch without where",
+			sql:  `SELECT id FROM users WHERE active /* sqld:where */ UNION SELECT id FROM admins ORDER BY id /* sqld:orderby */`,
+			want: []string{"only 1 of the 2 UNION branches have a where annotation; the others are not filtered"},
+		},
+		{
+			name: "UNION branch without WHERE",
+			sql:  `SELECT id FROM users WHERE active /* sqld:where */ UNION SELECT id FROM admins /* sqld:where */`,
+			want: []string{"query has no WHERE clause; conditions are appended with AND"},
+		},
+		{
+			name: "where without WHERE",
+			sql:  `SELECT * FROM users /* sqld:where */`,
+			want: []string{"query has no WHERE clause; conditions are appended with AND"},
+		},
+		{
+			name: "where after GROUP BY",
+			sql:  `SELECT status, count(*) FROM users WHERE 1=1 GROUP BY status /* sqld:where */`,
+			want: []string{"must be inside the WHERE clause, not after GROUP BY"},
+		},
+		{
+			name: "orderby without ORDER BY",
+			sql:  `SELECT * FROM users /* sqld:orderby */`,
+			want: []string{"query has no ORDER BY clause to replace"},
+		},
+		{
+			name: "orderby in lower case",
+			sql:  `SELECT * FROM users order by id /* sqld:orderby */`,
+			want: []string{"ORDER BY must be written in upper case to be replaced"},
+		},
+		{
+			name: "orderby without a default list",
+			sql:  `SELECT * FROM users ORDER BY /* sqld:orderby */`,
+			want: []string{"needs a default ORDER BY list for requests without a sort"},
+		},
+		{
+			name: "limit with a LIMIT clause",
+			sql:  `SELECT * FROM users LIMIT 10 /* sqld:limit */`,
+			want: []string{"query already has a LIMIT clause"},
+		},
+		{
+			name: "limit with bad options",
+			sql:  `SELECT * FROM users /* sqld:limit default=50 max=10 */`,
+			want: []string{"invalid query: limit annotation default 50 exceeds max 10"},
+		},
+		{
+			name: "cursor without its columns or where",
+			sql:  `SELECT name, email FROM users /* sqld:cursor */`,
+			want: []string{"cursor pagination needs the created_at and id columns",
+				"has no effect without /* sqld:where */, which the cursor condition is added to"},
+		},
+		{
+			name: "total in the SELECT list",
+			sql:  `SELECT id, name /* sqld:total */ FROM users ORDER BY id /* sqld:limit */`,
+		},
+		{
+			name: "total after FROM",
+			sql:  `SELECT id, name FROM users /* sqld:total */ /* sqld:limit */`,
+			want: []string{"must be at the end of the SELECT list"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var messages []string
+			for _, issue := range VetAnnotations(tt.sql) {
+				assert.Equal(t, issue.Annotation, tt.sql[issue.Offset:issue.Offset+len(issue.Annotation)])
+				messages = append(messages, issue.Message)
+			}
+			assert.Equal(t, tt.want, messages)
+		})
+	}
+}
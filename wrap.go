@@ -0,0 +1,30 @@
+package sqld
+
+import "strings"
+
+// WrapQuery returns an annotated query selecting from baseSQL as a
+// subquery, for queries that cannot carry annotations themselves, such as
+// legacy queries shared with other code:
+//
+//	SELECT * /* sqld:total */ FROM (
+//	<baseSQL>
+//	) AS sub WHERE 1 = 1 /* sqld:where */ /* sqld:cursor */ /* sqld:orderby */ /* sqld:limit */
+//
+// Conditions, ordering, cursors and limits apply to the outer query, so
+// they refer to the columns the base query returns, under the names it
+// returns them. The parameters of baseSQL are passed as the original
+// parameters and keep their placeholders; added conditions are numbered
+// after them. Without an ordering the outer query has no ORDER BY, and rows
+// come in whatever order the database returns the subquery's.
+//
+// Example:
+//
+//	users, err := exec.QueryAll(ctx, sqld.WrapQuery(legacy.ActiveUsers), where, nil, orderBy, 50, orgID)
+func WrapQuery(baseSQL string) string {
+	baseSQL = strings.TrimRight(strings.TrimSpace(baseSQL), "; \t\r\n")
+
+	// The base query sits on lines of its own, so a trailing line comment
+	// cannot swallow the closing parenthesis
+	return "SELECT * /* sqld:total */ FROM (\n" + baseSQL + "\n) AS sub" +
+		" WHERE 1 = 1 /* sqld:where */ /* sqld:cursor */ /* sqld:orderby */ /* sqld:limit */"
+}
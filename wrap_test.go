@@ -0,0 +1,95 @@
+package sqld
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const legacyQuery = `SELECT u.id, u.name, u.created_at, COUNT(o.id) AS orders
+FROM users u LEFT JOIN orders o ON o.user_id = u.id
+WHERE u.org_id = $1
+GROUP BY u.id
+ORDER BY u.name -- alphabetical
+LIMIT 1000;`
+
+func TestWrapQuery(t *testing.T) {
+	wrapped := WrapQuery(legacyQuery)
+	assert.Equal(t, "SELECT * /* sqld:total */ FROM (\n"+legacyQuery[:len(legacyQuery)-1]+"\n) AS sub"+
+		" WHERE 1 = 1 /* sqld:where */ /* sqld:cursor */ /* sqld:orderby */ /* sqld:limit */", wrapped)
+
+	t.Run("filters, sorts and limits the outer query", func(t *testing.T) {
+		where := NewWhereBuilder(Postgres)
+		where.GreaterThan("orders", 5)
+		orderBy := NewOrderByBuilder().Add("orders", SortDesc)
+
+		sql, params, err := NewAnnotationProcessor(Postgres).ProcessQuery(wrapped, where, nil, orderBy, 20, 7)
+		require.NoError(t, err)
+		assert.Equal(t, "SELECT *  FROM (\n"+legacyQuery[:len(legacyQuery)-1]+"\n) AS sub"+
+			" WHERE 1 = 1  AND orders > $2  ORDER BY orders DESC  LIMIT $3", sql)
+		assert.Equal(t, []interface{}{7, 5, 20}, params)
+	})
+
+	t.Run("without ordering", func(t *testing.T) {
+		sql, params, err := NewAnnotationProcessor(MySQL).ProcessQuery(WrapQuery("SELECT * FROM users ORDER BY id"), nil, nil, nil, 0)
+		require.NoError(t, err)
+		assert.Equal(t, "SELECT *  FROM (\nSELECT * FROM users ORDER BY id\n) AS sub WHERE 1 = 1    ", sql)
+		assert.Empty(t, params)
+	})
+
+	t.Run("cursor", func(t *testing.T) {
+		cursor := &Cursor{CreatedAt: "2024-01-01", ID: 42}
+		sql, params, err := NewAnnotationProcessor(Postgres).ProcessQuery(WrapQuery(legacyQuery), nil, cursor, nil, 0, 7)
+		require.NoError(t, err)
+		assert.Contains(t, sql, ") AS sub WHERE 1 = 1  AND (created_at < $2 OR (created_at = $2 AND id < $3))")
+		assert.Equal(t, []interface{}{7, "2024-01-01", int32(42)}, params)
+	})
+}
+
+func TestExecutor_WrapQuery(t *testing.T) {
+	db := &countDB{count: 3}
+	where := NewWhereBuilder(Postgres)
+	where.Equal("name", "ann")
+
+	total, err := NewExecutor[testUser](New(db, Postgres)).Count(context.Background(), WrapQuery(legacyQuery), where, 7)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), total.Total)
+	require.Len(t, db.queries, 1)
+	assert.Contains(t, db.queries[0], ") AS sub WHERE 1 = 1  AND name = $2")
+	assert.Equal(t, []interface{}{7, "ann"}, db.args[0])
+}
+
+func TestAnnotationProcessor_OrderByScope(t *testing.T) {
+	orderBy := NewOrderByBuilder().Add("name", SortAsc)
+	tests := []struct {
+		name string
+		sql  string
+		want string
+	}{
+		{
+			name: "window function before the clause",
+			sql:  "SELECT id, ROW_NUMBER() OVER (ORDER BY score DESC) AS rank FROM users ORDER BY rank /* sqld:orderby */",
+			want: "SELECT id, ROW_NUMBER() OVER (ORDER BY score DESC) AS rank FROM users ORDER BY name ASC ",
+		},
+		{
+			name: "subquery without a default order",
+			sql:  "SELECT * FROM (SELECT * FROM users ORDER BY id) AS u /* sqld:orderby */",
+			want: "SELECT * FROM (SELECT * FROM users ORDER BY id) AS u ORDER BY name ASC",
+		},
+		{
+			name: "annotation inside a subquery",
+			sql:  "SELECT * FROM (SELECT * FROM users ORDER BY id /* sqld:orderby */ LIMIT 5) AS u ORDER BY id",
+			want: "SELECT * FROM (SELECT * FROM users ORDER BY name ASC  LIMIT 5) AS u ORDER BY id",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, _, err := NewAnnotationProcessor(Postgres).ProcessQuery(tt.sql, nil, nil, orderBy, 0)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, sql)
+		})
+	}
+}
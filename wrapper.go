@@ -4,6 +4,8 @@ package sqld
 
 import (
 	"context"
+	"fmt"
+	"strings"
 )
 
 // Queries wraps a database connection with dialect information for simplified sqld usage.
@@ -15,10 +17,29 @@ import (
 //	exec := sqld.NewExecutor[db.User](q)
 //	users, err := exec.QueryAll(ctx, db.SearchUsers, where, cursor, orderBy, limit)
 type Queries struct {
-	db      DBTX
-	dialect Dialect
+	db         DBTX
+	dialect    Dialect
+	tenant     *tenantScope
+	filters    []ContextFilter
+	middleware []Middleware
+	redaction  *ParamRedaction
 }
 
+// TenantFunc extracts the current tenant ID from a request context.
+// It should return ErrMissingTenant (or any error) when no tenant is present.
+type TenantFunc func(ctx context.Context) (interface{}, error)
+
+// tenantScope is a registered tenant column and its extractor
+type tenantScope struct {
+	column  string
+	extract TenantFunc
+}
+
+// ContextFilter adds conditions derived from the request context, such as
+// the caller's region from auth claims, to the mandatory scope of every
+// query. An error fails the query.
+type ContextFilter func(ctx context.Context, where ConditionBuilder) error
+
 // New creates a new Queries wrapper with database and dialect.
 // The returned Queries instance can be used to create typed executors
 // or passed to the helper functions for query execution.
@@ -44,6 +65,124 @@ func (q *Queries) Dialect() Dialect {
 	return q.dialect
 }
 
+// WithTenantScope registers a tenant scope: every query run through an
+// Executor or QueryBuilder created from these Queries gets a mandatory
+// "column = <tenant>" condition, with the tenant taken from the context.
+// Queries without a tenant in context fail with ErrMissingTenant.
+//
+// Example:
+//
+//	q := sqld.New(database, sqld.Postgres).
+//		WithTenantScope("tenant_id", func(ctx context.Context) (interface{}, error) {
+//			if id, ok := auth.TenantID(ctx); ok {
+//				return id, nil
+//			}
+//			return nil, sqld.ErrMissingTenant
+//		})
+func (q *Queries) WithTenantScope(column string, fn TenantFunc) *Queries {
+	q.tenant = &tenantScope{column: column, extract: fn}
+	return q
+}
+
+// WithContextFilter registers filters applied, like the tenant scope, to
+// every query run through an Executor or QueryBuilder created from these
+// Queries, so conditions every call site would repeat live in one place
+//
+// Example:
+//
+//	q := sqld.New(database, sqld.Postgres).
+//		WithContextFilter(func(ctx context.Context, where sqld.ConditionBuilder) error {
+//			claims := auth.Claims(ctx)
+//			where.Equal("region", claims.Region)
+//			where.In("team_id", claims.TeamIDs)
+//			return nil
+//		})
+func (q *Queries) WithContextFilter(filters ...ContextFilter) *Queries {
+	q.filters = append(q.filters, filters...)
+	return q
+}
+
+// EqualFromContext returns a ContextFilter adding "column = value" when
+// value reports one for the context, and nothing otherwise
+//
+// Example:
+//
+//	q.WithContextFilter(sqld.EqualFromContext("region", func(ctx context.Context) (interface{}, bool) {
+//		region, ok := ctx.Value(regionKey{}).(string)
+//		return region, ok
+//	}))
+func EqualFromContext(column string, value func(ctx context.Context) (interface{}, bool)) ContextFilter {
+	return func(ctx context.Context, where ConditionBuilder) error {
+		if v, ok := value(ctx); ok {
+			where.Equal(column, v)
+		}
+		return nil
+	}
+}
+
+// WithTx returns a copy of the Queries that runs on tx instead of the
+// original database. The dialect, tenant scope and context filters are kept.
+//
+// Example:
+//
+//	err := txm.WithTransaction(ctx, func(tx *sqld.Tx) error {
+//		users, err := sqld.NewExecutor[db.User](q.WithTx(tx)).QueryAll(ctx, db.SearchUsers, where, nil, nil, 0)
+//		...
+//	})
+func (q *Queries) WithTx(tx *Tx) *Queries {
+	clone := *q
+	clone.db = tx
+	return &clone
+}
+
+// Scope returns the mandatory conditions registered on the Queries for the
+// given context: the tenant scope and the context filters. The builder is
+// empty when nothing is registered.
+func (q *Queries) Scope(ctx context.Context) (*WhereBuilder, error) {
+	scope := NewWhereBuilder(q.dialect)
+
+	if q.tenant != nil {
+		tenantID, err := q.tenant.extract(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if tenantID == nil {
+			return nil, ErrMissingTenant
+		}
+		scope.Equal(q.tenant.column, tenantID)
+	}
+
+	for _, filter := range q.filters {
+		if err := filter(ctx, scope); err != nil {
+			return nil, err
+		}
+	}
+	if err := scope.Err(); err != nil {
+		return nil, err
+	}
+
+	return scope, nil
+}
+
+// QueryBuilder creates a QueryBuilder for baseQuery with the mandatory
+// conditions from Scope applied. The scope is kept separate from the
+// conditions set via Where, so it cannot be replaced by the caller. A base
+// query the scope cannot be added to, such as a UNION, is rejected with
+// ErrInvalidQuery rather than built unscoped.
+func (q *Queries) QueryBuilder(ctx context.Context, baseQuery string) (*QueryBuilder, error) {
+	scope, err := q.Scope(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	qb := NewQueryBuilder(baseQuery, q.dialect)
+	qb.scope = scope
+	if _, _, err := qb.BuildChecked(); err != nil {
+		return nil, err
+	}
+	return qb, nil
+}
+
 // Executor provides a fluent interface for executing queries with a specific type.
 // By binding the type at creation time, it eliminates the need to specify the type
 // parameter on every query call and provides a cleaner API.
@@ -64,9 +203,30 @@ func (q *Queries) Dialect() Dialect {
 //	users, err := userExec.QueryAll(ctx, db.SearchUsers, where, nil, orderBy, 50)
 //	user, err := userExec.QueryOne(ctx, db.GetUser, whereClause)
 type Executor[T any] struct {
-	queries *Queries
+	queries    *Queries
+	config     *Config
+	softDelete softDeleteMode
+	policies   []Policy
+	scanner    *ReflectionScanner[T]
+	maxRows    int
+	truncate   bool
+
+	countEstimate   bool
+	exactCountBelow int64
+	windowCount     bool
+	transformers    []RowTransformer[T]
+	redact          bool
 }
 
+// softDeleteMode controls how an Executor treats soft-deleted rows
+type softDeleteMode int
+
+const (
+	softDeleteExclude softDeleteMode = iota
+	softDeleteInclude
+	softDeleteOnly
+)
+
 // NewExecutor creates a typed executor for a specific result type.
 // This should typically be created once during initialization and reused.
 //
@@ -78,34 +238,241 @@ func NewExecutor[T any](q *Queries) *Executor[T] {
 	return &Executor[T]{queries: q}
 }
 
-// QueryAll executes a query and scans all results
+// WithConfig returns a copy of the executor bound to the given config.
+// Automatic conditions declared on the config, such as soft-delete filtering,
+// are applied to every query run through the returned executor.
+//
+// Example:
+//
+//	config := sqld.DefaultConfig().WithSoftDelete("deleted_at")
+//	userExec := sqld.NewExecutor[db.User](q).WithConfig(config)
+func (e *Executor[T]) WithConfig(config *Config) *Executor[T] {
+	clone := *e
+	clone.config = config
+	return &clone
+}
+
+// WithScanner returns a copy of the executor that scans rows with scanner,
+// e.g. one configured with WithNullsAsZero or WithTypeConverters
+//
+// Example:
+//
+//	userExec := sqld.NewExecutor[db.User](q).WithScanner(sqld.NewReflectionScanner[db.User]().WithNullsAsZero())
+func (e *Executor[T]) WithScanner(scanner *ReflectionScanner[T]) *Executor[T] {
+	clone := *e
+	clone.scanner = scanner
+	return &clone
+}
+
+// WithTx returns a copy of the executor that runs its queries on tx, so
+// filtered reads and writes such as BulkInsert share one transaction
+//
+// Example:
+//
+//	err := txm.WithTransaction(ctx, func(tx *sqld.Tx) error {
+//		users, err := userExec.WithTx(tx).QueryAll(ctx, db.SearchUsers, where, nil, nil, 0)
+//		...
+//	})
+func (e *Executor[T]) WithTx(tx *Tx) *Executor[T] {
+	clone := *e
+	clone.queries = e.queries.WithTx(tx)
+	return &clone
+}
+
+// rowScanner returns the executor's scanner or a default one
+func (e *Executor[T]) rowScanner() *ReflectionScanner[T] {
+	if e.scanner != nil {
+		return e.scanner
+	}
+	return NewReflectionScanner[T]()
+}
+
+// WithMaxRows returns a copy of the executor that refuses to read more than
+// n rows from a query, protecting handlers whose queries have no LIMIT.
+// Queries returning more fail with ErrTooManyRows; with truncate set the
+// first n rows are returned instead, and QueryPaginated reports it in
+// PaginatedResult.Truncated. Zero removes the limit.
+//
+// Example:
+//
+//	userExec := sqld.NewExecutor[db.User](q).WithMaxRows(10000, false)
+func (e *Executor[T]) WithMaxRows(n int, truncate bool) *Executor[T] {
+	clone := *e
+	clone.maxRows = n
+	clone.truncate = truncate
+	return &clone
+}
+
+// WithWindowCount returns a copy of the executor whose QueryPaginatedOffset
+// reports the total number of matching rows in PaginatedResult.Total. The
+// count comes from the same query, as a COUNT(*) OVER() column the query's
+// /* sqld:total */ annotation expands into, instead of a second round trip.
+// The annotation goes at the end of the SELECT list.
+//
+// Example:
+//
+//	// SELECT id, name /* sqld:total */ FROM users WHERE 1=1 /* sqld:where */ ORDER BY id /* sqld:limit */
+//	userExec := sqld.NewExecutor[db.User](q).WithWindowCount()
+//	page, err := userExec.QueryPaginatedOffset(ctx, query, params.Where, params.OrderBy, params.Limit, params.Offset)
+func (e *Executor[T]) WithWindowCount() *Executor[T] {
+	clone := *e
+	clone.windowCount = true
+	return &clone
+}
+
+// queryOptions returns the settings used to build and scan the executor's
+// queries. Config.MaxLimit caps the limit of annotated queries.
+func (e *Executor[T]) queryOptions() queryOptions {
+	processor := NewAnnotationProcessor(e.queries.dialect)
+	if e.config != nil {
+		processor.WithMaxLimit(e.config.MaxLimit)
+	}
+	return queryOptions{processor: processor, maxRows: e.maxRows, truncate: e.truncate, windowCount: e.windowCount, redaction: e.queries.redaction}
+}
+
+// IncludeDeleted returns a copy of the executor that does not filter out
+// soft-deleted rows
+func (e *Executor[T]) IncludeDeleted() *Executor[T] {
+	clone := *e
+	clone.softDelete = softDeleteInclude
+	return &clone
+}
+
+// OnlyDeleted returns a copy of the executor that only returns soft-deleted rows
+func (e *Executor[T]) OnlyDeleted() *Executor[T] {
+	clone := *e
+	clone.softDelete = softDeleteOnly
+	return &clone
+}
+
+// QueryAll executes a query and scans all results. A zero or negative
+// limit is unset, see QueryAllLimit.
 func (e *Executor[T]) QueryAll(ctx context.Context, sqlcQuery string, where *WhereBuilder, cursor *Cursor, orderBy *OrderByBuilder, limit int, originalParams ...interface{}) ([]T, error) {
-	return QueryAll[T](ctx, e.queries.db, sqlcQuery, e.queries.dialect, where, cursor, orderBy, limit, originalParams...)
+	return e.QueryAllLimit(ctx, sqlcQuery, where, cursor, orderBy, LimitFromInt(limit), originalParams...)
+}
+
+// QueryAllLimit is QueryAll with a Limit, which can also ask for every row
+// with NoLimit, overriding the limit annotation's default, or for no rows
+// with LimitTo(0)
+//
+// Example:
+//
+//	// An export ignores the page size default of the listing query
+//	users, err := userExec.QueryAllLimit(ctx, db.SearchUsers, where, nil, orderBy, sqld.NoLimit)
+func (e *Executor[T]) QueryAllLimit(ctx context.Context, sqlcQuery string, where *WhereBuilder, cursor *Cursor, orderBy *OrderByBuilder, limit Limit, originalParams ...interface{}) ([]T, error) {
+	where, err := e.scopedWhere(ctx, sqlcQuery, where)
+	if err != nil {
+		return nil, err
+	}
+	items, err := e.rowScanner().queryAll(ctx, e.queries.conn(), sqlcQuery, e.queryOptions(), where, cursor, orderBy, limit, originalParams...)
+	if err != nil {
+		return nil, err
+	}
+	if err := e.transformRows(ctx, items); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
 // QueryOne executes a query and scans a single result
 func (e *Executor[T]) QueryOne(ctx context.Context, sqlcQuery string, where *WhereBuilder, originalParams ...interface{}) (T, error) {
-	return QueryOne[T](ctx, e.queries.db, sqlcQuery, e.queries.dialect, where, originalParams...)
+	where, err := e.scopedWhere(ctx, sqlcQuery, where)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	item, err := e.rowScanner().queryOne(ctx, e.queries.conn(), sqlcQuery, e.queryOptions(), where, originalParams...)
+	if err != nil {
+		return item, err
+	}
+	return e.transformRow(ctx, item)
 }
 
 // QueryPaginated executes a paginated query
 func (e *Executor[T]) QueryPaginated(ctx context.Context, sqlcQuery string, where *WhereBuilder, cursor *Cursor, orderBy *OrderByBuilder, limit int, getCursorFields func(T) (interface{}, interface{}), originalParams ...interface{}) (*PaginatedResult[T], error) {
-	return QueryPaginated[T](ctx, e.queries.db, sqlcQuery, e.queries.dialect, where, cursor, orderBy, limit, getCursorFields, originalParams...)
+	where, err := e.scopedWhere(ctx, sqlcQuery, where)
+	if err != nil {
+		return nil, err
+	}
+	result, err := e.rowScanner().queryPaginated(ctx, e.queries.conn(), sqlcQuery, e.queryOptions(), where, cursor, orderBy, limit, getCursorFields, originalParams...)
+	if err != nil {
+		return nil, err
+	}
+	if err := e.transformRows(ctx, result.Items); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// QueryPaginatedOffset executes a page of a query with a limit annotation,
+// skipping offset rows, e.g. QueryParams.Offset
+func (e *Executor[T]) QueryPaginatedOffset(ctx context.Context, sqlcQuery string, where *WhereBuilder, orderBy *OrderByBuilder, limit, offset int, originalParams ...interface{}) (*PaginatedResult[T], error) {
+	where, err := e.scopedWhere(ctx, sqlcQuery, where)
+	if err != nil {
+		return nil, err
+	}
+	result, err := e.rowScanner().queryPaginatedOffset(ctx, e.queries.conn(), sqlcQuery, e.queryOptions(), where, orderBy, limit, offset, originalParams...)
+	if err != nil {
+		return nil, err
+	}
+	if err := e.transformRows(ctx, result.Items); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// scopedWhere combines the caller's conditions with the executor's policies
+// and automatic conditions. These are mandatory, so a query without a where
+// annotation to receive them is rejected rather than silently run unscoped.
+func (e *Executor[T]) scopedWhere(ctx context.Context, sqlcQuery string, where *WhereBuilder) (*WhereBuilder, error) {
+	policies, err := e.policyConditions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	scope, err := e.queries.Scope(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if e.config != nil && e.config.SoftDeleteColumn != "" {
+		switch e.softDelete {
+		case softDeleteExclude:
+			scope.IsNull(e.config.SoftDeleteColumn)
+		case softDeleteOnly:
+			scope.IsNotNull(e.config.SoftDeleteColumn)
+		}
+	}
+
+	if len(policies) == 0 && !scope.HasConditions() {
+		return where, nil
+	}
+
+	if !strings.Contains(sqlcQuery, "/* sqld:where */") {
+		return nil, fmt.Errorf("%w: automatic conditions require a /* sqld:where */ annotation", ErrInvalidQuery)
+	}
+
+	builders := make([]*WhereBuilder, 0, len(policies)+2)
+	builders = append(builders, where)
+	builders = append(builders, policies...)
+	builders = append(builders, scope)
+	return CombineConditions(e.queries.dialect, builders...), nil
 }
 
-// Legacy helper functions for backward compatibility
+// Legacy helper functions for backward compatibility.
+// They run through an Executor so the scope registered on the Queries applies.
 
 // QueryAllWith executes a query and scans all results using the Queries wrapper
 func QueryAllWith[T any](ctx context.Context, q *Queries, sqlcQuery string, where *WhereBuilder, cursor *Cursor, orderBy *OrderByBuilder, limit int, originalParams ...interface{}) ([]T, error) {
-	return QueryAll[T](ctx, q.db, sqlcQuery, q.dialect, where, cursor, orderBy, limit, originalParams...)
+	return NewExecutor[T](q).QueryAll(ctx, sqlcQuery, where, cursor, orderBy, limit, originalParams...)
 }
 
 // QueryOneWith executes a query and scans a single result using the Queries wrapper
 func QueryOneWith[T any](ctx context.Context, q *Queries, sqlcQuery string, where *WhereBuilder, originalParams ...interface{}) (T, error) {
-	return QueryOne[T](ctx, q.db, sqlcQuery, q.dialect, where, originalParams...)
+	return NewExecutor[T](q).QueryOne(ctx, sqlcQuery, where, originalParams...)
 }
 
 // QueryPaginatedWith executes a paginated query using the Queries wrapper
 func QueryPaginatedWith[T any](ctx context.Context, q *Queries, sqlcQuery string, where *WhereBuilder, cursor *Cursor, orderBy *OrderByBuilder, limit int, getCursorFields func(T) (interface{}, interface{}), originalParams ...interface{}) (*PaginatedResult[T], error) {
-	return QueryPaginated[T](ctx, q.db, sqlcQuery, q.dialect, where, cursor, orderBy, limit, getCursorFields, originalParams...)
+	return NewExecutor[T](q).QueryPaginated(ctx, sqlcQuery, where, cursor, orderBy, limit, getCursorFields, originalParams...)
 }
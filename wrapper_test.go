@@ -2,10 +2,12 @@ package sqld
 
 import (
 	"context"
+	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 // Mock implementations for testing
@@ -81,3 +83,374 @@ func TestQueries(t *testing.T) {
 		assert.Equal(t, Postgres, q.Dialect())
 	})
 }
+
+// testUser is a minimal row type for executor tests
+type testUser struct {
+	ID   int32
+	Name string
+}
+
+// expectEmptyQuery sets up mockDB to return no rows for the given query
+func expectEmptyQuery(mockDB *MockDB, query string, params ...interface{}) {
+	rows := &MockRows{}
+	rows.On("Next").Return(false)
+	rows.On("Err").Return(nil)
+	rows.On("Close").Return(nil)
+
+	args := append([]interface{}{mock.Anything, query}, params...)
+	mockDB.On("Query", args...).Return(rows, nil)
+}
+
+func TestExecutorSoftDelete(t *testing.T) {
+	const query = "SELECT id, name FROM users WHERE status = 'active' /* sqld:where */"
+	config := DefaultConfig().WithSoftDelete("deleted_at")
+
+	t.Run("excludes deleted rows by default", func(t *testing.T) {
+		mockDB := &MockDB{}
+		expectEmptyQuery(mockDB, "SELECT id, name FROM users WHERE status = 'active'  AND name = $1 AND deleted_at IS NULL", "john")
+
+		exec := NewExecutor[testUser](New(mockDB, Postgres)).WithConfig(config)
+		where := NewWhereBuilder(Postgres)
+		where.Equal("name", "john")
+
+		_, err := exec.QueryAll(context.Background(), query, where, nil, nil, 0)
+		assert.NoError(t, err)
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("include deleted", func(t *testing.T) {
+		mockDB := &MockDB{}
+		expectEmptyQuery(mockDB, "SELECT id, name FROM users WHERE status = 'active' ")
+
+		exec := NewExecutor[testUser](New(mockDB, Postgres)).WithConfig(config).IncludeDeleted()
+
+		_, err := exec.QueryAll(context.Background(), query, nil, nil, nil, 0)
+		assert.NoError(t, err)
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("only deleted", func(t *testing.T) {
+		mockDB := &MockDB{}
+		expectEmptyQuery(mockDB, "SELECT id, name FROM users WHERE status = 'active'  AND deleted_at IS NOT NULL")
+
+		exec := NewExecutor[testUser](New(mockDB, Postgres)).WithConfig(config).OnlyDeleted()
+
+		_, err := exec.QueryAll(context.Background(), query, nil, nil, nil, 0)
+		assert.NoError(t, err)
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("query without where annotation is rejected", func(t *testing.T) {
+		mockDB := &MockDB{}
+		exec := NewExecutor[testUser](New(mockDB, Postgres)).WithConfig(config)
+
+		_, err := exec.QueryAll(context.Background(), "SELECT id, name FROM users", nil, nil, nil, 0)
+		assert.ErrorIs(t, err, ErrInvalidQuery)
+		mockDB.AssertNotCalled(t, "Query")
+	})
+
+	t.Run("overrides do not leak into the base executor", func(t *testing.T) {
+		base := NewExecutor[testUser](New(&MockDB{}, Postgres)).WithConfig(config)
+		_ = base.IncludeDeleted()
+		assert.Equal(t, softDeleteExclude, base.softDelete)
+	})
+}
+
+func TestExecutorQueryPaginated_LimitAnnotation(t *testing.T) {
+	const query = "SELECT id, name FROM users ORDER BY id /* sqld:limit default=20 max=100 */"
+
+	mockDB := &MockDB{}
+	// Clamped to the max, plus one row to detect further pages
+	expectEmptyQuery(mockDB, "SELECT id, name FROM users ORDER BY id  LIMIT $1", 101)
+
+	exec := NewExecutor[testUser](New(mockDB, Postgres))
+	result, err := exec.QueryPaginated(context.Background(), query, nil, nil, nil, 500, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 100, result.Limit)
+	assert.False(t, result.HasMore)
+	mockDB.AssertExpectations(t)
+}
+
+func TestExecutor_MaxLimit(t *testing.T) {
+	mockDB := &MockDB{}
+	expectEmptyQuery(mockDB, "SELECT id, name FROM users ORDER BY id  LIMIT $1", 25)
+
+	exec := NewExecutor[testUser](New(mockDB, Postgres)).WithConfig(DefaultConfig().WithLimits(10, 25))
+	_, err := exec.QueryAll(context.Background(), "SELECT id, name FROM users ORDER BY id /* sqld:limit */", nil, nil, nil, 0)
+	assert.NoError(t, err)
+	mockDB.AssertExpectations(t)
+}
+
+// fixedRowsDB returns the same rows for every query, whatever its limit
+type fixedRowsDB struct{ rows [][]interface{} }
+
+func (db fixedRowsDB) Query(ctx context.Context, query string, args ...interface{}) (Rows, error) {
+	return &nullRows{rows: db.rows}, nil
+}
+
+func (db fixedRowsDB) QueryRow(ctx context.Context, query string, args ...interface{}) Row {
+	return nil
+}
+
+func TestExecutor_WithMaxRows(t *testing.T) {
+	const query = "SELECT id, name FROM users ORDER BY id /* sqld:limit */"
+	newDB := func(rows int) fixedRowsDB {
+		values := make([][]interface{}, rows)
+		for i := range values {
+			values[i] = []interface{}{int32(i + 1), "user"}
+		}
+		return fixedRowsDB{rows: values}
+	}
+	ctx := context.Background()
+
+	t.Run("fails past the limit", func(t *testing.T) {
+		exec := NewExecutor[testUser](New(newDB(4), Postgres)).WithMaxRows(3, false)
+		_, err := exec.QueryAll(ctx, query, nil, nil, nil, 0)
+		assert.ErrorIs(t, err, ErrTooManyRows)
+	})
+
+	t.Run("allows results within the limit", func(t *testing.T) {
+		exec := NewExecutor[testUser](New(newDB(3), Postgres)).WithMaxRows(3, false)
+		users, err := exec.QueryAll(ctx, query, nil, nil, nil, 0)
+		assert.NoError(t, err)
+		assert.Len(t, users, 3)
+	})
+
+	t.Run("truncates", func(t *testing.T) {
+		exec := NewExecutor[testUser](New(newDB(4), Postgres)).WithMaxRows(3, true)
+		users, err := exec.QueryAll(ctx, query, nil, nil, nil, 0)
+		assert.NoError(t, err)
+		assert.Len(t, users, 3)
+
+		result, err := exec.QueryPaginated(ctx, query, nil, nil, nil, 0, nil)
+		assert.NoError(t, err)
+		assert.Len(t, result.Items, 3)
+		assert.True(t, result.Truncated)
+	})
+
+	t.Run("lookahead row of a page within the limit", func(t *testing.T) {
+		// LIMIT 3 fetches a fourth row to detect the next page
+		exec := NewExecutor[testUser](New(newDB(4), Postgres)).WithMaxRows(3, false)
+		result, err := exec.QueryPaginated(ctx, query, nil, nil, nil, 3, nil)
+		assert.NoError(t, err)
+		assert.Len(t, result.Items, 3)
+		assert.True(t, result.HasMore)
+		assert.False(t, result.Truncated)
+	})
+}
+
+// recordingDB returns rows like fixedRowsDB and records the last query
+type recordingDB struct {
+	rows  [][]interface{}
+	query string
+	args  []interface{}
+}
+
+func (db *recordingDB) Query(ctx context.Context, query string, args ...interface{}) (Rows, error) {
+	db.query, db.args = query, args
+	return &nullRows{rows: db.rows}, nil
+}
+
+func (db *recordingDB) QueryRow(ctx context.Context, query string, args ...interface{}) Row {
+	return nil
+}
+
+func TestExecutor_QueryPaginatedOffset(t *testing.T) {
+	const query = "SELECT id, name /* sqld:total */ FROM users WHERE 1=1 /* sqld:where */ ORDER BY id /* sqld:limit */"
+	ctx := context.Background()
+
+	t.Run("offset without a total", func(t *testing.T) {
+		db := &recordingDB{rows: [][]interface{}{{int32(21), "a"}, {int32(22), "b"}, {int32(23), "c"}}}
+		exec := NewExecutor[testUser](New(db, Postgres))
+
+		result, err := exec.QueryPaginatedOffset(ctx, query, nil, nil, 2, 20)
+		require.NoError(t, err)
+		assert.Equal(t, "SELECT id, name  FROM users WHERE 1=1  ORDER BY id  LIMIT $1 OFFSET $2", db.query)
+		assert.Equal(t, []interface{}{3, 20}, db.args)
+		assert.Len(t, result.Items, 2)
+		assert.True(t, result.HasMore)
+		assert.Nil(t, result.Total)
+	})
+
+	t.Run("window count routes the total column", func(t *testing.T) {
+		db := &recordingDB{rows: [][]interface{}{{int32(21), "a", int64(22)}, {int32(22), "b", int64(22)}}}
+		exec := NewExecutor[testUser](New(db, Postgres)).WithWindowCount()
+
+		where := NewWhereBuilder(Postgres)
+		where.Equal("status", "active")
+		result, err := exec.QueryPaginatedOffset(ctx, query, where, nil, 10, 20)
+		require.NoError(t, err)
+		assert.Equal(t, "SELECT id, name , COUNT(*) OVER() AS sqld_total FROM users WHERE 1=1  AND status = $1 ORDER BY id  LIMIT $2 OFFSET $3", db.query)
+		assert.Equal(t, []interface{}{"active", 11, 20}, db.args)
+		assert.Equal(t, []testUser{{ID: 21, Name: "a"}, {ID: 22, Name: "b"}}, result.Items)
+		assert.False(t, result.HasMore)
+		require.NotNil(t, result.Total)
+		assert.Equal(t, int64(22), *result.Total)
+	})
+
+	t.Run("page past the end has no total", func(t *testing.T) {
+		exec := NewExecutor[testUser](New(&recordingDB{}, Postgres)).WithWindowCount()
+		result, err := exec.QueryPaginatedOffset(ctx, query, nil, nil, 10, 100)
+		require.NoError(t, err)
+		assert.Nil(t, result.Total)
+
+		result, err = exec.QueryPaginatedOffset(ctx, query, nil, nil, 10, 0)
+		require.NoError(t, err)
+		require.NotNil(t, result.Total)
+		assert.Zero(t, *result.Total)
+	})
+
+	t.Run("invalid queries and offsets", func(t *testing.T) {
+		exec := NewExecutor[testUser](New(&recordingDB{}, Postgres))
+		_, err := exec.QueryPaginatedOffset(ctx, "SELECT id, name FROM users", nil, nil, 10, 20)
+		assert.ErrorIs(t, err, ErrInvalidQuery)
+
+		_, err = exec.QueryPaginatedOffset(ctx, "SELECT id, name FROM users /* sqld:limit */", nil, nil, 0, 20)
+		assert.ErrorIs(t, err, ErrInvalidParameter)
+
+		_, err = exec.WithWindowCount().QueryPaginatedOffset(ctx, "SELECT id, name FROM users /* sqld:limit */", nil, nil, 10, 0)
+		assert.ErrorIs(t, err, ErrInvalidQuery)
+	})
+}
+
+type tenantKey struct{}
+
+func tenantFromContext(ctx context.Context) (interface{}, error) {
+	if id, ok := ctx.Value(tenantKey{}).(int); ok {
+		return id, nil
+	}
+	return nil, ErrMissingTenant
+}
+
+func TestTenantScope(t *testing.T) {
+	const query = "SELECT id, name FROM users WHERE active /* sqld:where */"
+	ctx := context.WithValue(context.Background(), tenantKey{}, 42)
+
+	t.Run("executor injects tenant condition", func(t *testing.T) {
+		mockDB := &MockDB{}
+		expectEmptyQuery(mockDB, "SELECT id, name FROM users WHERE active  AND name = $1 AND tenant_id = $2", "john", 42)
+
+		q := New(mockDB, Postgres).WithTenantScope("tenant_id", tenantFromContext)
+		where := NewWhereBuilder(Postgres)
+		where.Equal("name", "john")
+
+		_, err := NewExecutor[testUser](q).QueryAll(ctx, query, where, nil, nil, 0)
+		assert.NoError(t, err)
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("legacy helpers are scoped too", func(t *testing.T) {
+		mockDB := &MockDB{}
+		expectEmptyQuery(mockDB, "SELECT id, name FROM users WHERE active  AND tenant_id = ?", 42)
+
+		q := New(mockDB, MySQL).WithTenantScope("tenant_id", tenantFromContext)
+
+		_, err := QueryAllWith[testUser](ctx, q, query, nil, nil, nil, 0)
+		assert.NoError(t, err)
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("missing tenant fails", func(t *testing.T) {
+		mockDB := &MockDB{}
+		q := New(mockDB, Postgres).WithTenantScope("tenant_id", tenantFromContext)
+
+		_, err := NewExecutor[testUser](q).QueryAll(context.Background(), query, nil, nil, nil, 0)
+		assert.ErrorIs(t, err, ErrMissingTenant)
+		mockDB.AssertNotCalled(t, "Query")
+	})
+
+	t.Run("query without where annotation is rejected", func(t *testing.T) {
+		q := New(&MockDB{}, Postgres).WithTenantScope("tenant_id", tenantFromContext)
+
+		_, err := NewExecutor[testUser](q).QueryOne(ctx, "SELECT id, name FROM users WHERE id = $1", nil, 1)
+		assert.ErrorIs(t, err, ErrInvalidQuery)
+	})
+
+	t.Run("query builder keeps scope when Where is replaced", func(t *testing.T) {
+		q := New(&MockDB{}, Postgres).WithTenantScope("tenant_id", tenantFromContext)
+
+		qb, err := q.QueryBuilder(ctx, "SELECT * FROM orders")
+		assert.NoError(t, err)
+
+		where := NewWhereBuilder(Postgres)
+		where.GreaterThan("total", 100)
+		query, params := qb.Where(where).Build()
+
+		assert.Equal(t, "SELECT * FROM orders WHERE total > $1 AND tenant_id = $2", query)
+		assert.Equal(t, []interface{}{100, 42}, params)
+	})
+
+	t.Run("query builder scopes every branch of an OR", func(t *testing.T) {
+		q := New(&MockDB{}, Postgres).WithTenantScope("tenant_id", tenantFromContext)
+
+		qb, err := q.QueryBuilder(ctx, "SELECT * FROM orders WHERE status = 'open' OR total > 100")
+		require.NoError(t, err)
+		query, params := qb.Build()
+
+		assert.Equal(t, "SELECT * FROM orders WHERE (status = 'open' OR total > 100) AND tenant_id = $1", query)
+		assert.Equal(t, []interface{}{42}, params)
+	})
+
+	t.Run("query builder scopes before ORDER BY and LIMIT", func(t *testing.T) {
+		q := New(&MockDB{}, Postgres).WithTenantScope("o.tenant_id", tenantFromContext)
+
+		qb, err := q.QueryBuilder(ctx, "SELECT o.* FROM orders o ORDER BY o.created_at DESC LIMIT 10")
+		require.NoError(t, err)
+		query, params := qb.Build()
+
+		assert.Equal(t, "SELECT o.* FROM orders o WHERE o.tenant_id = $1 ORDER BY o.created_at DESC LIMIT 10", query)
+		assert.Equal(t, []interface{}{42}, params)
+	})
+
+	t.Run("query builder rejects a union", func(t *testing.T) {
+		q := New(&MockDB{}, Postgres).WithTenantScope("tenant_id", tenantFromContext)
+
+		_, err := q.QueryBuilder(ctx, "SELECT id FROM orders UNION SELECT id FROM archived_orders")
+		assert.ErrorIs(t, err, ErrInvalidQuery)
+	})
+}
+
+type regionKey struct{}
+
+func TestContextFilters(t *testing.T) {
+	const query = "SELECT id, name FROM users WHERE active /* sqld:where */"
+	region := EqualFromContext("region", func(ctx context.Context) (interface{}, bool) {
+		r, ok := ctx.Value(regionKey{}).(string)
+		return r, ok
+	})
+	ctx := context.WithValue(context.WithValue(context.Background(), tenantKey{}, 42), regionKey{}, "eu")
+
+	t.Run("merged with the tenant scope", func(t *testing.T) {
+		mockDB := &MockDB{}
+		expectEmptyQuery(mockDB, "SELECT id, name FROM users WHERE active  AND name = $1 AND tenant_id = $2 AND region = $3 AND deleted_at IS NULL", "john", 42, "eu")
+
+		q := New(mockDB, Postgres).WithTenantScope("tenant_id", tenantFromContext).
+			WithContextFilter(region, func(ctx context.Context, where ConditionBuilder) error {
+				where.IsNull("deleted_at")
+				return nil
+			})
+		where := NewWhereBuilder(Postgres)
+		where.Equal("name", "john")
+		_, err := NewExecutor[testUser](q).QueryAll(ctx, query, where, nil, nil, 0)
+		assert.NoError(t, err)
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("missing values add nothing", func(t *testing.T) {
+		q := New(&MockDB{}, Postgres).WithContextFilter(region)
+		scope, err := q.Scope(context.Background())
+		require.NoError(t, err)
+		assert.False(t, scope.HasConditions())
+	})
+
+	t.Run("errors fail the query", func(t *testing.T) {
+		mockDB := &MockDB{}
+		errNoClaims := errors.New("no claims")
+		q := New(mockDB, Postgres).WithContextFilter(func(ctx context.Context, where ConditionBuilder) error {
+			return errNoClaims
+		})
+		_, err := NewExecutor[testUser](q).QueryAll(ctx, query, nil, nil, nil, 0)
+		assert.ErrorIs(t, err, errNoClaims)
+		mockDB.AssertNotCalled(t, "Query")
+	})
+}